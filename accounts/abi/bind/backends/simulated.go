@@ -66,8 +66,15 @@ type SimulatedBackend struct {
 // NewSimulatedBackend creates a new binding backend using a simulated blockchain
 // for testing purposes.
 func NewSimulatedBackend(alloc core.GenesisAlloc) *SimulatedBackend {
+	return NewSimulatedBackendWithGasLimit(alloc, params.GenesisGasLimit)
+}
+
+// NewSimulatedBackendWithGasLimit creates a new binding backend using a simulated
+// blockchain whose genesis block has the given gas limit, allowing the deployment
+// of contracts too large for the default one.
+func NewSimulatedBackendWithGasLimit(alloc core.GenesisAlloc, gasLimit uint64) *SimulatedBackend {
 	database := athdb.NewMemDatabase()
-	genesis := core.Genesis{Config: params.AllEthashProtocolChanges, Alloc: alloc}
+	genesis := core.Genesis{Config: params.AllEthashProtocolChanges, GasLimit: gasLimit, Alloc: alloc}
 	genesis.MustCommit(database)
 	blockchain, _ := core.NewBlockChain(database, nil, genesis.Config, athash.NewFaker(), vm.Config{})
 
@@ -470,7 +477,11 @@ func (fb *filterBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscr
 	return fb.bc.SubscribeLogsEvent(ch)
 }
 
+func (fb *filterBackend) SubscribePendingLogsEvent() (*event.TypeMuxSubscription, error) {
+	return nil, filters.ErrPendingLogsUnsupported
+}
+
 func (fb *filterBackend) BloomStatus() (uint64, uint64) { return 4096, 0 }
-func (fb *filterBackend) ServiceFilter(ctx context.Context, ms *bloombits.MatcherSession) {
+func (fb *filterBackend) ServiceFilter(ctx context.Context, ms *bloombits.MatcherSession) error {
 	panic("not supported")
 }
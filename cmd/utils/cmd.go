@@ -301,7 +301,7 @@ func ExportPreimages(db *athdb.LDBDatabase, fn string) error {
 		defer writer.(*gzip.Writer).Close()
 	}
 	// Iterate over the preimages and export them
-	it := db.NewIteratorWithPrefix([]byte("secure-key-"))
+	it := db.NewIteratorWithPrefix(rawdb.PreimagePrefix)
 	for it.Next() {
 		if err := rlp.Encode(writer, it.Value()); err != nil {
 			return err
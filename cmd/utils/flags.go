@@ -485,7 +485,7 @@ var (
 	NATFlag = cli.StringFlag{
 		Name:  "nat",
 		Usage: "NAT port mapping mechanism (any|none|upnp|pmp|extip:<IP>)",
-		Value: "any",
+		Value: node.DefaultNATSpec,
 	}
 	NoDiscoverFlag = cli.BoolFlag{
 		Name:  "nodiscover",
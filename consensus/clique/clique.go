@@ -203,9 +203,10 @@ type Clique struct {
 
 	proposals map[common.Address]bool // Current list of proposals we are pushing
 
-	signer common.Address // Atlantis address of the signing key
-	signFn SignerFn       // Signer function to authorize hashes with
-	lock   sync.RWMutex   // Protects the signer fields
+	signer      common.Address // Atlantis address of the signing key
+	signFn      SignerFn       // Signer function to authorize hashes with
+	emptyPeriod uint64         // Minimum seconds between a block and an empty child sealed locally
+	lock        sync.RWMutex   // Protects the signer fields
 }
 
 // New creates a Clique proof-of-authority consensus engine with the initial
@@ -588,6 +589,53 @@ func (c *Clique) Authorize(signer common.Address, signFn SignerFn) {
 	c.signFn = signFn
 }
 
+// SetEmptyBlockPeriod sets the minimum number of seconds between a block and an
+// empty child sealed locally. Blocks with transactions are still sealed after the
+// chain's period. This is a local mining policy, not a consensus rule, zero
+// disables it.
+func (c *Clique) SetEmptyBlockPeriod(period uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.emptyPeriod = period
+}
+
+// EmptyBlockPeriod returns the minimum number of seconds between a block and an
+// empty child sealed locally, zero if empty blocks aren't held back.
+func (c *Clique) EmptyBlockPeriod() uint64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.emptyPeriod
+}
+
+// Authorized checks whather the local signing credentials are authorized to seal
+// a block on top of the given parent, returning errUnauthorized if not.
+func (c *Clique) Authorized(chain consensus.ChainReader, parent *types.Header) error {
+	c.lock.RLock()
+	signer := c.signer
+	c.lock.RUnlock()
+
+	snap, err := c.snapshot(chain, parent.Number.Uint64(), parent.Hash(), nil)
+	if err != nil {
+		return err
+	}
+	if _, authorized := snap.Signers[signer]; !authorized {
+		return errUnauthorized
+	}
+	return nil
+}
+
+// Signers retrieves the list of addresses authorized to seal blocks on top of
+// the given header, in ascending order.
+func (c *Clique) Signers(chain consensus.ChainReader, header *types.Header) ([]common.Address, error) {
+	snap, err := c.snapshot(chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return snap.signers(), nil
+}
+
 // Seal implements consensus.Engine, attempting to create a sealed block using
 // the local signing credentials.
 func (c *Clique) Seal(chain consensus.ChainReader, block *types.Block, stop <-chan struct{}) (*types.Block, error) {
@@ -602,6 +650,17 @@ func (c *Clique) Seal(chain consensus.ChainReader, block *types.Block, stop <-ch
 	if c.config.Period == 0 && len(block.Transactions()) == 0 {
 		return nil, errWaitTransactions
 	}
+	// Hold back empty blocks until the empty block period elapsed since the parent
+	if period := c.EmptyBlockPeriod(); period > 0 && len(block.Transactions()) == 0 {
+		parent := chain.GetHeader(header.ParentHash, number-1)
+		if parent == nil {
+			return nil, consensus.ErrUnknownAncestor
+		}
+		idle := new(big.Int).Add(parent.Time, new(big.Int).SetUint64(period))
+		if header.Time.Cmp(idle) < 0 {
+			header.Time = idle
+		}
+	}
 	// Don't hold the signer fields for the entire sealing procedure
 	c.lock.RLock()
 	signer, signFn := c.signer, c.signFn
@@ -0,0 +1,138 @@
+// Copyright 2018 The go-athereum Authors
+// This file is part of the go-athereum library.
+//
+// The go-athereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-athereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-athereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package clique
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/athereum/go-athereum/accounts"
+	"github.com/athereum/go-athereum/athdb"
+	"github.com/athereum/go-athereum/common"
+	"github.com/athereum/go-athereum/core"
+	"github.com/athereum/go-athereum/core/types"
+	"github.com/athereum/go-athereum/core/vm"
+	"github.com/athereum/go-athereum/crypto"
+	"github.com/athereum/go-athereum/params"
+)
+
+// newSealingTester creates a single signer clique chain with the given empty
+// block period, the genesis of which was created at the given time. The engine
+// is authorized to seal blocks on top of it.
+func newSealingTester(t *testing.T, emptyPeriod uint64, genesisTime time.Time) (*Clique, *core.BlockChain) {
+	key, _ := crypto.GenerateKey()
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+
+	config := *params.AllCliqueProtocolChanges
+	config.Clique = &params.CliqueConfig{Period: 1, Epoch: 30000}
+
+	genesis := &core.Genesis{
+		Config:    &config,
+		Timestamp: uint64(genesisTime.Unix()),
+		ExtraData: make([]byte, extraVanity+common.AddressLength+extraSeal),
+	}
+	copy(genesis.ExtraData[extraVanity:], signer[:])
+
+	db := athdb.NewMemDatabase()
+	genesis.MustCommit(db)
+
+	engine := New(config.Clique, db)
+	engine.SetEmptyBlockPeriod(emptyPeriod)
+	engine.Authorize(signer, func(account accounts.Account, hash []byte) ([]byte, error) {
+		return crypto.Sign(hash, key)
+	})
+	chain, err := core.NewBlockChain(db, nil, &config, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	return engine, chain
+}
+
+// newSealingBlock assembles an unsealed child of the chain head with the given
+// transactions, timestamped a block period after its parent.
+func newSealingBlock(chain *core.BlockChain, txs []*types.Transaction) *types.Block {
+	parent := chain.CurrentBlock()
+
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		Time:       new(big.Int).Add(parent.Time(), common.Big1),
+		Difficulty: new(big.Int).Set(diffInTurn),
+		GasLimit:   parent.GasLimit(),
+		Extra:      make([]byte, extraVanity+extraSeal),
+	}
+	return types.NewBlock(header, txs, nil, nil)
+}
+
+// Tests that empty blocks are only sealed once the empty block period elapsed
+// since their parent, whereas blocks with transactions keep the regular period.
+func TestSealEmptyBlockPeriod(t *testing.T) {
+	genesisTime := time.Now().Add(-time.Minute)
+	engine, chain := newSealingTester(t, 10, genesisTime)
+	defer chain.Stop()
+
+	tx := types.NewTransaction(0, common.Address{}, new(big.Int), params.TxGas, new(big.Int), nil)
+	tests := []struct {
+		txs  []*types.Transaction
+		time int64
+	}{
+		{nil, genesisTime.Unix() + 10},
+		{[]*types.Transaction{tx}, genesisTime.Unix() + 1},
+	}
+	for i, tt := range tests {
+		sealed, err := engine.Seal(chain, newSealingBlock(chain, tt.txs), make(chan struct{}))
+		if err != nil {
+			t.Fatalf("test %d: failed to seal block: %v", i, err)
+		}
+		if sealed.Time().Int64() != tt.time {
+			t.Errorf("test %d: sealed time mismatch: have %v, want %v", i, sealed.Time(), tt.time)
+		}
+		if err := engine.VerifySeal(chain, sealed.Header()); err != nil {
+			t.Errorf("test %d: invalid seal: %v", i, err)
+		}
+	}
+}
+
+// Tests that sealing an empty block is held back until the empty block period is
+// over, and can be aborted in the meantime to seal a replacement.
+func TestSealEmptyBlockHeldBack(t *testing.T) {
+	engine, chain := newSealingTester(t, 60, time.Now())
+	defer chain.Stop()
+
+	stop := make(chan struct{})
+	done := make(chan *types.Block)
+	go func() {
+		sealed, _ := engine.Seal(chain, newSealingBlock(chain, nil), stop)
+		done <- sealed
+	}()
+	select {
+	case sealed := <-done:
+		t.Fatalf("empty block #%v sealed before the empty block period", sealed.Number())
+	case <-time.After(1500 * time.Millisecond):
+	}
+	close(stop)
+
+	select {
+	case sealed := <-done:
+		if sealed != nil {
+			t.Fatalf("aborted sealing returned block #%v", sealed.Number())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("sealing not aborted")
+	}
+}
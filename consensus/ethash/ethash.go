@@ -378,6 +378,24 @@ const (
 	ModeFullFake
 )
 
+// String implements fmt.Stringer, returning the name of the verification mode.
+func (m Mode) String() string {
+	switch m {
+	case ModeNormal:
+		return "normal"
+	case ModeShared:
+		return "shared"
+	case ModeTest:
+		return "test"
+	case ModeFake:
+		return "fake"
+	case ModeFullFake:
+		return "fullfake"
+	default:
+		return "unknown"
+	}
+}
+
 // Config are the configuration parameters of the athash.
 type Config struct {
 	CacheDir       string
@@ -489,6 +507,14 @@ func NewShared() *Ethash {
 	return &Ethash{shared: sharedEthash}
 }
 
+// Mode returns the PoW verification mode the engine is running in.
+func (athash *Ethash) Mode() Mode {
+	if athash.shared != nil {
+		return ModeShared
+	}
+	return athash.config.PowMode
+}
+
 // cache tries to retrieve a verification cache for the specified block number
 // by first checking against a list of in-memory caches, then against caches
 // stored on disk, and finally generating one if none can be found.
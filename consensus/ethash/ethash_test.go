@@ -27,6 +27,28 @@ import (
 	"github.com/athereum/go-athereum/core/types"
 )
 
+// Tests that the engine reports the verification mode it was created with.
+func TestEngineMode(t *testing.T) {
+	tests := []struct {
+		engine *Ethash
+		mode   Mode
+		name   string
+	}{
+		{NewTester(), ModeTest, "test"},
+		{NewFaker(), ModeFake, "fake"},
+		{NewFullFaker(), ModeFullFake, "fullfake"},
+		{NewShared(), ModeShared, "shared"},
+	}
+	for i, tt := range tests {
+		if mode := tt.engine.Mode(); mode != tt.mode {
+			t.Errorf("test %d: mode mismatch: have %v, want %v", i, mode, tt.mode)
+		}
+		if name := tt.engine.Mode().String(); name != tt.name {
+			t.Errorf("test %d: mode name mismatch: have %s, want %s", i, name, tt.name)
+		}
+	}
+}
+
 // Tests that athash works correctly in test mode.
 func TestTestMode(t *testing.T) {
 	head := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100)}
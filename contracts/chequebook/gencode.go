@@ -37,10 +37,13 @@ var (
 	testAlloc  = core.GenesisAlloc{
 		crypto.PubkeyToAddress(testKey.PublicKey): {Balance: big.NewInt(500000000000)},
 	}
+	// testGasLimit is the block gas limit of the simulated chain, raise it if the
+	// contract outgrows it and the deployment fails with out of gas.
+	testGasLimit uint64 = 8000000
 )
 
 func main() {
-	backend := backends.NewSimulatedBackend(testAlloc)
+	backend := backends.NewSimulatedBackendWithGasLimit(testAlloc, testGasLimit)
 	auth := bind.NewKeyedTransactor(testKey)
 
 	// Deploy the contract, get the code.
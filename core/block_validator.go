@@ -131,3 +131,26 @@ func CalcGasLimit(parent *types.Block) uint64 {
 	}
 	return limit
 }
+
+// CalcGasLimitWithin computes the gas limit of the next block after parent like
+// CalcGasLimit, but steers it into the [gasFloor, gasCeil] range as fast as the
+// gas limit bound divisor permits. Once inside, the limit never leaves the range.
+// This is miner strategy, not consensus protocol.
+func CalcGasLimitWithin(parent *types.Block, gasFloor, gasCeil uint64) uint64 {
+	limit := CalcGasLimit(parent)
+
+	decay := parent.GasLimit()/params.GasLimitBoundDivisor - 1
+	switch {
+	case limit < gasFloor:
+		limit = parent.GasLimit() + decay
+		if limit > gasFloor {
+			limit = gasFloor
+		}
+	case limit > gasCeil:
+		limit = parent.GasLimit() - decay
+		if limit < gasCeil {
+			limit = gasCeil
+		}
+	}
+	return limit
+}
@@ -197,3 +197,31 @@ func testHeaderConcurrentAbortion(t *testing.T, threads int) {
 		t.Errorf("verification count too large: have %d, want below %d", verified, 2*threads)
 	}
 }
+
+// Tests that the bounded gas limit calculation moves towards the requested range
+// without exceeding the per block adjustment bound, and stays inside once there.
+func TestCalcGasLimitWithin(t *testing.T) {
+	const target = 20000000
+
+	parent := types.NewBlockWithHeader(&types.Header{GasLimit: params.GenesisGasLimit})
+	for i := 0; i < 2048; i++ {
+		limit := CalcGasLimitWithin(parent, target, target)
+
+		bound := parent.GasLimit() / params.GasLimitBoundDivisor
+		if limit > parent.GasLimit()+bound || limit+bound < parent.GasLimit() {
+			t.Fatalf("step %d: gas limit %d outside bounds of parent %d", i, limit, parent.GasLimit())
+		}
+		if limit > target {
+			t.Fatalf("step %d: gas limit %d above ceiling %d", i, limit, target)
+		}
+		parent = types.NewBlockWithHeader(&types.Header{GasLimit: limit})
+	}
+	if parent.GasLimit() != target {
+		t.Fatalf("gas limit not converged: have %d, want %d", parent.GasLimit(), target)
+	}
+	// Lowering the ceiling must pull the limit back down
+	parent = types.NewBlockWithHeader(&types.Header{GasLimit: target, GasUsed: target})
+	if limit := CalcGasLimitWithin(parent, 0, target-1); limit != target-1 {
+		t.Fatalf("gas limit above ceiling: have %d, want %d", limit, target-1)
+	}
+}
@@ -49,12 +49,27 @@ import (
 var (
 	blockInsertTimer = metrics.NewRegisteredTimer("chain/inserts", nil)
 
+	trieFlushMeter      = metrics.NewRegisteredMeter("ath/state/trie/flush", nil)
+	trieFlushNodesGauge = metrics.NewRegisteredGauge("ath/state/trie/flush/nodes", nil)
+
+	reorgDepthHistogram = metrics.NewRegisteredHistogram("ath/chain/reorg/depth", nil, metrics.NewExpDecaySample(1028, 0.015))
+	reorgRejectedMeter  = metrics.NewRegisteredMeter("ath/chain/reorg/rejected", nil)
+
 	ErrNoGenesis = errors.New("Genesis not found in chain")
+
+	// errReorgTooDeep is returned internally if a reorg was refused because it
+	// would drop more canonical blocks than the configured limit.
+	errReorgTooDeep = errors.New("reorg exceeds maximum depth")
+
+	// errReadOnlyReset is returned if a read-only chain database has no usable
+	// head block, which could only be fixed by resetting the chain.
+	errReadOnlyReset = errors.New("head block missing from read-only chain database")
 )
 
 const (
 	bodyCacheLimit      = 256
 	blockCacheLimit     = 256
+	receiptsCacheLimit  = 32
 	maxFutureBlocks     = 256
 	maxTimeFutureBlocks = 30
 	badBlockLimit       = 10
@@ -66,10 +81,20 @@ const (
 
 // CacheConfig contains the configuration values for the trie caching/pruning
 // that's resident in a blockchain.
+//
+// The header, body and receipt cache limits are measured in number of entries
+// and allocated on top of the trie memory allowance. A zero value selects the
+// default limit.
 type CacheConfig struct {
-	Disabled      bool          // Whather to disable trie write caching (archive node)
-	TrieNodeLimit int           // Memory limit (MB) at which to flush the current in-memory trie to disk
-	TrieTimeLimit time.Duration // Time limit after which to flush the current in-memory trie to disk
+	Disabled       bool          // Whather to disable trie write caching (archive node)
+	TrieNodeLimit  int           // Memory limit (MB) at which to flush the current in-memory trie to disk
+	TrieTimeLimit  time.Duration // Time limit after which to flush the current in-memory trie to disk
+	TriesInMemory  uint64        // Number of recent block states retained in memory (128 if zero)
+	TrieGCInterval uint64        // Number of blocks between dereferencing stale in-memory tries (1 if zero)
+
+	HeaderCache  int // Number of recent headers to keep cached in memory
+	BodyCache    int // Number of recent block bodies to keep cached in memory
+	ReceiptCache int // Number of recent block receipt sets to keep cached in memory
 }
 
 // BlockChain represents the canonical chain given a database with a genesis
@@ -94,14 +119,16 @@ type BlockChain struct {
 	triegc *prque.Prque   // Priority queue mapping block numbers to tries to gc
 	gcproc time.Duration  // Accumulates canonical block processing for trie dumping
 
-	hc            *HeaderChain
-	rmLogsFeed    event.Feed
-	chainFeed     event.Feed
-	chainSideFeed event.Feed
-	chainHeadFeed event.Feed
-	logsFeed      event.Feed
-	scope         event.SubscriptionScope
-	genesisBlock  *types.Block
+	hc             *HeaderChain
+	rmLogsFeed     event.Feed
+	chainFeed      event.Feed
+	chainSideFeed  event.Feed
+	chainHeadFeed  event.Feed
+	chainReorgFeed event.Feed
+	logsFeed       event.Feed
+	trieFlushFeed  event.Feed
+	scope          event.SubscriptionScope
+	genesisBlock   *types.Block
 
 	mu      sync.RWMutex // global mutex for locking chain operations
 	chainmu sync.RWMutex // blockchain insertion lock
@@ -111,11 +138,12 @@ type BlockChain struct {
 	currentBlock     atomic.Value // Current head of the block chain
 	currentFastBlock atomic.Value // Current head of the fast-sync chain (may be above the block chain!)
 
-	stateCache   state.Database // State database to reuse between imports (contains state cache)
-	bodyCache    *lru.Cache     // Cache for the most recent block bodies
-	bodyRLPCache *lru.Cache     // Cache for the most recent block bodies in RLP encoded format
-	blockCache   *lru.Cache     // Cache for the most recent entire blocks
-	futureBlocks *lru.Cache     // future blocks are blocks added for later processing
+	stateCache    state.Database // State database to reuse between imports (contains state cache)
+	bodyCache     *lru.Cache     // Cache for the most recent block bodies
+	bodyRLPCache  *lru.Cache     // Cache for the most recent block bodies in RLP encoded format
+	blockCache    *lru.Cache     // Cache for the most recent entire blocks
+	receiptsCache *lru.Cache     // Cache for the most recent block receipts
+	futureBlocks  *lru.Cache     // future blocks are blocks added for later processing
 
 	quit    chan struct{} // blockchain quit channel
 	running int32         // running must be called atomically
@@ -129,6 +157,9 @@ type BlockChain struct {
 	vmConfig  vm.Config
 
 	badBlocks *lru.Cache // Bad block cache
+
+	statePrefetch int32 // Whether to prefetch the state of upcoming blocks during import (atomic access)
+	importing     int32 // Whether a block import is currently in progress (atomic access)
 }
 
 // NewBlockChain returns a fully initialised block chain using information
@@ -141,32 +172,51 @@ func NewBlockChain(db athdb.Database, cacheConfig *CacheConfig, chainConfig *par
 			TrieTimeLimit: 5 * time.Minute,
 		}
 	}
-	bodyCache, _ := lru.New(bodyCacheLimit)
-	bodyRLPCache, _ := lru.New(bodyCacheLimit)
+	// Fill in the defaults on a copy to leave the caller's config untouched
+	cpy := *cacheConfig
+	cacheConfig = &cpy
+
+	if cacheConfig.TriesInMemory == 0 {
+		cacheConfig.TriesInMemory = triesInMemory
+	}
+	if cacheConfig.TrieGCInterval == 0 {
+		cacheConfig.TrieGCInterval = 1
+	}
+	bodyLimit, receiptsLimit := bodyCacheLimit, receiptsCacheLimit
+	if cacheConfig.BodyCache > 0 {
+		bodyLimit = cacheConfig.BodyCache
+	}
+	if cacheConfig.ReceiptCache > 0 {
+		receiptsLimit = cacheConfig.ReceiptCache
+	}
+	bodyCache, _ := lru.New(bodyLimit)
+	bodyRLPCache, _ := lru.New(bodyLimit)
 	blockCache, _ := lru.New(blockCacheLimit)
+	receiptsCache, _ := lru.New(receiptsLimit)
 	futureBlocks, _ := lru.New(maxFutureBlocks)
 	badBlocks, _ := lru.New(badBlockLimit)
 
 	bc := &BlockChain{
-		chainConfig:  chainConfig,
-		cacheConfig:  cacheConfig,
-		db:           db,
-		triegc:       prque.New(),
-		stateCache:   state.NewDatabase(db),
-		quit:         make(chan struct{}),
-		bodyCache:    bodyCache,
-		bodyRLPCache: bodyRLPCache,
-		blockCache:   blockCache,
-		futureBlocks: futureBlocks,
-		engine:       engine,
-		vmConfig:     vmConfig,
-		badBlocks:    badBlocks,
+		chainConfig:   chainConfig,
+		cacheConfig:   cacheConfig,
+		db:            db,
+		triegc:        prque.New(),
+		stateCache:    state.NewDatabase(db),
+		quit:          make(chan struct{}),
+		bodyCache:     bodyCache,
+		bodyRLPCache:  bodyRLPCache,
+		blockCache:    blockCache,
+		receiptsCache: receiptsCache,
+		futureBlocks:  futureBlocks,
+		engine:        engine,
+		vmConfig:      vmConfig,
+		badBlocks:     badBlocks,
 	}
 	bc.SetValidator(NewBlockValidator(chainConfig, bc, engine))
 	bc.SetProcessor(NewStateProcessor(chainConfig, bc, engine))
 
 	var err error
-	bc.hc, err = NewHeaderChain(db, chainConfig, engine, bc.getProcInterrupt)
+	bc.hc, err = newHeaderChain(db, chainConfig, engine, bc.getProcInterrupt, cacheConfig.HeaderCache)
 	if err != nil {
 		return nil, err
 	}
@@ -179,6 +229,9 @@ func NewBlockChain(db athdb.Database, cacheConfig *CacheConfig, chainConfig *par
 	}
 	// Check the current state of the block hashes and make sure that we do not have any of the bad blocks in our chain
 	for hash := range BadHashes {
+		if athdb.IsReadOnly(db) {
+			break // Read-only chains can't be rewound, load them as is
+		}
 		if header := bc.GetHeaderByHash(hash); header != nil {
 			// get the canonical block corresponding to the offending header's number
 			headerByNumber := bc.GetHeaderByNumber(header.Number.Uint64())
@@ -206,6 +259,9 @@ func (bc *BlockChain) loadLastState() error {
 	head := rawdb.ReadHeadBlockHash(bc.db)
 	if head == (common.Hash{}) {
 		// Corrupt or empty database, init from scratch
+		if athdb.IsReadOnly(bc.db) {
+			return errReadOnlyReset
+		}
 		log.Warn("Empty database, resetting chain")
 		return bc.Reset()
 	}
@@ -213,6 +269,9 @@ func (bc *BlockChain) loadLastState() error {
 	currentBlock := bc.GetBlockByHash(head)
 	if currentBlock == nil {
 		// Corrupt or empty database, init from scratch
+		if athdb.IsReadOnly(bc.db) {
+			return errReadOnlyReset
+		}
 		log.Warn("Head block missing, resetting chain", "hash", head)
 		return bc.Reset()
 	}
@@ -279,6 +338,7 @@ func (bc *BlockChain) SetHead(head uint64) error {
 	bc.bodyCache.Purge()
 	bc.bodyRLPCache.Purge()
 	bc.blockCache.Purge()
+	bc.receiptsCache.Purge()
 	bc.futureBlocks.Purge()
 
 	// Rewind the block chain, ensuring we don't end up with a stateless head block
@@ -348,6 +408,29 @@ func (bc *BlockChain) CurrentFastBlock() *types.Block {
 	return bc.currentFastBlock.Load().(*types.Block)
 }
 
+// SetMaxReorgDepth limits the number of canonical blocks or headers a reorg may
+// drop. Deeper reorgs are refused, keeping the current chain and firing a
+// ReorgRejectedEvent once per refused fork. Zero disables the limit.
+func (bc *BlockChain) SetMaxReorgDepth(depth uint64) {
+	bc.hc.SetMaxReorgDepth(depth)
+}
+
+// SetStatePrefetch toggles speculatively loading the accounts touched by the next
+// block into the state caches while the current one is being imported.
+func (bc *BlockChain) SetStatePrefetch(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&bc.statePrefetch, 1)
+	} else {
+		atomic.StoreInt32(&bc.statePrefetch, 0)
+	}
+}
+
+// Importing reports whether the chain is currently in the middle of importing
+// blocks.
+func (bc *BlockChain) Importing() bool {
+	return atomic.LoadInt32(&bc.importing) == 1
+}
+
 // SetProcessor sets the processor required for making state modifications.
 func (bc *BlockChain) SetProcessor(processor Processor) {
 	bc.procmu.Lock()
@@ -386,6 +469,11 @@ func (bc *BlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
 	return state.New(root, bc.stateCache)
 }
 
+// StateCache returns the caching database underpinning the blockchain instance.
+func (bc *BlockChain) StateCache() state.Database {
+	return bc.stateCache
+}
+
 // Reset purges the entire blockchain, restoring it to its genesis state.
 func (bc *BlockChain) Reset() error {
 	return bc.ResetWithGenesisBlock(bc.genesisBlock)
@@ -597,11 +685,19 @@ func (bc *BlockChain) GetBlockByNumber(number uint64) *types.Block {
 
 // GetReceiptsByHash retrieves the receipts for all transactions in a given block.
 func (bc *BlockChain) GetReceiptsByHash(hash common.Hash) types.Receipts {
+	if receipts, ok := bc.receiptsCache.Get(hash); ok {
+		return receipts.(types.Receipts)
+	}
 	number := rawdb.ReadHeaderNumber(bc.db, hash)
 	if number == nil {
 		return nil
 	}
-	return rawdb.ReadReceipts(bc.db, hash, *number)
+	receipts := rawdb.ReadReceipts(bc.db, hash, *number)
+	if receipts != nil {
+		// Only cache found receipts, they might be inserted later (fast sync)
+		bc.receiptsCache.Add(hash, receipts)
+	}
+	return receipts
 }
 
 // GetBlocksFromHash returns the block corresponding to hash and up to n-1 ancestors.
@@ -658,10 +754,10 @@ func (bc *BlockChain) Stop() {
 	//  - HEAD:     So we don't need to reprocess any blocks in the general case
 	//  - HEAD-1:   So we don't do large reorgs if our HEAD becomes an uncle
 	//  - HEAD-127: So we have a hard limit on the number of blocks reexecuted
-	if !bc.cacheConfig.Disabled {
+	if !bc.cacheConfig.Disabled && !athdb.IsReadOnly(bc.db) {
 		triedb := bc.stateCache.TrieDB()
 
-		for _, offset := range []uint64{0, 1, triesInMemory - 1} {
+		for _, offset := range []uint64{0, 1, bc.cacheConfig.TriesInMemory - 1} {
 			if number := bc.CurrentBlock().NumberU64(); number > offset {
 				recent := bc.GetBlockByNumber(number - offset)
 
@@ -915,7 +1011,7 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 		triedb.Reference(root, common.Hash{}) // metadata reference to keep trie alive
 		bc.triegc.Push(root, -float32(block.NumberU64()))
 
-		if current := block.NumberU64(); current > triesInMemory {
+		if current, retain := block.NumberU64(), bc.cacheConfig.TriesInMemory; current > retain {
 			// If we exceeded our memory allowance, flush matured singleton nodes to disk
 			var (
 				nodes, imgs = triedb.Size()
@@ -925,29 +1021,49 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 				triedb.Cap(limit - athdb.IdealBatchSize)
 			}
 			// Find the next state trie we need to commit
-			header := bc.GetHeaderByNumber(current - triesInMemory)
+			header := bc.GetHeaderByNumber(current - retain)
 			chosen := header.Number.Uint64()
 
 			// If we exceeded out time allowance, flush an entire trie to disk
 			if bc.gcproc > bc.cacheConfig.TrieTimeLimit {
 				// If we're exceeding limits but haven't reached a large enough memory gap,
 				// warn the user that the system is becoming unstable.
-				if chosen < lastWrite+triesInMemory && bc.gcproc >= 2*bc.cacheConfig.TrieTimeLimit {
-					log.Info("State in memory for too long, committing", "time", bc.gcproc, "allowance", bc.cacheConfig.TrieTimeLimit, "optimum", float64(chosen-lastWrite)/triesInMemory)
+				if chosen < lastWrite+retain && bc.gcproc >= 2*bc.cacheConfig.TrieTimeLimit {
+					log.Info("State in memory for too long, committing", "time", bc.gcproc, "allowance", bc.cacheConfig.TrieTimeLimit, "optimum", float64(chosen-lastWrite)/float64(retain))
 				}
 				// Flush an entire trie and restart the counters
-				triedb.Commit(header.Root, true)
+				var (
+					start   = time.Now()
+					nodes   = triedb.NodeCount()
+					size, _ = triedb.Size()
+				)
+				if err := triedb.Commit(header.Root, true); err == nil {
+					remaining, _ := triedb.Size()
+					ev := TrieFlushEvent{
+						Number:  chosen,
+						Root:    header.Root,
+						Nodes:   nodes - triedb.NodeCount(),
+						Size:    size - remaining,
+						Elapsed: time.Since(start),
+					}
+					trieFlushMeter.Mark(1)
+					trieFlushNodesGauge.Update(int64(ev.Nodes))
+					go bc.trieFlushFeed.Send(ev)
+				}
 				lastWrite = chosen
 				bc.gcproc = 0
 			}
-			// Garbage collect anything below our required write retention
-			for !bc.triegc.Empty() {
-				root, number := bc.triegc.Pop()
-				if uint64(-number) > chosen {
-					bc.triegc.Push(root, number)
-					break
+			// Garbage collect anything below our required write retention, batching
+			// the dereferences over the configured interval
+			if current%bc.cacheConfig.TrieGCInterval == 0 {
+				for !bc.triegc.Empty() {
+					root, number := bc.triegc.Pop()
+					if uint64(-number) > chosen {
+						bc.triegc.Push(root, number)
+						break
+					}
+					triedb.Dereference(root.(common.Hash), common.Hash{})
 				}
-				triedb.Dereference(root.(common.Hash), common.Hash{})
 			}
 		}
 	}
@@ -963,12 +1079,17 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 		reorg = block.NumberU64() < currentBlock.NumberU64() || (block.NumberU64() == currentBlock.NumberU64() && mrand.Float64() < 0.5)
 	}
 	if reorg {
-		// Reorganise the chain if the parent is not the head block
+		// Reorganise the chain if the parent is not the head block. If the reorg is
+		// refused for being too deep, stay on the current chain.
 		if block.ParentHash() != currentBlock.Hash() {
-			if err := bc.reorg(currentBlock, block); err != nil {
+			if err := bc.reorg(currentBlock, block); err == errReorgTooDeep {
+				reorg = false
+			} else if err != nil {
 				return NonStatTy, err
 			}
 		}
+	}
+	if reorg {
 		// Write the positional metadata for transaction/receipt lookups and preimages
 		rawdb.WriteTxLookupEntries(batch, block)
 		rawdb.WritePreimages(batch, block.NumberU64(), state.Preimages())
@@ -1027,6 +1148,9 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 	bc.chainmu.Lock()
 	defer bc.chainmu.Unlock()
 
+	atomic.StoreInt32(&bc.importing, 1)
+	defer atomic.StoreInt32(&bc.importing, 0)
+
 	// A queued approach to delivering events. This is generally
 	// faster than direct delivery and requires much less mutex
 	// acquiring.
@@ -1050,6 +1174,14 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 	// Start a parallel signature recovery (signer will fluke on fork transition, minimal perf loss)
 	senderCacher.recoverFromBlocks(types.MakeSigner(bc.chainConfig, chain[0].Number()), chain)
 
+	// Abort any state prefetch still running once the import terminates
+	var prefetcher *statePrefetcher
+	defer func() {
+		if prefetcher != nil {
+			prefetcher.stop(nil)
+		}
+	}()
+
 	// Iterate over the blocks and insert when the verifier permits
 	for i, block := range chain {
 		// If the chain is terminating, stop processing blocks
@@ -1139,10 +1271,19 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 		} else {
 			parent = chain[i-1]
 		}
+		if prefetcher != nil {
+			prefetcher.stop(block)
+			prefetcher = nil
+		}
 		state, err := state.New(parent.Root(), bc.stateCache)
 		if err != nil {
 			return i, events, coalescedLogs, err
 		}
+		// Warm up the state caches for the next block while this one is processed
+		if atomic.LoadInt32(&bc.statePrefetch) == 1 && i+1 < len(chain) {
+			next := chain[i+1]
+			prefetcher = newStatePrefetcher(next, parent.Root(), bc.stateCache, types.MakeSigner(bc.chainConfig, next.Number()))
+		}
 		// Process block using the parent state as reference point.
 		receipts, logs, usedGas, err := bc.processor.Process(block, state, bc.vmConfig)
 		if err != nil {
@@ -1316,6 +1457,11 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 			return fmt.Errorf("Invalid new chain")
 		}
 	}
+	// Refuse reorgs beyond the configured depth, leaving the decision to the user
+	if limit := bc.hc.MaxReorgDepth(); limit > 0 && uint64(len(oldChain)) > limit && len(newChain) > 0 {
+		bc.hc.reportReorgRejected(newChain[len(newChain)-1].Hash(), ReorgRejectedEvent{CommonAncestor: commonBlock, OldHead: oldChain[0], NewHead: newChain[0], Depth: uint64(len(oldChain))})
+		return errReorgTooDeep
+	}
 	// Ensure the user sees large reorgs
 	if len(oldChain) > 0 && len(newChain) > 0 {
 		logFn := log.Debug
@@ -1352,6 +1498,9 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 				bc.chainSideFeed.Send(ChainSideEvent{Block: block})
 			}
 		}()
+		// Report the depth of the reorg, i.e. the number of canonical blocks dropped
+		reorgDepthHistogram.Update(int64(len(oldChain)))
+		go bc.chainReorgFeed.Send(ChainReorgEvent{CommonAncestor: commonBlock, OldChain: oldChain, NewChain: newChain})
 	}
 
 	return nil
@@ -1404,6 +1553,11 @@ func (bc *BlockChain) BadBlocks() []*types.Block {
 	return blocks
 }
 
+// ClearBadBlocks forgets all the bad blocks seen on the network.
+func (bc *BlockChain) ClearBadBlocks() {
+	bc.badBlocks.Purge()
+}
+
 // addBadBlock adds a bad block to the bad-block LRU cache
 func (bc *BlockChain) addBadBlock(block *types.Block) {
 	bc.badBlocks.Add(block.Hash(), block)
@@ -1536,6 +1690,12 @@ func (bc *BlockChain) GetAncestor(hash common.Hash, number, ancestor uint64, max
 	return bc.hc.GetAncestor(hash, number, ancestor, maxNonCanonical)
 }
 
+// GetCanonicalHash retrieves the hash of the canonical block at the given
+// height, or the zero hash if it's not known.
+func (bc *BlockChain) GetCanonicalHash(number uint64) common.Hash {
+	return bc.hc.GetCanonicalHash(number)
+}
+
 // GetHeaderByNumber retrieves a block header from the database by number,
 // caching it (associated with its hash) if found.
 func (bc *BlockChain) GetHeaderByNumber(number uint64) *types.Header {
@@ -1568,6 +1728,21 @@ func (bc *BlockChain) SubscribeChainSideEvent(ch chan<- ChainSideEvent) event.Su
 	return bc.scope.Track(bc.chainSideFeed.Subscribe(ch))
 }
 
+// SubscribeChainReorgEvent registers a subscription of ChainReorgEvent.
+func (bc *BlockChain) SubscribeChainReorgEvent(ch chan<- ChainReorgEvent) event.Subscription {
+	return bc.scope.Track(bc.chainReorgFeed.Subscribe(ch))
+}
+
+// SubscribeReorgRejectedEvent registers a subscription of ReorgRejectedEvent.
+func (bc *BlockChain) SubscribeReorgRejectedEvent(ch chan<- ReorgRejectedEvent) event.Subscription {
+	return bc.scope.Track(bc.hc.SubscribeReorgRejectedEvent(ch))
+}
+
+// SubscribeTrieFlushEvent registers a subscription of TrieFlushEvent.
+func (bc *BlockChain) SubscribeTrieFlushEvent(ch chan<- TrieFlushEvent) event.Subscription {
+	return bc.scope.Track(bc.trieFlushFeed.Subscribe(ch))
+}
+
 // SubscribeLogsEvent registers a subscription of []*types.Log.
 func (bc *BlockChain) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription {
 	return bc.scope.Track(bc.logsFeed.Subscribe(ch))
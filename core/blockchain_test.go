@@ -979,6 +979,187 @@ done:
 
 }
 
+// Tests that reorgs deeper than the configured limit are refused, keeping the
+// current head and firing a ReorgRejectedEvent.
+func TestMaxReorgDepth(t *testing.T) {
+	var (
+		db      = athdb.NewMemDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig}
+		genesis = gspec.MustCommit(db)
+	)
+	blockchain, _ := NewBlockChain(db, nil, gspec.Config, athash.NewFaker(), vm.Config{})
+	defer blockchain.Stop()
+
+	blockchain.SetMaxReorgDepth(2)
+
+	chain, _ := GenerateChain(gspec.Config, genesis, athash.NewFaker(), db, 3, nil)
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	rejectCh := make(chan ReorgRejectedEvent, 16)
+	blockchain.SubscribeReorgRejectedEvent(rejectCh)
+
+	fork, _ := GenerateChain(gspec.Config, genesis, athash.NewFaker(), db, 5, func(i int, gen *BlockGen) {
+		gen.SetCoinbase(common.Address{0x01})
+	})
+	if _, err := blockchain.InsertChain(fork); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	if head := blockchain.CurrentBlock().Hash(); head != chain[len(chain)-1].Hash() {
+		t.Fatalf("head moved despite deep reorg: have %x, want %x", head, chain[len(chain)-1].Hash())
+	}
+	if !blockchain.HasBlock(fork[len(fork)-1].Hash(), fork[len(fork)-1].NumberU64()) {
+		t.Fatalf("rejected fork not retained as side chain")
+	}
+	select {
+	case ev := <-rejectCh:
+		if ev.Depth != 3 {
+			t.Errorf("rejected depth mismatch: have %d, want %d", ev.Depth, 3)
+		}
+		if ev.CommonAncestor.Hash() != genesis.Hash() {
+			t.Errorf("common ancestor mismatch: have %x, want %x", ev.CommonAncestor.Hash(), genesis.Hash())
+		}
+		if ev.OldHead.Hash() != chain[len(chain)-1].Hash() {
+			t.Errorf("old head mismatch: have %x, want %x", ev.OldHead.Hash(), chain[len(chain)-1].Hash())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("reorg rejection not reported")
+	}
+	// Extend the rejected fork and ensure it isn't reported again
+	extension, _ := GenerateChain(gspec.Config, fork[len(fork)-1], athash.NewFaker(), db, 2, func(i int, gen *BlockGen) {
+		gen.SetCoinbase(common.Address{0x01})
+	})
+	if _, err := blockchain.InsertChain(extension); err != nil {
+		t.Fatalf("failed to extend fork: %v", err)
+	}
+	select {
+	case ev := <-rejectCh:
+		t.Fatalf("rejected fork reported again: new head %x", ev.NewHead.Hash())
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// Tests that header reorgs deeper than the configured limit are refused too, as
+// done when importing headers during fast sync.
+func TestMaxReorgDepthHeaders(t *testing.T) {
+	var (
+		db      = athdb.NewMemDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig}
+		genesis = gspec.MustCommit(db)
+	)
+	blockchain, _ := NewBlockChain(db, nil, gspec.Config, athash.NewFaker(), vm.Config{})
+	defer blockchain.Stop()
+
+	blockchain.SetMaxReorgDepth(2)
+
+	headers := func(blocks []*types.Block) []*types.Header {
+		headers := make([]*types.Header, len(blocks))
+		for i, block := range blocks {
+			headers[i] = block.Header()
+		}
+		return headers
+	}
+	chain, _ := GenerateChain(gspec.Config, genesis, athash.NewFaker(), db, 3, nil)
+	if _, err := blockchain.InsertHeaderChain(headers(chain), 1); err != nil {
+		t.Fatalf("failed to insert headers: %v", err)
+	}
+	rejectCh := make(chan ReorgRejectedEvent, 16)
+	blockchain.SubscribeReorgRejectedEvent(rejectCh)
+
+	fork, _ := GenerateChain(gspec.Config, genesis, athash.NewFaker(), db, 5, func(i int, gen *BlockGen) {
+		gen.SetCoinbase(common.Address{0x01})
+	})
+	if _, err := blockchain.InsertHeaderChain(headers(fork), 1); err != nil {
+		t.Fatalf("failed to insert forked headers: %v", err)
+	}
+	if head := blockchain.CurrentHeader().Hash(); head != chain[len(chain)-1].Hash() {
+		t.Fatalf("head header moved despite deep reorg: have %x, want %x", head, chain[len(chain)-1].Hash())
+	}
+	select {
+	case ev := <-rejectCh:
+		if ev.Depth != 3 {
+			t.Errorf("rejected depth mismatch: have %d, want %d", ev.Depth, 3)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("reorg rejection not reported")
+	}
+	select {
+	case ev := <-rejectCh:
+		t.Fatalf("rejected fork reported again: new head %x", ev.NewHead.Hash())
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// Tests that a reorg event is fired with the dropped and added blocks when the
+// canonical chain is replaced by a heavier fork.
+func TestReorgEvent(t *testing.T) {
+	var (
+		db      = athdb.NewMemDatabase()
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		gspec   = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  GenesisAlloc{addr1: {Balance: big.NewInt(10000000000000)}},
+		}
+		genesis = gspec.MustCommit(db)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainID)
+	)
+
+	blockchain, _ := NewBlockChain(db, nil, gspec.Config, athash.NewFaker(), vm.Config{})
+	defer blockchain.Stop()
+
+	chain, _ := GenerateChain(gspec.Config, genesis, athash.NewFaker(), db, 3, func(i int, gen *BlockGen) {})
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	replacementBlocks, _ := GenerateChain(gspec.Config, genesis, athash.NewFaker(), db, 4, func(i int, gen *BlockGen) {
+		tx, err := types.SignTx(types.NewContractCreation(gen.TxNonce(addr1), new(big.Int), 1000000, new(big.Int), nil), signer, key1)
+		if i == 2 {
+			gen.OffsetTime(-9)
+		}
+		if err != nil {
+			t.Fatalf("failed to create tx: %v", err)
+		}
+		gen.AddTx(tx)
+	})
+	reorgCh := make(chan ChainReorgEvent, 64)
+	blockchain.SubscribeChainReorgEvent(reorgCh)
+	if _, err := blockchain.InsertChain(replacementBlocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	// The third replacement block makes the fork heavier, dropping the whole original chain
+	select {
+	case ev := <-reorgCh:
+		if ev.CommonAncestor.Hash() != genesis.Hash() {
+			t.Errorf("common ancestor mismatch: have %x, want %x", ev.CommonAncestor.Hash(), genesis.Hash())
+		}
+		if len(ev.OldChain) != 3 {
+			t.Fatalf("reorg depth mismatch: have %d, want %d", len(ev.OldChain), 3)
+		}
+		for i, block := range ev.OldChain {
+			if want := chain[len(chain)-1-i].Hash(); block.Hash() != want {
+				t.Errorf("dropped block %d mismatch: have %x, want %x", i, block.Hash(), want)
+			}
+		}
+		if len(ev.NewChain) != 3 {
+			t.Fatalf("added block count mismatch: have %d, want %d", len(ev.NewChain), 3)
+		}
+		for i, block := range ev.NewChain {
+			if want := replacementBlocks[2-i].Hash(); block.Hash() != want {
+				t.Errorf("added block %d mismatch: have %x, want %x", i, block.Hash(), want)
+			}
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for reorg event")
+	}
+	// Extending the new canonical chain must not count as a reorg
+	select {
+	case ev := <-reorgCh:
+		t.Errorf("unexpected reorg event fired: depth %d", len(ev.OldChain))
+	case <-time.After(250 * time.Millisecond):
+	}
+}
+
 // Tests if the canonical block can be fetched from the database during chain insertion.
 func TestCanonicalBlockRetrieval(t *testing.T) {
 	_, blockchain, err := newCanonical(athash.NewFaker(), 0, true)
@@ -1287,6 +1468,90 @@ func TestTrieForkGC(t *testing.T) {
 	}
 }
 
+// Tests that the number of recent states retained in memory can be configured.
+func TestTriesInMemoryConfig(t *testing.T) {
+	engine := athash.NewFaker()
+
+	db := athdb.NewMemDatabase()
+	genesis := new(Genesis).MustCommit(db)
+	blocks, _ := GenerateChain(params.TestChainConfig, genesis, engine, db, 64, func(i int, b *BlockGen) { b.SetCoinbase(common.Address{1}) })
+
+	diskdb := athdb.NewMemDatabase()
+	new(Genesis).MustCommit(diskdb)
+
+	cacheConfig := &CacheConfig{
+		TrieNodeLimit: 256 * 1024 * 1024,
+		TrieTimeLimit: time.Hour,
+		TriesInMemory: 16,
+	}
+	chain, err := NewBlockChain(diskdb, cacheConfig, params.TestChainConfig, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	for i := 0; i < 16; i++ {
+		if block := blocks[len(blocks)-1-i]; !chain.HasState(block.Root()) {
+			t.Errorf("state of block #%d missing", block.NumberU64())
+		}
+	}
+	if block := blocks[len(blocks)-17]; chain.HasState(block.Root()) {
+		t.Errorf("state of block #%d retained beyond the configured limit", block.NumberU64())
+	}
+	// Ensure the defaults are not written back into the caller's config
+	if cacheConfig.TrieGCInterval != 0 {
+		t.Errorf("caller's config modified: have GC interval %d, want %d", cacheConfig.TrieGCInterval, 0)
+	}
+}
+
+// Tests that flushing a trie to disk after exceeding the time allowance is
+// announced with the flushed block and node count.
+func TestTrieFlushEvent(t *testing.T) {
+	engine := athash.NewFaker()
+
+	db := athdb.NewMemDatabase()
+	genesis := new(Genesis).MustCommit(db)
+	blocks, _ := GenerateChain(params.TestChainConfig, genesis, engine, db, 8, func(i int, b *BlockGen) { b.SetCoinbase(common.Address{byte(i + 1)}) })
+
+	diskdb := athdb.NewMemDatabase()
+	new(Genesis).MustCommit(diskdb)
+
+	cacheConfig := &CacheConfig{
+		TrieNodeLimit: 256 * 1024 * 1024,
+		TrieTimeLimit: time.Nanosecond,
+		TriesInMemory: 4,
+	}
+	chain, err := NewBlockChain(diskdb, cacheConfig, params.TestChainConfig, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	flushes := make(chan TrieFlushEvent, 8)
+	sub := chain.SubscribeTrieFlushEvent(flushes)
+	defer sub.Unsubscribe()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	select {
+	case ev := <-flushes:
+		block := blocks[ev.Number-1]
+		if ev.Root != block.Root() {
+			t.Errorf("flushed root mismatch: have %x, want %x", ev.Root, block.Root())
+		}
+		if ev.Nodes <= 0 {
+			t.Errorf("flushed node count mismatch: have %d, want > 0", ev.Nodes)
+		}
+		if ok, _ := diskdb.Has(ev.Root[:]); !ok {
+			t.Errorf("flushed state of block #%d missing from disk", ev.Number)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("trie flush not announced")
+	}
+}
+
 // Tests that doing large reorgs works even if the state associated with the
 // forking point is not available any more.
 func TestLargeReorgTrieGC(t *testing.T) {
@@ -1450,3 +1715,108 @@ func BenchmarkBlockChain_1x1000Executions(b *testing.B) {
 
 	benchmarkLargeNumberOfValueToNonexisting(b, numTxs, numBlocks, recipientFn, dataFn)
 }
+
+// Tests that importing a chain with state prefetching enabled yields the same
+// state as a plain import.
+func TestStatePrefetchImport(t *testing.T) {
+	var (
+		gendb   = athdb.NewMemDatabase()
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		funds   = big.NewInt(1000000000)
+		gspec   = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  GenesisAlloc{address: {Balance: funds}},
+		}
+		genesis = gspec.MustCommit(gendb)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainID)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, athash.NewFaker(), gendb, 64, func(i int, block *BlockGen) {
+		for j := 0; j < i%3+1; j++ {
+			tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{byte(j + 1)}, big.NewInt(1000), params.TxGas, nil, nil), signer, key)
+			if err != nil {
+				panic(err)
+			}
+			block.AddTx(tx)
+		}
+	})
+	db := athdb.NewMemDatabase()
+	gspec.MustCommit(db)
+
+	blockchain, _ := NewBlockChain(db, nil, gspec.Config, athash.NewFaker(), vm.Config{})
+	defer blockchain.Stop()
+
+	blockchain.SetStatePrefetch(true)
+	if n, err := blockchain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to process block %d: %v", n, err)
+	}
+	if head := blockchain.CurrentBlock().Hash(); head != blocks[len(blocks)-1].Hash() {
+		t.Fatalf("head mismatch: have %x, want %x", head, blocks[len(blocks)-1].Hash())
+	}
+	if _, err := blockchain.State(); err != nil {
+		t.Fatalf("failed to open head state: %v", err)
+	}
+}
+
+// importingValidator is a block validator recording whether the chain reported an
+// import in progress while validating, optionally rejecting the body of a block.
+type importingValidator struct {
+	Validator
+	chain     *BlockChain
+	reject    common.Hash
+	importing []bool
+}
+
+func (v *importingValidator) ValidateBody(block *types.Block) error {
+	v.importing = append(v.importing, v.chain.Importing())
+	if block.Hash() == v.reject {
+		return fmt.Errorf("rejected block #%d", block.NumberU64())
+	}
+	return v.Validator.ValidateBody(block)
+}
+
+// Tests that the chain reports an import in progress only while inserting blocks,
+// clearing the flag even when the import fails.
+func TestImportingFlag(t *testing.T) {
+	engine := athash.NewFaker()
+
+	db := athdb.NewMemDatabase()
+	genesis := new(Genesis).MustCommit(db)
+	blocks, _ := GenerateChain(params.TestChainConfig, genesis, engine, db, 4, func(i int, b *BlockGen) { b.SetCoinbase(common.Address{byte(i + 1)}) })
+
+	diskdb := athdb.NewMemDatabase()
+	new(Genesis).MustCommit(diskdb)
+
+	chain, err := NewBlockChain(diskdb, nil, params.TestChainConfig, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	validator := &importingValidator{Validator: chain.Validator(), chain: chain, reject: blocks[3].Hash()}
+	chain.SetValidator(validator)
+
+	if chain.Importing() {
+		t.Fatalf("importing before inserting blocks")
+	}
+	if _, err := chain.InsertChain(blocks[:2]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if chain.Importing() {
+		t.Errorf("importing after a successful insert")
+	}
+	if _, err := chain.InsertChain(blocks[2:]); err == nil {
+		t.Fatalf("rejected block inserted")
+	}
+	if chain.Importing() {
+		t.Errorf("importing after a failed insert")
+	}
+	for i, importing := range validator.importing {
+		if !importing {
+			t.Errorf("block %d: not importing during validation", i)
+		}
+	}
+	if len(validator.importing) != len(blocks) {
+		t.Errorf("validated block count mismatch: have %d, want %d", len(validator.importing), len(blocks))
+	}
+}
@@ -534,6 +534,11 @@ func (s *MatcherSession) Close() {
 	})
 }
 
+// Done returns a channel that is closed when the matching session is terminated.
+func (s *MatcherSession) Done() <-chan struct{} {
+	return s.quit
+}
+
 // Error returns any failure encountered during the matching session.
 func (s *MatcherSession) Error() error {
 	if err := s.err.Load(); err != nil {
@@ -18,6 +18,7 @@ package core
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -31,6 +32,10 @@ import (
 	"github.com/athereum/go-athereum/log"
 )
 
+// errReindexPending is returned if a section range is requested to be reindexed
+// while an earlier request is still being processed.
+var errReindexPending = errors.New("section reindexing already in progress")
+
 // ChainIndexerBackend defines the methods needed to process chain segments in
 // the background and write the segment results into the database. These can be
 // used to create filter blooms or CHTs.
@@ -71,9 +76,11 @@ type ChainIndexer struct {
 	backend  ChainIndexerBackend // Background processor generating the index data content
 	children []*ChainIndexer     // Child indexers to cascade chain updates to
 
-	active uint32          // Flag whather the event loop was started
-	update chan struct{}   // Notification channel that headers should be processed
-	quit   chan chan error // Quit channel to tear down running goroutines
+	active     uint32              // Flag whather the event loop was started
+	update     chan struct{}       // Notification channel that headers should be processed
+	reindex    chan reindexRequest // Section ranges scheduled for reprocessing
+	reindexing uint32              // Flag whather a section range is being reprocessed
+	quit       chan chan error     // Quit channel to tear down running goroutines
 
 	sectionSize uint64 // Number of blocks in a single chain segment to process
 	confirmsReq uint64 // Number of confirmations before processing a completed segment
@@ -88,6 +95,12 @@ type ChainIndexer struct {
 	lock sync.RWMutex
 }
 
+// reindexRequest is a range of already stored sections scheduled to be processed
+// again, both ends inclusive.
+type reindexRequest struct {
+	from, to uint64
+}
+
 // NewChainIndexer creates a new chain indexer to do background processing on
 // chain segments of a given size after certain number of confirmations passed.
 // The throttling parameter might be used to prevent database thrashing.
@@ -97,6 +110,7 @@ func NewChainIndexer(chainDb, indexDb athdb.Database, backend ChainIndexerBacken
 		indexDb:     indexDb,
 		backend:     backend,
 		update:      make(chan struct{}, 1),
+		reindex:     make(chan reindexRequest, 1),
 		quit:        make(chan chan error),
 		sectionSize: section,
 		confirmsReq: confirm,
@@ -275,6 +289,11 @@ func (c *ChainIndexer) updateLoop() {
 			errc <- nil
 			return
 
+		case req := <-c.reindex:
+			// Stored sections requested to be rebuilt, process them in place
+			c.reindexSections(req.from, req.to)
+			atomic.StoreUint32(&c.reindexing, 0)
+
 		case <-c.update:
 			// Section headers completed (or rolled back), update the index
 			c.lock.Lock()
@@ -335,6 +354,82 @@ func (c *ChainIndexer) updateLoop() {
 	}
 }
 
+// Reindex schedules the already stored sections in the [from, to] range to be
+// processed again in the background, leaving all other sections untouched. Only
+// one range may be scheduled at a time.
+func (c *ChainIndexer) Reindex(from, to uint64) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if from > to {
+		return fmt.Errorf("invalid section range [%d, %d]", from, to)
+	}
+	if to >= c.storedSections {
+		return fmt.Errorf("section %d not indexed, have %d sections", to, c.storedSections)
+	}
+	if !atomic.CompareAndSwapUint32(&c.reindexing, 0, 1) {
+		return errReindexPending
+	}
+	c.reindex <- reindexRequest{from, to}
+	return nil
+}
+
+// reindexSections processes the stored sections in the [from, to] range again,
+// overwriting their previous index data. If a section turns out to end in a
+// different head than recorded, all subsequent sections are dropped and left for
+// the update loop to rebuild. Children are notified to reprocess their sections
+// depending on the range.
+func (c *ChainIndexer) reindexSections(from, to uint64) {
+	c.log.Info("Reindexing chain sections", "from", from, "to", to)
+	start := time.Now()
+
+	for section := from; section <= to; section++ {
+		c.lock.Lock()
+		if section >= c.storedSections {
+			// Sections reverted by a reorg are rebuilt by the regular update path
+			c.lock.Unlock()
+			break
+		}
+		var oldHead common.Hash
+		if section > 0 {
+			oldHead = c.SectionHead(section - 1)
+		}
+		c.lock.Unlock()
+
+		newHead, err := c.processSection(section, oldHead)
+		if err != nil {
+			c.log.Error("Section reindexing failed", "section", section, "error", err)
+			break
+		}
+		c.lock.Lock()
+		if section >= c.storedSections || (section > 0 && oldHead != c.SectionHead(section-1)) {
+			c.lock.Unlock()
+			break
+		}
+		if newHead != c.SectionHead(section) {
+			c.setSectionHead(section, newHead)
+			c.setValidSections(section + 1)
+
+			select {
+			case c.update <- struct{}{}:
+			default:
+			}
+		}
+		c.lock.Unlock()
+	}
+	c.lock.Lock()
+	if c.storedSections > 0 {
+		c.cascadedHead = c.storedSections*c.sectionSize - 1
+		for _, child := range c.children {
+			child.newHead(from*c.sectionSize, true)
+			child.newHead(c.cascadedHead, false)
+		}
+	}
+	c.lock.Unlock()
+
+	c.log.Info("Reindexed chain sections", "from", from, "to", to, "elapsed", common.PrettyDuration(time.Since(start)))
+}
+
 // processSection processes an entire section by calling backend functions while
 // ensuring the continuity of the passed headers. Since the chain mutex is not
 // held while processing, the continuity can be broken by a long reorg, in which
@@ -27,6 +27,7 @@ import (
 	"github.com/athereum/go-athereum/core/rawdb"
 	"github.com/athereum/go-athereum/core/types"
 	"github.com/athereum/go-athereum/athdb"
+	"github.com/athereum/go-athereum/event"
 )
 
 // Runs multiple tests with randomized parameters.
@@ -133,6 +134,146 @@ func testChainIndexer(t *testing.T, count int) {
 	}
 }
 
+// Tests that a range of stored sections can be reprocessed without touching the
+// sections outside of it.
+func TestChainIndexerReindex(t *testing.T) {
+	db := athdb.NewMemDatabase()
+	defer db.Close()
+
+	backend := &testChainIndexBackend{t: t, processCh: make(chan uint64)}
+	backend.indexer = NewChainIndexer(db, athdb.NewTable(db, "i"), backend, 10, 0, 0, "indexer")
+	defer backend.indexer.Close()
+
+	for i := uint64(0); i < 50; i++ {
+		header := &types.Header{Number: big.NewInt(int64(i)), Extra: big.NewInt(rand.Int63()).Bytes()}
+		if i > 0 {
+			header.ParentHash = rawdb.ReadCanonicalHash(db, i-1)
+		}
+		rawdb.WriteHeader(db, header)
+		rawdb.WriteCanonicalHash(db, header.Hash(), i)
+	}
+	backend.indexer.newHead(49, false)
+	backend.assertBlocks(49, 49)
+	backend.assertSections()
+
+	// Reject ranges that are inverted or reach past the stored sections
+	if err := backend.indexer.Reindex(3, 1); err == nil {
+		t.Errorf("inverted range accepted")
+	}
+	if err := backend.indexer.Reindex(4, 5); err == nil {
+		t.Errorf("unindexed section accepted")
+	}
+	// Reindex a range in the middle and ensure only its blocks are processed
+	head := backend.indexer.SectionHead(2)
+	if err := backend.indexer.Reindex(1, 2); err != nil {
+		t.Fatalf("failed to schedule reindex: %v", err)
+	}
+	if err := backend.indexer.Reindex(0, 0); err != errReindexPending {
+		t.Errorf("concurrent reindex error mismatch: have %v, want %v", err, errReindexPending)
+	}
+	for want := uint64(10); want < 30; want++ {
+		select {
+		case <-time.After(10 * time.Second):
+			t.Fatalf("Expected processed block #%d, got nothing", want)
+		case processed := <-backend.processCh:
+			if processed != want {
+				t.Errorf("Expected processed block #%d, got #%d", want, processed)
+			}
+		}
+	}
+	backend.assertSections()
+	if have := backend.indexer.SectionHead(2); have != head {
+		t.Errorf("section head mismatch: have %x, want %x", have, head)
+	}
+	select {
+	case processed := <-backend.processCh:
+		t.Errorf("Unexpected processed block #%d", processed)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// Tests that reindexing a started indexer is serialized with the processing of
+// sections completed by new chain head events, as they share the same backend.
+func TestChainIndexerReindexStarted(t *testing.T) {
+	db := athdb.NewMemDatabase()
+	defer db.Close()
+
+	backend := &testChainIndexBackend{t: t, processCh: make(chan uint64)}
+	backend.indexer = NewChainIndexer(db, athdb.NewTable(db, "i"), backend, 10, 0, 0, "indexer")
+	defer backend.indexer.Close()
+
+	headers := make([]*types.Header, 50)
+	for i := range headers {
+		headers[i] = &types.Header{Number: big.NewInt(int64(i)), Extra: big.NewInt(rand.Int63()).Bytes()}
+		if i > 0 {
+			headers[i].ParentHash = headers[i-1].Hash()
+		}
+		rawdb.WriteHeader(db, headers[i])
+		rawdb.WriteCanonicalHash(db, headers[i].Hash(), uint64(i))
+	}
+	chain := &testChainIndexChain{head: headers[29]}
+	backend.indexer.Start(chain)
+	backend.assertBlocks(29, 29)
+	backend.assertSections()
+
+	// Extend the chain and schedule a reindex while the new sections are processed
+	go func() {
+		for _, header := range headers[30:] {
+			chain.feed.Send(ChainEvent{Block: types.NewBlockWithHeader(header), Hash: header.Hash()})
+		}
+	}()
+	var processed []uint64
+	select {
+	case <-time.After(10 * time.Second):
+		t.Fatalf("Expected processed block #30, got nothing")
+	case number := <-backend.processCh:
+		processed = append(processed, number)
+	}
+	if err := backend.indexer.Reindex(0, 1); err != nil {
+		t.Fatalf("failed to schedule reindex: %v", err)
+	}
+	for len(processed) < 40 {
+		select {
+		case <-time.After(10 * time.Second):
+			t.Fatalf("Expected 40 processed blocks, got %d", len(processed))
+		case number := <-backend.processCh:
+			processed = append(processed, number)
+		}
+	}
+	// Sections must be processed whole, one after the other
+	done := make(map[uint64]bool)
+	for i := 0; i < len(processed); i += 10 {
+		section := processed[i] / 10
+		for j := uint64(0); j < 10; j++ {
+			if want := section*10 + j; processed[i+int(j)] != want {
+				t.Fatalf("Interleaved section processing: have #%d, want #%d", processed[i+int(j)], want)
+			}
+		}
+		done[section] = true
+	}
+	for _, section := range []uint64{0, 1, 3, 4} {
+		if !done[section] {
+			t.Errorf("section %d not processed", section)
+		}
+	}
+	backend.stored = 5
+	backend.assertSections()
+}
+
+// testChainIndexChain implements ChainIndexerChain
+type testChainIndexChain struct {
+	head *types.Header
+	feed event.Feed
+}
+
+func (c *testChainIndexChain) CurrentHeader() *types.Header {
+	return c.head
+}
+
+func (c *testChainIndexChain) SubscribeChainEvent(ch chan<- ChainEvent) event.Subscription {
+	return c.feed.Subscribe(ch)
+}
+
 // testChainIndexBackend implements ChainIndexerBackend
 type testChainIndexBackend struct {
 	t                          *testing.T
@@ -17,6 +17,8 @@
 package core
 
 import (
+	"time"
+
 	"github.com/athereum/go-athereum/common"
 	"github.com/athereum/go-athereum/core/types"
 )
@@ -24,9 +26,29 @@ import (
 // NewTxsEvent is posted when a batch of transactions enter the transaction pool.
 type NewTxsEvent struct{ Txs []*types.Transaction }
 
-// PendingLogsEvent is posted pre mining and notifies of pending logs.
+// TxEvictionReason describes why transactions were evicted from the pool.
+type TxEvictionReason string
+
+const (
+	EvictUnderpriced TxEvictionReason = "underpriced" // Displaced by better priced transactions in a full pool
+	EvictNonceGap    TxEvictionReason = "noncegap"    // Stuck behind a nonce gap while the queue limits were exceeded
+	EvictLifetime    TxEvictionReason = "lifetime"    // Queued for longer than the configured lifetime
+	EvictPendingCap  TxEvictionReason = "pendingcap"  // Executable, but over the pending limits as a high volume sender
+)
+
+// TxsEvictedEvent is posted when transactions are evicted from the transaction
+// pool due to price or limit pressure, as opposed to being mined or invalidated.
+type TxsEvictedEvent struct {
+	Hashes []common.Hash
+	Reason TxEvictionReason
+}
+
+// PendingLogsEvent is posted pre mining and notifies of pending logs. If Reset is
+// set, the pending block was rebuilt and Logs is its complete set of logs, which
+// supersedes any previously announced ones.
 type PendingLogsEvent struct {
-	Logs []*types.Log
+	Logs  []*types.Log
+	Reset bool
 }
 
 // PendingStateEvent is posted pre mining and notifies of pending state changes.
@@ -49,3 +71,34 @@ type ChainSideEvent struct {
 }
 
 type ChainHeadEvent struct{ Block *types.Block }
+
+// ChainReorgEvent is posted when the canonical chain is reorganised, and forwarded
+// onto the event mux by the full node. OldChain are the blocks dropped from the
+// canonical chain and NewChain the ones replacing them, both ordered from the tip
+// back towards the common ancestor. The depth of the reorg is the length of
+// OldChain.
+type ChainReorgEvent struct {
+	CommonAncestor *types.Block
+	OldChain       types.Blocks
+	NewChain       types.Blocks
+}
+
+// ReorgRejectedEvent is posted when a reorg was refused for dropping more canonical
+// blocks than the configured maximum reorg depth. The node stays on OldHead until
+// the competing chain is dealt with manually.
+type ReorgRejectedEvent struct {
+	CommonAncestor *types.Block
+	OldHead        *types.Block
+	NewHead        *types.Block
+	Depth          uint64 // Number of canonical blocks the reorg would have dropped
+}
+
+// TrieFlushEvent is posted when the in-memory state of a block is flushed to
+// disk because the TrieTimeout allowance was exceeded.
+type TrieFlushEvent struct {
+	Number  uint64             // Number of the block whose state was flushed
+	Root    common.Hash        // State root of the flushed trie
+	Nodes   int                // Number of trie nodes written to disk
+	Size    common.StorageSize // Size of the trie nodes written to disk
+	Elapsed time.Duration      // Time taken to flush the trie
+}
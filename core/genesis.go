@@ -40,7 +40,10 @@ import (
 //go:generate gencodec -type Genesis -field-override genesisSpecMarshaling -out gen_genesis.go
 //go:generate gencodec -type GenesisAccount -field-override genesisAccountMarshaling -out gen_genesis_account.go
 
-var errGenesisNoConfig = errors.New("genesis has no chain configuration")
+var (
+	errGenesisNoConfig = errors.New("genesis has no chain configuration")
+	errGenesisNotFound = errors.New("genesis block not found in database")
+)
 
 // Genesis specifies the header fields, state of a genesis block. It also defines hard
 // fork switch-over blocks through the chain configuration.
@@ -205,6 +208,34 @@ func SetupGenesisBlock(db athdb.Database, genesis *Genesis) (*params.ChainConfig
 	return newcfg, stored, nil
 }
 
+// ReadGenesisConfig is the read-only counterpart of SetupGenesisBlock. It
+// retrieves the chain configuration of an already initialised database without
+// ever writing to it, failing if no genesis block is stored yet.
+//
+// The stored chain configuration takes precedence; the supplied genesis (if
+// any) is only used to validate the stored genesis hash and as a fallback when
+// no configuration was persisted.
+func ReadGenesisConfig(db athdb.Database, genesis *Genesis) (*params.ChainConfig, common.Hash, error) {
+	if genesis != nil && genesis.Config == nil {
+		return params.AllEthashProtocolChanges, common.Hash{}, errGenesisNoConfig
+	}
+	stored := rawdb.ReadCanonicalHash(db, 0)
+	if (stored == common.Hash{}) {
+		return nil, common.Hash{}, errGenesisNotFound
+	}
+	if genesis != nil {
+		hash := genesis.ToBlock(nil).Hash()
+		if hash != stored {
+			return genesis.Config, hash, &GenesisMismatchError{stored, hash}
+		}
+	}
+	if storedcfg := rawdb.ReadChainConfig(db, stored); storedcfg != nil {
+		return storedcfg, stored, nil
+	}
+	log.Warn("Found genesis block without chain config")
+	return genesis.configOrDefault(stored), stored, nil
+}
+
 func (g *Genesis) configOrDefault(ghash common.Hash) *params.ChainConfig {
 	switch {
 	case g != nil:
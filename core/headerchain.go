@@ -31,6 +31,7 @@ import (
 	"github.com/athereum/go-athereum/core/rawdb"
 	"github.com/athereum/go-athereum/core/types"
 	"github.com/athereum/go-athereum/athdb"
+	"github.com/athereum/go-athereum/event"
 	"github.com/athereum/go-athereum/log"
 	"github.com/athereum/go-athereum/params"
 	"github.com/hashicorp/golang-lru"
@@ -40,6 +41,10 @@ const (
 	headerCacheLimit = 512
 	tdCacheLimit     = 1024
 	numberCacheLimit = 2048
+
+	// rejectedForkLimit is the number of refused deep forks remembered to report
+	// each of them only once.
+	rejectedForkLimit = 16
 )
 
 // HeaderChain implements the basic block header chain logic that is shared by
@@ -62,6 +67,10 @@ type HeaderChain struct {
 
 	procInterrupt func() bool
 
+	maxReorgDepth uint64     // Maximum number of canonical headers a reorg may drop, zero if unlimited (atomic access)
+	rejectedForks *lru.Cache // First headers of the forks already refused for being too deep
+	reorgRejFeed  event.Feed // Feed reporting refused reorgs, once per fork
+
 	rand   *mrand.Rand
 	engine consensus.Engine
 }
@@ -71,9 +80,19 @@ type HeaderChain struct {
 //  procInterrupt points to the parent's interrupt semaphore
 //  wg points to the parent's shutdown wait group
 func NewHeaderChain(chainDb athdb.Database, config *params.ChainConfig, engine consensus.Engine, procInterrupt func() bool) (*HeaderChain, error) {
-	headerCache, _ := lru.New(headerCacheLimit)
+	return newHeaderChain(chainDb, config, engine, procInterrupt, 0)
+}
+
+// newHeaderChain creates a new HeaderChain caching up to the given number of
+// recent headers. A zero cache limit selects the default one.
+func newHeaderChain(chainDb athdb.Database, config *params.ChainConfig, engine consensus.Engine, procInterrupt func() bool, cacheLimit int) (*HeaderChain, error) {
+	if cacheLimit <= 0 {
+		cacheLimit = headerCacheLimit
+	}
+	headerCache, _ := lru.New(cacheLimit)
 	tdCache, _ := lru.New(tdCacheLimit)
 	numberCache, _ := lru.New(numberCacheLimit)
+	rejectedForks, _ := lru.New(rejectedForkLimit)
 
 	// Seed a fast but crypto originating random generator
 	seed, err := crand.Int(crand.Reader, big.NewInt(math.MaxInt64))
@@ -87,6 +106,7 @@ func NewHeaderChain(chainDb athdb.Database, config *params.ChainConfig, engine c
 		headerCache:   headerCache,
 		tdCache:       tdCache,
 		numberCache:   numberCache,
+		rejectedForks: rejectedForks,
 		procInterrupt: procInterrupt,
 		rand:          mrand.New(mrand.NewSource(seed.Int64())),
 		engine:        engine,
@@ -154,7 +174,11 @@ func (hc *HeaderChain) WriteHeader(header *types.Header) (status WriteStatus, er
 	// If the total difficulty is higher than our known, add it to the canonical chain
 	// Second clause in the if statement reduces the vulnerability to selfish mining.
 	// Please refer to http://www.cs.cornell.edu/~ie53/publications/btcProcFC.pdf
-	if externTd.Cmp(localTd) > 0 || (externTd.Cmp(localTd) == 0 && mrand.Float64() < 0.5) {
+	reorg := externTd.Cmp(localTd) > 0 || (externTd.Cmp(localTd) == 0 && mrand.Float64() < 0.5)
+	if reorg && header.ParentHash != hc.currentHeaderHash {
+		reorg = hc.reorgAllowed(header)
+	}
+	if reorg {
 		// Delete any canonical number assignments above the new head
 		for i := number + 1; ; i++ {
 			hash := rawdb.ReadCanonicalHash(hc.chainDb, i)
@@ -194,6 +218,68 @@ func (hc *HeaderChain) WriteHeader(header *types.Header) (status WriteStatus, er
 	return
 }
 
+// reorgAllowed checks whether making the given side chain header the new head
+// stays within the configured reorg depth, reporting the fork otherwise.
+func (hc *HeaderChain) reorgAllowed(header *types.Header) bool {
+	limit := atomic.LoadUint64(&hc.maxReorgDepth)
+	if limit == 0 {
+		return true
+	}
+	// Walk the fork back until it joins the canonical chain
+	fork := header
+	for {
+		parent := hc.GetHeader(fork.ParentHash, fork.Number.Uint64()-1)
+		if parent == nil {
+			return true // Missing ancestry, leave it to the regular import
+		}
+		if rawdb.ReadCanonicalHash(hc.chainDb, parent.Number.Uint64()) == parent.Hash() {
+			head := hc.CurrentHeader()
+			if depth := head.Number.Uint64() - parent.Number.Uint64(); depth > limit {
+				hc.reportReorgRejected(fork.Hash(), ReorgRejectedEvent{
+					CommonAncestor: types.NewBlockWithHeader(parent),
+					OldHead:        types.NewBlockWithHeader(head),
+					NewHead:        types.NewBlockWithHeader(header),
+					Depth:          depth,
+				})
+				return false
+			}
+			return true
+		}
+		fork = parent
+	}
+}
+
+// reportReorgRejected fires a ReorgRejectedEvent for a refused reorg, unless one
+// was already fired for the fork starting with the given block.
+func (hc *HeaderChain) reportReorgRejected(fork common.Hash, ev ReorgRejectedEvent) {
+	if known, _ := hc.rejectedForks.ContainsOrAdd(fork, struct{}{}); known {
+		log.Debug("Refusing deep chain reorg of known fork", "number", ev.CommonAncestor.Number(), "newhead", ev.NewHead.Hash())
+		return
+	}
+	log.Error("Refusing deep chain reorg", "number", ev.CommonAncestor.Number(), "hash", ev.CommonAncestor.Hash(),
+		"drop", ev.Depth, "limit", atomic.LoadUint64(&hc.maxReorgDepth), "newhead", ev.NewHead.Hash())
+	reorgRejectedMeter.Mark(1)
+	go hc.reorgRejFeed.Send(ev)
+}
+
+// SetMaxReorgDepth limits the number of canonical headers a reorg may drop. Deeper
+// reorgs are refused, keeping the current chain and firing a ReorgRejectedEvent
+// once per refused fork. Zero disables the limit.
+func (hc *HeaderChain) SetMaxReorgDepth(depth uint64) {
+	atomic.StoreUint64(&hc.maxReorgDepth, depth)
+}
+
+// MaxReorgDepth returns the maximum number of canonical headers a reorg may drop,
+// zero if unlimited.
+func (hc *HeaderChain) MaxReorgDepth() uint64 {
+	return atomic.LoadUint64(&hc.maxReorgDepth)
+}
+
+// SubscribeReorgRejectedEvent registers a subscription of ReorgRejectedEvent.
+func (hc *HeaderChain) SubscribeReorgRejectedEvent(ch chan<- ReorgRejectedEvent) event.Subscription {
+	return hc.reorgRejFeed.Subscribe(ch)
+}
+
 // WhCallback is a callback function for inserting individual headers.
 // A callback is used for two reasons: first, in a LightChain, status should be
 // processed and light chain events sent, while in a BlockChain this is not
@@ -412,6 +498,12 @@ func (hc *HeaderChain) HasHeader(hash common.Hash, number uint64) bool {
 	return rawdb.HasHeader(hc.chainDb, hash, number)
 }
 
+// GetCanonicalHash retrieves the hash of the canonical block at the given
+// height, or the zero hash if it's not known.
+func (hc *HeaderChain) GetCanonicalHash(number uint64) common.Hash {
+	return rawdb.ReadCanonicalHash(hc.chainDb, number)
+}
+
 // GetHeaderByNumber retrieves a block header from the database by number,
 // caching it (associated with its hash) if found.
 func (hc *HeaderChain) GetHeaderByNumber(number uint64) *types.Header {
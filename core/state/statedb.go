@@ -260,6 +260,35 @@ func (self *StateDB) StorageTrie(addr common.Address) Trie {
 	return cpy.updateTrie(self.db)
 }
 
+// proofList collects the nodes of a Merkle proof in the order they are produced,
+// from the root downwards.
+type proofList [][]byte
+
+func (n *proofList) Put(key []byte, value []byte) error {
+	*n = append(*n, value)
+	return nil
+}
+
+// GetProof returns the Merkle proof of the given account in the account trie. For
+// non-existent accounts the proof proves their absence.
+func (self *StateDB) GetProof(addr common.Address) ([][]byte, error) {
+	var proof proofList
+	err := self.trie.Prove(crypto.Keccak256(addr.Bytes()), 0, &proof)
+	return [][]byte(proof), err
+}
+
+// GetStorageProof returns the Merkle proof of the given storage slot in the
+// storage trie of an account.
+func (self *StateDB) GetStorageProof(addr common.Address, key common.Hash) ([][]byte, error) {
+	trie := self.StorageTrie(addr)
+	if trie == nil {
+		return nil, fmt.Errorf("storage trie of %x does not exist", addr)
+	}
+	var proof proofList
+	err := trie.Prove(crypto.Keccak256(key.Bytes()), 0, &proof)
+	return [][]byte(proof), err
+}
+
 func (self *StateDB) HasSuicided(addr common.Address) bool {
 	stateObject := self.getStateObject(addr)
 	if stateObject != nil {
@@ -32,7 +32,9 @@ import (
 
 	"github.com/athereum/go-athereum/common"
 	"github.com/athereum/go-athereum/core/types"
+	"github.com/athereum/go-athereum/crypto"
 	"github.com/athereum/go-athereum/athdb"
+	"github.com/athereum/go-athereum/trie"
 )
 
 // Tests that updating a state trie does not leak any database writes prior to
@@ -435,3 +437,53 @@ func TestCopyOfCopy(t *testing.T) {
 		t.Fatalf("2nd copy fail, expected 42, got %v", got)
 	}
 }
+
+// Tests that account and storage proofs verify against the state roots, and that
+// absent accounts yield a valid exclusion proof.
+func TestGetProof(t *testing.T) {
+	db := NewDatabase(athdb.NewMemDatabase())
+	sdb, _ := New(common.Hash{}, db)
+
+	addr, key := common.HexToAddress("aaaa"), common.HexToHash("01")
+	sdb.SetBalance(addr, big.NewInt(42))
+	sdb.SetState(addr, key, common.HexToHash("2a"))
+	root, _ := sdb.Commit(false)
+
+	sdb, _ = New(root, db)
+
+	// proofDb indexes the nodes of a proof by hash, as expected by the verifier
+	proofDb := func(proof [][]byte) *athdb.MemDatabase {
+		db := athdb.NewMemDatabase()
+		for _, node := range proof {
+			db.Put(crypto.Keccak256(node), node)
+		}
+		return db
+	}
+	// Check the proof of an existing account and its storage slot
+	proof, err := sdb.GetProof(addr)
+	if err != nil {
+		t.Fatalf("failed to prove account: %v", err)
+	}
+	if val, _, err := trie.VerifyProof(root, crypto.Keccak256(addr.Bytes()), proofDb(proof)); err != nil || val == nil {
+		t.Fatalf("account proof verification failed: value %x, error %v", val, err)
+	}
+	proof, err = sdb.GetStorageProof(addr, key)
+	if err != nil {
+		t.Fatalf("failed to prove storage: %v", err)
+	}
+	storageRoot := sdb.StorageTrie(addr).Hash()
+	if val, _, err := trie.VerifyProof(storageRoot, crypto.Keccak256(key.Bytes()), proofDb(proof)); err != nil || val == nil {
+		t.Fatalf("storage proof verification failed: value %x, error %v", val, err)
+	}
+	// Check that a missing account is proven absent
+	missing := common.HexToAddress("bbbb")
+	if proof, err = sdb.GetProof(missing); err != nil {
+		t.Fatalf("failed to prove missing account: %v", err)
+	}
+	if val, _, err := trie.VerifyProof(root, crypto.Keccak256(missing.Bytes()), proofDb(proof)); err != nil || val != nil {
+		t.Fatalf("exclusion proof verification failed: value %x, error %v", val, err)
+	}
+	if _, err := sdb.GetStorageProof(missing, key); err == nil {
+		t.Fatalf("storage proof of missing account succeeded")
+	}
+}
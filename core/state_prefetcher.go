@@ -0,0 +1,92 @@
+// Copyright 2018 The go-athereum Authors
+// This file is part of the go-athereum library.
+//
+// The go-athereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-athereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-athereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync/atomic"
+
+	"github.com/athereum/go-athereum/common"
+	"github.com/athereum/go-athereum/core/state"
+	"github.com/athereum/go-athereum/core/types"
+	"github.com/athereum/go-athereum/metrics"
+)
+
+var (
+	prefetchHitMeter  = metrics.NewRegisteredMeter("chain/prefetch/hits", nil)
+	prefetchMissMeter = metrics.NewRegisteredMeter("chain/prefetch/misses", nil)
+)
+
+// statePrefetcher speculatively loads the accounts touched by the transactions
+// of a block into the state caches, so that the block's execution finds them
+// warm. The state is read at the root of an earlier block, as the block's real
+// parent state is usually not yet available when the prefetch starts.
+type statePrefetcher struct {
+	block     *types.Block
+	done      chan struct{}
+	interrupt uint32
+}
+
+// newStatePrefetcher starts prefetching the accounts of the given block on top
+// of the state at root.
+func newStatePrefetcher(block *types.Block, root common.Hash, db state.Database, signer types.Signer) *statePrefetcher {
+	p := &statePrefetcher{
+		block: block,
+		done:  make(chan struct{}),
+	}
+	go p.prefetch(root, db, signer)
+	return p
+}
+
+// prefetch reads the senders, recipients and coinbase of the block from the
+// state, aborting early if interrupted.
+func (p *statePrefetcher) prefetch(root common.Hash, db state.Database, signer types.Signer) {
+	defer close(p.done)
+
+	statedb, err := state.New(root, db)
+	if err != nil {
+		return
+	}
+	for _, tx := range p.block.Transactions() {
+		if atomic.LoadUint32(&p.interrupt) == 1 {
+			return
+		}
+		if from, err := types.Sender(signer, tx); err == nil {
+			statedb.GetNonce(from)
+		}
+		if to := tx.To(); to != nil {
+			statedb.GetCode(*to)
+		}
+	}
+	statedb.GetBalance(p.block.Coinbase())
+}
+
+// stop aborts the prefetch if it is still running. If block is the one being
+// prefetched, the prefetch is accounted as a hit if it completed before the
+// block's execution started, or as a miss otherwise.
+func (p *statePrefetcher) stop(block *types.Block) {
+	select {
+	case <-p.done:
+		if block == p.block {
+			prefetchHitMeter.Mark(1)
+		}
+	default:
+		atomic.StoreUint32(&p.interrupt, 1)
+		if block == p.block {
+			prefetchMissMeter.Mark(1)
+		}
+	}
+}
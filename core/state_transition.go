@@ -132,6 +132,13 @@ func ApplyMessage(evm *vm.EVM, msg Message, gp *GasPool) ([]byte, uint64, bool,
 	return NewStateTransition(evm, msg, gp).TransitionDb()
 }
 
+// ApplyMessageWithVMError is a variant of ApplyMessage which also returns the
+// error the EVM execution failed with (if any) instead of a plain failure flag,
+// allowing callers to tell an explicit revert apart from e.g. running out of gas.
+func ApplyMessageWithVMError(evm *vm.EVM, msg Message, gp *GasPool) ([]byte, uint64, error, error) {
+	return NewStateTransition(evm, msg, gp).transitionDb()
+}
+
 // to returns the recipient of the message.
 func (st *StateTransition) to() common.Address {
 	if st.msg == nil || st.msg.To() == nil /* contract creation */ {
@@ -181,6 +188,13 @@ func (st *StateTransition) preCheck() error {
 // returning the result including the the used gas. It returns an error if it
 // failed. An error indicates a consensus issue.
 func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bool, err error) {
+	ret, usedGas, vmerr, err := st.transitionDb()
+	return ret, usedGas, vmerr != nil, err
+}
+
+// transitionDb is the implementation of TransitionDb, returning the non-consensus
+// EVM error the execution failed with instead of only flagging the failure.
+func (st *StateTransition) transitionDb() (ret []byte, usedGas uint64, vmerr error, err error) {
 	if err = st.preCheck(); err != nil {
 		return
 	}
@@ -192,19 +206,16 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 	// Pay intrinsic gas
 	gas, err := IntrinsicGas(st.data, contractCreation, homestead)
 	if err != nil {
-		return nil, 0, false, err
+		return nil, 0, nil, err
 	}
 	if err = st.useGas(gas); err != nil {
-		return nil, 0, false, err
+		return nil, 0, nil, err
 	}
 
-	var (
-		evm = st.evm
-		// vm errors do not effect consensus and are therefor
-		// not assigned to err, except for insufficient balance
-		// error.
-		vmerr error
-	)
+	// vm errors do not effect consensus and are therefor
+	// not assigned to err, except for insufficient balance
+	// error.
+	evm := st.evm
 	if contractCreation {
 		ret, _, st.gas, vmerr = evm.Create(sender, st.data, st.gas, st.value)
 	} else {
@@ -218,13 +229,13 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 		// sufficient balance to make the transfer happen. The first
 		// balance transfer may never fail.
 		if vmerr == vm.ErrInsufficientBalance {
-			return nil, 0, false, vmerr
+			return nil, 0, nil, vmerr
 		}
 	}
 	st.refundGas()
 	st.state.AddBalance(st.evm.Coinbase, new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.gasPrice))
 
-	return ret, st.gasUsed(), vmerr != nil, err
+	return ret, st.gasUsed(), vmerr, err
 }
 
 func (st *StateTransition) refundGas() {
@@ -18,7 +18,6 @@ package core
 
 import (
 	"errors"
-	"fmt"
 	"math"
 	"math/big"
 	"sort"
@@ -76,6 +75,14 @@ var (
 	// than some meaningful limit a user might use. This is not a consensus error
 	// making the transaction invalid, rather a DOS protection.
 	ErrOversizedData = errors.New("oversized data")
+
+	// ErrUnknownTransaction is returned if a transaction to operate on is not
+	// contained in the pool.
+	ErrUnknownTransaction = errors.New("unknown transaction")
+
+	// ErrKnownTransaction is returned if a transaction to add is already contained
+	// in the pool.
+	ErrKnownTransaction = errors.New("known transaction")
 )
 
 var (
@@ -99,6 +106,8 @@ var (
 	// General tx metrics
 	invalidTxCounter     = metrics.NewRegisteredCounter("txpool/invalid", nil)
 	underpricedTxCounter = metrics.NewRegisteredCounter("txpool/underpriced", nil)
+	duplicateTxMeter     = metrics.NewRegisteredMeter("ath/txpool/duplicate/in", nil) // Dropped as already known
+	evictedTxMeter       = metrics.NewRegisteredMeter("ath/txpool/evicted", nil)      // Evicted due to price or limit pressure
 )
 
 // TxStatus is the current status of a transaction as seen by the pool.
@@ -187,9 +196,11 @@ type TxPool struct {
 	chain        blockChain
 	gasPrice     *big.Int
 	txFeed       event.Feed
+	evictFeed    event.Feed
 	scope        event.SubscriptionScope
 	chainHeadCh  chan ChainHeadEvent
 	chainHeadSub event.Subscription
+	resetHeadCh  chan resetHeadRequest
 	signer       types.Signer
 	mu           sync.RWMutex
 
@@ -228,6 +239,7 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		beats:       make(map[common.Address]time.Time),
 		all:         newTxLookup(),
 		chainHeadCh: make(chan ChainHeadEvent, chainHeadChanSize),
+		resetHeadCh: make(chan resetHeadRequest),
 		gasPrice:    new(big.Int).SetUint64(config.PriceLimit),
 	}
 	pool.locals = newAccountSet(pool.signer)
@@ -291,6 +303,15 @@ func (pool *TxPool) loop() {
 
 				pool.mu.Unlock()
 			}
+		// Handle explicit head resets after chain rewinds
+		case req := <-pool.resetHeadCh:
+			pool.mu.Lock()
+			pool.reset(nil, req.head.Header())
+			head = req.head
+			pool.mu.Unlock()
+
+			close(req.done)
+
 		// Be unsubscribed due to system stopped
 		case <-pool.chainHeadSub.Err():
 			return
@@ -317,9 +338,11 @@ func (pool *TxPool) loop() {
 				}
 				// Any non-locals old enough should be removed
 				if time.Since(pool.beats[addr]) > pool.config.Lifetime {
-					for _, tx := range pool.queue[addr].Flatten() {
+					txs := pool.queue[addr].Flatten()
+					for _, tx := range txs {
 						pool.removeTx(tx.Hash(), true)
 					}
+					pool.evicted(EvictLifetime, txs)
 				}
 			}
 			pool.mu.Unlock()
@@ -337,6 +360,25 @@ func (pool *TxPool) loop() {
 	}
 }
 
+// resetHeadRequest is a request to revalidate the pool against a new chain head.
+type resetHeadRequest struct {
+	head *types.Block
+	done chan struct{}
+}
+
+// ResetHead revalidates the content of the pool against the given block, which
+// also replaces the chain head tracked by the pool. It is meant to be called after
+// the chain was rewound, as no chain head event is emitted in that case. Any
+// transactions still valid on top of the new head are retained.
+func (pool *TxPool) ResetHead(head *types.Block) {
+	req := resetHeadRequest{head: head, done: make(chan struct{})}
+	select {
+	case pool.resetHeadCh <- req:
+		<-req.done
+	case <-pool.chainHeadSub.Err():
+	}
+}
+
 // lockedReset is a wrapper around reset to allow calling it in a thread safe
 // manner. This method is only ever used in the tester!
 func (pool *TxPool) lockedReset(oldHead, newHead *types.Header) {
@@ -451,6 +493,26 @@ func (pool *TxPool) SubscribeNewTxsEvent(ch chan<- NewTxsEvent) event.Subscripti
 	return pool.scope.Track(pool.txFeed.Subscribe(ch))
 }
 
+// SubscribeTxsEvictedEvent registers a subscription of TxsEvictedEvent and
+// starts sending event to the given channel.
+func (pool *TxPool) SubscribeTxsEvictedEvent(ch chan<- TxsEvictedEvent) event.Subscription {
+	return pool.scope.Track(pool.evictFeed.Subscribe(ch))
+}
+
+// evicted accounts for transactions evicted from the pool for the given reason
+// and announces them to subscribers.
+func (pool *TxPool) evicted(reason TxEvictionReason, txs []*types.Transaction) {
+	if len(txs) == 0 {
+		return
+	}
+	hashes := make([]common.Hash, len(txs))
+	for i, tx := range txs {
+		hashes[i] = tx.Hash()
+	}
+	evictedTxMeter.Mark(int64(len(txs)))
+	go pool.evictFeed.Send(TxsEvictedEvent{Hashes: hashes, Reason: reason})
+}
+
 // GasPrice returns the current gas price enforced by the transaction pool.
 func (pool *TxPool) GasPrice() *big.Int {
 	pool.mu.RLock()
@@ -520,6 +582,22 @@ func (pool *TxPool) Content() (map[common.Address]types.Transactions, map[common
 	return pending, queued
 }
 
+// ContentFrom retrieves the pending and queued transactions of a single account,
+// each sorted by nonce.
+func (pool *TxPool) ContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	var pending, queued types.Transactions
+	if list, ok := pool.pending[addr]; ok {
+		pending = list.Flatten()
+	}
+	if list, ok := pool.queue[addr]; ok {
+		queued = list.Flatten()
+	}
+	return pending, queued
+}
+
 // Pending retrieves all currently processable transactions, groupped by origin
 // account and sorted by nonce. The returned transaction set is a copy and can be
 // freely modified by calling code.
@@ -534,6 +612,40 @@ func (pool *TxPool) Pending() (map[common.Address]types.Transactions, error) {
 	return pending, nil
 }
 
+// Locals retrieves all currently known local transactions, groupped by origin
+// account and sorted by nonce. The returned transaction set is a copy and can be
+// freely modified by calling code.
+func (pool *TxPool) Locals() map[common.Address]types.Transactions {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	return pool.local()
+}
+
+// MarkLocal promotes the sender of a pooled transaction to a local account. As
+// locality is tracked per account, all of the sender's transactions become exempt
+// from eviction and price limits, and are journaled to survive restarts.
+func (pool *TxPool) MarkLocal(hash common.Hash) error {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	tx := pool.all.Get(hash)
+	if tx == nil {
+		return ErrUnknownTransaction
+	}
+	from, _ := types.Sender(pool.signer, tx) // already validated
+	if pool.locals.contains(from) {
+		return nil
+	}
+	pool.locals.add(from)
+
+	for _, tx := range pool.local()[from] {
+		pool.journalTx(from, tx)
+	}
+	log.Debug("Marked account as local", "hash", hash, "from", from)
+	return nil
+}
+
 // local retrieves all currently known local transactions, groupped by origin
 // account and sorted by nonce. The returned transaction set is a copy and can be
 // freely modified by calling code.
@@ -608,7 +720,8 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 	hash := tx.Hash()
 	if pool.all.Get(hash) != nil {
 		log.Trace("Discarding already known transaction", "hash", hash)
-		return false, fmt.Errorf("known transaction: %x", hash)
+		duplicateTxMeter.Mark(1)
+		return false, ErrKnownTransaction
 	}
 	// If the transaction fails basic validation, discard it
 	if err := pool.validateTx(tx, local); err != nil {
@@ -631,6 +744,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 			underpricedTxCounter.Inc(1)
 			pool.removeTx(tx.Hash(), false)
 		}
+		pool.evicted(EvictUnderpriced, drop)
 	}
 	// If the transaction is replacing an already pending one, do directly
 	from, _ := types.Sender(pool.signer, tx) // already validated
@@ -906,8 +1020,8 @@ func (pool *TxPool) removeTx(hash common.Hash, outofbound bool) {
 // future queue to the set of pending transactions. During this process, all
 // invalidated transactions (low nonce, low balance) are deleted.
 func (pool *TxPool) promoteExecutables(accounts []common.Address) {
-	// Track the promoted transactions to broadcast them at once
-	var promoted []*types.Transaction
+	// Track the promoted and evicted transactions to broadcast them at once
+	var promoted, gapped, capped []*types.Transaction
 
 	// Gather all the accounts potentially needing updates
 	if accounts == nil {
@@ -954,6 +1068,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 				pool.priced.Removed()
 				queuedRateLimitCounter.Inc(1)
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
+				gapped = append(gapped, tx)
 			}
 		}
 		// Delete the entire queue entry if it became empty.
@@ -1007,6 +1122,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 								pool.pendingState.SetNonce(offenders[i], nonce)
 							}
 							log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
+							capped = append(capped, tx)
 						}
 						pending--
 					}
@@ -1029,6 +1145,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 							pool.pendingState.SetNonce(addr, nonce)
 						}
 						log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
+						capped = append(capped, tx)
 					}
 					pending--
 				}
@@ -1062,6 +1179,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			if size := uint64(list.Len()); size <= drop {
 				for _, tx := range list.Flatten() {
 					pool.removeTx(tx.Hash(), true)
+					gapped = append(gapped, tx)
 				}
 				drop -= size
 				queuedRateLimitCounter.Inc(int64(size))
@@ -1071,11 +1189,14 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			txs := list.Flatten()
 			for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
 				pool.removeTx(txs[i].Hash(), true)
+				gapped = append(gapped, txs[i])
 				drop--
 				queuedRateLimitCounter.Inc(1)
 			}
 		}
 	}
+	pool.evicted(EvictPendingCap, capped)
+	pool.evicted(EvictNonceGap, gapped)
 }
 
 // demoteUnexecutables removes invalid and processed transactions from the pools
@@ -356,6 +356,132 @@ func TestTransactionChainFork(t *testing.T) {
 	}
 }
 
+// Tests that explicitly resetting the pool to a rewound head revalidates the
+// pending transactions against it, retaining the ones still valid.
+func TestTransactionResetHead(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	setState := func(nonce uint64) {
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(athdb.NewMemDatabase()))
+		statedb.AddBalance(addr, big.NewInt(100000000000000))
+		statedb.SetNonce(addr, nonce)
+
+		pool.mu.Lock()
+		pool.chain = &testBlockChain{statedb, 1000000, new(event.Feed)}
+		pool.mu.Unlock()
+	}
+	setState(2)
+	pool.lockedReset(nil, nil)
+
+	if err := pool.AddRemotes([]*types.Transaction{transaction(2, 100000, key), transaction(3, 100000, key)})[0]; err != nil {
+		t.Fatalf("failed to add transactions: %v", err)
+	}
+	if pending, queued := pool.Stats(); pending != 2 || queued != 0 {
+		t.Fatalf("pool content mismatch: have %d/%d pending/queued, want 2/0", pending, queued)
+	}
+	// Rewind the chain to a state where the account has not yet sent anything
+	setState(0)
+	pool.ResetHead(pool.chain.CurrentBlock())
+
+	if pending, queued := pool.Stats(); pending != 0 || queued != 2 {
+		t.Fatalf("pool content mismatch after reset: have %d/%d pending/queued, want 0/2", pending, queued)
+	}
+	// Filling the nonce gap should promote the retained transactions
+	if err := pool.AddRemotes([]*types.Transaction{transaction(0, 100000, key), transaction(1, 100000, key)})[0]; err != nil {
+		t.Fatalf("failed to add gap transactions: %v", err)
+	}
+	if pending, queued := pool.Stats(); pending != 4 || queued != 0 {
+		t.Fatalf("pool content mismatch after gap fill: have %d/%d pending/queued, want 4/0", pending, queued)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the pool content of a single account can be retrieved, split into
+// executable and gapped transactions, both sorted by nonce.
+func TestTransactionContentFrom(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.AddBalance(addr, big.NewInt(100000000000000))
+
+	pool.AddRemotes([]*types.Transaction{
+		transaction(1, 100000, key), transaction(0, 100000, key),
+		transaction(5, 100000, key), transaction(3, 100000, key),
+	})
+	pending, queued := pool.ContentFrom(addr)
+	if len(pending) != 2 || pending[0].Nonce() != 0 || pending[1].Nonce() != 1 {
+		t.Fatalf("pending content mismatch: have %d txs, want nonces 0-1", len(pending))
+	}
+	if len(queued) != 2 || queued[0].Nonce() != 3 || queued[1].Nonce() != 5 {
+		t.Fatalf("queued content mismatch: have %d txs, want nonces 3 and 5", len(queued))
+	}
+	other, _ := crypto.GenerateKey()
+	if pending, queued := pool.ContentFrom(crypto.PubkeyToAddress(other.PublicKey)); len(pending) != 0 || len(queued) != 0 {
+		t.Fatalf("unknown account content mismatch: have %d/%d pending/queued, want 0/0", len(pending), len(queued))
+	}
+}
+
+// Tests that marking a pooled transaction as local turns its sender into a local
+// account, exempting its transactions from price based eviction.
+func TestTransactionMarkLocal(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.AddBalance(addr, big.NewInt(100000000000000))
+
+	tx := transaction(0, 100000, key)
+	if err := pool.AddRemote(tx); err != nil {
+		t.Fatalf("failed to add remote transaction: %v", err)
+	}
+	if len(pool.Locals()) != 0 {
+		t.Fatalf("remote transaction reported as local")
+	}
+	if err := pool.MarkLocal(common.Hash{0x01}); err != ErrUnknownTransaction {
+		t.Fatalf("unknown transaction error mismatch: have %v, want %v", err, ErrUnknownTransaction)
+	}
+	if err := pool.MarkLocal(tx.Hash()); err != nil {
+		t.Fatalf("failed to mark transaction local: %v", err)
+	}
+	if locals := pool.Locals()[addr]; len(locals) != 1 || locals[0].Hash() != tx.Hash() {
+		t.Fatalf("local transactions mismatch: have %v, want %x", locals, tx.Hash())
+	}
+	// Raising the price limit must not evict the now local transaction
+	pool.SetGasPrice(big.NewInt(2))
+	if pool.Get(tx.Hash()) == nil {
+		t.Fatalf("local transaction evicted by price limit")
+	}
+}
+
+// Tests that re-adding an already pooled transaction is reported as a duplicate.
+func TestTransactionDuplicate(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(100000000000000))
+
+	tx := transaction(0, 100000, key)
+	if err := pool.AddRemote(tx); err != nil {
+		t.Fatalf("failed to add remote transaction: %v", err)
+	}
+	if err := pool.AddRemote(tx); err != ErrKnownTransaction {
+		t.Fatalf("duplicate transaction error mismatch: have %v, want %v", err, ErrKnownTransaction)
+	}
+}
+
 func TestTransactionDoubleNonce(t *testing.T) {
 	t.Parallel()
 
@@ -1421,6 +1547,58 @@ func TestTransactionPoolUnderpricing(t *testing.T) {
 	}
 }
 
+// Tests that transactions pushed out of a full pool by better priced ones are
+// metered and announced as underpriced evictions.
+func TestTransactionPoolEvictionEvent(t *testing.T) {
+	t.Parallel()
+
+	// Create a pool with room for only two transactions
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(athdb.NewMemDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.GlobalSlots = 1
+	config.GlobalQueue = 1
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	events := make(chan TxsEvictedEvent, 32)
+	sub := pool.SubscribeTxsEvictedEvent(events)
+	defer sub.Unsubscribe()
+
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := 0; i < len(keys); i++ {
+		keys[i], _ = crypto.GenerateKey()
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000))
+	}
+	cheap := pricedTransaction(0, 100000, big.NewInt(1), keys[0])
+	if err := pool.AddRemote(cheap); err != nil {
+		t.Fatalf("failed to add cheap transaction: %v", err)
+	}
+	if err := pool.AddRemote(pricedTransaction(0, 100000, big.NewInt(2), keys[1])); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	// Fill the pool beyond its capacity and ensure the cheapest one is announced
+	if err := pool.AddRemote(pricedTransaction(0, 100000, big.NewInt(3), keys[2])); err != nil {
+		t.Fatalf("failed to add well priced transaction: %v", err)
+	}
+	select {
+	case ev := <-events:
+		if ev.Reason != EvictUnderpriced {
+			t.Errorf("eviction reason mismatch: have %s, want %s", ev.Reason, EvictUnderpriced)
+		}
+		if len(ev.Hashes) != 1 || ev.Hashes[0] != cheap.Hash() {
+			t.Errorf("evicted hashes mismatch: have %x, want [%x]", ev.Hashes, cheap.Hash())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("eviction event not fired")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // Tests that more expensive transactions push out cheap ones from the pool, but
 // without producing instability by creating gaps that start jumping transactions
 // back and forth between queued/pending.
@@ -0,0 +1,77 @@
+// Copyright 2018 The go-athereum Authors
+// This file is part of the go-athereum library.
+//
+// The go-athereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-athereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-athereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package dashboard
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/athereum/go-athereum/log"
+)
+
+// getCoreCPUTimes retrieves the cumulative busy and total CPU time of every core
+// from /proc/stat.
+func getCoreCPUTimes() []coreCPUTime {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		log.Warn("Failed to retrieve per-core CPU time", "err", err)
+		return nil
+	}
+	defer f.Close()
+
+	times, err := parseCoreCPUTimes(f)
+	if err != nil {
+		log.Warn("Failed to parse per-core CPU time", "err", err)
+		return nil
+	}
+	return times
+}
+
+// parseCoreCPUTimes parses the per-core lines of a /proc/stat formatted stream,
+// skipping the aggregate cpu line. The reported times are in clock ticks.
+func parseCoreCPUTimes(r io.Reader) ([]coreCPUTime, error) {
+	var times []coreCPUTime
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 || !strings.HasPrefix(fields[0], "cpu") || fields[0] == "cpu" {
+			continue
+		}
+		// Sum up user, nice, system, idle, iowait, irq, softirq and steal. Guest
+		// time is already accounted for in user and nice.
+		var time coreCPUTime
+		for i, field := range fields[1:] {
+			if i >= 8 {
+				break
+			}
+			ticks, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			time.total += ticks
+			if i != 3 && i != 4 { // idle and iowait
+				time.busy += ticks
+			}
+		}
+		times = append(times, time)
+	}
+	return times, scanner.Err()
+}
@@ -0,0 +1,53 @@
+// Copyright 2018 The go-athereum Authors
+// This file is part of the go-athereum library.
+//
+// The go-athereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-athereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-athereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package dashboard
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Tests that the per-core lines of /proc/stat are parsed and the usage between
+// two measurements is calculated correctly.
+func TestCoreCPUUsage(t *testing.T) {
+	prev, err := parseCoreCPUTimes(strings.NewReader(
+		"cpu  300 0 100 1600 0 0 0 0 0 0\n" +
+			"cpu0 200 0 50 750 0 0 0 0 0 0\n" +
+			"cpu1 100 0 50 850 0 0 0 0 0 0\n" +
+			"intr 12345 0 0\n"))
+	if err != nil {
+		t.Fatalf("failed to parse first sample: %v", err)
+	}
+	want := []coreCPUTime{{busy: 250, total: 1000}, {busy: 150, total: 1000}}
+	if !reflect.DeepEqual(prev, want) {
+		t.Fatalf("core times mismatch: have %+v, want %+v", prev, want)
+	}
+	cur, err := parseCoreCPUTimes(strings.NewReader(
+		"cpu  400 0 100 1700 0 0 0 0 0 0\n" +
+			"cpu0 290 0 60 750 0 0 0 0 0 0\n" +
+			"cpu1 110 0 50 940 0 0 0 0 0 0\n"))
+	if err != nil {
+		t.Fatalf("failed to parse second sample: %v", err)
+	}
+	if usage := coreCPUUsage(prev, cur); !reflect.DeepEqual(usage, []float64{100, 10}) {
+		t.Errorf("core usage mismatch: have %v, want %v", usage, []float64{100, 10})
+	}
+	if usage := coreCPUUsage(prev, cur[:1]); usage != nil {
+		t.Errorf("mismatching core count usage: have %v, want nil", usage)
+	}
+}
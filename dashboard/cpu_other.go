@@ -0,0 +1,25 @@
+// Copyright 2018 The go-athereum Authors
+// This file is part of the go-athereum library.
+//
+// The go-athereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-athereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-athereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build !linux
+
+package dashboard
+
+// getCoreCPUTimes returns nil on non-Linux platforms as the per-core breakdown
+// is only available through procfs.
+func getCoreCPUTimes() []coreCPUTime {
+	return nil
+}
@@ -235,6 +235,7 @@ func (db *Dashboard) apiHandler(conn *websocket.Conn) {
 			NetworkEgress:  db.charts.NetworkEgress,
 			ProcessCPU:     db.charts.ProcessCPU,
 			SystemCPU:      db.charts.SystemCPU,
+			CoreCPU:        db.charts.CoreCPU,
 			DiskRead:       db.charts.DiskRead,
 			DiskWrite:      db.charts.DiskWrite,
 		},
@@ -258,6 +259,27 @@ func (db *Dashboard) apiHandler(conn *websocket.Conn) {
 	}
 }
 
+// coreCPUTime is the cumulative CPU time of a single core, in clock ticks.
+type coreCPUTime struct {
+	busy  uint64 // Time spent doing work, excluding idle and iowait
+	total uint64 // Total time elapsed on the core
+}
+
+// coreCPUUsage calculates the utilisation percentage of every core between two
+// per-core CPU time measurements. Nil is returned if the core counts mismatch.
+func coreCPUUsage(prev, cur []coreCPUTime) []float64 {
+	if len(cur) == 0 || len(prev) != len(cur) {
+		return nil
+	}
+	usage := make([]float64, len(cur))
+	for i := range cur {
+		if total := cur[i].total - prev[i].total; total > 0 {
+			usage[i] = float64(cur[i].busy-prev[i].busy) / float64(total) * 100
+		}
+	}
+	return usage
+}
+
 // collectData collects the required data to plot on the dashboard.
 func (db *Dashboard) collectData() {
 	defer db.wg.Done()
@@ -270,6 +292,7 @@ func (db *Dashboard) collectData() {
 		prevNetworkEgress  = metrics.DefaultRegistry.Get("p2p/OutboundTraffic").(metrics.Meter).Count()
 		prevProcessCPUTime = getProcessCPUTime()
 		prevSystemCPUUsage = systemCPUUsage
+		prevCoreCPUTimes   = getCoreCPUTimes()
 		prevDiskRead       = metrics.DefaultRegistry.Get("ath/db/chaindata/disk/read").(metrics.Meter).Count()
 		prevDiskWrite      = metrics.DefaultRegistry.Get("ath/db/chaindata/disk/write").(metrics.Meter).Count()
 
@@ -289,6 +312,7 @@ func (db *Dashboard) collectData() {
 				curNetworkEgress  = metrics.DefaultRegistry.Get("p2p/OutboundTraffic").(metrics.Meter).Count()
 				curProcessCPUTime = getProcessCPUTime()
 				curSystemCPUUsage = systemCPUUsage
+				curCoreCPUTimes   = getCoreCPUTimes()
 				curDiskRead       = metrics.DefaultRegistry.Get("ath/db/chaindata/disk/read").(metrics.Meter).Count()
 				curDiskWrite      = metrics.DefaultRegistry.Get("ath/db/chaindata/disk/write").(metrics.Meter).Count()
 
@@ -296,6 +320,7 @@ func (db *Dashboard) collectData() {
 				deltaNetworkEgress  = float64(curNetworkEgress - prevNetworkEgress)
 				deltaProcessCPUTime = curProcessCPUTime - prevProcessCPUTime
 				deltaSystemCPUUsage = curSystemCPUUsage.Delta(prevSystemCPUUsage)
+				coreCPU             = coreCPUUsage(prevCoreCPUTimes, curCoreCPUTimes)
 				deltaDiskRead       = curDiskRead - prevDiskRead
 				deltaDiskWrite      = curDiskWrite - prevDiskWrite
 			)
@@ -303,6 +328,7 @@ func (db *Dashboard) collectData() {
 			prevNetworkEgress = curNetworkEgress
 			prevProcessCPUTime = curProcessCPUTime
 			prevSystemCPUUsage = curSystemCPUUsage
+			prevCoreCPUTimes = curCoreCPUTimes
 			prevDiskRead = curDiskRead
 			prevDiskWrite = curDiskWrite
 
@@ -347,6 +373,7 @@ func (db *Dashboard) collectData() {
 			db.charts.NetworkEgress = append(db.charts.NetworkEgress[1:], networkEgress)
 			db.charts.ProcessCPU = append(db.charts.ProcessCPU[1:], processCPU)
 			db.charts.SystemCPU = append(db.charts.SystemCPU[1:], systemCPU)
+			db.charts.CoreCPU = coreCPU
 			db.charts.DiskRead = append(db.charts.DiskRead[1:], diskRead)
 			db.charts.DiskWrite = append(db.charts.DiskRead[1:], diskWrite)
 
@@ -358,6 +385,7 @@ func (db *Dashboard) collectData() {
 					NetworkEgress:  ChartEntries{networkEgress},
 					ProcessCPU:     ChartEntries{processCPU},
 					SystemCPU:      ChartEntries{systemCPU},
+					CoreCPU:        coreCPU,
 					DiskRead:       ChartEntries{diskRead},
 					DiskWrite:      ChartEntries{diskWrite},
 				},
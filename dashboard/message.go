@@ -63,6 +63,7 @@ type SystemMessage struct {
 	NetworkEgress  ChartEntries `json:"networkEgress,omitempty"`
 	ProcessCPU     ChartEntries `json:"processCPU,omitempty"`
 	SystemCPU      ChartEntries `json:"systemCPU,omitempty"`
+	CoreCPU        []float64    `json:"coreCPU,omitempty"` // Latest utilisation percentage of each core (Linux only)
 	DiskRead       ChartEntries `json:"diskRead,omitempty"`
 	DiskWrite      ChartEntries `json:"diskWrite,omitempty"`
 }
@@ -24,21 +24,31 @@ import (
 	"io"
 	"math/big"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/athereum/go-athereum/common"
 	"github.com/athereum/go-athereum/common/hexutil"
+	"github.com/athereum/go-athereum/consensus/clique"
+	"github.com/athereum/go-athereum/consensus/athash"
 	"github.com/athereum/go-athereum/core"
 	"github.com/athereum/go-athereum/core/rawdb"
 	"github.com/athereum/go-athereum/core/state"
 	"github.com/athereum/go-athereum/core/types"
+	"github.com/athereum/go-athereum/crypto"
+	"github.com/athereum/go-athereum/athdb"
 	"github.com/athereum/go-athereum/internal/athapi"
 	"github.com/athereum/go-athereum/log"
+	"github.com/athereum/go-athereum/metrics"
 	"github.com/athereum/go-athereum/miner"
+	"github.com/athereum/go-athereum/p2p/discover"
 	"github.com/athereum/go-athereum/params"
 	"github.com/athereum/go-athereum/rlp"
 	"github.com/athereum/go-athereum/rpc"
 	"github.com/athereum/go-athereum/trie"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 // PublicAtlantisAPI provides an API to access Atlantis full node-related
@@ -67,6 +77,120 @@ func (api *PublicAtlantisAPI) Hashrate() hexutil.Uint64 {
 	return hexutil.Uint64(api.e.Miner().HashRate())
 }
 
+// Importing returns whether the node is currently importing blocks. Even a node
+// in sync may be busy importing a freshly announced block, during which its
+// state lags behind the network's.
+func (api *PublicAtlantisAPI) Importing() bool {
+	return api.e.Importing()
+}
+
+// PeerTarget describes the current peer count of the node against the bounds it
+// is configured to keep it within.
+type PeerTarget struct {
+	Current hexutil.Uint `json:"current"` // Number of peers currently connected
+	Min     hexutil.Uint `json:"min"`     // Floor below which more peers are dialed, zero if unset
+	Max     hexutil.Uint `json:"max"`     // Maximum number of peers allowed
+}
+
+// PeerTarget returns the current peer count along with the configured minimum
+// and maximum.
+func (api *PublicAtlantisAPI) PeerTarget() (*PeerTarget, error) {
+	srvr := api.e.P2PServer()
+	if srvr == nil {
+		return nil, errServerNotRunning
+	}
+	return &PeerTarget{
+		Current: hexutil.Uint(srvr.PeerCount()),
+		Min:     hexutil.Uint(api.e.config.MinPeers),
+		Max:     hexutil.Uint(srvr.MaxPeers),
+	}, nil
+}
+
+// HealthStatus summarizes whather the node is ready to serve requests.
+type HealthStatus struct {
+	Synced       bool           `json:"synced"`       // Whather the initial sync completed and no sync is running
+	Peers        hexutil.Uint   `json:"peers"`        // Number of connected ath peers
+	HeadAge      hexutil.Uint64 `json:"headAge"`      // Seconds elapsed since the head block's timestamp
+	AcceptingTxs bool           `json:"acceptingTxs"` // Whather transactions are accepted from the network
+}
+
+// Health returns a summary of the node's readiness, meant to be polled by load
+// balancers. It only reads atomic flags and cached head data.
+func (api *PublicAtlantisAPI) Health() *HealthStatus {
+	pm := api.e.protocolManager
+
+	accepting := atomic.LoadUint32(&pm.acceptTxs) == 1
+	status := &HealthStatus{
+		Synced:       accepting && !pm.downloader.Synchronising(),
+		Peers:        hexutil.Uint(pm.peers.Len()),
+		AcceptingTxs: accepting,
+	}
+	if now, stamp := uint64(time.Now().Unix()), api.e.BlockChain().CurrentBlock().Time().Uint64(); now > stamp {
+		status.HeadAge = hexutil.Uint64(now - stamp)
+	}
+	return status
+}
+
+// BloomIndexStatus describes how far the bloom bits indexer, which log filtering
+// relies upon, is behind the chain head.
+type BloomIndexStatus struct {
+	SectionSize     hexutil.Uint64 `json:"sectionSize"`     // Number of blocks in an indexed section
+	IndexedSections hexutil.Uint64 `json:"indexedSections"` // Number of sections fully indexed
+	HeadSection     hexutil.Uint64 `json:"headSection"`     // Section the current chain head belongs to
+	Gap             hexutil.Uint64 `json:"gap"`             // Number of blocks up to the head not covered by the index
+}
+
+// BloomIndexStatus reports the progress of the bloom bits indexer relative to the
+// chain head. Logs in the blocks of the gap are filtered by slow block scanning.
+func (api *PublicAtlantisAPI) BloomIndexStatus() *BloomIndexStatus {
+	sections, _, _ := api.e.bloomIndexer.Sections()
+	head := api.e.BlockChain().CurrentBlock().NumberU64()
+
+	status := &BloomIndexStatus{
+		SectionSize:     hexutil.Uint64(params.BloomBitsBlocks),
+		IndexedSections: hexutil.Uint64(sections),
+		HeadSection:     hexutil.Uint64(head / params.BloomBitsBlocks),
+	}
+	if indexed := sections * params.BloomBitsBlocks; head+1 > indexed {
+		status.Gap = hexutil.Uint64(head + 1 - indexed)
+	}
+	return status
+}
+
+// ConsensusInfo describes the consensus engine the node is running.
+type ConsensusInfo struct {
+	Engine  string           `json:"engine"`            // clique, ethash, the non-standard ethash mode (fake, fullfake, test, shared) or a custom engine's name
+	Period  *hexutil.Uint64  `json:"period,omitempty"`  // Clique block period in seconds
+	Signers []common.Address `json:"signers,omitempty"` // Clique signers authorized at the current head
+}
+
+// ConsensusInfo returns the type of the consensus engine in use and, for clique,
+// its block period and the signers authorized at the current head.
+func (api *PublicAtlantisAPI) ConsensusInfo() (*ConsensusInfo, error) {
+	switch engine := api.e.Engine().(type) {
+	case *clique.Clique:
+		period := hexutil.Uint64(api.e.chainConfig.Clique.Period)
+
+		signers, err := engine.Signers(api.e.BlockChain(), api.e.BlockChain().CurrentHeader())
+		if err != nil {
+			return nil, err
+		}
+		return &ConsensusInfo{Engine: "clique", Period: &period, Signers: signers}, nil
+
+	case *athash.Ethash:
+		if mode := engine.Mode(); mode != athash.ModeNormal {
+			return &ConsensusInfo{Engine: mode.String()}, nil
+		}
+		return &ConsensusInfo{Engine: "ethash"}, nil
+
+	default:
+		if api.e.config.Engine != "" {
+			return &ConsensusInfo{Engine: api.e.config.Engine}, nil
+		}
+		return nil, errUnknownEngine
+	}
+}
+
 // PublicMinerAPI provides an API to control the miner.
 // It offers only methods that operate on data that pose no security risk when it is publicly accessible.
 type PublicMinerAPI struct {
@@ -118,6 +242,53 @@ func (api *PublicMinerAPI) SubmitHashrate(hashrate hexutil.Uint64, id common.Has
 	return true
 }
 
+// errZeroAtlantisbase is returned if the miner is requested to pay its rewards
+// to the zero address.
+var errZeroAtlantisbase = errors.New("atherbase cannot be the zero address")
+
+// errUnknownEngine is returned if the consensus engine in use cannot be identified.
+var errUnknownEngine = errors.New("unknown consensus engine")
+
+// errMinerNotRunning is returned if the miner is requested to pause or resume
+// while it's not running at all.
+var errMinerNotRunning = errors.New("miner not running")
+
+// errServerNotRunning is returned if peer information is requested before the
+// service was started on a p2p server.
+var errServerNotRunning = errors.New("p2p server not running")
+
+// errDbAccessOverHTTP is returned if raw database access is requested over an
+// HTTP endpoint instead of IPC or websocket.
+var errDbAccessOverHTTP = errors.New("database access not allowed over HTTP")
+
+// errTrafficNotMetered is returned if per-peer bandwidth accounting is requested
+// while the metrics system is disabled.
+var errTrafficNotMetered = errors.New("peer bandwidth accounting requires metrics to be enabled")
+
+// errOutsideDocRoot is returned if a file path handed to an admin or debug method
+// would escape the configured document root.
+var errOutsideDocRoot = errors.New("path outside of document root")
+
+// errPreimagesNotIterable is returned if the preimages are requested to be exported
+// from a database not supporting iteration.
+var errPreimagesNotIterable = errors.New("preimage export requires a persistent database")
+
+// errCompactionUnsupported is returned if compaction is requested on a chain
+// database not backed by LevelDB.
+var errCompactionUnsupported = errors.New("compaction requires a persistent database")
+
+// errCompactionRunning is returned if a compaction is requested while another one
+// is still in progress.
+var errCompactionRunning = errors.New("chain database compaction already running")
+
+// errBanTooLong is returned if a peer is requested to be banned for longer than
+// maxPeerBanDuration.
+var errBanTooLong = errors.New("ban duration too long")
+
+// errReadOnlyDatabase is returned if a method modifying the chain database is
+// invoked on a node that opened it read-only.
+var errReadOnlyDatabase = errors.New("chain database is read-only")
+
 // PrivateMinerAPI provides private RPC methods to control the miner.
 // These methods can be abused by external users and must be considered insecure for use by untrusted users.
 type PrivateMinerAPI struct {
@@ -172,6 +343,25 @@ func (api *PrivateMinerAPI) Stop() bool {
 	return true
 }
 
+// Pause suspends sealing new blocks, but keeps the pending block up to date with
+// incoming transactions so mining can be resumed without warming up again.
+func (api *PrivateMinerAPI) Pause() (bool, error) {
+	if !api.e.IsMining() {
+		return false, errMinerNotRunning
+	}
+	api.e.PauseMining()
+	return true, nil
+}
+
+// Resume restarts sealing on a previously paused miner.
+func (api *PrivateMinerAPI) Resume() (bool, error) {
+	if !api.e.IsMining() {
+		return false, errMinerNotRunning
+	}
+	api.e.ResumeMining()
+	return true, nil
+}
+
 // SetExtra sets the extra data string that is included when this miner mines a block.
 func (api *PrivateMinerAPI) SetExtra(extra string) (bool, error) {
 	if err := api.e.Miner().SetExtra([]byte(extra)); err != nil {
@@ -190,10 +380,35 @@ func (api *PrivateMinerAPI) SetGasPrice(gasPrice hexutil.Big) bool {
 	return true
 }
 
-// SetAtlantisbase sets the atherbase of the miner
-func (api *PrivateMinerAPI) SetAtlantisbase(atherbase common.Address) bool {
-	api.e.SetAtlantisbase(atherbase)
-	return true
+// SetAtlantisbase sets the atherbase of the miner, returning the newly configured
+// reward address.
+func (api *PrivateMinerAPI) SetAtlantisbase(atherbase common.Address) (common.Address, error) {
+	if atherbase == (common.Address{}) {
+		return common.Address{}, errZeroAtlantisbase
+	}
+	if err := api.e.SetAtlantisbase(atherbase); err != nil {
+		return common.Address{}, err
+	}
+	return atherbase, nil
+}
+
+// SetAtlantisbases sets a list of reward addresses the miner rotates through, one
+// per sealed block. An empty list reverts to the single atherbase.
+func (api *PrivateMinerAPI) SetAtlantisbases(atherbases []common.Address) (bool, error) {
+	for _, atherbase := range atherbases {
+		if atherbase == (common.Address{}) {
+			return false, errZeroAtlantisbase
+		}
+	}
+	if err := api.e.SetAtlantisbases(atherbases); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RewardLog returns the reward addresses of the most recently sealed blocks.
+func (api *PrivateMinerAPI) RewardLog() []miner.RewardRecord {
+	return api.e.miner.RewardLog()
 }
 
 // GetHashrate returns the current hashrate of the miner.
@@ -204,7 +419,8 @@ func (api *PrivateMinerAPI) GetHashrate() uint64 {
 // PrivateAdminAPI is the collection of Atlantis full node-related APIs
 // exposed over the private admin endpoint.
 type PrivateAdminAPI struct {
-	ath *Atlantis
+	ath        *Atlantis
+	compacting int32 // Flag whether a chain database compaction is running
 }
 
 // NewPrivateAdminAPI creates a new API definition for the full node private
@@ -213,8 +429,84 @@ func NewPrivateAdminAPI(ath *Atlantis) *PrivateAdminAPI {
 	return &PrivateAdminAPI{ath: ath}
 }
 
-// ExportChain exports the current blockchain into a local file.
-func (api *PrivateAdminAPI) ExportChain(file string) (bool, error) {
+// defaultPeerBanDuration is the time a peer dropped via admin_dropPeer is kept
+// from reconnecting if no explicit ban duration is given.
+const defaultPeerBanDuration = 10 * time.Minute
+
+// maxPeerBanDuration is the longest time a peer dropped via admin_dropPeer can be
+// kept from reconnecting.
+const maxPeerBanDuration = 365 * 24 * time.Hour
+
+// DropPeer disconnects a remote node from the Atlantis protocol and refuses any
+// reconnection attempt for banDuration seconds (10 minutes if unspecified). The
+// number of Atlantis peers remaining connected is returned.
+func (api *PrivateAdminAPI) DropPeer(url string, banDuration *uint64) (int, error) {
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return 0, fmt.Errorf("invalid enode: %v", err)
+	}
+	duration := defaultPeerBanDuration
+	if banDuration != nil {
+		if *banDuration > uint64(maxPeerBanDuration/time.Second) {
+			return 0, errBanTooLong
+		}
+		duration = time.Duration(*banDuration) * time.Second
+	}
+	api.ath.protocolManager.banPeer(node.ID, duration)
+	return api.ath.protocolManager.peers.Len(), nil
+}
+
+// ResyncFrom recovers from a bad chain segment by rewinding the local chain to the
+// given block, forgetting the known bad blocks and starting a fresh sync with the
+// best available peer.
+func (api *PrivateAdminAPI) ResyncFrom(number uint64) (bool, error) {
+	if api.ath.config.DatabaseReadOnly {
+		return false, errReadOnlyDatabase
+	}
+	if head := api.ath.BlockChain().CurrentBlock().NumberU64(); number > head {
+		return false, fmt.Errorf("block #%d is above the current head #%d", number, head)
+	}
+	pm := api.ath.protocolManager
+
+	pm.downloader.Cancel()
+	api.ath.BlockChain().SetHead(number)
+	api.ath.BlockChain().ClearBadBlocks()
+
+	log.Warn("Resynchronising from rewound chain", "number", number)
+	go pm.synchronise(pm.peers.BestPeer())
+	return true, nil
+}
+
+// ReindexBloom schedules the bloom bits of the stored sections in the [fromSection,
+// toSection] range to be rebuilt in the background, e.g. after a reorg or rewind
+// left them stale, without reindexing the rest of the chain.
+func (api *PrivateAdminAPI) ReindexBloom(fromSection, toSection uint64) (bool, error) {
+	if err := api.ath.bloomIndexer.Reindex(fromSection, toSection); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ExportChain exports the current blockchain into a local file, or only the blocks
+// in the optional [first, last] range if specified. Blocks are streamed one by one
+// so memory use doesn't depend on the size of the range.
+func (api *PrivateAdminAPI) ExportChain(file string, first *uint64, last *uint64) (bool, error) {
+	// Validate the requested range against the current chain
+	head := api.ath.BlockChain().CurrentBlock().NumberU64()
+
+	from, to := uint64(0), head
+	if first != nil {
+		from = *first
+	}
+	if last != nil {
+		to = *last
+	}
+	if from > to {
+		return false, fmt.Errorf("first block (%d) is greater than last (%d)", from, to)
+	}
+	if to > head {
+		return false, fmt.Errorf("last block (%d) is beyond the current head (%d)", to, head)
+	}
 	// Make sure we can create the file to export into
 	out, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
 	if err != nil {
@@ -229,7 +521,7 @@ func (api *PrivateAdminAPI) ExportChain(file string) (bool, error) {
 	}
 
 	// Export the blockchain
-	if err := api.ath.BlockChain().Export(writer); err != nil {
+	if err := api.ath.BlockChain().ExportN(writer, from, to); err != nil {
 		return false, err
 	}
 	return true, nil
@@ -247,6 +539,9 @@ func hasAllBlocks(chain *core.BlockChain, bs []*types.Block) bool {
 
 // ImportChain imports a blockchain from a local file.
 func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
+	if api.ath.config.DatabaseReadOnly {
+		return false, errReadOnlyDatabase
+	}
 	// Make sure the can access the file to import
 	in, err := os.Open(file)
 	if err != nil {
@@ -294,6 +589,133 @@ func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
 	return true, nil
 }
 
+// docRootPath resolves a user supplied file path against the given document root,
+// rejecting any path that would escape it. If no document root is set, the path
+// is used as is.
+func docRootPath(root, file string) (string, error) {
+	if root == "" {
+		return file, nil
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(root, file)
+	}
+	file = filepath.Clean(file)
+
+	rel, err := filepath.Rel(root, file)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errOutsideDocRoot
+	}
+	return file, nil
+}
+
+// ExportTxPool exports all the pending and queued transactions of the pool into
+// a local file, ordered by account and nonce.
+func (api *PrivateAdminAPI) ExportTxPool(file string) (bool, error) {
+	file, err := docRootPath(api.ath.config.DocRoot, file)
+	if err != nil {
+		return false, err
+	}
+	// Make sure we can create the file to export into
+	out, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return false, err
+	}
+	defer out.Close()
+
+	// Gather the transactions and stream them out one by one
+	pending, queued := api.ath.TxPool().Content()
+	for _, content := range []map[common.Address]types.Transactions{pending, queued} {
+		for _, txs := range content {
+			for _, tx := range txs {
+				if err := rlp.Encode(out, tx); err != nil {
+					return false, err
+				}
+			}
+		}
+	}
+	return true, nil
+}
+
+// ImportTxPool injects all the transactions from a local file previously created
+// by ExportTxPool into the pool, returning the number of transactions accepted.
+func (api *PrivateAdminAPI) ImportTxPool(file string) (int, error) {
+	file, err := docRootPath(api.ath.config.DocRoot, file)
+	if err != nil {
+		return 0, err
+	}
+	// Make sure we can access the file to import
+	in, err := os.Open(file)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	// Inject the transactions one by one, skipping the rejected ones
+	stream := rlp.NewStream(in, 0)
+
+	imported := 0
+	for index := 0; ; index++ {
+		tx := new(types.Transaction)
+		if err := stream.Decode(tx); err == io.EOF {
+			break
+		} else if err != nil {
+			return imported, fmt.Errorf("transaction %d: failed to parse: %v", index, err)
+		}
+		if err := api.ath.APIBackend.SendTx(context.Background(), tx); err != nil {
+			log.Debug("Failed to import pooled transaction", "hash", tx.Hash(), "err", err)
+			continue
+		}
+		imported++
+	}
+	return imported, nil
+}
+
+// CompactChainDb compacts the chain database over the given key range, or over
+// the entire database if both limits are omitted, returning once done. Only one
+// compaction may run at a time to avoid compounding the IO load.
+func (api *PrivateAdminAPI) CompactChainDb(start, limit *hexutil.Bytes) (bool, error) {
+	if api.ath.config.DatabaseReadOnly {
+		return false, errReadOnlyDatabase
+	}
+	db, ok := api.ath.ChainDb().(*athdb.LDBDatabase)
+	if !ok {
+		return false, errCompactionUnsupported
+	}
+	if !atomic.CompareAndSwapInt32(&api.compacting, 0, 1) {
+		return false, errCompactionRunning
+	}
+	defer atomic.StoreInt32(&api.compacting, 0)
+
+	var r util.Range
+	if start != nil {
+		r.Start = *start
+	}
+	if limit != nil {
+		r.Limit = *limit
+	}
+	log.Info("Compacting chain database", "start", r.Start, "limit", r.Limit)
+	compactStart := time.Now()
+	if err := db.LDB().CompactRange(r); err != nil {
+		log.Error("Database compaction failed", "err", err)
+		return false, err
+	}
+	log.Info("Compacted chain database", "elapsed", common.PrettyDuration(time.Since(compactStart)))
+	return true, nil
+}
+
+// PeerBandwidth retrieves the data traffic exchanged with each connected peer,
+// keyed by node ID and broken out by message category.
+func (api *PrivateAdminAPI) PeerBandwidth() (map[string]map[string]TrafficStats, error) {
+	if !metrics.Enabled {
+		return nil, errTrafficNotMetered
+	}
+	return api.ath.protocolManager.peers.Traffic(), nil
+}
+
 // PublicDebugAPI is the collection of Atlantis full node APIs exposed
 // over the public debugging endpoint.
 type PublicDebugAPI struct {
@@ -316,9 +738,12 @@ func (api *PublicDebugAPI) DumpBlock(blockNr rpc.BlockNumber) (state.Dump, error
 		return stateDb.RawDump(), nil
 	}
 	var block *types.Block
-	if blockNr == rpc.LatestBlockNumber {
+	switch blockNr {
+	case rpc.LatestBlockNumber:
 		block = api.ath.blockchain.CurrentBlock()
-	} else {
+	case rpc.SafeBlockNumber, rpc.FinalizedBlockNumber:
+		block = api.ath.blockchain.GetBlockByNumber(uint64(api.ath.APIBackend.finalizedNumber()))
+	default:
 		block = api.ath.blockchain.GetBlockByNumber(uint64(blockNr))
 	}
 	if block == nil {
@@ -352,6 +777,115 @@ func (api *PrivateDebugAPI) Preimage(ctx context.Context, hash common.Hash) (hex
 	return nil, errors.New("unknown preimage")
 }
 
+// DbGet returns the raw value stored under the given key in the chain database.
+// Being able to read arbitrary database content, it refuses to serve requests
+// arriving over HTTP, even if the debug namespace was exposed there.
+func (api *PrivateDebugAPI) DbGet(ctx context.Context, key hexutil.Bytes) (hexutil.Bytes, error) {
+	if ctx.Value("scheme") != nil {
+		return nil, errDbAccessOverHTTP
+	}
+	return api.ath.ChainDb().Get(key)
+}
+
+// preimageBatchSize is the number of preimages accumulated in memory during an
+// import before being flushed to the database.
+const preimageBatchSize = 1024
+
+// ExportPreimages streams all known hash preimages into a local file, returning
+// the number of preimages exported.
+func (api *PrivateDebugAPI) ExportPreimages(file string) (uint64, error) {
+	db, ok := api.ath.ChainDb().(*athdb.LDBDatabase)
+	if !ok {
+		return 0, errPreimagesNotIterable
+	}
+	file, err := docRootPath(api.ath.config.DocRoot, file)
+	if err != nil {
+		return 0, err
+	}
+	// Make sure we can create the file to export into
+	out, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer out.Close()
+
+	var writer io.Writer = out
+	if strings.HasSuffix(file, ".gz") {
+		writer = gzip.NewWriter(writer)
+		defer writer.(*gzip.Writer).Close()
+	}
+	// Iterate over the preimages and export them one by one
+	it := db.NewIteratorWithPrefix(rawdb.PreimagePrefix)
+	defer it.Release()
+
+	var exported uint64
+	for it.Next() {
+		if err := rlp.Encode(writer, it.Value()); err != nil {
+			return exported, err
+		}
+		exported++
+	}
+	if err := it.Error(); err != nil {
+		return exported, err
+	}
+	if exported == 0 {
+		log.Warn("No preimages to export", "recording", api.ath.config.EnablePreimageRecording)
+	}
+	return exported, nil
+}
+
+// ImportPreimages imports the hash preimages from a local file created by
+// ExportPreimages, returning the number of preimages imported.
+func (api *PrivateDebugAPI) ImportPreimages(file string) (uint64, error) {
+	if api.ath.config.DatabaseReadOnly {
+		return 0, errReadOnlyDatabase
+	}
+	file, err := docRootPath(api.ath.config.DocRoot, file)
+	if err != nil {
+		return 0, err
+	}
+	// Make sure we can access the file to import
+	in, err := os.Open(file)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	var reader io.Reader = in
+	if strings.HasSuffix(file, ".gz") {
+		if reader, err = gzip.NewReader(reader); err != nil {
+			return 0, err
+		}
+	}
+	stream := rlp.NewStream(reader, 0)
+
+	// Import the preimages in batches to prevent disk trashing
+	var (
+		db        = api.ath.ChainDb()
+		preimages = make(map[common.Hash][]byte)
+		imported  uint64
+	)
+	for {
+		var blob []byte
+		if err := stream.Decode(&blob); err == io.EOF {
+			break
+		} else if err != nil {
+			return imported, fmt.Errorf("preimage %d: failed to parse: %v", imported+uint64(len(preimages)), err)
+		}
+		preimages[crypto.Keccak256Hash(blob)] = blob
+		if len(preimages) >= preimageBatchSize {
+			rawdb.WritePreimages(db, 0, preimages)
+			imported += uint64(len(preimages))
+			preimages = make(map[common.Hash][]byte)
+		}
+	}
+	if len(preimages) > 0 {
+		rawdb.WritePreimages(db, 0, preimages)
+		imported += uint64(len(preimages))
+	}
+	return imported, nil
+}
+
 // BadBlockArgs represents the entries in the list returned when bad blocks are queried.
 type BadBlockArgs struct {
 	Hash  common.Hash            `json:"hash"`
@@ -18,7 +18,9 @@ package ath
 
 import (
 	"context"
+	"errors"
 	"math/big"
+	"time"
 
 	"github.com/athereum/go-athereum/accounts"
 	"github.com/athereum/go-athereum/common"
@@ -33,14 +35,27 @@ import (
 	"github.com/athereum/go-athereum/ath/gasprice"
 	"github.com/athereum/go-athereum/athdb"
 	"github.com/athereum/go-athereum/event"
+	"github.com/athereum/go-athereum/internal/athapi"
 	"github.com/athereum/go-athereum/params"
 	"github.com/athereum/go-athereum/rpc"
+	"github.com/hashicorp/golang-lru"
 )
 
+// defaultTdCacheSize is the number of total difficulties cached by the API backend
+// if no explicit size is configured.
+const defaultTdCacheSize = 4096
+
+// errLogsBusy is returned if a log filtering session could not be started because
+// the maximum number of concurrent sessions was reached.
+var errLogsBusy = errors.New("server busy: too many concurrent log queries")
+
 // EthAPIBackend implements athapi.Backend for full nodes
 type EthAPIBackend struct {
 	ath *Atlantis
 	gpo *gasprice.Oracle
+
+	logsSlots chan struct{} // Semaphore limiting the concurrent log filtering sessions, nil if unlimited
+	tdCache   *lru.Cache    // Cache for the total difficulties of recently queried blocks
 }
 
 func (b *EthAPIBackend) ChainConfig() *params.ChainConfig {
@@ -51,9 +66,12 @@ func (b *EthAPIBackend) CurrentBlock() *types.Block {
 	return b.ath.blockchain.CurrentBlock()
 }
 
-func (b *EthAPIBackend) SetHead(number uint64) {
+func (b *EthAPIBackend) SetHead(number uint64, resetTxPool bool) {
 	b.ath.protocolManager.downloader.Cancel()
 	b.ath.blockchain.SetHead(number)
+	if resetTxPool {
+		b.ath.txPool.ResetHead(b.ath.blockchain.CurrentBlock())
+	}
 }
 
 func (b *EthAPIBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error) {
@@ -62,19 +80,37 @@ func (b *EthAPIBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNum
 		block := b.ath.miner.PendingBlock()
 		return block.Header(), nil
 	}
+	if blockNr == rpc.SafeBlockNumber || blockNr == rpc.FinalizedBlockNumber {
+		blockNr = b.finalizedNumber()
+	}
 	// Otherwise resolve and return the block
 	if blockNr == rpc.LatestBlockNumber {
+		// Use the cached head header unless it's ahead of the head block (fast sync)
+		if header := b.ath.blockchain.CurrentHeader(); header.Number.Uint64() == b.ath.blockchain.CurrentBlock().NumberU64() {
+			return header, nil
+		}
 		return b.ath.blockchain.CurrentBlock().Header(), nil
 	}
 	return b.ath.blockchain.GetHeaderByNumber(uint64(blockNr)), nil
 }
 
+func (b *EthAPIBackend) GetCanonicalHash(ctx context.Context, number uint64) (common.Hash, error) {
+	return b.ath.blockchain.GetCanonicalHash(number), nil
+}
+
+func (b *EthAPIBackend) HeaderByHash(ctx context.Context, blockHash common.Hash) (*types.Header, error) {
+	return b.ath.blockchain.GetHeaderByHash(blockHash), nil
+}
+
 func (b *EthAPIBackend) BlockByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Block, error) {
 	// Pending block is only known by the miner
 	if blockNr == rpc.PendingBlockNumber {
 		block := b.ath.miner.PendingBlock()
 		return block, nil
 	}
+	if blockNr == rpc.SafeBlockNumber || blockNr == rpc.FinalizedBlockNumber {
+		blockNr = b.finalizedNumber()
+	}
 	// Otherwise resolve and return the block
 	if blockNr == rpc.LatestBlockNumber {
 		return b.ath.blockchain.CurrentBlock(), nil
@@ -82,6 +118,17 @@ func (b *EthAPIBackend) BlockByNumber(ctx context.Context, blockNr rpc.BlockNumb
 	return b.ath.blockchain.GetBlockByNumber(uint64(blockNr)), nil
 }
 
+// finalizedNumber resolves the safe and finalized block tags. Lacking explicit
+// finality, a block is deemed final once buried under the configured number of
+// confirmations, the genesis block being the fallback on shorter chains.
+func (b *EthAPIBackend) finalizedNumber() rpc.BlockNumber {
+	head, confirms := b.ath.blockchain.CurrentBlock().NumberU64(), b.ath.config.FinalityConfirmations
+	if head < confirms {
+		return rpc.EarliestBlockNumber
+	}
+	return rpc.BlockNumber(head - confirms)
+}
+
 func (b *EthAPIBackend) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
 	// Pending state is only known by the miner
 	if blockNr == rpc.PendingBlockNumber {
@@ -97,15 +144,75 @@ func (b *EthAPIBackend) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.
 	return stateDb, header, err
 }
 
+func (b *EthAPIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	if blockNr, ok := blockNrOrHash.Number(); ok {
+		return b.StateAndHeaderByNumber(ctx, blockNr)
+	}
+	hash, ok := blockNrOrHash.Hash()
+	if !ok {
+		return nil, nil, errors.New("invalid arguments; neither block nor hash specified")
+	}
+	header := b.ath.blockchain.GetHeaderByHash(hash)
+	if header == nil {
+		return nil, nil, errors.New("header for hash not found")
+	}
+	if blockNrOrHash.RequireCanonical && rawdb.ReadCanonicalHash(b.ath.chainDb, header.Number.Uint64()) != hash {
+		return nil, nil, errors.New("hash is not currently canonical")
+	}
+	stateDb, err := b.ath.BlockChain().StateAt(header.Root)
+	return stateDb, header, err
+}
+
+func (b *EthAPIBackend) GetCodeSize(ctx context.Context, addr common.Address, blockNrOrHash rpc.BlockNumberOrHash) (int, error) {
+	state, _, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if state == nil || err != nil {
+		return 0, err
+	}
+	size := state.GetCodeSize(addr)
+	return size, state.Error()
+}
+
 func (b *EthAPIBackend) GetBlock(ctx context.Context, hash common.Hash) (*types.Block, error) {
 	return b.ath.blockchain.GetBlockByHash(hash), nil
 }
 
+func (b *EthAPIBackend) PendingBlock() (*types.Block, error) {
+	return b.ath.miner.PendingBlock(), nil
+}
+
+func (b *EthAPIBackend) GetBlockTransactions(ctx context.Context, hash common.Hash) (types.Transactions, error) {
+	body := b.ath.blockchain.GetBody(hash)
+	if body == nil {
+		return nil, nil
+	}
+	if body.Transactions == nil {
+		return types.Transactions{}, nil
+	}
+	return body.Transactions, nil
+}
+
 func (b *EthAPIBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
-	if number := rawdb.ReadHeaderNumber(b.ath.chainDb, hash); number != nil {
-		return rawdb.ReadReceipts(b.ath.chainDb, hash, *number), nil
+	return b.ath.blockchain.GetReceiptsByHash(hash), nil
+}
+
+func (b *EthAPIBackend) GetReceiptsRange(ctx context.Context, from, to rpc.BlockNumber) ([]types.Receipts, error) {
+	first, last, err := athapi.ReceiptsRangeBounds(from, to, b.ath.blockchain.CurrentBlock().NumberU64())
+	if err != nil {
+		return nil, err
+	}
+	var receipts []types.Receipts
+	for number := first; number <= last; number++ {
+		hash := rawdb.ReadCanonicalHash(b.ath.chainDb, number)
+		if (hash == common.Hash{}) {
+			break
+		}
+		blockReceipts := b.ath.blockchain.GetReceiptsByHash(hash)
+		if blockReceipts == nil {
+			break
+		}
+		receipts = append(receipts, blockReceipts)
 	}
-	return nil, nil
+	return receipts, nil
 }
 
 func (b *EthAPIBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*types.Log, error) {
@@ -124,12 +231,60 @@ func (b *EthAPIBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*typ
 	return logs, nil
 }
 
+func (b *EthAPIBackend) GetBlockLogs(ctx context.Context, hash common.Hash) ([]*types.Log, error) {
+	number := rawdb.ReadHeaderNumber(b.ath.chainDb, hash)
+	if number == nil {
+		return nil, nil
+	}
+	receipts := rawdb.ReadReceipts(b.ath.chainDb, hash, *number)
+	if receipts == nil {
+		return nil, nil
+	}
+	return athapi.FlattenLogs(hash, *number, receipts), nil
+}
+
 func (b *EthAPIBackend) GetTd(blockHash common.Hash) *big.Int {
-	return b.ath.blockchain.GetTdByHash(blockHash)
+	if td, ok := b.tdCache.Get(blockHash); ok {
+		return td.(*big.Int)
+	}
+	td := b.ath.blockchain.GetTdByHash(blockHash)
+	if td != nil {
+		b.tdCache.Add(blockHash, td)
+	}
+	return td
 }
 
-func (b *EthAPIBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg vm.Config) (*vm.EVM, func() error, error) {
-	state.SetBalance(msg.From(), math.MaxBig256)
+// startTdCache allocates the total difficulty cache and starts evicting blocks
+// from it as they are moved to a side chain. The eviction loop terminates when
+// the blockchain is stopped.
+func (b *EthAPIBackend) startTdCache(size int) {
+	if size <= 0 {
+		size = defaultTdCacheSize
+	}
+	b.tdCache, _ = lru.New(size)
+
+	sideCh := make(chan core.ChainSideEvent, 16)
+	sub := b.ath.blockchain.SubscribeChainSideEvent(sideCh)
+	go func() {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case ev := <-sideCh:
+				b.tdCache.Remove(ev.Block.Hash())
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+}
+
+// GetEVM creates a new EVM for executing msg on top of the given state. If
+// overrideBalance is set, the sender is credited with an unlimited balance so the
+// call can't fail on insufficient funds, otherwise its real balance is used.
+func (b *EthAPIBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg vm.Config, overrideBalance bool) (*vm.EVM, func() error, error) {
+	if overrideBalance {
+		state.SetBalance(msg.From(), math.MaxBig256)
+	}
 	vmError := func() error { return nil }
 
 	context := core.NewEVMContext(msg, header, b.ath.BlockChain(), nil)
@@ -156,7 +311,17 @@ func (b *EthAPIBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscri
 	return b.ath.BlockChain().SubscribeLogsEvent(ch)
 }
 
+// SubscribePendingLogsEvent subscribes to the logs of the pending block assembled
+// by the miner.
+func (b *EthAPIBackend) SubscribePendingLogsEvent() (*event.TypeMuxSubscription, error) {
+	return b.ath.EventMux().Subscribe(core.PendingLogsEvent{}), nil
+}
+
 func (b *EthAPIBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
+	// Local transactions bypass the pool's price limit, enforce the hard floor here
+	if floor := b.ath.config.MinAcceptedGasPrice; floor != nil && signedTx.GasPrice().Cmp(floor) < 0 {
+		return core.ErrUnderpriced
+	}
 	return b.ath.txPool.AddLocal(signedTx)
 }
 
@@ -188,6 +353,27 @@ func (b *EthAPIBackend) TxPoolContent() (map[common.Address]types.Transactions,
 	return b.ath.TxPool().Content()
 }
 
+func (b *EthAPIBackend) TxPoolInspectByAccount(addr common.Address) ([]athapi.TxSummary, []athapi.TxSummary) {
+	pending, queued := b.ath.TxPool().ContentFrom(addr)
+	return athapi.SummarizeTxs(pending), athapi.SummarizeTxs(queued)
+}
+
+func (b *EthAPIBackend) TxPoolLocals() map[common.Address]types.Transactions {
+	return b.ath.TxPool().Locals()
+}
+
+func (b *EthAPIBackend) TxPoolMarkLocal(txHash common.Hash) error {
+	return b.ath.TxPool().MarkLocal(txHash)
+}
+
+func (b *EthAPIBackend) TxPoolRebroadcast(txHash common.Hash) (int, error) {
+	tx := b.ath.TxPool().Get(txHash)
+	if tx == nil {
+		return 0, core.ErrUnknownTransaction
+	}
+	return b.ath.protocolManager.RebroadcastTx(tx), nil
+}
+
 func (b *EthAPIBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
 	return b.ath.TxPool().SubscribeNewTxsEvent(ch)
 }
@@ -204,6 +390,14 @@ func (b *EthAPIBackend) SuggestPrice(ctx context.Context) (*big.Int, error) {
 	return b.gpo.SuggestPrice(ctx)
 }
 
+func (b *EthAPIBackend) FeeHistory(ctx context.Context, blockCount int, lastBlock rpc.BlockNumber, percentiles []float64) (*big.Int, [][]*big.Int, []*big.Int, []float64, error) {
+	history, err := b.gpo.FeeHistory(ctx, blockCount, lastBlock, percentiles)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return history.OldestBlock, history.Reward, history.BaseGasPrice, history.GasUsedRatio, nil
+}
+
 func (b *EthAPIBackend) ChainDb() athdb.Database {
 	return b.ath.ChainDb()
 }
@@ -216,13 +410,40 @@ func (b *EthAPIBackend) AccountManager() *accounts.Manager {
 	return b.ath.AccountManager()
 }
 
+func (b *EthAPIBackend) RPCEVMTimeout() time.Duration {
+	return b.ath.config.EVMTimeout()
+}
+
+func (b *EthAPIBackend) PersonalAPIDisabled() bool {
+	return b.ath.config.DisablePersonalAPI
+}
+
 func (b *EthAPIBackend) BloomStatus() (uint64, uint64) {
 	sections, _, _ := b.ath.bloomIndexer.Sections()
 	return params.BloomBitsBlocks, sections
 }
 
-func (b *EthAPIBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {
-	for i := 0; i < bloomFilterThreads; i++ {
+func (b *EthAPIBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) error {
+	// Wait for a free filtering slot if concurrency is limited, holding it until
+	// the session terminates
+	if b.logsSlots != nil {
+		timeout := time.NewTimer(logsQueueTimeout)
+		defer timeout.Stop()
+
+		select {
+		case b.logsSlots <- struct{}{}:
+		case <-timeout.C:
+			return errLogsBusy
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		go func() {
+			<-session.Done()
+			<-b.logsSlots
+		}()
+	}
+	for i := 0; i < b.ath.config.BloomServiceThreads; i++ {
 		go session.Multiplex(bloomRetrievalBatch, bloomRetrievalWait, b.ath.bloomRequests)
 	}
+	return nil
 }
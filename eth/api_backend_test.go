@@ -0,0 +1,374 @@
+// Copyright 2018 The go-athereum Authors
+// This file is part of the go-athereum library.
+//
+// The go-athereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-athereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-athereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ath
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/athereum/go-athereum/common"
+	"github.com/athereum/go-athereum/consensus/athash"
+	"github.com/athereum/go-athereum/core"
+	"github.com/athereum/go-athereum/core/bloombits"
+	"github.com/athereum/go-athereum/core/rawdb"
+	"github.com/athereum/go-athereum/core/types"
+	"github.com/athereum/go-athereum/core/vm"
+	"github.com/athereum/go-athereum/athdb"
+	"github.com/athereum/go-athereum/ath/filters"
+	"github.com/athereum/go-athereum/internal/athapi"
+	"github.com/athereum/go-athereum/params"
+	"github.com/athereum/go-athereum/rpc"
+)
+
+// Tests that retrieving the latest header is served directly from the header
+// chain cache, without going through (and copying out of) the head block.
+func TestLatestHeaderFastPath(t *testing.T) {
+	var (
+		db      = athdb.NewMemDatabase()
+		gspec   = &core.Genesis{Config: params.TestChainConfig}
+		genesis = gspec.MustCommit(db)
+	)
+	blockchain, err := core.NewBlockChain(db, nil, gspec.Config, athash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer blockchain.Stop()
+
+	chain, _ := core.GenerateChain(gspec.Config, genesis, athash.NewFaker(), db, 4, nil)
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	backend := &EthAPIBackend{ath: &Atlantis{blockchain: blockchain}}
+
+	header, err := backend.HeaderByNumber(context.Background(), rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatalf("failed to retrieve latest header: %v", err)
+	}
+	if header != blockchain.CurrentHeader() {
+		t.Fatalf("latest header not served from the header chain cache")
+	}
+	if header.Hash() != blockchain.CurrentBlock().Hash() {
+		t.Fatalf("latest header mismatch: have %x, want %x", header.Hash(), blockchain.CurrentBlock().Hash())
+	}
+}
+
+// Tests that the receipts of a range of blocks are returned in order, cut short at
+// the first unavailable block, and that invalid ranges are rejected.
+func TestGetReceiptsRange(t *testing.T) {
+	var (
+		db      = athdb.NewMemDatabase()
+		gspec   = &core.Genesis{Config: params.TestChainConfig, Alloc: core.GenesisAlloc{testBank: {Balance: big.NewInt(params.Atlantis)}}}
+		genesis = gspec.MustCommit(db)
+		signer  = types.HomesteadSigner{}
+	)
+	blockchain, err := core.NewBlockChain(db, nil, gspec.Config, athash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer blockchain.Stop()
+
+	chain, _ := core.GenerateChain(gspec.Config, genesis, athash.NewFaker(), db, 4, func(i int, b *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(testBank), common.Address{0x01}, big.NewInt(1), params.TxGas, nil, nil), signer, testBankKey)
+		b.AddTx(tx)
+	})
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	backend := &EthAPIBackend{ath: &Atlantis{blockchain: blockchain, chainDb: db}}
+
+	tests := []struct {
+		from, to rpc.BlockNumber
+		first    uint64
+		count    int
+		fail     bool
+	}{
+		{1, 3, 1, 3, false},
+		{2, rpc.LatestBlockNumber, 2, 3, false},
+		{3, 10, 3, 2, false},
+		{3, 1, 0, 0, true},
+		{0, athapi.MaxReceiptsRange, 0, 0, true},
+	}
+	for i, tt := range tests {
+		receipts, err := backend.GetReceiptsRange(context.Background(), tt.from, tt.to)
+		if (err != nil) != tt.fail {
+			t.Errorf("test %d: error mismatch: have %v, want failure %v", i, err, tt.fail)
+			continue
+		}
+		if len(receipts) != tt.count {
+			t.Errorf("test %d: block count mismatch: have %d, want %d", i, len(receipts), tt.count)
+			continue
+		}
+		for j, blockReceipts := range receipts {
+			txs := chain[tt.first+uint64(j)-1].Transactions()
+			if len(blockReceipts) != 1 || blockReceipts[0].TxHash != txs[0].Hash() {
+				t.Errorf("test %d: block %d receipts mismatch: have %v, want receipt of %x", i, tt.first+uint64(j), blockReceipts, txs[0].Hash())
+			}
+		}
+	}
+}
+
+// Tests that log filtering sessions beyond the concurrency limit are queued until
+// a running session terminates.
+func TestServiceFilterConcurrencyLimit(t *testing.T) {
+	backend := &EthAPIBackend{
+		ath:       &Atlantis{config: &Config{}},
+		logsSlots: make(chan struct{}, 1),
+	}
+	start := func() *bloombits.MatcherSession {
+		matcher := bloombits.NewMatcher(4096, [][][]byte{{{0x01}}})
+		session, err := matcher.Start(context.Background(), 0, 0, make(chan uint64))
+		if err != nil {
+			t.Fatalf("failed to start matcher session: %v", err)
+		}
+		return session
+	}
+	first := start()
+	defer first.Close()
+	if err := backend.ServiceFilter(context.Background(), first); err != nil {
+		t.Fatalf("failed to service first session: %v", err)
+	}
+	// A second session must wait for the first one to terminate
+	second := start()
+	defer second.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := backend.ServiceFilter(ctx, second); err != context.DeadlineExceeded {
+		t.Fatalf("queued session error mismatch: have %v, want %v", err, context.DeadlineExceeded)
+	}
+	// Terminating the first session frees up its slot
+	first.Close()
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := backend.ServiceFilter(ctx, second); err != nil {
+		t.Fatalf("failed to service session after slot release: %v", err)
+	}
+}
+
+// Tests that total difficulties are served from the backend cache and that blocks
+// reorged out of the canonical chain are evicted from it.
+func TestGetTdCache(t *testing.T) {
+	var (
+		db      = athdb.NewMemDatabase()
+		gspec   = &core.Genesis{Config: params.TestChainConfig}
+		genesis = gspec.MustCommit(db)
+	)
+	blockchain, err := core.NewBlockChain(db, nil, gspec.Config, athash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer blockchain.Stop()
+
+	chain, _ := core.GenerateChain(gspec.Config, genesis, athash.NewFaker(), db, 3, nil)
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	backend := &EthAPIBackend{ath: &Atlantis{blockchain: blockchain}}
+	backend.startTdCache(16)
+
+	head := chain[len(chain)-1].Hash()
+	td := backend.GetTd(head)
+	if td == nil || td.Cmp(blockchain.GetTdByHash(head)) != 0 {
+		t.Fatalf("total difficulty mismatch: have %v, want %v", td, blockchain.GetTdByHash(head))
+	}
+	if !backend.tdCache.Contains(head) {
+		t.Fatalf("total difficulty not cached")
+	}
+	// Reorg to a longer fork and ensure the old head is evicted
+	fork, _ := core.GenerateChain(gspec.Config, genesis, athash.NewFaker(), db, 5, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(common.Address{0x01})
+	})
+	if _, err := blockchain.InsertChain(fork); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	for i := 0; i < 100 && backend.tdCache.Contains(head); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if backend.tdCache.Contains(head) {
+		t.Fatalf("reorged block not evicted from the cache")
+	}
+}
+
+// Tests that block logs are returned as a flat list in emission order, with the
+// positional fields filled in from the enclosing receipts.
+func TestGetBlockLogs(t *testing.T) {
+	db := athdb.NewMemDatabase()
+	header := &types.Header{Number: big.NewInt(1)}
+	rawdb.WriteHeader(db, header)
+
+	receipts := types.Receipts{
+		{TxHash: common.Hash{0x01}, Logs: []*types.Log{{Address: common.Address{0x01}}, {Address: common.Address{0x02}}}},
+		{TxHash: common.Hash{0x02}},
+		{TxHash: common.Hash{0x03}, Logs: []*types.Log{{Address: common.Address{0x03}}, {Address: common.Address{0x04}}, {Address: common.Address{0x05}}}},
+	}
+	rawdb.WriteReceipts(db, header.Hash(), 1, receipts)
+
+	backend := &EthAPIBackend{ath: &Atlantis{chainDb: db}}
+	logs, err := backend.GetBlockLogs(context.Background(), header.Hash())
+	if err != nil {
+		t.Fatalf("failed to retrieve block logs: %v", err)
+	}
+	want := []struct {
+		addr    byte
+		txHash  byte
+		txIndex uint
+	}{
+		{0x01, 0x01, 0}, {0x02, 0x01, 0}, {0x03, 0x03, 2}, {0x04, 0x03, 2}, {0x05, 0x03, 2},
+	}
+	if len(logs) != len(want) {
+		t.Fatalf("log count mismatch: have %d, want %d", len(logs), len(want))
+	}
+	for i, log := range logs {
+		if log.Address != (common.Address{want[i].addr}) {
+			t.Errorf("log %d: address mismatch: have %x, want %x", i, log.Address, common.Address{want[i].addr})
+		}
+		if log.TxHash != (common.Hash{want[i].txHash}) || log.TxIndex != want[i].txIndex {
+			t.Errorf("log %d: transaction mismatch: have %x/%d, want %x/%d", i, log.TxHash, log.TxIndex, common.Hash{want[i].txHash}, want[i].txIndex)
+		}
+		if log.BlockHash != header.Hash() || log.BlockNumber != 1 {
+			t.Errorf("log %d: block mismatch: have %x/%d, want %x/%d", i, log.BlockHash, log.BlockNumber, header.Hash(), 1)
+		}
+		if log.Index != uint(i) {
+			t.Errorf("log %d: index mismatch: have %d, want %d", i, log.Index, i)
+		}
+	}
+}
+
+// Tests that log filters resolve the safe and finalized block tags through the
+// backend instead of treating them as raw negative block numbers.
+func TestFilterLogsFinalityTags(t *testing.T) {
+	var (
+		db      = athdb.NewMemDatabase()
+		gspec   = &core.Genesis{Config: params.TestChainConfig, Alloc: core.GenesisAlloc{testBank: {Balance: big.NewInt(1000000000)}}}
+		genesis = gspec.MustCommit(db)
+		signer  = types.HomesteadSigner{}
+		logger  = []byte{byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.LOG0)}
+	)
+	blockchain, err := core.NewBlockChain(db, nil, gspec.Config, athash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer blockchain.Stop()
+
+	// Emit a single log in every block
+	chain, _ := core.GenerateChain(gspec.Config, genesis, athash.NewFaker(), db, 8, func(i int, b *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewContractCreation(b.TxNonce(testBank), nil, 100000, nil, logger), signer, testBankKey)
+		b.AddTx(tx)
+	})
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	indexer := NewBloomIndexer(db, params.BloomBitsBlocks)
+	defer indexer.Close()
+
+	backend := &EthAPIBackend{ath: &Atlantis{
+		config:       &Config{FinalityConfirmations: 3},
+		chainDb:      db,
+		blockchain:   blockchain,
+		bloomIndexer: indexer,
+	}}
+	tests := []struct {
+		from, to    rpc.BlockNumber
+		first, last uint64
+	}{
+		{rpc.FinalizedBlockNumber, rpc.LatestBlockNumber, 5, 8},
+		{rpc.SafeBlockNumber, rpc.LatestBlockNumber, 5, 8},
+		{rpc.EarliestBlockNumber, rpc.FinalizedBlockNumber, 1, 5},
+		{2, rpc.SafeBlockNumber, 2, 5},
+	}
+	for i, tt := range tests {
+		logs, err := filters.New(backend, tt.from.Int64(), tt.to.Int64(), nil, nil).Logs(context.Background())
+		if err != nil {
+			t.Fatalf("test %d: failed to filter logs: %v", i, err)
+		}
+		if len(logs) != int(tt.last-tt.first+1) {
+			t.Fatalf("test %d: log count mismatch: have %d, want %d", i, len(logs), tt.last-tt.first+1)
+		}
+		if logs[0].BlockNumber != tt.first || logs[len(logs)-1].BlockNumber != tt.last {
+			t.Errorf("test %d: range mismatch: have %d-%d, want %d-%d", i, logs[0].BlockNumber, logs[len(logs)-1].BlockNumber, tt.first, tt.last)
+		}
+	}
+}
+
+// Tests that an unset call timeout falls back to the default, and only negative
+// ones disable the limit.
+func TestRPCEVMTimeout(t *testing.T) {
+	tests := []struct {
+		configured time.Duration
+		timeout    time.Duration
+	}{
+		{0, defaultRPCEVMTimeout},
+		{time.Second, time.Second},
+		{-1, 0},
+	}
+	for i, tt := range tests {
+		backend := &EthAPIBackend{ath: &Atlantis{config: &Config{RPCEVMTimeout: tt.configured}}}
+		if timeout := backend.RPCEVMTimeout(); timeout != tt.timeout {
+			t.Errorf("test %d: timeout mismatch: have %v, want %v", i, timeout, tt.timeout)
+		}
+	}
+}
+
+// Tests that the code size is read from the state of the requested block, both by
+// number and by hash.
+func TestGetCodeSize(t *testing.T) {
+	var (
+		db       = athdb.NewMemDatabase()
+		contract = common.Address{0x02}
+		gspec    = &core.Genesis{Config: params.TestChainConfig, Alloc: core.GenesisAlloc{
+			testBank: {Balance: big.NewInt(params.Atlantis)},
+			contract: {Balance: new(big.Int), Code: []byte{0x60, 0x00, 0x00}},
+		}}
+		genesis = gspec.MustCommit(db)
+	)
+	blockchain, err := core.NewBlockChain(db, nil, gspec.Config, athash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer blockchain.Stop()
+
+	chain, _ := core.GenerateChain(gspec.Config, genesis, athash.NewFaker(), db, 2, nil)
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	backend := &EthAPIBackend{ath: &Atlantis{blockchain: blockchain, chainDb: db}}
+
+	tests := []struct {
+		addr  common.Address
+		block rpc.BlockNumberOrHash
+		size  int
+		fail  bool
+	}{
+		{contract, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), 3, false},
+		{contract, rpc.BlockNumberOrHashWithHash(genesis.Hash(), true), 3, false},
+		{testBank, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), 0, false},
+		{contract, rpc.BlockNumberOrHashWithNumber(10), 0, false},
+		{contract, rpc.BlockNumberOrHashWithHash(common.Hash{0xff}, false), 0, true},
+	}
+	for i, tt := range tests {
+		size, err := backend.GetCodeSize(context.Background(), tt.addr, tt.block)
+		if (err != nil) != tt.fail {
+			t.Errorf("test %d: error mismatch: have %v, want failure %v", i, err, tt.fail)
+		}
+		if size != tt.size {
+			t.Errorf("test %d: code size mismatch: have %d, want %d", i, size, tt.size)
+		}
+	}
+}
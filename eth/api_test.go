@@ -17,13 +17,34 @@
 package ath
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/athereum/go-athereum/common"
+	"github.com/athereum/go-athereum/common/hexutil"
+	"github.com/athereum/go-athereum/consensus/athash"
+	"github.com/athereum/go-athereum/core"
+	"github.com/athereum/go-athereum/core/rawdb"
 	"github.com/athereum/go-athereum/core/state"
+	"github.com/athereum/go-athereum/core/types"
+	"github.com/athereum/go-athereum/core/vm"
+	"github.com/athereum/go-athereum/crypto"
+	"github.com/athereum/go-athereum/ath/downloader"
 	"github.com/athereum/go-athereum/athdb"
+	"github.com/athereum/go-athereum/params"
+	"github.com/athereum/go-athereum/rlp"
 )
 
 var dumper = spew.ConfigState{Indent: "    "}
@@ -88,3 +109,353 @@ func TestStorageRangeAt(t *testing.T) {
 		}
 	}
 }
+
+// Tests that admin and debug file paths are confined to the configured document
+// root.
+func TestDocRootPath(t *testing.T) {
+	root, _ := filepath.Abs(filepath.Join("testdata", "docroot"))
+
+	tests := []struct {
+		root, file, want string
+		fail             bool
+	}{
+		{"", "../pool.rlp", "../pool.rlp", false},
+		{root, "pool.rlp", filepath.Join(root, "pool.rlp"), false},
+		{root, "dumps/../pool.rlp", filepath.Join(root, "pool.rlp"), false},
+		{root, filepath.Join(root, "pool.rlp"), filepath.Join(root, "pool.rlp"), false},
+		{root, "../pool.rlp", "", true},
+		{root, "..", "", true},
+		{root, filepath.Join(root, "..", "docroot-sibling", "pool.rlp"), "", true},
+	}
+	for i, tt := range tests {
+		have, err := docRootPath(tt.root, tt.file)
+		if tt.fail {
+			if err != errOutsideDocRoot {
+				t.Errorf("test %d: error mismatch: have %v, want %v", i, err, errOutsideDocRoot)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+			continue
+		}
+		if have != tt.want {
+			t.Errorf("test %d: path mismatch: have %s, want %s", i, have, tt.want)
+		}
+	}
+}
+
+// Tests that preimages can be exported from one database and imported into another.
+func TestPreimagesExportImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "preimages")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	src, err := athdb.NewLDBDatabase(filepath.Join(dir, "src"), 0, 0)
+	if err != nil {
+		t.Fatalf("failed to create source database: %v", err)
+	}
+	defer src.Close()
+
+	preimages := make(map[common.Hash][]byte)
+	for i := 0; i < 2*preimageBatchSize+1; i++ {
+		blob := []byte{byte(i), byte(i >> 8), 0xff}
+		preimages[crypto.Keccak256Hash(blob)] = blob
+	}
+	rawdb.WritePreimages(src, 0, preimages)
+
+	file := filepath.Join(dir, "preimages.rlp.gz")
+	exporter := NewPrivateDebugAPI(nil, &Atlantis{chainDb: src, config: &Config{}})
+	if n, err := exporter.ExportPreimages(file); err != nil || n != uint64(len(preimages)) {
+		t.Fatalf("export mismatch: have %d (%v), want %d", n, err, len(preimages))
+	}
+	dst := athdb.NewMemDatabase()
+	importer := NewPrivateDebugAPI(nil, &Atlantis{chainDb: dst, config: &Config{}})
+	if n, err := importer.ImportPreimages(file); err != nil || n != uint64(len(preimages)) {
+		t.Fatalf("import mismatch: have %d (%v), want %d", n, err, len(preimages))
+	}
+	for hash, blob := range preimages {
+		if have := rawdb.ReadPreimage(dst, hash); !bytes.Equal(have, blob) {
+			t.Errorf("preimage %x mismatch: have %x, want %x", hash, have, blob)
+		}
+	}
+	// Exporting from a database without iteration support should fail cleanly
+	if _, err := importer.ExportPreimages(filepath.Join(dir, "mem.rlp")); err != errPreimagesNotIterable {
+		t.Errorf("in-memory export error mismatch: have %v, want %v", err, errPreimagesNotIterable)
+	}
+}
+
+// Tests that preimage files are confined to the configured document root.
+func TestPreimagesOutsideDocRoot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "preimages")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := athdb.NewLDBDatabase(filepath.Join(dir, "db"), 0, 0)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	root := filepath.Join(dir, "docroot")
+	api := NewPrivateDebugAPI(nil, &Atlantis{chainDb: db, config: &Config{DocRoot: root}})
+
+	file := filepath.Join(root, "..", "preimages.rlp")
+	if _, err := api.ExportPreimages(file); err != errOutsideDocRoot {
+		t.Errorf("export error mismatch: have %v, want %v", err, errOutsideDocRoot)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("export outside of document root created file: %v", err)
+	}
+	if _, err := api.ImportPreimages(file); err != errOutsideDocRoot {
+		t.Errorf("import error mismatch: have %v, want %v", err, errOutsideDocRoot)
+	}
+}
+
+// Tests that raw database values can be read, but not over HTTP.
+func TestDbGet(t *testing.T) {
+	db := athdb.NewMemDatabase()
+	db.Put([]byte("key"), []byte("value"))
+
+	api := NewPrivateDebugAPI(nil, &Atlantis{chainDb: db, config: &Config{}})
+	if value, err := api.DbGet(context.Background(), []byte("key")); err != nil || !bytes.Equal(value, []byte("value")) {
+		t.Fatalf("value mismatch: have %x (%v), want %x", value, err, []byte("value"))
+	}
+	if _, err := api.DbGet(context.Background(), []byte("missing")); err == nil {
+		t.Fatalf("missing key retrieved")
+	}
+	ctx := context.WithValue(context.Background(), "scheme", "HTTP/1.1")
+	if _, err := api.DbGet(ctx, []byte("key")); err != errDbAccessOverHTTP {
+		t.Fatalf("HTTP access error mismatch: have %v, want %v", err, errDbAccessOverHTTP)
+	}
+}
+
+// Tests that the chain database can be compacted, but not concurrently.
+func TestCompactChainDb(t *testing.T) {
+	dir, err := ioutil.TempDir("", "compact")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := athdb.NewLDBDatabase(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	api := NewPrivateAdminAPI(&Atlantis{chainDb: db, config: &Config{}})
+	if ok, err := api.CompactChainDb(nil, nil); !ok || err != nil {
+		t.Fatalf("full compaction failed: %v", err)
+	}
+	start, limit := hexutil.Bytes{0x00}, hexutil.Bytes{0x80}
+	if ok, err := api.CompactChainDb(&start, &limit); !ok || err != nil {
+		t.Fatalf("range compaction failed: %v", err)
+	}
+	api.compacting = 1
+	if _, err := api.CompactChainDb(nil, nil); err != errCompactionRunning {
+		t.Fatalf("concurrent compaction error mismatch: have %v, want %v", err, errCompactionRunning)
+	}
+	memapi := NewPrivateAdminAPI(&Atlantis{chainDb: athdb.NewMemDatabase(), config: &Config{}})
+	if _, err := memapi.CompactChainDb(nil, nil); err != errCompactionUnsupported {
+		t.Fatalf("in-memory compaction error mismatch: have %v, want %v", err, errCompactionUnsupported)
+	}
+}
+
+// Tests that a range of blocks can be exported and that invalid ranges are
+// rejected before touching the output file.
+func TestExportChainRange(t *testing.T) {
+	dir, err := ioutil.TempDir("", "export")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		db      = athdb.NewMemDatabase()
+		gspec   = &core.Genesis{Config: params.TestChainConfig}
+		genesis = gspec.MustCommit(db)
+	)
+	blockchain, err := core.NewBlockChain(db, nil, gspec.Config, athash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer blockchain.Stop()
+
+	chain, _ := core.GenerateChain(gspec.Config, genesis, athash.NewFaker(), db, 5, nil)
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	api := NewPrivateAdminAPI(&Atlantis{blockchain: blockchain})
+
+	file := filepath.Join(dir, "range.rlp")
+	first, last := uint64(2), uint64(4)
+	if ok, err := api.ExportChain(file, &first, &last); !ok || err != nil {
+		t.Fatalf("range export failed: %v", err)
+	}
+	blob, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatalf("failed to read export: %v", err)
+	}
+	stream := rlp.NewStream(bytes.NewReader(blob), 0)
+	for number := first; number <= last; number++ {
+		block := new(types.Block)
+		if err := stream.Decode(block); err != nil {
+			t.Fatalf("block #%d: failed to decode: %v", number, err)
+		}
+		if block.Hash() != chain[number-1].Hash() {
+			t.Fatalf("block #%d: hash mismatch: have %x, want %x", number, block.Hash(), chain[number-1].Hash())
+		}
+	}
+	if err := stream.Decode(new(types.Block)); err != io.EOF {
+		t.Fatalf("trailing data after range: %v", err)
+	}
+	// Reversed and out of chain ranges must be rejected
+	if _, err := api.ExportChain(file, &last, &first); err == nil {
+		t.Errorf("reversed range accepted")
+	}
+	beyond := uint64(len(chain) + 1)
+	if _, err := api.ExportChain(file, &first, &beyond); err == nil {
+		t.Errorf("range beyond head accepted")
+	}
+}
+
+// Tests that resyncing rewinds the chain to the requested block, and that blocks
+// above the current head are refused.
+func TestResyncFrom(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 5, nil, nil)
+	defer pm.Stop()
+
+	api := NewPrivateAdminAPI(&Atlantis{blockchain: pm.blockchain, protocolManager: pm, config: &Config{}})
+	if _, err := api.ResyncFrom(6); err == nil {
+		t.Fatalf("resync above head succeeded")
+	}
+	if head := pm.blockchain.CurrentBlock().NumberU64(); head != 5 {
+		t.Fatalf("head moved by refused resync: have %d, want %d", head, 5)
+	}
+	if ok, err := api.ResyncFrom(2); !ok || err != nil {
+		t.Fatalf("resync failed: %v", err)
+	}
+	if head := pm.blockchain.CurrentBlock().NumberU64(); head != 2 {
+		t.Fatalf("head mismatch after resync: have %d, want %d", head, 2)
+	}
+	if bad := pm.blockchain.BadBlocks(); len(bad) != 0 {
+		t.Fatalf("bad blocks not cleared: %d left", len(bad))
+	}
+}
+
+// Tests that the methods modifying the chain database are rejected if it was
+// opened read-only.
+func TestReadOnlyDatabaseMethods(t *testing.T) {
+	ath := &Atlantis{chainDb: athdb.NewMemDatabase(), config: &Config{DatabaseReadOnly: true}}
+	admin, debug := NewPrivateAdminAPI(ath), NewPrivateDebugAPI(nil, ath)
+
+	if _, err := admin.ResyncFrom(0); err != errReadOnlyDatabase {
+		t.Errorf("resync error mismatch: have %v, want %v", err, errReadOnlyDatabase)
+	}
+	if _, err := admin.ImportChain("chain.rlp"); err != errReadOnlyDatabase {
+		t.Errorf("chain import error mismatch: have %v, want %v", err, errReadOnlyDatabase)
+	}
+	if _, err := admin.CompactChainDb(nil, nil); err != errReadOnlyDatabase {
+		t.Errorf("compaction error mismatch: have %v, want %v", err, errReadOnlyDatabase)
+	}
+	if _, err := debug.ImportPreimages("preimages.rlp"); err != errReadOnlyDatabase {
+		t.Errorf("preimage import error mismatch: have %v, want %v", err, errReadOnlyDatabase)
+	}
+}
+
+// Tests that peers can't be banned for durations overflowing the ban expiry.
+func TestDropPeerBanTooLong(t *testing.T) {
+	api := NewPrivateAdminAPI(&Atlantis{config: &Config{}})
+	url := "enode://" + strings.Repeat("01", 64) + "@127.0.0.1:30303"
+
+	for _, duration := range []uint64{uint64(maxPeerBanDuration/time.Second) + 1, math.MaxUint64} {
+		if _, err := api.DropPeer(url, &duration); err != errBanTooLong {
+			t.Errorf("ban of %d seconds error mismatch: have %v, want %v", duration, err, errBanTooLong)
+		}
+	}
+}
+
+// Tests that the bloom index status reports the blocks up to the chain head not
+// yet covered by the indexed sections.
+func TestBloomIndexStatus(t *testing.T) {
+	var (
+		db      = athdb.NewMemDatabase()
+		gspec   = &core.Genesis{Config: params.TestChainConfig}
+		genesis = gspec.MustCommit(db)
+	)
+	blockchain, err := core.NewBlockChain(db, nil, gspec.Config, athash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer blockchain.Stop()
+
+	chain, _ := core.GenerateChain(gspec.Config, genesis, athash.NewFaker(), db, 8, nil)
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	tests := []struct {
+		sections uint64
+		gap      uint64
+	}{
+		{0, 9}, // Nothing indexed, the whole chain is scanned
+		{1, 0}, // Index reaching past the head (e.g. after a rewind), nothing scanned
+	}
+	for i, tt := range tests {
+		// Seed the number of indexed sections the indexer loads on startup
+		indexdb := athdb.NewMemDatabase()
+
+		var count [8]byte
+		binary.BigEndian.PutUint64(count[:], tt.sections)
+		athdb.NewTable(indexdb, string(rawdb.BloomBitsIndexPrefix)).Put([]byte("count"), count[:])
+
+		indexer := NewBloomIndexer(indexdb, params.BloomBitsBlocks)
+		status := NewPublicAtlantisAPI(&Atlantis{blockchain: blockchain, bloomIndexer: indexer}).BloomIndexStatus()
+		indexer.Close()
+
+		want := &BloomIndexStatus{
+			SectionSize:     hexutil.Uint64(params.BloomBitsBlocks),
+			IndexedSections: hexutil.Uint64(tt.sections),
+			HeadSection:     0,
+			Gap:             hexutil.Uint64(tt.gap),
+		}
+		if !reflect.DeepEqual(status, want) {
+			t.Errorf("test %d: status mismatch: have %+v, want %+v", i, status, want)
+		}
+	}
+}
+
+// Tests that the health summary reports the sync state, the connected peers and
+// the age of the head block.
+func TestHealth(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 4, nil, nil)
+	defer pm.Stop()
+
+	api := NewPublicAtlantisAPI(&Atlantis{protocolManager: pm, blockchain: pm.blockchain})
+
+	status := api.Health()
+	if status.Synced || status.AcceptingTxs || status.Peers != 0 {
+		t.Fatalf("fresh node status mismatch: have %+v, want unsynced without peers", status)
+	}
+	want := uint64(time.Now().Unix()) - pm.blockchain.CurrentBlock().Time().Uint64()
+	if age := uint64(status.HeadAge); age < want || age > want+1 {
+		t.Errorf("head age mismatch: have %d, want %d", age, want)
+	}
+	// Mark the initial sync done and connect a peer
+	atomic.StoreUint32(&pm.acceptTxs, 1)
+
+	peer, _ := newTestPeer("peer", ath63, pm, true)
+	defer peer.close()
+
+	for start := time.Now(); pm.peers.Len() == 0 && time.Since(start) < time.Second; {
+		time.Sleep(10 * time.Millisecond)
+	}
+	status = api.Health()
+	if !status.Synced || !status.AcceptingTxs || status.Peers != 1 {
+		t.Fatalf("synced node status mismatch: have %+v, want synced with 1 peer", status)
+	}
+}
@@ -101,6 +101,8 @@ func (api *PrivateDebugAPI) TraceChain(ctx context.Context, start, end rpc.Block
 		from = api.ath.miner.PendingBlock()
 	case rpc.LatestBlockNumber:
 		from = api.ath.blockchain.CurrentBlock()
+	case rpc.SafeBlockNumber, rpc.FinalizedBlockNumber:
+		from = api.ath.blockchain.GetBlockByNumber(uint64(api.ath.APIBackend.finalizedNumber()))
 	default:
 		from = api.ath.blockchain.GetBlockByNumber(uint64(start))
 	}
@@ -109,6 +111,8 @@ func (api *PrivateDebugAPI) TraceChain(ctx context.Context, start, end rpc.Block
 		to = api.ath.miner.PendingBlock()
 	case rpc.LatestBlockNumber:
 		to = api.ath.blockchain.CurrentBlock()
+	case rpc.SafeBlockNumber, rpc.FinalizedBlockNumber:
+		to = api.ath.blockchain.GetBlockByNumber(uint64(api.ath.APIBackend.finalizedNumber()))
 	default:
 		to = api.ath.blockchain.GetBlockByNumber(uint64(end))
 	}
@@ -346,6 +350,8 @@ func (api *PrivateDebugAPI) TraceBlockByNumber(ctx context.Context, number rpc.B
 		block = api.ath.miner.PendingBlock()
 	case rpc.LatestBlockNumber:
 		block = api.ath.blockchain.CurrentBlock()
+	case rpc.SafeBlockNumber, rpc.FinalizedBlockNumber:
+		block = api.ath.blockchain.GetBlockByNumber(uint64(api.ath.APIBackend.finalizedNumber()))
 	default:
 		block = api.ath.blockchain.GetBlockByNumber(uint64(number))
 	}
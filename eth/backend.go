@@ -20,11 +20,15 @@ package ath
 import (
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
 	"runtime"
 	"sync"
 	"sync/atomic"
+	"time"
+	"unicode/utf8"
 
+	athereum "github.com/athereum/go-athereum"
 	"github.com/athereum/go-athereum/accounts"
 	"github.com/athereum/go-athereum/common"
 	"github.com/athereum/go-athereum/common/hexutil"
@@ -58,6 +62,29 @@ type LesServer interface {
 	SetBloomBitsIndexer(bbIndexer *core.ChainIndexer)
 }
 
+// AtlantisbaseAutoselectedEvent is posted when no atherbase was configured and
+// the first account of the first wallet was picked as the reward address.
+type AtlantisbaseAutoselectedEvent struct{ Address common.Address }
+
+// MiningHaltedEvent is posted when mining is stopped on the node's behalf, e.g.
+// because the wallet holding the atherbase signing key was removed.
+type MiningHaltedEvent struct {
+	Address common.Address
+	Err     error
+}
+
+// errReadOnlyMining is returned if mining is requested on a node whose chain
+// database was opened in read-only mode.
+var errReadOnlyMining = errors.New("cannot mine on a read-only chain database")
+
+// errAtlantisbaseWalletDropped is reported if the wallet backing the atherbase is
+// removed while sealing, leaving the node unable to sign blocks.
+var errAtlantisbaseWalletDropped = errors.New("atherbase wallet dropped")
+
+// errProtocolAfterStart is returned if a custom protocol is registered after the
+// service was already started.
+var errProtocolAfterStart = errors.New("cannot register protocol after start")
+
 // Atlantis implements the Atlantis full node service.
 type Atlantis struct {
 	config      *Config
@@ -71,6 +98,8 @@ type Atlantis struct {
 	blockchain      *core.BlockChain
 	protocolManager *ProtocolManager
 	lesServer       LesServer
+	extraProtocols  []p2p.Protocol // Custom protocols registered by embedders
+	started         bool           // Whether the service was started, rejecting new protocols
 
 	// DB interfaces
 	chainDb athdb.Database // Block chain database
@@ -84,12 +113,14 @@ type Atlantis struct {
 
 	APIBackend *EthAPIBackend
 
-	miner     *miner.Miner
-	gasPrice  *big.Int
-	atherbase common.Address
+	miner        *miner.Miner
+	gasPrice     *big.Int
+	atherbase    common.Address
+	walletHalted bool // Whether mining was stopped because the atherbase wallet was dropped
 
 	networkId     uint64
 	netRPCService *athapi.PublicNetAPI
+	p2pServer     *p2p.Server // Server the service runs on, set at start (protected by lock)
 
 	lock sync.RWMutex // Protects the variadic fields (e.g. gas price and atherbase)
 }
@@ -108,23 +139,45 @@ func New(ctx *node.ServiceContext, config *Config) (*Atlantis, error) {
 	if !config.SyncMode.IsValid() {
 		return nil, fmt.Errorf("invalid sync mode %d", config.SyncMode)
 	}
+	if config.BloomServiceThreads <= 0 {
+		config.BloomServiceThreads = bloomFilterThreads
+	}
+	if config.MinerGasCeil != 0 && config.MinerGasFloor > config.MinerGasCeil {
+		return nil, fmt.Errorf("miner gas floor %d above gas ceiling %d", config.MinerGasFloor, config.MinerGasCeil)
+	}
+	if floor := config.MinAcceptedGasPrice; floor != nil && !floor.IsUint64() {
+		return nil, fmt.Errorf("accepted gas price floor %v out of range", floor)
+	}
 	chainDb, err := CreateDB(ctx, config, "chaindata")
 	if err != nil {
 		return nil, err
 	}
-	chainConfig, genesisHash, genesisErr := core.SetupGenesisBlock(chainDb, config.Genesis)
+	var (
+		chainConfig *params.ChainConfig
+		genesisHash common.Hash
+		genesisErr  error
+	)
+	if config.DatabaseReadOnly {
+		chainConfig, genesisHash, genesisErr = core.ReadGenesisConfig(chainDb, config.Genesis)
+	} else {
+		chainConfig, genesisHash, genesisErr = core.SetupGenesisBlock(chainDb, config.Genesis)
+	}
 	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
 		return nil, genesisErr
 	}
 	log.Info("Initialised chain configuration", "config", chainConfig)
 
+	engine, err := CreateConsensusEngine(ctx, config.Engine, &config.Ethash, chainConfig, config.MinerEmptyBlockPeriod, chainDb)
+	if err != nil {
+		return nil, err
+	}
 	ath := &Atlantis{
 		config:         config,
 		chainDb:        chainDb,
 		chainConfig:    chainConfig,
 		eventMux:       ctx.EventMux,
 		accountManager: ctx.AccountManager,
-		engine:         CreateConsensusEngine(ctx, &config.Ethash, chainConfig, chainDb),
+		engine:         engine,
 		shutdownChan:   make(chan bool),
 		networkId:      config.NetworkId,
 		gasPrice:       config.GasPrice,
@@ -140,36 +193,76 @@ func New(ctx *node.ServiceContext, config *Config) (*Atlantis, error) {
 		if bcVersion != core.BlockChainVersion && bcVersion != 0 {
 			return nil, fmt.Errorf("Blockchain DB version mismatch (%d / %d). Run gath upgradedb.\n", bcVersion, core.BlockChainVersion)
 		}
-		rawdb.WriteDatabaseVersion(chainDb, core.BlockChainVersion)
+		if !config.DatabaseReadOnly {
+			rawdb.WriteDatabaseVersion(chainDb, core.BlockChainVersion)
+		}
 	}
 	var (
 		vmConfig    = vm.Config{EnablePreimageRecording: config.EnablePreimageRecording}
-		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieNodeLimit: config.TrieCache, TrieTimeLimit: config.TrieTimeout}
+		cacheConfig = &core.CacheConfig{
+			Disabled:       config.NoPruning,
+			TrieNodeLimit:  config.TrieCache,
+			TrieTimeLimit:  config.TrieTimeout,
+			TriesInMemory:  config.TriesInMemory,
+			TrieGCInterval: config.TrieGCInterval,
+			HeaderCache:    config.HeaderCache,
+			BodyCache:      config.BodyCache,
+			ReceiptCache:   config.ReceiptCache,
+		}
 	)
 	ath.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, ath.chainConfig, ath.engine, vmConfig)
 	if err != nil {
 		return nil, err
 	}
+	ath.blockchain.SetMaxReorgDepth(config.MaxReorgDepth)
+	ath.blockchain.SetStatePrefetch(config.StatePrefetch)
 	// Rewind the chain in case of an incompatible config upgrade.
-	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
+	if compat, ok := genesisErr.(*params.ConfigCompatError); ok && config.DatabaseReadOnly {
+		log.Warn("Skipping chain rewind on read-only database", "err", compat)
+	} else if ok {
 		log.Warn("Rewinding chain to upgrade configuration", "err", compat)
 		ath.blockchain.SetHead(compat.RewindTo)
 		rawdb.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
-	ath.bloomIndexer.Start(ath.blockchain)
+	if !config.DatabaseReadOnly {
+		ath.bloomIndexer.Start(ath.blockchain)
+	}
 
+	if config.DatabaseReadOnly {
+		// Nothing is synced or mined, don't persist local transactions either
+		config.TxPool.Journal = ""
+	}
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
 	}
+	if floor := config.MinAcceptedGasPrice; floor != nil && floor.Uint64() > config.TxPool.PriceLimit {
+		log.Info("Raising txpool price limit to the accepted gas price floor", "limit", config.TxPool.PriceLimit, "floor", floor)
+		config.TxPool.PriceLimit = floor.Uint64()
+	}
 	ath.txPool = core.NewTxPool(config.TxPool, ath.chainConfig, ath.blockchain)
 
 	if ath.protocolManager, err = NewProtocolManager(ath.chainConfig, config.SyncMode, config.NetworkId, ath.eventMux, ath.txPool, ath.engine, ath.blockchain, chainDb); err != nil {
 		return nil, err
 	}
-	ath.miner = miner.New(ath, ath.chainConfig, ath.EventMux(), ath.engine)
-	ath.miner.SetExtra(makeExtraData(config.ExtraData))
+	ath.protocolManager.txBroadcastPeers = config.TxBroadcastPeers
+	ath.protocolManager.requestRate = config.RequestRateLimit
+	ath.protocolManager.requestBurst = config.RequestBurstLimit
+	ath.protocolManager.requestDropLimit = config.RequestDropLimit
+	ath.protocolManager.peerIdleTimeout = config.PeerIdleTimeout
+	ath.protocolManager.fastSyncRetries = config.FastSyncRetries
+	ath.protocolManager.setPeerFilter(config.TrustedPeerOnly, config.AllowedPeers, config.DeniedPeers)
+	gasCeil := config.MinerGasCeil
+	if gasCeil == 0 && config.MinerGasFloor != 0 {
+		gasCeil = math.MaxUint64
+	}
+	ath.miner = miner.New(ath, ath.chainConfig, ath.EventMux(), ath.engine, config.MinerGasFloor, gasCeil)
+	ath.miner.SetExtra(makeExtraData(config.ExtraData, config.ExtraDataClientName))
 
-	ath.APIBackend = &EthAPIBackend{ath, nil}
+	ath.APIBackend = &EthAPIBackend{ath: ath}
+	ath.APIBackend.startTdCache(config.TdCache)
+	if config.MaxLogsConcurrency > 0 {
+		ath.APIBackend.logsSlots = make(chan struct{}, config.MaxLogsConcurrency)
+	}
 	gpoParams := config.GPO
 	if gpoParams.Default == nil {
 		gpoParams.Default = config.GasPrice
@@ -179,15 +272,33 @@ func New(ctx *node.ServiceContext, config *Config) (*Atlantis, error) {
 	return ath, nil
 }
 
-func makeExtraData(extra []byte) []byte {
+// defaultExtraDataClientName is the client identity embedded in the default block
+// extra data if none is configured.
+const defaultExtraDataClientName = "gath"
+
+func makeExtraData(extra []byte, clientName string) []byte {
 	if len(extra) == 0 {
-		// create default extradata
-		extra, _ = rlp.EncodeToBytes([]interface{}{
-			uint(params.VersionMajor<<16 | params.VersionMinor<<8 | params.VersionPatch),
-			"gath",
-			runtime.Version(),
-			runtime.GOOS,
-		})
+		// create default extradata, truncating the client name if it doesn't fit
+		if clientName == "" {
+			clientName = defaultExtraDataClientName
+		}
+		name := clientName
+		for {
+			extra, _ = rlp.EncodeToBytes([]interface{}{
+				uint(params.VersionMajor<<16 | params.VersionMinor<<8 | params.VersionPatch),
+				name,
+				runtime.Version(),
+				runtime.GOOS,
+			})
+			if uint64(len(extra)) <= params.MaximumExtraDataSize || name == "" {
+				break
+			}
+			_, size := utf8.DecodeLastRuneInString(name)
+			name = name[:len(name)-size]
+		}
+		if name != clientName {
+			log.Warn("Miner client name truncated", "name", clientName, "truncated", name, "limit", params.MaximumExtraDataSize)
+		}
 	}
 	if uint64(len(extra)) > params.MaximumExtraDataSize {
 		log.Warn("Miner extra data exceed limit", "extra", hexutil.Bytes(extra), "limit", params.MaximumExtraDataSize)
@@ -198,7 +309,11 @@ func makeExtraData(extra []byte) []byte {
 
 // CreateDB creates the chain database.
 func CreateDB(ctx *node.ServiceContext, config *Config, name string) (athdb.Database, error) {
-	db, err := ctx.OpenDatabase(name, config.DatabaseCache, config.DatabaseHandles)
+	open := ctx.OpenDatabase
+	if config.DatabaseReadOnly {
+		open = ctx.OpenDatabaseReadOnly
+	}
+	db, err := open(name, config.DatabaseCache, config.DatabaseHandles)
 	if err != nil {
 		return nil, err
 	}
@@ -208,23 +323,60 @@ func CreateDB(ctx *node.ServiceContext, config *Config, name string) (athdb.Data
 	return db, nil
 }
 
-// CreateConsensusEngine creates the required type of consensus engine instance for an Atlantis service
-func CreateConsensusEngine(ctx *node.ServiceContext, config *athash.Config, chainConfig *params.ChainConfig, db athdb.Database) consensus.Engine {
+// EngineFactory creates a custom consensus engine for an Atlantis service.
+type EngineFactory func(ctx *node.ServiceContext, chainConfig *params.ChainConfig, db athdb.Database) (consensus.Engine, error)
+
+var (
+	enginesLock sync.RWMutex
+	engines     = make(map[string]EngineFactory) // Custom consensus engines by name
+)
+
+// RegisterEngine makes a custom consensus engine available under the given name,
+// which can then be selected via the Engine config field. It's meant to be called
+// by embedders before the service is created and panics on duplicate names.
+func RegisterEngine(name string, factory EngineFactory) {
+	enginesLock.Lock()
+	defer enginesLock.Unlock()
+
+	if _, exists := engines[name]; exists {
+		panic(fmt.Sprintf("consensus engine %q already registered", name))
+	}
+	engines[name] = factory
+}
+
+// CreateConsensusEngine creates the required type of consensus engine instance for an Atlantis service.
+// A named engine must have been registered via RegisterEngine, otherwise clique
+// or ethash is created as the chain configuration demands.
+func CreateConsensusEngine(ctx *node.ServiceContext, name string, config *athash.Config, chainConfig *params.ChainConfig, emptyBlockPeriod uint64, db athdb.Database) (consensus.Engine, error) {
+	// If a custom engine is requested, delegate to its factory
+	if name != "" {
+		enginesLock.RLock()
+		factory, ok := engines[name]
+		enginesLock.RUnlock()
+
+		if !ok {
+			return nil, fmt.Errorf("%v: %q", errUnknownEngine, name)
+		}
+		log.Info("Using custom consensus engine", "name", name)
+		return factory(ctx, chainConfig, db)
+	}
 	// If proof-of-authority is requested, set it up
 	if chainConfig.Clique != nil {
-		return clique.New(chainConfig.Clique, db)
+		engine := clique.New(chainConfig.Clique, db)
+		engine.SetEmptyBlockPeriod(emptyBlockPeriod)
+		return engine, nil
 	}
 	// Otherwise assume proof-of-work
 	switch config.PowMode {
 	case athash.ModeFake:
 		log.Warn("Ethash used in fake mode")
-		return athash.NewFaker()
+		return athash.NewFaker(), nil
 	case athash.ModeTest:
 		log.Warn("Ethash used in test mode")
-		return athash.NewTester()
+		return athash.NewTester(), nil
 	case athash.ModeShared:
 		log.Warn("Ethash used in shared mode")
-		return athash.NewShared()
+		return athash.NewShared(), nil
 	default:
 		engine := athash.New(athash.Config{
 			CacheDir:       ctx.ResolvePath(config.CacheDir),
@@ -235,7 +387,7 @@ func CreateConsensusEngine(ctx *node.ServiceContext, config *athash.Config, chai
 			DatasetsOnDisk: config.DatasetsOnDisk,
 		})
 		engine.SetThreads(-1) // Disable CPU mining
-		return engine
+		return engine, nil
 	}
 }
 
@@ -272,7 +424,7 @@ func (s *Atlantis) APIs() []rpc.API {
 		}, {
 			Namespace: "ath",
 			Version:   "1.0",
-			Service:   filters.NewPublicFilterAPI(s.APIBackend, false),
+			Service:   filters.NewPublicFilterAPIWithLogLimit(s.APIBackend, false, s.config.MaxLogsReturned),
 			Public:    true,
 		}, {
 			Namespace: "admin",
@@ -317,27 +469,47 @@ func (s *Atlantis) Atlantisbase() (eb common.Address, err error) {
 			s.lock.Unlock()
 
 			log.Info("Atlantisbase automatically configured", "address", atherbase)
+			s.eventMux.Post(AtlantisbaseAutoselectedEvent{Address: atherbase})
 			return atherbase, nil
 		}
 	}
 	return common.Address{}, fmt.Errorf("atherbase must be explicitly specified")
 }
 
-// SetAtlantisbase sets the mining reward address.
-func (s *Atlantis) SetAtlantisbase(atherbase common.Address) {
+// SetAtlantisbase sets the mining reward address. If the consensus engine is
+// clique, the signer is re-authorized with the new address so that subsequently
+// sealed blocks aren't signed with a stale key.
+func (s *Atlantis) SetAtlantisbase(atherbase common.Address) error {
+	if err := s.authorizeSigner(atherbase); err != nil {
+		return err
+	}
 	s.lock.Lock()
 	s.atherbase = atherbase
 	s.lock.Unlock()
 
 	s.miner.SetAtlantisbase(atherbase)
+	s.miner.SetAtlantisbases(nil)
+	return nil
 }
 
-func (s *Atlantis) StartMining(local bool) error {
-	eb, err := s.Atlantisbase()
-	if err != nil {
-		log.Error("Cannot start mining without atherbase", "err", err)
-		return fmt.Errorf("atherbase missing: %v", err)
+// SetAtlantisbases sets a list of reward addresses the miner rotates through per
+// sealed block, the first of which also becomes the atherbase. An empty list
+// stops the rotation, reverting to the single atherbase.
+func (s *Atlantis) SetAtlantisbases(atherbases []common.Address) error {
+	if len(atherbases) == 0 {
+		s.miner.SetAtlantisbases(nil)
+		return nil
+	}
+	if err := s.SetAtlantisbase(atherbases[0]); err != nil {
+		return err
 	}
+	s.miner.SetAtlantisbases(atherbases)
+	return nil
+}
+
+// authorizeSigner injects the signing credentials of the given account into the
+// consensus engine if it's a proof-of-authority one. It's a noop otherwise.
+func (s *Atlantis) authorizeSigner(eb common.Address) error {
 	if clique, ok := s.engine.(*clique.Clique); ok {
 		wallet, err := s.accountManager.Find(accounts.Account{Address: eb})
 		if wallet == nil || err != nil {
@@ -346,6 +518,21 @@ func (s *Atlantis) StartMining(local bool) error {
 		}
 		clique.Authorize(eb, wallet.SignHash)
 	}
+	return nil
+}
+
+func (s *Atlantis) StartMining(local bool) error {
+	if s.config.DatabaseReadOnly {
+		return errReadOnlyMining
+	}
+	eb, err := s.Atlantisbase()
+	if err != nil {
+		log.Error("Cannot start mining without atherbase", "err", err)
+		return fmt.Errorf("atherbase missing: %v", err)
+	}
+	if err := s.authorizeSigner(eb); err != nil {
+		return err
+	}
 	if local {
 		// If local (CPU) mining is started, we can disable the transaction rejection
 		// mechanism introduced to speed sync times. CPU mining on mainnet is ludicrous
@@ -353,14 +540,126 @@ func (s *Atlantis) StartMining(local bool) error {
 		// will ensure that private networks work in single miner mode too.
 		atomic.StoreUint32(&s.protocolManager.acceptTxs, 1)
 	}
-	go s.miner.Start(eb)
-	return nil
+	return s.miner.Start(eb)
+}
+
+func (s *Atlantis) StopMining() {
+	s.lock.Lock()
+	s.walletHalted = false
+	s.lock.Unlock()
+
+	s.miner.Stop()
 }
 
-func (s *Atlantis) StopMining()         { s.miner.Stop() }
 func (s *Atlantis) IsMining() bool      { return s.miner.Mining() }
 func (s *Atlantis) Miner() *miner.Miner { return s.miner }
 
+// Importing reports whether the node is currently importing blocks into its
+// chain, as opposed to sitting idle.
+func (s *Atlantis) Importing() bool { return s.blockchain.Importing() }
+
+// walletLoop tracks the wallets of the account manager, halting mining if the
+// wallet holding the atherbase key disappears and resuming it once it's back.
+func (s *Atlantis) walletLoop(events chan accounts.WalletEvent, sub event.Subscription) {
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case <-events:
+			s.checkAtlantisbaseWallet()
+		case <-sub.Err():
+			return
+		case <-s.shutdownChan:
+			return
+		}
+	}
+}
+
+// reorgLoop forwards the chain reorganisation events of the blockchain onto the
+// event mux, next to the other node level events.
+func (s *Atlantis) reorgLoop(events chan core.ChainReorgEvent, sub event.Subscription) {
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-events:
+			s.eventMux.Post(ev)
+		case <-sub.Err():
+			return
+		case <-s.shutdownChan:
+			return
+		}
+	}
+}
+
+// minPeersCheckInterval is the interval at which the peer count is checked against
+// the configured floor.
+const minPeersCheckInterval = 10 * time.Second
+
+// peerFloorLoop periodically checks the peer count of the server, asking it to
+// dial more peers whenever it dropped below the configured minimum.
+func (s *Atlantis) peerFloorLoop(srvr *p2p.Server) {
+	ticker := time.NewTicker(minPeersCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if count := srvr.PeerCount(); count < s.config.MinPeers {
+				log.Debug("Peer count below floor, dialing more", "peers", count, "min", s.config.MinPeers)
+				srvr.RefillPeers()
+			}
+		case <-s.shutdownChan:
+			return
+		}
+	}
+}
+
+// checkAtlantisbaseWallet stops mining if the atherbase signer is no longer
+// available locally, or restarts it if the signer reappeared after a halt. Only
+// clique needs the key for sealing, other engines are left running.
+func (s *Atlantis) checkAtlantisbaseWallet() {
+	if _, ok := s.engine.(*clique.Clique); !ok {
+		return
+	}
+	s.lock.RLock()
+	eb, halted := s.atherbase, s.walletHalted
+	s.lock.RUnlock()
+
+	if eb == (common.Address{}) {
+		return
+	}
+	_, err := s.accountManager.Find(accounts.Account{Address: eb})
+	switch {
+	case err != nil && !halted && s.IsMining():
+		log.Error("Atlantisbase wallet dropped, stopping mining", "address", eb)
+		s.miner.Stop()
+
+		s.lock.Lock()
+		s.walletHalted = true
+		s.lock.Unlock()
+
+		s.eventMux.Post(MiningHaltedEvent{Address: eb, Err: errAtlantisbaseWalletDropped})
+
+	case err == nil && halted:
+		log.Info("Atlantisbase wallet reappeared, resuming mining", "address", eb)
+		s.lock.Lock()
+		s.walletHalted = false
+		s.lock.Unlock()
+
+		if err := s.StartMining(false); err != nil {
+			log.Error("Failed to resume mining", "err", err)
+		}
+	}
+}
+
+// PauseMining suspends block sealing while keeping the pending block assembly
+// alive, so that ResumeMining can produce a block right away.
+func (s *Atlantis) PauseMining() { s.miner.Pause() }
+
+// ResumeMining restarts block sealing after a previous PauseMining.
+func (s *Atlantis) ResumeMining() { s.miner.Resume() }
+
 func (s *Atlantis) AccountManager() *accounts.Manager  { return s.accountManager }
 func (s *Atlantis) BlockChain() *core.BlockChain       { return s.blockchain }
 func (s *Atlantis) TxPool() *core.TxPool               { return s.txPool }
@@ -372,24 +671,78 @@ func (s *Atlantis) EthVersion() int                    { return int(s.protocolMa
 func (s *Atlantis) NetVersion() uint64                 { return s.networkId }
 func (s *Atlantis) Downloader() *downloader.Downloader { return s.protocolManager.downloader }
 
+// P2PServer returns the p2p server the service runs on, or nil if it wasn't
+// started yet.
+func (s *Atlantis) P2PServer() *p2p.Server {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.p2pServer
+}
+
+// SyncProgress retrieves the current progress of the chain synchronisation.
+func (s *Atlantis) SyncProgress() athereum.SyncProgress {
+	return s.protocolManager.downloader.Progress()
+}
+
 // Protocols implements node.Service, returning all the currently configured
 // network protocols to start.
 func (s *Atlantis) Protocols() []p2p.Protocol {
-	if s.lesServer == nil {
-		return s.protocolManager.SubProtocols
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var protos []p2p.Protocol
+	if !s.config.DatabaseReadOnly {
+		// A read-only chain can't be synced, only serve it over RPC
+		protos = append(protos, s.protocolManager.SubProtocols...)
+		if s.lesServer != nil {
+			protos = append(protos, s.lesServer.Protocols()...)
+		}
 	}
-	return append(s.protocolManager.SubProtocols, s.lesServer.Protocols()...)
+	return append(protos, s.extraProtocols...)
+}
+
+// RegisterProtocol adds a custom protocol to run alongside the Atlantis ones on
+// the same p2p server. It must be called before the node is started, typically
+// from the service constructor passed to node.Register.
+func (s *Atlantis) RegisterProtocol(p p2p.Protocol) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.started {
+		return errProtocolAfterStart
+	}
+	s.extraProtocols = append(s.extraProtocols, p)
+	return nil
 }
 
 // Start implements node.Service, starting all internal goroutines needed by the
 // Atlantis protocol implementation.
 func (s *Atlantis) Start(srvr *p2p.Server) error {
+	s.lock.Lock()
+	s.started = true
+	s.p2pServer = srvr
+	s.lock.Unlock()
+
 	// Start the bloom bits servicing goroutines
 	s.startBloomHandlers()
 
+	// Watch the wallets to halt sealing if the atherbase signer disappears
+	walletEvents := make(chan accounts.WalletEvent, 16)
+	go s.walletLoop(walletEvents, s.accountManager.Subscribe(walletEvents))
+
+	// Post the chain reorganisations on the event mux
+	reorgEvents := make(chan core.ChainReorgEvent, 16)
+	go s.reorgLoop(reorgEvents, s.blockchain.SubscribeChainReorgEvent(reorgEvents))
+
 	// Start the RPC service
 	s.netRPCService = athapi.NewPublicNetAPI(srvr, s.NetVersion())
 
+	// Keep the peer count above the configured floor
+	if s.config.MinPeers > 0 {
+		go s.peerFloorLoop(srvr)
+	}
+
 	// Figure out a max peers count based on the server limits
 	maxPeers := srvr.MaxPeers
 	if s.config.LightServ > 0 {
@@ -398,7 +751,12 @@ func (s *Atlantis) Start(srvr *p2p.Server) error {
 		}
 		maxPeers -= s.config.LightPeers
 	}
-	// Start the networking layer and the light server if requested
+	// Start the networking layer and the light server if requested. A read-only
+	// chain can't import blocks, so neither is started for it.
+	if s.config.DatabaseReadOnly {
+		log.Info("Chain database is read-only, not syncing")
+		return nil
+	}
 	s.protocolManager.Start(maxPeers)
 	if s.lesServer != nil {
 		s.lesServer.Start(srvr)
@@ -409,18 +767,42 @@ func (s *Atlantis) Start(srvr *p2p.Server) error {
 // Stop implements node.Service, terminating all internal goroutines used by the
 // Atlantis protocol.
 func (s *Atlantis) Stop() error {
-	s.bloomIndexer.Close()
-	s.blockchain.Stop()
-	s.protocolManager.Stop()
-	if s.lesServer != nil {
-		s.lesServer.Stop()
+	s.stopWithTimeout("bloom indexer", func() { s.bloomIndexer.Close() })
+	s.stopWithTimeout("blockchain", s.blockchain.Stop)
+	if !s.config.DatabaseReadOnly {
+		s.stopWithTimeout("protocol manager", s.protocolManager.Stop)
+		if s.lesServer != nil {
+			s.stopWithTimeout("light server", s.lesServer.Stop)
+		}
 	}
-	s.txPool.Stop()
-	s.miner.Stop()
-	s.eventMux.Stop()
+	s.stopWithTimeout("transaction pool", s.txPool.Stop)
+	s.stopWithTimeout("miner", s.miner.Stop)
+	s.stopWithTimeout("event mux", s.eventMux.Stop)
 
 	s.chainDb.Close()
 	close(s.shutdownChan)
 
 	return nil
 }
+
+// stopWithTimeout runs the stop function of a subsystem, giving up on waiting for
+// it if it does not return within the configured shutdown timeout.
+func (s *Atlantis) stopWithTimeout(name string, stop func()) {
+	if s.config.ShutdownTimeout <= 0 {
+		stop()
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		stop()
+		close(done)
+	}()
+	timeout := time.NewTimer(s.config.ShutdownTimeout)
+	defer timeout.Stop()
+
+	select {
+	case <-done:
+	case <-timeout.C:
+		log.Error("Subsystem shutdown timed out", "subsystem", name, "timeout", s.config.ShutdownTimeout)
+	}
+}
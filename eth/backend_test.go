@@ -0,0 +1,477 @@
+// Copyright 2018 The go-athereum Authors
+// This file is part of the go-athereum library.
+//
+// The go-athereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-athereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-athereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ath
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/athereum/go-athereum/accounts"
+	"github.com/athereum/go-athereum/accounts/keystore"
+	"github.com/athereum/go-athereum/ath/downloader"
+	"github.com/athereum/go-athereum/athdb"
+	"github.com/athereum/go-athereum/common"
+	"github.com/athereum/go-athereum/consensus"
+	"github.com/athereum/go-athereum/consensus/athash"
+	"github.com/athereum/go-athereum/consensus/clique"
+	"github.com/athereum/go-athereum/core"
+	"github.com/athereum/go-athereum/core/types"
+	"github.com/athereum/go-athereum/core/vm"
+	"github.com/athereum/go-athereum/event"
+	"github.com/athereum/go-athereum/miner"
+	"github.com/athereum/go-athereum/node"
+	"github.com/athereum/go-athereum/p2p"
+	"github.com/athereum/go-athereum/params"
+	"github.com/athereum/go-athereum/rlp"
+)
+
+// Tests that a wedged subsystem does not block the shutdown beyond the configured
+// timeout, while well behaved ones are waited for.
+func TestStopWithTimeout(t *testing.T) {
+	ath := &Atlantis{config: &Config{ShutdownTimeout: 50 * time.Millisecond}}
+
+	stopped := false
+	ath.stopWithTimeout("healthy", func() { stopped = true })
+	if !stopped {
+		t.Fatalf("healthy subsystem not stopped")
+	}
+	wedged := make(chan struct{})
+	defer close(wedged)
+
+	start := time.Now()
+	ath.stopWithTimeout("wedged", func() { <-wedged })
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("wedged subsystem blocked shutdown for %v", elapsed)
+	}
+}
+
+// Tests that custom protocols are served alongside the Atlantis ones, but can't
+// be registered anymore once the service was started.
+func TestRegisterProtocol(t *testing.T) {
+	ath := &Atlantis{
+		config:          &Config{},
+		protocolManager: &ProtocolManager{SubProtocols: []p2p.Protocol{{Name: "ath", Version: 63}}},
+	}
+	if err := ath.RegisterProtocol(p2p.Protocol{Name: "mon", Version: 1}); err != nil {
+		t.Fatalf("failed to register protocol: %v", err)
+	}
+	protos := ath.Protocols()
+	if len(protos) != 2 || protos[0].Name != "ath" || protos[1].Name != "mon" {
+		t.Fatalf("protocol set mismatch: have %v", protos)
+	}
+	if len(ath.protocolManager.SubProtocols) != 1 {
+		t.Fatalf("protocol manager's protocols modified")
+	}
+	ath.started = true
+	if err := ath.RegisterProtocol(p2p.Protocol{Name: "late", Version: 1}); err != errProtocolAfterStart {
+		t.Fatalf("late registration error mismatch: have %v, want %v", err, errProtocolAfterStart)
+	}
+}
+
+// Tests that a node on a read-only chain database doesn't run the Atlantis
+// protocols, as it can't import any blocks received from its peers.
+func TestReadOnlyProtocols(t *testing.T) {
+	ath := &Atlantis{
+		config:          &Config{DatabaseReadOnly: true},
+		protocolManager: &ProtocolManager{SubProtocols: []p2p.Protocol{{Name: "ath", Version: 63}}},
+	}
+	if err := ath.RegisterProtocol(p2p.Protocol{Name: "mon", Version: 1}); err != nil {
+		t.Fatalf("failed to register protocol: %v", err)
+	}
+	protos := ath.Protocols()
+	if len(protos) != 1 || protos[0].Name != "mon" {
+		t.Fatalf("protocol set mismatch: have %v", protos)
+	}
+}
+
+// Tests that an accepted gas price floor not fitting into the txpool's price limit
+// is rejected instead of being silently ignored.
+func TestMinAcceptedGasPriceOverflow(t *testing.T) {
+	floor := new(big.Int).Lsh(big.NewInt(1), 64)
+
+	_, err := New(nil, &Config{SyncMode: downloader.FullSync, MinAcceptedGasPrice: floor})
+	if err == nil || !strings.Contains(err.Error(), "out of range") {
+		t.Fatalf("error mismatch: have %v, want out of range", err)
+	}
+}
+
+// Tests that the default extra data embeds the configured client name, truncating
+// it to stay within the protocol limit.
+func TestMakeExtraDataClientName(t *testing.T) {
+	decode := func(extra []byte) string {
+		var fields []interface{}
+		if err := rlp.DecodeBytes(extra, &fields); err != nil {
+			t.Fatalf("failed to decode extra data: %v", err)
+		}
+		return string(fields[1].([]byte))
+	}
+	if name := decode(makeExtraData(nil, "")); name != defaultExtraDataClientName {
+		t.Errorf("default client name mismatch: have %q, want %q", name, defaultExtraDataClientName)
+	}
+	if name := decode(makeExtraData(nil, "mypool")); name != "mypool" {
+		t.Errorf("client name mismatch: have %q, want %q", name, "mypool")
+	}
+	long := strings.Repeat("x", int(params.MaximumExtraDataSize))
+
+	extra := makeExtraData(nil, long)
+	if uint64(len(extra)) > params.MaximumExtraDataSize {
+		t.Fatalf("extra data exceeds limit: have %d, want <= %d", len(extra), params.MaximumExtraDataSize)
+	}
+	if name := decode(extra); name == "" || !strings.HasPrefix(long, name) {
+		t.Errorf("client name not truncated: have %q", name)
+	}
+	// Ensure multi-byte client names are truncated on rune boundaries
+	long = strings.Repeat("ж", int(params.MaximumExtraDataSize))
+	for i := 0; i < 2; i++ {
+		name := decode(makeExtraData(nil, strings.Repeat("x", i)+long))
+		if !utf8.ValidString(name) {
+			t.Errorf("offset %d: client name truncated mid-rune: have %q", i, name)
+		}
+	}
+}
+
+// testWalletBackend is an account backend whose wallets can be dropped and
+// restored on demand.
+type testWalletBackend struct {
+	wallets []accounts.Wallet
+	feed    event.Feed
+}
+
+func (b *testWalletBackend) Wallets() []accounts.Wallet {
+	return b.wallets
+}
+
+func (b *testWalletBackend) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	return b.feed.Subscribe(sink)
+}
+
+// newCliqueTester creates an Atlantis service on top of a clique chain authorizing
+// the given number of freshly created and unlocked signers, the first of which is
+// set as the atherbase. The wallets holding the signers, in the same order, can be
+// dropped and restored via the returned account backend.
+func newCliqueTester(t *testing.T, signers int) (*Atlantis, *testWalletBackend, []accounts.Account, func()) {
+	dir, err := ioutil.TempDir("", "ath-wallet-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+
+	accs := make([]accounts.Account, signers)
+	for i := range accs {
+		if accs[i], err = ks.NewAccount(""); err != nil {
+			t.Fatalf("failed to create signer: %v", err)
+		}
+		if err := ks.Unlock(accs[i], ""); err != nil {
+			t.Fatalf("failed to unlock signer: %v", err)
+		}
+	}
+	backend := &testWalletBackend{wallets: make([]accounts.Wallet, len(accs))}
+	for _, wallet := range ks.Wallets() {
+		for i, acc := range accs {
+			if wallet.Contains(acc) {
+				backend.wallets[i] = wallet
+			}
+		}
+	}
+	am := accounts.NewManager(backend)
+
+	// Assemble a clique chain authorizing the signers and a node mining on it
+	var (
+		db     = athdb.NewMemDatabase()
+		config = params.AllCliqueProtocolChanges
+		engine = clique.New(config.Clique, db)
+	)
+	genesis := &core.Genesis{
+		Config:    config,
+		ExtraData: make([]byte, 32+len(accs)*common.AddressLength+65),
+	}
+	for i, acc := range accs {
+		copy(genesis.ExtraData[32+i*common.AddressLength:], acc.Address[:])
+	}
+	genesis.MustCommit(db)
+
+	chain, err := core.NewBlockChain(db, nil, config, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	pool := core.NewTxPool(core.DefaultTxPoolConfig, config, chain)
+
+	ath := &Atlantis{
+		config:         &Config{},
+		chainDb:        db,
+		engine:         engine,
+		blockchain:     chain,
+		txPool:         pool,
+		accountManager: am,
+		eventMux:       new(event.TypeMux),
+		shutdownChan:   make(chan bool),
+		atherbase:      accs[0].Address,
+	}
+	ath.miner = miner.New(ath, config, ath.eventMux, engine, params.GenesisGasLimit, params.GenesisGasLimit)
+
+	return ath, backend, accs, func() {
+		ath.miner.Stop()
+		close(ath.shutdownChan)
+		pool.Stop()
+		chain.Stop()
+		am.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+// Tests that clique sealing is halted when the wallet holding the atherbase key
+// is dropped, and resumed once the wallet reappears.
+func TestAtlantisbaseWalletDropped(t *testing.T) {
+	ath, backend, accs, done := newCliqueTester(t, 1)
+	defer done()
+
+	signer := accs[0]
+
+	if err := ath.StartMining(false); err != nil {
+		t.Fatalf("failed to start mining: %v", err)
+	}
+	halts := ath.eventMux.Subscribe(MiningHaltedEvent{})
+	defer halts.Unsubscribe()
+
+	events := make(chan accounts.WalletEvent, 16)
+	go ath.walletLoop(events, ath.accountManager.Subscribe(events))
+
+	// Drop the signer's wallet and ensure mining is halted
+	backend.feed.Send(accounts.WalletEvent{Wallet: backend.wallets[0], Kind: accounts.WalletDropped})
+
+	select {
+	case ev := <-halts.Chan():
+		halt := ev.Data.(MiningHaltedEvent)
+		if halt.Address != signer.Address || halt.Err != errAtlantisbaseWalletDropped {
+			t.Fatalf("halt event mismatch: have %x/%v, want %x/%v", halt.Address, halt.Err, signer.Address, errAtlantisbaseWalletDropped)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("mining halt not reported")
+	}
+	if ath.IsMining() {
+		t.Fatalf("mining not stopped after dropping the signer")
+	}
+	// Restore the signer's wallet and ensure mining resumes
+	backend.feed.Send(accounts.WalletEvent{Wallet: backend.wallets[0], Kind: accounts.WalletArrived})
+
+	for start := time.Now(); !ath.IsMining(); time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > time.Second {
+			t.Fatalf("mining not resumed after restoring the signer")
+		}
+	}
+}
+
+// Tests that automatically selecting the atherbase from the local accounts is
+// announced on the event mux, and that an error is returned without accounts.
+func TestAtlantisbaseAutoselected(t *testing.T) {
+	ath, backend, accs, done := newCliqueTester(t, 1)
+	defer done()
+
+	ath.atherbase = common.Address{}
+
+	sub := ath.eventMux.Subscribe(AtlantisbaseAutoselectedEvent{})
+	defer sub.Unsubscribe()
+
+	eb, err := ath.Atlantisbase()
+	if err != nil {
+		t.Fatalf("failed to select atherbase: %v", err)
+	}
+	if eb != accs[0].Address {
+		t.Fatalf("atherbase mismatch: have %x, want %x", eb, accs[0].Address)
+	}
+	select {
+	case ev := <-sub.Chan():
+		if selected := ev.Data.(AtlantisbaseAutoselectedEvent); selected.Address != eb {
+			t.Errorf("event address mismatch: have %x, want %x", selected.Address, eb)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("atherbase selection not announced")
+	}
+	// Drop the only wallet and ensure no atherbase can be selected
+	ath.atherbase = common.Address{}
+
+	wallets := make(chan accounts.WalletEvent)
+	walletSub := ath.accountManager.Subscribe(wallets)
+	defer walletSub.Unsubscribe()
+
+	backend.feed.Send(accounts.WalletEvent{Wallet: backend.wallets[0], Kind: accounts.WalletDropped})
+	<-wallets
+
+	if _, err := ath.Atlantisbase(); err == nil {
+		t.Fatalf("atherbase selected without accounts")
+	}
+}
+
+// Tests that the atherbase can be changed via the miner API, re-authorizing the
+// clique signer, while zero and locally unavailable addresses are rejected.
+func TestSetAtlantisbase(t *testing.T) {
+	ath, _, accs, done := newCliqueTester(t, 2)
+	defer done()
+
+	api := NewPrivateMinerAPI(ath)
+	if _, err := api.SetAtlantisbase(common.Address{}); err != errZeroAtlantisbase {
+		t.Errorf("zero address error mismatch: have %v, want %v", err, errZeroAtlantisbase)
+	}
+	if _, err := api.SetAtlantisbase(common.Address{0x01}); err == nil {
+		t.Errorf("unavailable signer accepted")
+	}
+	if eb, _ := ath.Atlantisbase(); eb != accs[0].Address {
+		t.Fatalf("atherbase changed by rejected calls: have %x, want %x", eb, accs[0].Address)
+	}
+	// Switch to the second signer and ensure blocks get sealed by it
+	eb, err := api.SetAtlantisbase(accs[1].Address)
+	if err != nil {
+		t.Fatalf("failed to set atherbase: %v", err)
+	}
+	if eb != accs[1].Address {
+		t.Errorf("returned atherbase mismatch: have %x, want %x", eb, accs[1].Address)
+	}
+	if eb, _ := ath.Atlantisbase(); eb != accs[1].Address {
+		t.Errorf("atherbase mismatch: have %x, want %x", eb, accs[1].Address)
+	}
+	genesis := ath.blockchain.Genesis()
+	header := &types.Header{
+		ParentHash: genesis.Hash(),
+		Number:     big.NewInt(1),
+		Time:       big.NewInt(time.Now().Unix()),
+		Difficulty: big.NewInt(2),
+		GasLimit:   genesis.GasLimit(),
+		Extra:      make([]byte, 32+65),
+	}
+	tx := types.NewTransaction(0, common.Address{}, new(big.Int), params.TxGas, new(big.Int), nil)
+
+	sealed, err := ath.engine.Seal(ath.blockchain, types.NewBlock(header, []*types.Transaction{tx}, nil, nil), make(chan struct{}))
+	if err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	if author, _ := ath.engine.Author(sealed.Header()); author != accs[1].Address {
+		t.Errorf("block signer mismatch: have %x, want %x", author, accs[1].Address)
+	}
+}
+
+// Tests that the locally configured empty block period is applied to the created
+// clique engine.
+func TestCreateConsensusEngineEmptyBlockPeriod(t *testing.T) {
+	chainConfig := &params.ChainConfig{Clique: &params.CliqueConfig{Period: 1, Epoch: 30000}}
+
+	engine, err := CreateConsensusEngine(nil, "", &athash.Config{}, chainConfig, 60, athdb.NewMemDatabase())
+	if err != nil {
+		t.Fatalf("failed to create consensus engine: %v", err)
+	}
+	sealer, ok := engine.(*clique.Clique)
+	if !ok {
+		t.Fatalf("engine type mismatch: have %T, want *clique.Clique", engine)
+	}
+	if period := sealer.EmptyBlockPeriod(); period != 60 {
+		t.Fatalf("empty block period mismatch: have %d, want %d", period, 60)
+	}
+}
+
+// Tests that custom consensus engines can be registered and selected by name, that
+// names can't be registered twice and that the built-in engines are used without
+// a name.
+func TestRegisterEngine(t *testing.T) {
+	custom := athash.NewFaker()
+	RegisterEngine("test-engine", func(ctx *node.ServiceContext, chainConfig *params.ChainConfig, db athdb.Database) (consensus.Engine, error) {
+		return custom, nil
+	})
+	defer func() {
+		enginesLock.Lock()
+		delete(engines, "test-engine")
+		enginesLock.Unlock()
+	}()
+
+	// Ensure the registered engine is created by name
+	engine, err := CreateConsensusEngine(nil, "test-engine", &athash.Config{}, params.TestChainConfig, 0, athdb.NewMemDatabase())
+	if err != nil {
+		t.Fatalf("failed to create registered engine: %v", err)
+	}
+	if engine != custom {
+		t.Errorf("registered engine mismatch: have %p, want %p", engine, custom)
+	}
+	// Ensure duplicate registrations are refused
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("duplicate registration accepted")
+			}
+		}()
+		RegisterEngine("test-engine", nil)
+	}()
+	// Ensure unknown names are rejected instead of falling back to a built-in engine
+	if _, err := CreateConsensusEngine(nil, "missing-engine", &athash.Config{}, params.TestChainConfig, 0, athdb.NewMemDatabase()); err == nil || !strings.Contains(err.Error(), errUnknownEngine.Error()) {
+		t.Errorf("unknown engine error mismatch: have %v, want %v", err, errUnknownEngine)
+	}
+	// Ensure the built-in engines are used without a name
+	engine, err = CreateConsensusEngine(nil, "", &athash.Config{PowMode: athash.ModeFake}, params.TestChainConfig, 0, athdb.NewMemDatabase())
+	if err != nil {
+		t.Fatalf("failed to create built-in engine: %v", err)
+	}
+	if _, ok := engine.(*athash.Ethash); !ok || engine == custom {
+		t.Errorf("built-in engine mismatch: have %T, want fresh *athash.Ethash", engine)
+	}
+}
+
+// Tests that chain reorganisations are posted on the event mux.
+func TestReorgEventMux(t *testing.T) {
+	var (
+		db      = athdb.NewMemDatabase()
+		engine  = athash.NewFaker()
+		genesis = new(core.Genesis).MustCommit(db)
+	)
+	chain, err := core.NewBlockChain(db, nil, params.TestChainConfig, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	ath := &Atlantis{blockchain: chain, eventMux: new(event.TypeMux), shutdownChan: make(chan bool)}
+	defer close(ath.shutdownChan)
+
+	sub := ath.eventMux.Subscribe(core.ChainReorgEvent{})
+	defer sub.Unsubscribe()
+
+	events := make(chan core.ChainReorgEvent, 16)
+	go ath.reorgLoop(events, chain.SubscribeChainReorgEvent(events))
+
+	// Import a chain and replace it by a longer fork
+	canon, _ := core.GenerateChain(params.TestChainConfig, genesis, engine, db, 2, func(i int, b *core.BlockGen) { b.SetCoinbase(common.Address{0x01}) })
+	fork, _ := core.GenerateChain(params.TestChainConfig, genesis, engine, db, 3, func(i int, b *core.BlockGen) { b.SetCoinbase(common.Address{0x02}) })
+	if _, err := chain.InsertChain(canon); err != nil {
+		t.Fatalf("failed to insert canonical chain: %v", err)
+	}
+	if _, err := chain.InsertChain(fork); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	select {
+	case ev := <-sub.Chan():
+		reorg := ev.Data.(core.ChainReorgEvent)
+		if reorg.CommonAncestor.Hash() != genesis.Hash() {
+			t.Errorf("common ancestor mismatch: have %x, want %x", reorg.CommonAncestor.Hash(), genesis.Hash())
+		}
+		if len(reorg.OldChain) != 2 {
+			t.Errorf("reorg depth mismatch: have %d, want %d", len(reorg.OldChain), 2)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("reorg not posted on the event mux")
+	}
+}
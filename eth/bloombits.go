@@ -45,6 +45,10 @@ const (
 	// bloomRetrievalWait is the maximum time to wait for enough bloom bit requests
 	// to accumulate request an entire batch (avoiding hysteresis).
 	bloomRetrievalWait = time.Duration(0)
+
+	// logsQueueTimeout is the maximum time a log filtering session waits for a
+	// free slot when MaxLogsConcurrency is reached before being rejected.
+	logsQueueTimeout = 5 * time.Second
 )
 
 // startBloomHandlers starts a batch of goroutines to accept bloom bit database
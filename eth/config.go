@@ -30,9 +30,14 @@ import (
 	"github.com/athereum/go-athereum/core"
 	"github.com/athereum/go-athereum/ath/downloader"
 	"github.com/athereum/go-athereum/ath/gasprice"
+	"github.com/athereum/go-athereum/p2p/discover"
 	"github.com/athereum/go-athereum/params"
 )
 
+// defaultRPCEVMTimeout is the execution time limit of an ath_call if none is
+// configured.
+const defaultRPCEVMTimeout = 5 * time.Second
+
 // DefaultConfig contains default settings for use on the Atlantis main net.
 var DefaultConfig = Config{
 	SyncMode: downloader.FastSync,
@@ -49,11 +54,15 @@ var DefaultConfig = Config{
 	TrieCache:     256,
 	TrieTimeout:   60 * time.Minute,
 	GasPrice:      big.NewInt(18 * params.Shannon),
+	RPCEVMTimeout: defaultRPCEVMTimeout,
+
+	FinalityConfirmations: 64,
 
 	TxPool: core.DefaultTxPoolConfig,
 	GPO: gasprice.Config{
 		Blocks:     20,
 		Percentile: 60,
+		MaxPrice:   gasprice.DefaultMaxPrice,
 	},
 }
 
@@ -84,16 +93,40 @@ type Config struct {
 	SyncMode  downloader.SyncMode
 	NoPruning bool
 
+	// Number of consecutive fast sync attempts stalling without progress (e.g. no
+	// peers serving the pivot) after which the node gives up and falls back to full
+	// sync. Zero keeps retrying fast sync indefinitely.
+	FastSyncRetries int `toml:",omitempty"`
+
 	// Light client options
 	LightServ  int `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
 	LightPeers int `toml:",omitempty"` // Maximum number of LES client peers
 
 	// Database options
 	SkipBcVersionCheck bool `toml:"-"`
+	DatabaseReadOnly   bool `toml:",omitempty"` // Open the chain database read-only (RPC inspection only, no syncing or mining)
 	DatabaseHandles    int  `toml:"-"`
 	DatabaseCache      int
 	TrieCache          int
 	TrieTimeout        time.Duration
+	TriesInMemory      uint64 `toml:",omitempty"` // Number of recent block states kept in memory (zero selects the default)
+	TrieGCInterval     uint64 `toml:",omitempty"` // Blocks between garbage collections of stale tries (zero selects the default)
+
+	// Chain cache options, measured in number of entries (zero selects the default).
+	// These caches are allocated on top of DatabaseCache and TrieCache, which are
+	// memory allowances in MB, and are not deducted from either of them.
+	HeaderCache  int `toml:",omitempty"` // Number of recent headers to cache
+	BodyCache    int `toml:",omitempty"` // Number of recent block bodies to cache
+	ReceiptCache int `toml:",omitempty"` // Number of recent block receipt sets to cache (useful for ath_getLogs)
+	TdCache      int `toml:",omitempty"` // Number of total difficulties cached by the RPC API (useful for block explorers)
+
+	// Maximum number of canonical blocks a reorg may drop. Deeper reorgs are refused
+	// and the node stays on its current chain. Zero means no limit.
+	MaxReorgDepth uint64 `toml:",omitempty"`
+
+	// Speculatively load the accounts touched by upcoming blocks into the state
+	// caches during import. Experimental, disabled by default.
+	StatePrefetch bool `toml:",omitempty"`
 
 	// Mining-related options
 	Atlantisbase    common.Address `toml:",omitempty"`
@@ -101,18 +134,99 @@ type Config struct {
 	ExtraData    []byte         `toml:",omitempty"`
 	GasPrice     *big.Int
 
+	// Client name replacing "gath" in the default block extra data. It is truncated
+	// if the extra data would exceed the protocol's size limit.
+	ExtraDataClientName string `toml:",omitempty"`
+
+	// Target range of the mined blocks' gas limit. The miner moves the limit into
+	// the range as fast as the protocol permits and keeps it there. A zero ceiling
+	// means no upper bound, and if both are zero the default strategy is used.
+	MinerGasFloor uint64 `toml:",omitempty"`
+	MinerGasCeil  uint64 `toml:",omitempty"`
+
+	// Minimum number of seconds between a block and an empty child sealed by a
+	// local clique signer, holding back empty blocks on idle chains. Blocks with
+	// transactions are still sealed after the chain's period. Zero disables it.
+	MinerEmptyBlockPeriod uint64 `toml:",omitempty"`
+
+	// Name of a custom consensus engine registered via RegisterEngine. If empty,
+	// clique or ethash is selected based on the chain configuration.
+	Engine string `toml:",omitempty"`
+
 	// Ethash options
 	Ethash athash.Config
 
 	// Transaction pool options
 	TxPool core.TxPoolConfig
 
+	// MinAcceptedGasPrice is a hard gas price floor below which transactions are
+	// rejected, even if submitted locally. If TxPool.PriceLimit is also set, the
+	// higher of the two is enforced for remote transactions. Must fit into 64 bits.
+	MinAcceptedGasPrice *big.Int `toml:",omitempty"`
+
+	// Number of peers each new transaction is pushed to. Zero keeps the default of
+	// pushing to all peers not yet knowing about it.
+	TxBroadcastPeers int `toml:",omitempty"`
+
+	// Per peer request rate limiting, applied separately to each request message
+	// type (headers, bodies, state and receipts). Requests beyond the limit are
+	// dropped unanswered. Zero RequestRateLimit disables limiting, and a zero
+	// RequestDropLimit never disconnects peers for exceeding it.
+	RequestRateLimit  float64 `toml:",omitempty"` // Requests per second allowed per peer and type
+	RequestBurstLimit int     `toml:",omitempty"` // Requests allowed in a burst per peer and type
+	RequestDropLimit  int     `toml:",omitempty"` // Dropped requests after which a peer is disconnected
+
+	// Time after which peers not sending any ath protocol message are disconnected
+	// to free up their slot. Zero keeps silent peers connected.
+	PeerIdleTimeout time.Duration `toml:",omitempty"`
+
+	// Peer count below which the node asks the p2p server to dial more aggressively
+	// from the discovery table, to refill its peer set after e.g. a partition. Zero
+	// disables the monitoring.
+	MinPeers int `toml:",omitempty"`
+
+	// Application level peer filtering. If TrustedPeerOnly is set, only peers in
+	// AllowedPeers or trusted at the p2p layer may complete the ath handshake.
+	// Peers in DeniedPeers are always rejected.
+	TrustedPeerOnly bool              `toml:",omitempty"`
+	AllowedPeers    []discover.NodeID `toml:",omitempty"`
+	DeniedPeers     []discover.NodeID `toml:",omitempty"`
+
 	// Gas Price Oracle options
 	GPO gasprice.Config
 
+	// Number of goroutines used per filter to multiplex bloom bit retrievals,
+	// zero defaults to bloomFilterThreads
+	BloomServiceThreads int `toml:",omitempty"`
+
+	// Maximum number of log filtering sessions serviced concurrently, excess ones
+	// are queued for a while and then rejected as busy. Zero means unlimited.
+	MaxLogsConcurrency int `toml:",omitempty"`
+
+	// Maximum number of logs returned by a single log query, queries matching more
+	// are aborted with an error. Zero means unlimited.
+	MaxLogsReturned int `toml:",omitempty"`
+
+	// Maximum execution time of an ath_call, after which the EVM is aborted and a
+	// timeout error returned. Zero selects the default of 5 seconds, a negative
+	// value leaves calls bounded by the request context only.
+	RPCEVMTimeout time.Duration `toml:",omitempty"`
+
+	// Leaves the personal namespace out of the registered APIs, so that account
+	// unlocking and signing are unreachable over any RPC transport, IPC included.
+	DisablePersonalAPI bool `toml:",omitempty"`
+
+	// Number of confirmations after which a block is reported as safe and finalized
+	// to RPC callers, as the chain has no explicit finality of its own.
+	FinalityConfirmations uint64 `toml:",omitempty"`
+
 	// Enables tracking of SHA3 preimages in the VM
 	EnablePreimageRecording bool
 
+	// Maximum time to wait for each subsystem to stop on shutdown before moving on
+	// and closing the database anyway. Zero waits indefinitely.
+	ShutdownTimeout time.Duration `toml:",omitempty"`
+
 	// Miscellaneous options
 	DocRoot string `toml:"-"`
 }
@@ -120,3 +234,16 @@ type Config struct {
 type configMarshaling struct {
 	ExtraData hexutil.Bytes
 }
+
+// EVMTimeout returns the execution time limit of an ath_call, substituting the
+// default for an unset one. Zero is returned if calls are configured unlimited.
+func (c *Config) EVMTimeout() time.Duration {
+	switch {
+	case c.RPCEVMTimeout < 0:
+		return 0
+	case c.RPCEVMTimeout == 0:
+		return defaultRPCEVMTimeout
+	default:
+		return c.RPCEVMTimeout
+	}
+}
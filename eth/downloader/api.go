@@ -19,8 +19,10 @@ package downloader
 import (
 	"context"
 	"sync"
+	"time"
 
 	athereum "github.com/athereum/go-athereum"
+	"github.com/athereum/go-athereum/common/hexutil"
 	"github.com/athereum/go-athereum/event"
 	"github.com/athereum/go-athereum/rpc"
 )
@@ -119,6 +121,29 @@ func (api *PublicDownloaderAPI) Syncing(ctx context.Context) (*rpc.Subscription,
 	return rpcSub, nil
 }
 
+// SyncETA returns the estimated number of seconds remaining until the current
+// synchronisation completes. Zero is returned if no sync is running or if the
+// import rate is not known yet.
+func (api *PublicDownloaderAPI) SyncETA() hexutil.Uint64 {
+	return hexutil.Uint64(api.d.ETA() / time.Second)
+}
+
+// SyncModeResult describes the synchronisation mode the downloader is operating in.
+type SyncModeResult struct {
+	Mode        SyncMode `json:"mode"`
+	PivotPassed bool     `json:"pivotPassed"`
+}
+
+// SyncMode returns the synchronisation mode the downloader is currently operating
+// in, along with whether the fast sync pivot block was passed. A fast sync reports
+// full mode once past its pivot, as the remaining blocks are imported in full.
+func (api *PublicDownloaderAPI) SyncMode() *SyncModeResult {
+	return &SyncModeResult{
+		Mode:        api.d.Mode(),
+		PivotPassed: api.d.PivotPassed(),
+	}
+}
+
 // SyncingResult provides information about the current synchronisation status for this node.
 type SyncingResult struct {
 	Syncing bool                  `json:"syncing"`
@@ -92,6 +92,17 @@ var (
 	errTooOld                  = errors.New("peer doesn't speak recent enough protocol version (need version >= 62)")
 )
 
+// IsStalled reports whether a synchronisation failure was caused by the lack of
+// progress (no usable peers, or peers not delivering the pivot and the chain up
+// to it), as opposed to a cancellation or an invalid chain.
+func IsStalled(err error) bool {
+	switch err {
+	case errNoPeers, errPeersUnavailable, errTimeout, errStallingPeer, errEmptyHeaderSet:
+		return true
+	}
+	return false
+}
+
 type Downloader struct {
 	mode SyncMode       // Synchronisation mode defining the strategy used (per sync cycle)
 	mux  *event.TypeMux // Event multiplexer to announce sync operation events
@@ -108,6 +119,7 @@ type Downloader struct {
 	syncStatsChainHeight uint64 // Highest block number known when syncing started
 	syncStatsState       stateSyncStats
 	syncStatsLock        sync.RWMutex // Lock protecting the sync stats fields
+	syncRate             *rateTracker // Import rate tracker for estimating the remaining sync time
 
 	lightchain LightChain
 	blockchain BlockChain
@@ -227,6 +239,10 @@ func New(mode SyncMode, stateDb athdb.Database, mux *event.TypeMux, chain BlockC
 			processed: rawdb.ReadFastTrieProgress(stateDb),
 		},
 		trackStateReq: make(chan *stateReq),
+		syncRate:      newRateTracker(syncRateWindow),
+	}
+	if mode != FastSync {
+		dl.committed = 1
 	}
 	go dl.qosTuner()
 	go dl.stateFetcher()
@@ -245,7 +261,10 @@ func (d *Downloader) Progress()atlantis.SyncProgress {
 	d.syncStatsLock.RLock()
 	defer d.syncStatsLock.RUnlock()
 
-	current := uint64(0)
+	var (
+		current = uint64(0)
+		pulled  = d.syncStatsState.processed + d.syncStatsState.uncommitted
+	)
 	switch d.mode {
 	case FullSync:
 		current = d.blockchain.CurrentBlock().NumberU64()
@@ -258,9 +277,30 @@ func (d *Downloader) Progress()atlantis.SyncProgress {
 		StartingBlock: d.syncStatsChainOrigin,
 		CurrentBlock:  current,
 		HighestBlock:  d.syncStatsChainHeight,
-		PulledStates:  d.syncStatsState.processed,
-		KnownStates:   d.syncStatsState.processed + d.syncStatsState.pending,
+		PulledStates:  pulled,
+		KnownStates:   pulled + d.syncStatsState.pending,
+	}
+}
+
+// Mode retrieves the synchronisation mode the downloader is live operating in,
+// being that of the running sync cycle or the last one if idle. A fast sync is
+// reported as full sync once its pivot block was committed.
+func (d *Downloader) Mode() SyncMode {
+	d.syncStatsLock.RLock()
+	mode := d.mode
+	d.syncStatsLock.RUnlock()
+
+	if mode == FastSync && d.PivotPassed() {
+		return FullSync
 	}
+	return mode
+}
+
+// PivotPassed returns whether the pivot block of the current or last fast sync
+// cycle was committed, after which blocks are imported in full. It is always
+// true outside of fast sync, as there is no pivot to pass.
+func (d *Downloader) PivotPassed() bool {
+	return atomic.LoadInt32(&d.committed) == 1
 }
 
 // Synchronising returns whather the downloader is currently retrieving blocks.
@@ -357,6 +397,7 @@ func (d *Downloader) synchronise(id string, hash common.Hash, td *big.Int, mode
 	// Reset the queue, peer set and wake channels to clean any internal leftover state
 	d.queue.Reset()
 	d.peers.Reset()
+	d.syncRate.reset()
 
 	for _, ch := range []chan bool{d.bodyWakeCh, d.receiptWakeCh} {
 		select {
@@ -389,7 +430,9 @@ func (d *Downloader) synchronise(id string, hash common.Hash, td *big.Int, mode
 	defer d.Cancel() // No matter what, we can't leave the cancel channel open
 
 	// Set the requested sync mode, unless it's forbidden
+	d.syncStatsLock.Lock()
 	d.mode = mode
+	d.syncStatsLock.Unlock()
 
 	// Retrieve the origin peer and initiate the downloading process
 	p := d.peers.Peer(id)
@@ -450,9 +493,9 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 			}
 		}
 	}
-	d.committed = 1
+	atomic.StoreInt32(&d.committed, 1)
 	if d.mode == FastSync && pivot != 0 {
-		d.committed = 0
+		atomic.StoreInt32(&d.committed, 0)
 	}
 	// Initiate the sync using a concurrent header and content retrieval algorithm
 	d.queue.Prepare(origin+1, d.mode)
@@ -1277,6 +1320,9 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 						log.Debug("Invalid header encountered", "number", chunk[n].Number, "hash", chunk[n].Hash(), "err", err)
 						return errInvalidChain
 					}
+					if d.mode == LightSync {
+						d.syncRate.update(time.Now(), chunk[len(chunk)-1].Number.Uint64())
+					}
 					// All verifications passed, store newly found uncertain headers
 					rollback = append(rollback, unknown...)
 					if len(rollback) > fsHeaderSafetyNet {
@@ -1362,6 +1408,7 @@ func (d *Downloader) importBlockResults(results []*fetchResult) error {
 		log.Debug("Downloaded item processing failed", "number", results[index].Header.Number, "hash", results[index].Header.Hash(), "err", err)
 		return errInvalidChain
 	}
+	d.syncRate.update(time.Now(), last.Number.Uint64())
 	return nil
 }
 
@@ -1505,6 +1552,7 @@ func (d *Downloader) commitFastSyncData(results []*fetchResult, stateSync *state
 		log.Debug("Downloaded item processing failed", "number", results[index].Header.Number, "hash", results[index].Header.Hash(), "err", err)
 		return errInvalidChain
 	}
+	d.syncRate.update(time.Now(), last.Number.Uint64())
 	return nil
 }
 
@@ -685,6 +685,39 @@ func testCanonicalSynchronisation(t *testing.T, protocol int, mode SyncMode) {
 	assertOwnChain(t, tester, targetBlocks+1)
 }
 
+// Tests that the downloader reports the live sync mode, flipping fast sync to full
+// once the pivot block was committed.
+func TestSyncModeReporting(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		mode SyncMode
+		want SyncMode
+	}{
+		{FullSync, FullSync},
+		{FastSync, FullSync},
+		{LightSync, LightSync},
+	}
+	for i, tt := range tests {
+		tester := newTester()
+
+		targetBlocks := blockCacheItems - 15
+		hashes, headers, blocks, receipts := tester.makeChain(targetBlocks, 0, tester.genesis, nil, false)
+		tester.newPeer("peer", 64, hashes, headers, blocks, receipts)
+
+		if err := tester.sync("peer", nil, tt.mode); err != nil {
+			t.Fatalf("test %d: failed to synchronise blocks: %v", i, err)
+		}
+		if mode := tester.downloader.Mode(); mode != tt.want {
+			t.Errorf("test %d: sync mode mismatch: have %v, want %v", i, mode, tt.want)
+		}
+		if !tester.downloader.PivotPassed() {
+			t.Errorf("test %d: pivot not passed after sync", i)
+		}
+		tester.terminate()
+	}
+}
+
 // Tests that if a large batch of blocks are being downloaded, it is throttled
 // until the cached blocks are retrieved.
 func TestThrottling62(t *testing.T)     { testThrottling(t, 62, FullSync) }
@@ -1353,6 +1386,22 @@ func testBlockHeaderAttackerDropping(t *testing.T, protocol int) {
 	}
 }
 
+// Tests that the state sync progress accounts for downloaded entries which have
+// not yet been committed to disk.
+func TestStateSyncProgress(t *testing.T) {
+	tester := newTester()
+	defer tester.terminate()
+
+	tester.downloader.syncStatsState = stateSyncStats{processed: 10, uncommitted: 5, pending: 20}
+	progress := tester.downloader.Progress()
+	if progress.PulledStates != 15 {
+		t.Errorf("pulled states mismatch: have %d, want %d", progress.PulledStates, 15)
+	}
+	if progress.KnownStates != 35 {
+		t.Errorf("known states mismatch: have %d, want %d", progress.KnownStates, 35)
+	}
+}
+
 // Tests that synchronisation progress (origin block number, current block number
 // and highest block number) is tracked and updated correctly.
 func TestSyncProgress62(t *testing.T)      { testSyncProgress(t, 62, FullSync) }
@@ -1753,3 +1802,28 @@ func testDeliverHeadersHang(t *testing.T, protocol int, mode SyncMode) {
 		tester.downloader.peers.peers["peer"].peer.(*floodingTestPeer).pend.Wait()
 	}
 }
+
+// Tests that only failures caused by the lack of sync progress are reported as
+// stalls, cancellations and bad chains are not.
+func TestStalledSyncErrors(t *testing.T) {
+	tests := []struct {
+		err     error
+		stalled bool
+	}{
+		{nil, false},
+		{errBusy, false},
+		{errNoPeers, true},
+		{errPeersUnavailable, true},
+		{errTimeout, true},
+		{errStallingPeer, true},
+		{errEmptyHeaderSet, true},
+		{errInvalidChain, false},
+		{errCancelStateFetch, false},
+		{errNoSyncActive, false},
+	}
+	for i, tt := range tests {
+		if stalled := IsStalled(tt.err); stalled != tt.stalled {
+			t.Errorf("test %d (%v): stall mismatch: have %v, want %v", i, tt.err, stalled, tt.stalled)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2018 The go-athereum Authors
+// This file is part of the go-athereum library.
+//
+// The go-athereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-athereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-athereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"sync"
+	"time"
+)
+
+// syncRateWindow is the time span over which the import rate is averaged when
+// estimating the remaining synchronisation time.
+const syncRateWindow = time.Minute
+
+// rateSample is a single chain progress measurement.
+type rateSample struct {
+	time  time.Time // Time the measurement was taken
+	block uint64    // Head block number at the time of the measurement
+}
+
+// rateTracker maintains a rolling window of chain progress measurements to
+// compute the average import rate in blocks per second. Unlike the metrics
+// meters, it is always active, regardless of whather metrics are enabled.
+type rateTracker struct {
+	window  time.Duration
+	samples []rateSample
+	lock    sync.Mutex
+}
+
+// newRateTracker creates a rate tracker averaging over the given window.
+func newRateTracker(window time.Duration) *rateTracker {
+	return &rateTracker{window: window}
+}
+
+// update records a new head block measurement and drops all the samples that
+// fell out of the averaging window.
+func (r *rateTracker) update(now time.Time, block uint64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.samples = append(r.samples, rateSample{time: now, block: block})
+
+	// Keep the newest sample beyond the window as the rate anchor
+	cutoff := now.Add(-r.window)
+	drop := 0
+	for drop < len(r.samples)-2 && !r.samples[drop+1].time.After(cutoff) {
+		drop++
+	}
+	r.samples = append(r.samples[:0], r.samples[drop:]...)
+}
+
+// rate returns the average import rate in blocks per second, or zero if there
+// are not enough measurements to tell.
+func (r *rateTracker) rate() float64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if len(r.samples) < 2 {
+		return 0
+	}
+	first, last := r.samples[0], r.samples[len(r.samples)-1]
+
+	elapsed := last.time.Sub(first.time).Seconds()
+	if elapsed <= 0 || last.block <= first.block {
+		return 0
+	}
+	return float64(last.block-first.block) / elapsed
+}
+
+// reset drops all the gathered measurements.
+func (r *rateTracker) reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.samples = r.samples[:0]
+}
+
+// ETA estimates the time remaining until the current synchronisation completes,
+// based on the average import rate over the last minute. Zero is returned if no
+// sync is running or if the import rate is not known yet.
+func (d *Downloader) ETA() time.Duration {
+	if !d.Synchronising() {
+		return 0
+	}
+	progress := d.Progress()
+	if progress.CurrentBlock >= progress.HighestBlock {
+		return 0
+	}
+	rate := d.syncRate.rate()
+	if rate == 0 {
+		return 0
+	}
+	remaining := float64(progress.HighestBlock - progress.CurrentBlock)
+	return time.Duration(remaining / rate * float64(time.Second))
+}
@@ -0,0 +1,54 @@
+// Copyright 2018 The go-athereum Authors
+// This file is part of the go-athereum library.
+//
+// The go-athereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-athereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-athereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"testing"
+	"time"
+)
+
+// Tests that the rate tracker averages over its window and forgets old samples.
+func TestRateTracker(t *testing.T) {
+	r := newRateTracker(10 * time.Second)
+	start := time.Now()
+
+	if rate := r.rate(); rate != 0 {
+		t.Fatalf("empty tracker rate mismatch: have %v, want 0", rate)
+	}
+	r.update(start, 100)
+	if rate := r.rate(); rate != 0 {
+		t.Fatalf("single sample rate mismatch: have %v, want 0", rate)
+	}
+	r.update(start.Add(5*time.Second), 150)
+	if rate := r.rate(); rate != 10 {
+		t.Fatalf("rate mismatch: have %v, want 10", rate)
+	}
+	// Push the first sample out of the window, the newest stale one stays as anchor
+	r.update(start.Add(20*time.Second), 250)
+	r.update(start.Add(25*time.Second), 300)
+	if rate := r.rate(); rate != 7.5 {
+		t.Fatalf("rolled rate mismatch: have %v, want 7.5", rate)
+	}
+	r.update(start.Add(35*time.Second), 300)
+	if rate := r.rate(); rate != 0 {
+		t.Fatalf("stalled rate mismatch: have %v, want 0", rate)
+	}
+	r.reset()
+	if rate := r.rate(); rate != 0 {
+		t.Fatalf("reset rate mismatch: have %v, want 0", rate)
+	}
+}
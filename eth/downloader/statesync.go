@@ -55,6 +55,8 @@ type stateSyncStats struct {
 	duplicate  uint64 // Number of state entries downloaded twice
 	unexpected uint64 // Number of non-requested state entries received
 	pending    uint64 // Number of still pending state entries
+
+	uncommitted uint64 // Number of state entries downloaded but not yet written to disk
 }
 
 // syncState starts downloading state with the given root hash.
@@ -287,6 +289,7 @@ func (s *stateSync) loop() (err error) {
 	}()
 
 	// Keep assigning new tasks until the sync completes or aborts
+	s.updateProgress()
 	for s.sched.Pending() > 0 {
 		if err = s.commit(false); err != nil {
 			return err
@@ -317,6 +320,7 @@ func (s *stateSync) loop() (err error) {
 				log.Warn("Node data write error", "err", err)
 				return err
 			}
+			s.updateProgress()
 			req.peer.SetNodeDataIdle(len(req.response))
 		}
 	}
@@ -469,6 +473,9 @@ func (s *stateSync) updateStats(written, duplicate, unexpected int, duration tim
 
 	s.d.syncStatsState.pending = uint64(s.sched.Pending())
 	s.d.syncStatsState.processed += uint64(written)
+	if written > 0 {
+		s.d.syncStatsState.uncommitted = 0
+	}
 	s.d.syncStatsState.duplicate += uint64(duplicate)
 	s.d.syncStatsState.unexpected += uint64(unexpected)
 
@@ -479,3 +486,13 @@ func (s *stateSync) updateStats(written, duplicate, unexpected int, duration tim
 		rawdb.WriteFastTrieProgress(s.d.stateDB, s.d.syncStatsState.processed)
 	}
 }
+
+// updateProgress refreshes the state sync progress counters after every delivery,
+// so that progress is reported incrementally instead of only on database commits.
+func (s *stateSync) updateProgress() {
+	s.d.syncStatsLock.Lock()
+	defer s.d.syncStatsLock.Unlock()
+
+	s.d.syncStatsState.pending = uint64(s.sched.Pending())
+	s.d.syncStatsState.uncommitted = uint64(s.numUncommitted)
+}
@@ -74,6 +74,7 @@ type announce struct {
 	number uint64        // Number of the block being announced (0 = unknown | old protocol)
 	header *types.Header // Header of the block partially reassembled (new protocol)
 	time   time.Time     // Timestamp of the announcement
+	first  time.Time     // Timestamp of the block's first announcement by any peer, set when fetching
 
 	origin string // Identifier of the peer originating the notification
 
@@ -99,8 +100,9 @@ type bodyFilterTask struct {
 
 // inject represents a schedules import operation.
 type inject struct {
-	origin string
-	block  *types.Block
+	origin    string
+	block     *types.Block
+	announced time.Time // Timestamp of the block's first announcement (zero if broadcast directly)
 }
 
 // Fetcher is responsible for accumulating block announcements from various peers
@@ -310,7 +312,7 @@ func (f *Fetcher) loop() {
 				f.forgetBlock(hash)
 				continue
 			}
-			f.insert(op.origin, op.block)
+			f.insert(op.origin, op.block, op.announced)
 		}
 		// Wait for an outside event to occur
 		select {
@@ -355,7 +357,7 @@ func (f *Fetcher) loop() {
 		case op := <-f.inject:
 			// A direct block insertion was requested, try and fill any pending gaps
 			propBroadcastInMeter.Mark(1)
-			f.enqueue(op.origin, op.block)
+			f.enqueue(op.origin, op.block, time.Time{})
 
 		case hash := <-f.done:
 			// A pending import finished, remove all traces of the notification
@@ -370,6 +372,7 @@ func (f *Fetcher) loop() {
 				if time.Since(announces[0].time) > arriveTimeout-gatherSlack {
 					// Pick a random peer to retrieve from, reset all others
 					announce := announces[rand.Intn(len(announces))]
+					announce.first = announces[0].time
 					f.forgetHash(hash)
 
 					// If the block still didn't arrive, queue for fetching
@@ -501,7 +504,7 @@ func (f *Fetcher) loop() {
 			// Schedule the header-only blocks for import
 			for _, block := range complete {
 				if announce := f.completing[block.Hash()]; announce != nil {
-					f.enqueue(announce.origin, block)
+					f.enqueue(announce.origin, block, announce.first)
 				}
 			}
 
@@ -557,7 +560,7 @@ func (f *Fetcher) loop() {
 			// Schedule the retrieved blocks for ordered import
 			for _, block := range blocks {
 				if announce := f.completing[block.Hash()]; announce != nil {
-					f.enqueue(announce.origin, block)
+					f.enqueue(announce.origin, block, announce.first)
 				}
 			}
 		}
@@ -598,7 +601,7 @@ func (f *Fetcher) rescheduleComplete(complete *time.Timer) {
 
 // enqueue schedules a new future import operation, if the block to be imported
 // has not yet been seen.
-func (f *Fetcher) enqueue(peer string, block *types.Block) {
+func (f *Fetcher) enqueue(peer string, block *types.Block, announced time.Time) {
 	hash := block.Hash()
 
 	// Ensure the peer isn't DOSing us
@@ -619,8 +622,9 @@ func (f *Fetcher) enqueue(peer string, block *types.Block) {
 	// Schedule the block for future importing
 	if _, ok := f.queued[hash]; !ok {
 		op := &inject{
-			origin: peer,
-			block:  block,
+			origin:    peer,
+			block:     block,
+			announced: announced,
 		}
 		f.queues[peer] = count
 		f.queued[hash] = op
@@ -635,7 +639,7 @@ func (f *Fetcher) enqueue(peer string, block *types.Block) {
 // insert spawns a new goroutine to run a block insertion into the chain. If the
 // block's number is at the same height as the current import phase, it updates
 // the phase states accordingly.
-func (f *Fetcher) insert(peer string, block *types.Block) {
+func (f *Fetcher) insert(peer string, block *types.Block, announced time.Time) {
 	hash := block.Hash()
 
 	// Run the import on a new thread
@@ -672,6 +676,9 @@ func (f *Fetcher) insert(peer string, block *types.Block) {
 		}
 		// If import succeeded, broadcast the block
 		propAnnounceOutTimer.UpdateSince(block.ReceivedAt)
+		if !announced.IsZero() {
+			propLatencyHistogram.Update(int64(time.Since(announced)))
+		}
 		go f.broadcastBlock(block, false)
 
 		// Invoke the testing hook if needed
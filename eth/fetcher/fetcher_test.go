@@ -30,6 +30,7 @@ import (
 	"github.com/athereum/go-athereum/core/types"
 	"github.com/athereum/go-athereum/crypto"
 	"github.com/athereum/go-athereum/athdb"
+	"github.com/athereum/go-athereum/metrics"
 	"github.com/athereum/go-athereum/params"
 )
 
@@ -788,3 +789,43 @@ func TestBlockMemoryExhaustionAttack(t *testing.T) {
 	}
 	verifyImportDone(t, imported)
 }
+
+// Tests that the propagation latency is measured from the first announcement of
+// a block up to its import, skipping blocks broadcast directly.
+func TestPropagationLatency(t *testing.T) {
+	// Swap in an enabled histogram to measure the latencies into
+	metrics.Enabled = true
+	histogram := metrics.NewHistogram(metrics.NewUniformSample(16))
+	metrics.Enabled = false
+
+	defer func(old metrics.Histogram) { propLatencyHistogram = old }(propLatencyHistogram)
+	propLatencyHistogram = histogram
+
+	hashes, blocks := makeChain(2, 0, genesis)
+
+	tester := newTester()
+	headerFetcher := tester.makeHeaderFetcher("valid", blocks, -gatherSlack)
+	bodyFetcher := tester.makeBodyFetcher("valid", blocks, 0)
+
+	imported := make(chan *types.Block)
+	tester.fetcher.importedHook = func(block *types.Block) { imported <- block }
+
+	// Announce the first block and ensure its latency is measured from the announcement
+	announced := time.Now().Add(-arriveTimeout)
+	tester.fetcher.Notify("valid", hashes[1], 1, announced, headerFetcher, bodyFetcher)
+	verifyImportEvent(t, imported, true)
+
+	if count := histogram.Count(); count != 1 {
+		t.Fatalf("latency sample count mismatch: have %d, want %d", count, 1)
+	}
+	if latency := time.Duration(histogram.Max()); latency < arriveTimeout {
+		t.Errorf("latency too short: have %v, want >= %v", latency, arriveTimeout)
+	}
+	// Propagate the second block directly and ensure no latency is measured
+	tester.fetcher.Enqueue("valid", blocks[hashes[0]])
+	verifyImportEvent(t, imported, true)
+
+	if count := histogram.Count(); count != 1 {
+		t.Errorf("latency sample count mismatch after broadcast: have %d, want %d", count, 1)
+	}
+}
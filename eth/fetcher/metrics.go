@@ -33,6 +33,8 @@ var (
 	propBroadcastDropMeter = metrics.NewRegisteredMeter("ath/fetcher/prop/broadcasts/drop", nil)
 	propBroadcastDOSMeter  = metrics.NewRegisteredMeter("ath/fetcher/prop/broadcasts/dos", nil)
 
+	propLatencyHistogram = metrics.NewRegisteredHistogram("ath/fetcher/prop/latency", nil, metrics.NewExpDecaySample(1028, 0.015))
+
 	headerFetchMeter = metrics.NewRegisteredMeter("ath/fetcher/fetch/headers", nil)
 	bodyFetchMeter   = metrics.NewRegisteredMeter("ath/fetcher/fetch/bodies", nil)
 
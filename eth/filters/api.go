@@ -28,9 +28,13 @@ import (
 	athereum "github.com/athereum/go-athereum"
 	"github.com/athereum/go-athereum/common"
 	"github.com/athereum/go-athereum/common/hexutil"
+	"github.com/athereum/go-athereum/core"
+	"github.com/athereum/go-athereum/core/rawdb"
 	"github.com/athereum/go-athereum/core/types"
 	"github.com/athereum/go-athereum/athdb"
 	"github.com/athereum/go-athereum/event"
+	"github.com/athereum/go-athereum/internal/athapi"
+	"github.com/athereum/go-athereum/log"
 	"github.com/athereum/go-athereum/rpc"
 )
 
@@ -38,6 +42,19 @@ var (
 	deadline = 5 * time.Minute // consider a filter inactive if it has not been polled for within deadline
 )
 
+// pendingTxsFullQueue is the maximum number of full pending transactions queued
+// up for a single subscriber, above which new transactions are dropped instead
+// of letting a slow subscriber accumulate an unbounded backlog.
+const pendingTxsFullQueue = 1024
+
+// defaultInclusionConfirmations is the number of blocks a transaction needs to
+// be buried under before an inclusion subscription ends, unless overridden.
+const defaultInclusionConfirmations = 12
+
+// maxInclusionScan is the maximum number of blocks scanned for a watched transaction
+// on a single chain head where the transaction index isn't maintained.
+const maxInclusionScan = 128
+
 // filter is a helper struct that holds meta information over the filter type
 // and associated subscription in the event system.
 type filter struct {
@@ -59,16 +76,24 @@ type PublicFilterAPI struct {
 	events    *EventSystem
 	filtersMu sync.Mutex
 	filters   map[rpc.ID]*filter
+	maxLogs   int // Maximum number of logs returned by a single query, 0 meaning unlimited
 }
 
 // NewPublicFilterAPI returns a new PublicFilterAPI instance.
 func NewPublicFilterAPI(backend Backend, lightMode bool) *PublicFilterAPI {
+	return NewPublicFilterAPIWithLogLimit(backend, lightMode, 0)
+}
+
+// NewPublicFilterAPIWithLogLimit returns a new PublicFilterAPI instance refusing
+// log queries that match more than maxLogs logs. Zero means unlimited.
+func NewPublicFilterAPIWithLogLimit(backend Backend, lightMode bool, maxLogs int) *PublicFilterAPI {
 	api := &PublicFilterAPI{
 		backend: backend,
 		mux:     backend.EventMux(),
 		chainDb: backend.ChainDb(),
 		events:  NewEventSystem(backend.EventMux(), backend, lightMode),
 		filters: make(map[rpc.ID]*filter),
+		maxLogs: maxLogs,
 	}
 	go api.timeoutLoop()
 
@@ -135,7 +160,8 @@ func (api *PublicFilterAPI) NewPendingTransactionFilter() rpc.ID {
 
 // NewPendingTransactions creates a subscription that is triggered each time a transaction
 // enters the transaction pool and was signed from one of the transactions this nodes manages.
-func (api *PublicFilterAPI) NewPendingTransactions(ctx context.Context) (*rpc.Subscription, error) {
+// If fullTx is set, the RPC encoded transactions are sent instead of only their hashes.
+func (api *PublicFilterAPI) NewPendingTransactions(ctx context.Context, fullTx *bool) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
@@ -143,6 +169,10 @@ func (api *PublicFilterAPI) NewPendingTransactions(ctx context.Context) (*rpc.Su
 
 	rpcSub := notifier.CreateSubscription()
 
+	if fullTx != nil && *fullTx {
+		go api.notifyPendingTransactionsFull(notifier, rpcSub)
+		return rpcSub, nil
+	}
 	go func() {
 		txHashes := make(chan []common.Hash, 128)
 		pendingTxSub := api.events.SubscribePendingTxs(txHashes)
@@ -168,6 +198,50 @@ func (api *PublicFilterAPI) NewPendingTransactions(ctx context.Context) (*rpc.Su
 	return rpcSub, nil
 }
 
+// notifyPendingTransactionsFull forwards every transaction entering the pool to
+// the given subscription in its RPC representation. Notifications are queued up
+// to a limit, dropping any excess if the subscriber can't keep up.
+func (api *PublicFilterAPI) notifyPendingTransactionsFull(notifier *rpc.Notifier, rpcSub *rpc.Subscription) {
+	var (
+		txsCh  = make(chan core.NewTxsEvent, 128)
+		txsSub = api.backend.SubscribeNewTxsEvent(txsCh)
+		queue  = make(chan *types.Transaction, pendingTxsFullQueue)
+	)
+	defer txsSub.Unsubscribe()
+
+	// Deliver the queued transactions on a separate goroutine, so a slow connection
+	// never blocks the transaction feed itself
+	go func() {
+		for tx := range queue {
+			notifier.Notify(rpcSub.ID, athapi.NewRPCPendingTransaction(tx))
+		}
+	}()
+	defer close(queue)
+
+	for {
+		select {
+		case ev := <-txsCh:
+			dropped := 0
+			for _, tx := range ev.Txs {
+				select {
+				case queue <- tx:
+				default:
+					dropped++
+				}
+			}
+			if dropped > 0 {
+				log.Debug("Dropped pending transaction notifications", "id", rpcSub.ID, "dropped", dropped)
+			}
+		case <-rpcSub.Err():
+			return
+		case <-notifier.Closed():
+			return
+		case <-txsSub.Err():
+			return
+		}
+	}
+}
+
 // NewBlockFilter creates a filter that fetches blocks that are imported into the chain.
 // It is part of the filter package since polling goes with ath_getFilterChanges.
 //
@@ -233,6 +307,131 @@ func (api *PublicFilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, er
 	return rpcSub, nil
 }
 
+// TxInclusion is the notification sent when a watched transaction is included in
+// the canonical chain, or is removed from it again by a reorg.
+type TxInclusion struct {
+	TxHash      common.Hash    `json:"transactionHash"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	Index       hexutil.Uint64 `json:"transactionIndex"`
+	Removed     bool           `json:"removed"`
+}
+
+// TransactionInclusion creates a subscription that fires when the transaction
+// with the given hash is included in the canonical chain. If a reorg drops the
+// transaction again, a notification with removed set is sent and the inclusion
+// is reported anew once the transaction is mined again. Inclusions are checked on
+// every new chain head, so an already mined transaction is reported with the next
+// block. The subscription is dropped by the server after the including block got
+// the given number of confirmations, 12 if omitted, without further notification.
+func (api *PublicFilterAPI) TransactionInclusion(ctx context.Context, txHash common.Hash, confirmations *hexutil.Uint64) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	depth := uint64(defaultInclusionConfirmations)
+	if confirmations != nil {
+		depth = uint64(*confirmations)
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		headers := make(chan *types.Header)
+		headersSub := api.events.SubscribeNewHeads(headers)
+		defer headersSub.Unsubscribe()
+
+		var (
+			included *TxInclusion
+			scanner  = &inclusionScanner{api: api, txHash: txHash, scanned: make(map[uint64]common.Hash)}
+		)
+		for {
+			select {
+			case h := <-headers:
+				// Report the inclusion as removed if its block was reorged out
+				if included != nil && rawdb.ReadCanonicalHash(api.chainDb, uint64(included.BlockNumber)) != included.BlockHash {
+					removed := *included
+					removed.Removed = true
+					notifier.Notify(rpcSub.ID, &removed)
+					included = nil
+				}
+				if included == nil {
+					if included = scanner.find(ctx, h); included != nil {
+						notifier.Notify(rpcSub.ID, included)
+					}
+				}
+				if included != nil && h.Number.Uint64() >= uint64(included.BlockNumber)+depth {
+					// Confirmed deep enough, drop the subscription and free its slot
+					notifier.Terminate(rpcSub.ID)
+					return
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// inclusionScanner looks up the canonical inclusion of a transaction, either via
+// the transaction index or, where it isn't maintained (light clients), by scanning
+// the receipts of the new canonical blocks. Scanned blocks are remembered, so all
+// blocks are covered even if chain head events were coalesced or the chain was
+// reorganised.
+type inclusionScanner struct {
+	api     *PublicFilterAPI
+	txHash  common.Hash
+	scanned map[uint64]common.Hash // Canonical blocks already scanned, by number
+}
+
+// find looks up the inclusion of the transaction in the chain up to the given head.
+func (s *inclusionScanner) find(ctx context.Context, head *types.Header) *TxInclusion {
+	db := s.api.chainDb
+	if blockHash, number, index := rawdb.ReadTxLookupEntry(db, s.txHash); blockHash != (common.Hash{}) {
+		if rawdb.ReadCanonicalHash(db, number) != blockHash {
+			return nil
+		}
+		return &TxInclusion{TxHash: s.txHash, BlockHash: blockHash, BlockNumber: hexutil.Uint64(number), Index: hexutil.Uint64(index)}
+	}
+	// Gather the ancestors of the head not scanned yet, starting at the head itself
+	// for the first one
+	var headers []*types.Header
+	for h := head; h != nil && len(headers) < maxInclusionScan; {
+		if hash, ok := s.scanned[h.Number.Uint64()]; ok && hash == h.Hash() {
+			break
+		}
+		headers = append(headers, h)
+		if len(s.scanned) == 0 || h.Number.Sign() == 0 {
+			break
+		}
+		h = rawdb.ReadHeader(db, h.ParentHash, h.Number.Uint64()-1)
+	}
+	// Scan the gathered blocks oldest first, forgetting blocks too old to matter
+	for i := len(headers) - 1; i >= 0; i-- {
+		header := headers[i]
+
+		receipts, err := s.api.backend.GetReceipts(ctx, header.Hash())
+		if err != nil {
+			return nil
+		}
+		s.scanned[header.Number.Uint64()] = header.Hash()
+
+		for j, receipt := range receipts {
+			if receipt.TxHash == s.txHash {
+				return &TxInclusion{TxHash: s.txHash, BlockHash: header.Hash(), BlockNumber: hexutil.Uint64(header.Number.Uint64()), Index: hexutil.Uint64(j)}
+			}
+		}
+	}
+	for number := range s.scanned {
+		if number+maxInclusionScan < head.Number.Uint64() {
+			delete(s.scanned, number)
+		}
+	}
+	return nil
+}
+
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
 func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
@@ -333,6 +532,7 @@ func (api *PublicFilterAPI) GetLogs(ctx context.Context, crit FilterCriteria) ([
 	}
 	// Create and run the filter to get all the logs
 	filter := New(api.backend, crit.FromBlock.Int64(), crit.ToBlock.Int64(), crit.Addresses, crit.Topics)
+	filter.SetMaxLogs(api.maxLogs)
 
 	logs, err := filter.Logs(ctx)
 	if err != nil {
@@ -381,6 +581,7 @@ func (api *PublicFilterAPI) GetFilterLogs(ctx context.Context, id rpc.ID) ([]*ty
 	}
 	// Create and run the filter to get all the logs
 	filter := New(api.backend, begin, end, f.crit.Addresses, f.crit.Topics)
+	filter.SetMaxLogs(api.maxLogs)
 
 	logs, err := filter.Logs(ctx)
 	if err != nil {
@@ -18,6 +18,7 @@ package filters
 
 import (
 	"context"
+	"errors"
 	"math/big"
 
 	"github.com/athereum/go-athereum/common"
@@ -29,6 +30,10 @@ import (
 	"github.com/athereum/go-athereum/rpc"
 )
 
+// ErrTooManyLogs is returned when a log query matches more logs than the filter
+// is allowed to return.
+var ErrTooManyLogs = errors.New("query returned too many logs, narrow the block range")
+
 type Backend interface {
 	ChainDb() athdb.Database
 	EventMux() *event.TypeMux
@@ -41,8 +46,12 @@ type Backend interface {
 	SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription
 	SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription
 
+	// SubscribePendingLogsEvent subscribes to the logs of the pending block. Backends
+	// without a pending block must return ErrPendingLogsUnsupported.
+	SubscribePendingLogsEvent() (*event.TypeMuxSubscription, error)
+
 	BloomStatus() (uint64, uint64)
-	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
+	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) error
 }
 
 // Filter can be used to retrieve and filter logs.
@@ -54,6 +63,9 @@ type Filter struct {
 	addresses  []common.Address
 	topics     [][]common.Hash
 
+	maxLogs int // Maximum number of logs to gather, 0 meaning unlimited
+	found   int // Number of logs gathered so far
+
 	matcher *bloombits.Matcher
 }
 
@@ -92,6 +104,19 @@ func New(backend Backend, begin, end int64, addresses []common.Address, topics [
 	}
 }
 
+// SetMaxLogs caps the number of logs the filter may gather. Queries matching more
+// logs are aborted with ErrTooManyLogs. Zero means unlimited.
+func (f *Filter) SetMaxLogs(max int) {
+	f.maxLogs = max
+}
+
+// tooManyLogs accounts for n newly gathered logs and reports whether the cap on
+// the number of logs has been exceeded.
+func (f *Filter) tooManyLogs(n int) bool {
+	f.found += n
+	return f.maxLogs > 0 && f.found > f.maxLogs
+}
+
 // Logs searches the blockchain for matching log entries, returning all from the
 // first block that contains matches, updating the start of the filter accordingly.
 func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
@@ -102,6 +127,14 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	}
 	head := header.Number.Uint64()
 
+	// Resolve the safe and finalized tags, which the backend tracks
+	var err error
+	if f.begin, err = f.resolveFinality(ctx, f.begin); err != nil {
+		return nil, err
+	}
+	if f.end, err = f.resolveFinality(ctx, f.end); err != nil {
+		return nil, err
+	}
 	if f.begin == -1 {
 		f.begin = int64(head)
 	}
@@ -110,10 +143,8 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 		end = head
 	}
 	// Gather all indexed logs, and finish with non indexed ones
-	var (
-		logs []*types.Log
-		err  error
-	)
+	var logs []*types.Log
+
 	size, sections := f.backend.BloomStatus()
 	if indexed := sections * size; indexed > uint64(f.begin) {
 		if indexed > end {
@@ -130,6 +161,22 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	return logs, err
 }
 
+// resolveFinality converts the safe and finalized block tags into the number of
+// the block they currently refer to, leaving all other numbers untouched.
+func (f *Filter) resolveFinality(ctx context.Context, number int64) (int64, error) {
+	if number != rpc.SafeBlockNumber.Int64() && number != rpc.FinalizedBlockNumber.Int64() {
+		return number, nil
+	}
+	header, err := f.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+	if err != nil {
+		return 0, err
+	}
+	if header == nil {
+		return 0, errors.New("finalized block not found")
+	}
+	return header.Number.Int64(), nil
+}
+
 // indexedLogs returns the logs matching the filter criteria based on the bloom
 // bits indexed available locally or via the network.
 func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, error) {
@@ -142,7 +189,9 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, err
 	}
 	defer session.Close()
 
-	f.backend.ServiceFilter(ctx, session)
+	if err := f.backend.ServiceFilter(ctx, session); err != nil {
+		return nil, err
+	}
 
 	// Iterate over the matches until exhausted or context closed
 	var logs []*types.Log
@@ -169,6 +218,9 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, err
 			if err != nil {
 				return logs, err
 			}
+			if f.tooManyLogs(len(found)) {
+				return logs, ErrTooManyLogs
+			}
 			logs = append(logs, found...)
 
 		case <-ctx.Done():
@@ -192,6 +244,9 @@ func (f *Filter) unindexedLogs(ctx context.Context, end uint64) ([]*types.Log, e
 			if err != nil {
 				return logs, err
 			}
+			if f.tooManyLogs(len(found)) {
+				return logs, ErrTooManyLogs
+			}
 			logs = append(logs, found...)
 		}
 	}
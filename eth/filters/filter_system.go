@@ -22,6 +22,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -72,6 +73,10 @@ const (
 
 var (
 	ErrInvalidSubscriptionID = errors.New("invalid id")
+
+	// ErrPendingLogsUnsupported is returned by backends without a pending block
+	// (e.g. light clients) when subscribing to pending logs.
+	ErrPendingLogsUnsupported = errors.New("pending logs unsupported on light client")
 )
 
 type subscription struct {
@@ -84,6 +89,55 @@ type subscription struct {
 	headers   chan *types.Header
 	installed chan struct{} // closed when the filter is installed
 	err       chan error    // closed when the filter is uninstalled
+
+	pendingLogs map[pendingLogKey]*types.Log // pending logs delivered since the last pending block rebuild
+}
+
+// pendingLogKey uniquely identifies a log within a pending block.
+type pendingLogKey struct {
+	txHash common.Hash
+	index  uint
+}
+
+// trackPendingLogs records the given logs of the current pending block as
+// delivered to the subscription.
+func (sub *subscription) trackPendingLogs(logs []*types.Log) {
+	if sub.pendingLogs == nil {
+		sub.pendingLogs = make(map[pendingLogKey]*types.Log)
+	}
+	for _, l := range logs {
+		sub.pendingLogs[pendingLogKey{l.TxHash, l.Index}] = l
+	}
+}
+
+// resetPendingLogs replaces the logs delivered for the previous pending block
+// with the complete log set of the rebuilt one. It returns the logs that need
+// to be delivered: previously delivered logs that disappeared, flagged as
+// removed, followed by the ones not delivered yet.
+func (sub *subscription) resetPendingLogs(logs []*types.Log) []*types.Log {
+	var (
+		fresh = make(map[pendingLogKey]*types.Log, len(logs))
+		diff  []*types.Log
+	)
+	for _, l := range logs {
+		fresh[pendingLogKey{l.TxHash, l.Index}] = l
+	}
+	for key, l := range sub.pendingLogs {
+		if _, ok := fresh[key]; !ok {
+			removed := *l
+			removed.Removed = true
+			diff = append(diff, &removed)
+		}
+	}
+	sort.Slice(diff, func(i, j int) bool { return diff[i].Index < diff[j].Index })
+
+	for _, l := range logs {
+		if _, ok := sub.pendingLogs[pendingLogKey{l.TxHash, l.Index}]; !ok {
+			diff = append(diff, l)
+		}
+	}
+	sub.pendingLogs = fresh
+	return diff
 }
 
 // EventSystem creates subscriptions, processes events and broadcasts them to the
@@ -99,7 +153,8 @@ type EventSystem struct {
 	logsSub       event.Subscription         // Subscription for new log event
 	rmLogsSub     event.Subscription         // Subscription for removed log event
 	chainSub      event.Subscription         // Subscription for new chain event
-	pendingLogSub *event.TypeMuxSubscription // Subscription for pending log event
+	pendingLogSub *event.TypeMuxSubscription // Subscription for pending log event, nil if unsupported
+	pendingLogErr error                      // Reason why pending logs are unavailable, if any
 
 	// Channels
 	install   chan *subscription         // install filter for event notification
@@ -134,12 +189,11 @@ func NewEventSystem(mux *event.TypeMux, backend Backend, lightMode bool) *EventS
 	m.logsSub = m.backend.SubscribeLogsEvent(m.logsCh)
 	m.rmLogsSub = m.backend.SubscribeRemovedLogsEvent(m.rmLogsCh)
 	m.chainSub = m.backend.SubscribeChainEvent(m.chainCh)
-	// TODO(rjl493456442): use feed to subscribe pending log event
-	m.pendingLogSub = m.mux.Subscribe(core.PendingLogsEvent{})
+	m.pendingLogSub, m.pendingLogErr = m.backend.SubscribePendingLogsEvent()
 
 	// Make sure none of the subscriptions are empty
 	if m.txsSub == nil || m.logsSub == nil || m.rmLogsSub == nil || m.chainSub == nil ||
-		m.pendingLogSub.Closed() {
+		(m.pendingLogErr == nil && m.pendingLogSub.Closed()) {
 		log.Crit("Subscribe for event system failed")
 	}
 
@@ -210,6 +264,9 @@ func (es *EventSystem) SubscribeLogs(critatlantis.FilterQuery, logs chan []*type
 
 	// only interested in pending logs
 	if from == rpc.PendingBlockNumber && to == rpc.PendingBlockNumber {
+		if es.pendingLogErr != nil {
+			return nil, es.pendingLogErr
+		}
 		return es.subscribePendingLogs(crit, logs), nil
 	}
 	// only interested in new mined logs
@@ -222,6 +279,9 @@ func (es *EventSystem) SubscribeLogs(critatlantis.FilterQuery, logs chan []*type
 	}
 	// interested in mined logs from a specific block number, new logs and pending logs
 	if from >= rpc.LatestBlockNumber && to == rpc.PendingBlockNumber {
+		if es.pendingLogErr != nil {
+			return nil, es.pendingLogErr
+		}
 		return es.subscribeMinedPendingLogs(crit, logs), nil
 	}
 	// interested in logs from a specific block number to new mined blocks
@@ -342,7 +402,13 @@ func (es *EventSystem) broadcast(filters filterIndex, ev interface{}) {
 		case core.PendingLogsEvent:
 			for _, f := range filters[PendingLogsSubscription] {
 				if e.Time.After(f.created) {
-					if matchedLogs := filterLogs(muxe.Logs, nil, f.logsCrit.ToBlock, f.logsCrit.Addresses, f.logsCrit.Topics); len(matchedLogs) > 0 {
+					matchedLogs := filterLogs(muxe.Logs, nil, f.logsCrit.ToBlock, f.logsCrit.Addresses, f.logsCrit.Topics)
+					if muxe.Reset {
+						matchedLogs = f.resetPendingLogs(matchedLogs)
+					} else {
+						f.trackPendingLogs(matchedLogs)
+					}
+					if len(matchedLogs) > 0 {
 						f.logs <- matchedLogs
 					}
 				}
@@ -449,7 +515,9 @@ func (es *EventSystem) lightFilterLogs(header *types.Header, addresses []common.
 func (es *EventSystem) eventLoop() {
 	// Ensure all subscriptions get cleaned up
 	defer func() {
-		es.pendingLogSub.Unsubscribe()
+		if es.pendingLogSub != nil {
+			es.pendingLogSub.Unsubscribe()
+		}
 		es.txsSub.Unsubscribe()
 		es.logsSub.Unsubscribe()
 		es.rmLogsSub.Unsubscribe()
@@ -460,6 +528,11 @@ func (es *EventSystem) eventLoop() {
 	for i := UnknownSubscription; i < LastIndexSubscription; i++ {
 		index[i] = make(map[rpc.ID]*subscription)
 	}
+	// Pending logs are only delivered if the backend supports them
+	var pendingLogs <-chan *event.TypeMuxEvent
+	if es.pendingLogSub != nil {
+		pendingLogs = es.pendingLogSub.Chan()
+	}
 
 	for {
 		select {
@@ -472,7 +545,7 @@ func (es *EventSystem) eventLoop() {
 			es.broadcast(index, ev)
 		case ev := <-es.chainCh:
 			es.broadcast(index, ev)
-		case ev, active := <-es.pendingLogSub.Chan():
+		case ev, active := <-pendingLogs:
 			if !active { // system stopped
 				return
 			}
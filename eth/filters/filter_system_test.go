@@ -27,6 +27,7 @@ import (
 
 	athereum "github.com/athereum/go-athereum"
 	"github.com/athereum/go-athereum/common"
+	"github.com/athereum/go-athereum/common/hexutil"
 	"github.com/athereum/go-athereum/consensus/athash"
 	"github.com/athereum/go-athereum/core"
 	"github.com/athereum/go-athereum/core/bloombits"
@@ -34,6 +35,7 @@ import (
 	"github.com/athereum/go-athereum/core/types"
 	"github.com/athereum/go-athereum/athdb"
 	"github.com/athereum/go-athereum/event"
+	"github.com/athereum/go-athereum/internal/athapi"
 	"github.com/athereum/go-athereum/params"
 	"github.com/athereum/go-athereum/rpc"
 )
@@ -108,6 +110,10 @@ func (b *testBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscript
 	return b.logsFeed.Subscribe(ch)
 }
 
+func (b *testBackend) SubscribePendingLogsEvent() (*event.TypeMuxSubscription, error) {
+	return b.mux.Subscribe(core.PendingLogsEvent{}), nil
+}
+
 func (b *testBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
 	return b.chainFeed.Subscribe(ch)
 }
@@ -116,7 +122,7 @@ func (b *testBackend) BloomStatus() (uint64, uint64) {
 	return params.BloomBitsBlocks, b.sections
 }
 
-func (b *testBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {
+func (b *testBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) error {
 	requests := make(chan chan *bloombits.Retrieval)
 
 	go session.Multiplex(16, 0, requests)
@@ -141,6 +147,7 @@ func (b *testBackend) ServiceFilter(ctx context.Context, session *bloombits.Matc
 			}
 		}
 	}()
+	return nil
 }
 
 // TestBlockSubscription tests if a block subscription returns block hashes for posted chain events.
@@ -204,6 +211,159 @@ func TestBlockSubscription(t *testing.T) {
 	<-sub1.Err()
 }
 
+// Tests that a transaction inclusion subscription reports the inclusion anew after
+// a reorg and is dropped by the server once confirmed.
+func TestTransactionInclusionSubscription(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mux        = new(event.TypeMux)
+		db         = athdb.NewMemDatabase()
+		txFeed     = new(event.Feed)
+		rmLogsFeed = new(event.Feed)
+		logsFeed   = new(event.Feed)
+		chainFeed  = new(event.Feed)
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		api        = NewPublicFilterAPI(backend, false)
+		tx         = types.NewTransaction(0, common.Address{0x01}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	)
+	server := rpc.NewServer()
+	server.SetMaxSubscriptions(1)
+	defer server.Stop()
+	if err := server.RegisterName("ath", api); err != nil {
+		t.Fatalf("failed to register filter API: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	inclusions := make(chan TxInclusion)
+	sub, err := client.EthSubscribe(context.Background(), inclusions, "transactionInclusion", tx.Hash(), hexutil.Uint64(2))
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond) // Wait for the subscription to be activated
+
+	// head makes the given block canonical and announces it as the new head
+	head := func(number int64, seed byte, txs types.Transactions) *types.Block {
+		block := types.NewBlock(&types.Header{Number: big.NewInt(number), Extra: []byte{seed}}, txs, nil, nil)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteTxLookupEntries(db, block)
+		chainFeed.Send(core.ChainEvent{Hash: block.Hash(), Block: block})
+		return block
+	}
+	expect := func(block *types.Block, removed bool) {
+		select {
+		case inclusion := <-inclusions:
+			if inclusion.TxHash != tx.Hash() || inclusion.BlockHash != block.Hash() || inclusion.Removed != removed {
+				t.Fatalf("inclusion mismatch: have %+v, want block %x removed %v", inclusion, block.Hash(), removed)
+			}
+		case err := <-sub.Err():
+			t.Fatalf("subscription ended prematurely: %v", err)
+		case <-time.After(time.Second):
+			t.Fatalf("no inclusion reported for block %x", block.Hash())
+		}
+	}
+	// Include the transaction, reorg it out and include it again in a later block
+	first := head(1, 0, types.Transactions{tx})
+	expect(first, false)
+
+	rawdb.DeleteTxLookupEntry(db, tx.Hash())
+	head(1, 1, nil)
+	expect(first, true)
+
+	second := head(2, 1, types.Transactions{tx})
+	expect(second, false)
+
+	// Confirm the inclusion and ensure the subscription is dropped by the server,
+	// freeing its slot
+	head(3, 1, nil)
+	head(4, 1, nil)
+
+	select {
+	case inclusion := <-inclusions:
+		t.Fatalf("unexpected inclusion reported: %+v", inclusion)
+	case <-time.After(100 * time.Millisecond):
+	}
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		sub, err := client.EthSubscribe(context.Background(), make(chan TxInclusion), "transactionInclusion", tx.Hash())
+		if err == nil {
+			sub.Unsubscribe()
+			break
+		}
+		if time.Since(start) > time.Second {
+			t.Fatalf("subscription slot not freed after confirmations: %v", err)
+		}
+	}
+}
+
+// Tests that a transaction inclusion subscription on a node without transaction
+// index finds the transaction in a block whose chain head event was coalesced.
+func TestTransactionInclusionCoalescedHeads(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mux        = new(event.TypeMux)
+		db         = athdb.NewMemDatabase()
+		txFeed     = new(event.Feed)
+		rmLogsFeed = new(event.Feed)
+		logsFeed   = new(event.Feed)
+		chainFeed  = new(event.Feed)
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		api        = NewPublicFilterAPI(backend, false)
+		tx         = types.NewTransaction(0, common.Address{0x01}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	)
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("ath", api); err != nil {
+		t.Fatalf("failed to register filter API: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	inclusions := make(chan TxInclusion)
+	sub, err := client.EthSubscribe(context.Background(), inclusions, "transactionInclusion", tx.Hash())
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+	time.Sleep(100 * time.Millisecond) // Wait for the subscription to be activated
+
+	// Create a chain with the transaction in the middle, storing only the headers
+	// and receipts as a light client would
+	var blocks []*types.Block
+	for i := 0; i < 4; i++ {
+		header := &types.Header{Number: big.NewInt(int64(i + 1))}
+		if i > 0 {
+			header.ParentHash = blocks[i-1].Hash()
+		}
+		var (
+			txs      types.Transactions
+			receipts types.Receipts
+		)
+		if i == 2 {
+			txs = types.Transactions{tx}
+			receipts = types.Receipts{&types.Receipt{TxHash: tx.Hash()}}
+		}
+		block := types.NewBlock(header, txs, nil, receipts)
+		rawdb.WriteHeader(db, block.Header())
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts)
+		blocks = append(blocks, block)
+	}
+	// Announce the first block, followed by the last one only
+	chainFeed.Send(core.ChainEvent{Hash: blocks[0].Hash(), Block: blocks[0]})
+	chainFeed.Send(core.ChainEvent{Hash: blocks[3].Hash(), Block: blocks[3]})
+
+	select {
+	case inclusion := <-inclusions:
+		if inclusion.BlockHash != blocks[2].Hash() || inclusion.BlockNumber != 3 || inclusion.Removed {
+			t.Fatalf("inclusion mismatch: have %+v, want block %x", inclusion, blocks[2].Hash())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no inclusion reported")
+	}
+}
+
 // TestPendingTxFilter tests whather pending tx filters retrieve all pending transactions that are posted to the event mux.
 func TestPendingTxFilter(t *testing.T) {
 	t.Parallel()
@@ -265,6 +425,69 @@ func TestPendingTxFilter(t *testing.T) {
 	}
 }
 
+// Tests that pending transaction subscriptions stream the full transactions if
+// requested, while plain subscriptions keep receiving only the hashes.
+func TestPendingTxSubscriptionFull(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mux        = new(event.TypeMux)
+		db         = athdb.NewMemDatabase()
+		txFeed     = new(event.Feed)
+		rmLogsFeed = new(event.Feed)
+		logsFeed   = new(event.Feed)
+		chainFeed  = new(event.Feed)
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		api        = NewPublicFilterAPI(backend, false)
+		tx         = types.NewTransaction(7, common.Address{0x01}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	)
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("ath", api); err != nil {
+		t.Fatalf("failed to register filter API: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	fullTxs := make(chan *athapi.RPCTransaction)
+	fullSub, err := client.EthSubscribe(context.Background(), fullTxs, "newPendingTransactions", true)
+	if err != nil {
+		t.Fatalf("failed to subscribe to full transactions: %v", err)
+	}
+	defer fullSub.Unsubscribe()
+
+	hashes := make(chan common.Hash)
+	hashSub, err := client.EthSubscribe(context.Background(), hashes, "newPendingTransactions")
+	if err != nil {
+		t.Fatalf("failed to subscribe to transaction hashes: %v", err)
+	}
+	defer hashSub.Unsubscribe()
+
+	time.Sleep(100 * time.Millisecond) // Wait for the subscriptions to be activated
+	txFeed.Send(core.NewTxsEvent{Txs: []*types.Transaction{tx}})
+
+	select {
+	case full := <-fullTxs:
+		if full.Hash != tx.Hash() || uint64(full.Nonce) != tx.Nonce() || full.To == nil || *full.To != *tx.To() {
+			t.Errorf("full transaction mismatch: have %x/%d/%v, want %x/%d/%x", full.Hash, full.Nonce, full.To, tx.Hash(), tx.Nonce(), *tx.To())
+		}
+	case err := <-fullSub.Err():
+		t.Fatalf("full transaction subscription ended prematurely: %v", err)
+	case <-time.After(time.Second):
+		t.Fatalf("full transaction not delivered")
+	}
+	select {
+	case hash := <-hashes:
+		if hash != tx.Hash() {
+			t.Errorf("transaction hash mismatch: have %x, want %x", hash, tx.Hash())
+		}
+	case err := <-hashSub.Err():
+		t.Fatalf("hash subscription ended prematurely: %v", err)
+	case <-time.After(time.Second):
+		t.Fatalf("transaction hash not delivered")
+	}
+}
+
 // TestLogFilterCreation test whather a given filter criteria makes sense.
 // If not it must return an error.
 func TestLogFilterCreation(t *testing.T) {
@@ -343,6 +566,44 @@ func TestInvalidLogFilterCreation(t *testing.T) {
 	}
 }
 
+// noPendingBackend is a test backend without a pending block, like light clients.
+type noPendingBackend struct {
+	*testBackend
+}
+
+func (b *noPendingBackend) SubscribePendingLogsEvent() (*event.TypeMuxSubscription, error) {
+	return nil, ErrPendingLogsUnsupported
+}
+
+// TestPendingLogFilterUnsupported tests that pending log filters are rejected if
+// the backend can't deliver pending logs, while mined log filters keep working.
+func TestPendingLogFilterUnsupported(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mux        = new(event.TypeMux)
+		db         = athdb.NewMemDatabase()
+		txFeed     = new(event.Feed)
+		rmLogsFeed = new(event.Feed)
+		logsFeed   = new(event.Feed)
+		chainFeed  = new(event.Feed)
+		backend    = &noPendingBackend{&testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}}
+		api        = NewPublicFilterAPI(backend, true)
+	)
+	testCases := []FilterCriteria{
+		0: {FromBlock: big.NewInt(rpc.PendingBlockNumber.Int64()), ToBlock: big.NewInt(rpc.PendingBlockNumber.Int64())},
+		1: {FromBlock: big.NewInt(rpc.LatestBlockNumber.Int64()), ToBlock: big.NewInt(rpc.PendingBlockNumber.Int64())},
+	}
+	for i, test := range testCases {
+		if _, err := api.NewFilter(test); err != ErrPendingLogsUnsupported {
+			t.Errorf("case #%d: error mismatch: have %v, want %v", i, err, ErrPendingLogsUnsupported)
+		}
+	}
+	if _, err := api.NewFilter(FilterCriteria{}); err != nil {
+		t.Errorf("mined log filter rejected: %v", err)
+	}
+}
+
 // TestLogFilter tests whather log filters match the correct logs that are posted to the event feed.
 func TestLogFilter(t *testing.T) {
 	t.Parallel()
@@ -579,3 +840,39 @@ func TestPendingLogsSubscription(t *testing.T) {
 		}
 	}
 }
+
+// Tests that rebuilding the pending block reports the vanished pending logs as
+// removed and only delivers the ones not seen yet.
+func TestPendingLogsReset(t *testing.T) {
+	var (
+		tx1 = common.HexToHash("0x01")
+		tx2 = common.HexToHash("0x02")
+		tx3 = common.HexToHash("0x03")
+
+		log1 = &types.Log{TxHash: tx1, Index: 0}
+		log2 = &types.Log{TxHash: tx2, Index: 1}
+		log3 = &types.Log{TxHash: tx3, Index: 1}
+
+		sub = new(subscription)
+	)
+	sub.trackPendingLogs([]*types.Log{log1, log2})
+
+	diff := sub.resetPendingLogs([]*types.Log{log1, log3})
+	if len(diff) != 2 {
+		t.Fatalf("diff length mismatch: have %d, want 2", len(diff))
+	}
+	if diff[0].TxHash != tx2 || !diff[0].Removed {
+		t.Errorf("removed log mismatch: have %+v, want removed log of %x", diff[0], tx2)
+	}
+	if log2.Removed {
+		t.Errorf("delivered log modified in place")
+	}
+	if diff[1] != log3 || diff[1].Removed {
+		t.Errorf("added log mismatch: have %+v, want %+v", diff[1], log3)
+	}
+	// An empty rebuilt pending block removes everything
+	diff = sub.resetPendingLogs(nil)
+	if len(diff) != 2 || !diff[0].Removed || !diff[1].Removed {
+		t.Fatalf("empty reset mismatch: have %+v", diff)
+	}
+}
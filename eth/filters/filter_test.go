@@ -230,3 +230,40 @@ func TestFilters(t *testing.T) {
 		t.Error("expected 0 log, got", len(logs))
 	}
 }
+
+// Tests that log queries matching more logs than the configured cap are rejected,
+// whereas ones staying within it return all matches.
+func TestFiltersMaxLogs(t *testing.T) {
+	var (
+		db      = athdb.NewMemDatabase()
+		backend = &testBackend{new(event.TypeMux), db, 0, new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed)}
+		addr    = common.BytesToAddress([]byte("logger"))
+		topic   = common.BytesToHash([]byte("topic"))
+	)
+	genesis := core.GenesisBlockForTesting(db, addr, big.NewInt(1000000))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, athash.NewFaker(), db, 10, func(i int, gen *core.BlockGen) {
+		if i%3 == 0 {
+			receipt := types.NewReceipt(nil, false, 0)
+			receipt.Logs = []*types.Log{{Address: addr, Topics: []common.Hash{topic}}}
+			gen.AddUncheckedReceipt(receipt)
+		}
+	})
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	filter := New(backend, 0, -1, []common.Address{addr}, [][]common.Hash{{topic}})
+	filter.SetMaxLogs(4)
+
+	if logs, err := filter.Logs(context.Background()); err != nil || len(logs) != 4 {
+		t.Errorf("capped filter failed: have %d logs, error %v; want 4 logs, no error", len(logs), err)
+	}
+	filter = New(backend, 0, -1, []common.Address{addr}, [][]common.Hash{{topic}})
+	filter.SetMaxLogs(3)
+
+	if _, err := filter.Logs(context.Background()); err != ErrTooManyLogs {
+		t.Errorf("over capped filter error mismatch: have %v, want %v", err, ErrTooManyLogs)
+	}
+}
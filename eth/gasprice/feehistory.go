@@ -0,0 +1,174 @@
+// Copyright 2018 The go-athereum Authors
+// This file is part of the go-athereum library.
+//
+// The go-athereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-athereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-athereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gasprice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/athereum/go-athereum/core/types"
+	"github.com/athereum/go-athereum/rpc"
+)
+
+const (
+	// maxFeeHistory is the maximum number of blocks that can be retrieved for a
+	// fee history request.
+	maxFeeHistory = 1024
+
+	// maxFeeHistoryPercentiles is the maximum number of reward percentiles that
+	// can be requested in a single fee history request.
+	maxFeeHistoryPercentiles = 100
+)
+
+var (
+	errInvalidBlockCount = errors.New("invalid block count")
+	errInvalidPercentile = errors.New("invalid reward percentile")
+)
+
+// FeeHistoryResult is the gas price distribution of a range of consecutive blocks.
+type FeeHistoryResult struct {
+	OldestBlock  *big.Int     // Number of the first block in the range
+	Reward       [][]*big.Int // Requested gas price percentiles of each block
+	BaseGasPrice []*big.Int   // Lowest gas price included in each block
+	GasUsedRatio []float64    // Ratio of gas used to the gas limit of each block
+}
+
+// FeeHistory returns the distribution of the gas prices paid in the blockCount
+// blocks ending with lastBlock, computing the requested percentiles of the
+// included transactions' gas prices for each block. Transactions sent by the
+// block's miner or by the oracle's ignored senders are skipped, same as when
+// suggesting gas prices.
+//
+// Empty blocks report zero rewards and carry forward the base gas price of the
+// previous non-empty block, looking before the requested range if needed. If no
+// such block is found within the oracle's block limit, the base is zero.
+func (gpo *Oracle) FeeHistory(ctx context.Context, blockCount int, lastBlock rpc.BlockNumber, percentiles []float64) (*FeeHistoryResult, error) {
+	if blockCount < 1 {
+		return nil, errInvalidBlockCount
+	}
+	if blockCount > maxFeeHistory {
+		blockCount = maxFeeHistory
+	}
+	if len(percentiles) > maxFeeHistoryPercentiles {
+		return nil, fmt.Errorf("%v: %d requested, maximum is %d", errInvalidPercentile, len(percentiles), maxFeeHistoryPercentiles)
+	}
+	for i, p := range percentiles {
+		if p < 0 || p > 100 {
+			return nil, fmt.Errorf("%v: %f", errInvalidPercentile, p)
+		}
+		if i > 0 && p < percentiles[i-1] {
+			return nil, fmt.Errorf("%v: #%d:%f > #%d:%f", errInvalidPercentile, i-1, percentiles[i-1], i, p)
+		}
+	}
+	// Resolve the range of blocks to walk, truncating at the genesis
+	head, err := gpo.backend.HeaderByNumber(ctx, lastBlock)
+	if head == nil {
+		if err == nil {
+			err = fmt.Errorf("block %d not found", lastBlock)
+		}
+		return nil, err
+	}
+	last := head.Number.Uint64()
+	if uint64(blockCount) > last+1 {
+		blockCount = int(last + 1)
+	}
+	oldest := last + 1 - uint64(blockCount)
+
+	base, err := gpo.lastBasePrice(ctx, oldest)
+	if err != nil {
+		return nil, err
+	}
+	result := &FeeHistoryResult{
+		OldestBlock:  new(big.Int).SetUint64(oldest),
+		Reward:       make([][]*big.Int, blockCount),
+		BaseGasPrice: make([]*big.Int, blockCount),
+		GasUsedRatio: make([]float64, blockCount),
+	}
+	for i := 0; i < blockCount; i++ {
+		number := oldest + uint64(i)
+
+		block, err := gpo.backend.BlockByNumber(ctx, rpc.BlockNumber(number))
+		if block == nil {
+			if err == nil {
+				err = fmt.Errorf("block %d not found", number)
+			}
+			return nil, err
+		}
+		prices := gpo.blockGasPrices(block)
+
+		reward := make([]*big.Int, len(percentiles))
+		if len(prices) == 0 {
+			for j := range reward {
+				reward[j] = new(big.Int)
+			}
+		} else {
+			for j, p := range percentiles {
+				reward[j] = prices[int(float64(len(prices)-1)*p/100)]
+			}
+			base = prices[0]
+		}
+		result.Reward[i] = reward
+		result.BaseGasPrice[i] = base
+		if limit := block.GasLimit(); limit > 0 {
+			result.GasUsedRatio[i] = float64(block.GasUsed()) / float64(limit)
+		}
+	}
+	return result, nil
+}
+
+// lastBasePrice returns the lowest sampled gas price of the closest non-empty
+// block before the given one, searching at most the oracle's block limit.
+func (gpo *Oracle) lastBasePrice(ctx context.Context, number uint64) (*big.Int, error) {
+	for i := 0; i < gpo.maxBlocks && number > 0; i++ {
+		number--
+
+		block, err := gpo.backend.BlockByNumber(ctx, rpc.BlockNumber(number))
+		if block == nil {
+			if err == nil {
+				err = fmt.Errorf("block %d not found", number)
+			}
+			return nil, err
+		}
+		if prices := gpo.blockGasPrices(block); len(prices) > 0 {
+			return prices[0], nil
+		}
+	}
+	return new(big.Int), nil
+}
+
+// blockGasPrices returns the gas prices of all the transactions in a block not
+// sent by its miner or an ignored sender, sorted in ascending order.
+func (gpo *Oracle) blockGasPrices(block *types.Block) []*big.Int {
+	signer := types.MakeSigner(gpo.backend.ChainConfig(), block.Number())
+
+	var prices []*big.Int
+	for _, tx := range block.Transactions() {
+		sender, err := types.Sender(signer, tx)
+		if err != nil || sender == block.Coinbase() {
+			continue
+		}
+		if _, ignored := gpo.ignore[sender]; ignored {
+			continue
+		}
+		prices = append(prices, tx.GasPrice())
+	}
+	sort.Sort(bigIntArray(prices))
+	return prices
+}
@@ -0,0 +1,229 @@
+// Copyright 2018 The go-athereum Authors
+// This file is part of the go-athereum library.
+//
+// The go-athereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-athereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-athereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gasprice
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/athereum/go-athereum/common"
+	"github.com/athereum/go-athereum/core/types"
+	"github.com/athereum/go-athereum/crypto"
+	"github.com/athereum/go-athereum/internal/athapi"
+	"github.com/athereum/go-athereum/params"
+	"github.com/athereum/go-athereum/rpc"
+)
+
+// testBackend is a gas price oracle backend serving a fixed chain of blocks.
+type testBackend struct {
+	athapi.Backend
+	blocks []*types.Block
+}
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	block, err := b.BlockByNumber(ctx, number)
+	if block == nil {
+		return nil, err
+	}
+	return block.Header(), nil
+}
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	if number == rpc.LatestBlockNumber {
+		number = rpc.BlockNumber(len(b.blocks) - 1)
+	}
+	if number < 0 || int(number) >= len(b.blocks) {
+		return nil, nil
+	}
+	return b.blocks[number], nil
+}
+
+func (b *testBackend) ChainConfig() *params.ChainConfig {
+	return params.TestChainConfig
+}
+
+// newTestBackend creates a chain of blocks containing transactions of the given
+// senders at the given gas prices, mined by the given coinbase.
+func newTestBackend(t *testing.T, coinbase common.Address, txs [][]*ecdsa.PrivateKey, prices [][]int64) *testBackend {
+	signer := types.NewEIP155Signer(params.TestChainConfig.ChainID)
+
+	backend := new(testBackend)
+	for i := range txs {
+		var included []*types.Transaction
+		for j, key := range txs[i] {
+			tx, err := types.SignTx(types.NewTransaction(uint64(j), common.Address{}, new(big.Int), params.TxGas, big.NewInt(prices[i][j]), nil), signer, key)
+			if err != nil {
+				t.Fatalf("failed to sign transaction: %v", err)
+			}
+			included = append(included, tx)
+		}
+		header := &types.Header{
+			Number:   big.NewInt(int64(i)),
+			Coinbase: coinbase,
+			GasLimit: 8 * params.TxGas,
+			GasUsed:  uint64(len(included)) * params.TxGas,
+		}
+		backend.blocks = append(backend.blocks, types.NewBlock(header, included, nil, nil))
+	}
+	return backend
+}
+
+// Tests that the fee history is assembled from the sampled transactions, skipping
+// the miner's and the ignored senders' own ones and carrying base prices across
+// empty blocks.
+func TestFeeHistory(t *testing.T) {
+	var (
+		user, _    = crypto.GenerateKey()
+		miner, _   = crypto.GenerateKey()
+		ignored, _ = crypto.GenerateKey()
+	)
+	backend := newTestBackend(t, crypto.PubkeyToAddress(miner.PublicKey),
+		[][]*ecdsa.PrivateKey{
+			{},
+			{user, user, miner, ignored},
+			{},
+			{miner, ignored},
+			{user},
+		},
+		[][]int64{
+			{},
+			{10, 30, 1, 2},
+			{},
+			{1, 2},
+			{20},
+		},
+	)
+	tests := []struct {
+		count       int
+		last        rpc.BlockNumber
+		percentiles []float64
+
+		oldest uint64
+		reward [][]int64
+		base   []int64
+		ratio  []float64
+	}{
+		// Ranges containing sampled transactions
+		{3, 4, []float64{0, 100}, 2, [][]int64{{0, 0}, {0, 0}, {20, 20}}, []int64{10, 10, 20}, []float64{0, 0.25, 0.125}},
+		{2, 1, []float64{50}, 0, [][]int64{{0}, {10}}, []int64{0, 10}, []float64{0, 0.5}},
+		{1, rpc.LatestBlockNumber, nil, 4, [][]int64{{}}, []int64{20}, []float64{0.125}},
+
+		// Leading empty blocks carry the base price from before the range
+		{1, 2, []float64{0}, 2, [][]int64{{0}}, []int64{10}, []float64{0}},
+		{2, 3, []float64{0}, 2, [][]int64{{0}, {0}}, []int64{10, 10}, []float64{0, 0.25}},
+
+		// Empty history has no base price to carry
+		{1, 0, []float64{0}, 0, [][]int64{{0}}, []int64{0}, []float64{0}},
+
+		// Ranges reaching past the genesis are truncated
+		{10, 1, nil, 0, [][]int64{{}, {}}, []int64{0, 10}, []float64{0, 0.5}},
+	}
+	for i, tt := range tests {
+		oracle := NewOracle(backend, Config{
+			Blocks:        2,
+			Default:       big.NewInt(1000),
+			IgnoreSenders: []common.Address{crypto.PubkeyToAddress(ignored.PublicKey)},
+		})
+		history, err := oracle.FeeHistory(context.Background(), tt.count, tt.last, tt.percentiles)
+		if err != nil {
+			t.Errorf("test %d: failed to retrieve fee history: %v", i, err)
+			continue
+		}
+		if history.OldestBlock.Uint64() != tt.oldest {
+			t.Errorf("test %d: oldest block mismatch: have %d, want %d", i, history.OldestBlock, tt.oldest)
+		}
+		reward := make([][]int64, len(history.Reward))
+		for j, rewards := range history.Reward {
+			reward[j] = make([]int64, len(rewards))
+			for k, r := range rewards {
+				reward[j][k] = r.Int64()
+			}
+		}
+		if !reflect.DeepEqual(reward, tt.reward) {
+			t.Errorf("test %d: reward mismatch: have %v, want %v", i, reward, tt.reward)
+		}
+		base := make([]int64, len(history.BaseGasPrice))
+		for j, price := range history.BaseGasPrice {
+			base[j] = price.Int64()
+		}
+		if !reflect.DeepEqual(base, tt.base) {
+			t.Errorf("test %d: base price mismatch: have %v, want %v", i, base, tt.base)
+		}
+		if !reflect.DeepEqual(history.GasUsedRatio, tt.ratio) {
+			t.Errorf("test %d: gas used ratio mismatch: have %v, want %v", i, history.GasUsedRatio, tt.ratio)
+		}
+	}
+}
+
+// Tests that invalid fee history requests are rejected.
+func TestFeeHistoryInvalid(t *testing.T) {
+	backend := newTestBackend(t, common.Address{}, [][]*ecdsa.PrivateKey{{}}, [][]int64{{}})
+	oracle := NewOracle(backend, Config{Blocks: 1})
+
+	tests := []struct {
+		count       int
+		percentiles []float64
+	}{
+		{0, nil},
+		{-1, nil},
+		{1, []float64{-1}},
+		{1, []float64{101}},
+		{1, []float64{50, 10}},
+		{1, make([]float64, maxFeeHistoryPercentiles+1)},
+	}
+	for i, tt := range tests {
+		if _, err := oracle.FeeHistory(context.Background(), tt.count, rpc.LatestBlockNumber, tt.percentiles); err == nil {
+			t.Errorf("test %d: invalid request accepted", i)
+		}
+	}
+}
+
+// Tests that the fee history and the suggested gas price sample the same set of
+// transactions, both skipping the ignored senders.
+func TestFeeHistoryMatchesSuggestion(t *testing.T) {
+	var (
+		user, _    = crypto.GenerateKey()
+		ignored, _ = crypto.GenerateKey()
+	)
+	backend := newTestBackend(t, common.Address{},
+		[][]*ecdsa.PrivateKey{{}, {ignored, user}},
+		[][]int64{{}, {1, 50}},
+	)
+	oracle := NewOracle(backend, Config{
+		Blocks:        1,
+		Default:       big.NewInt(1000),
+		IgnoreSenders: []common.Address{crypto.PubkeyToAddress(ignored.PublicKey)},
+	})
+	price, err := oracle.SuggestPrice(context.Background())
+	if err != nil {
+		t.Fatalf("failed to suggest gas price: %v", err)
+	}
+	if price.Int64() != 50 {
+		t.Fatalf("suggested price mismatch: have %v, want %v", price, 50)
+	}
+	history, err := oracle.FeeHistory(context.Background(), 1, rpc.LatestBlockNumber, []float64{0})
+	if err != nil {
+		t.Fatalf("failed to retrieve fee history: %v", err)
+	}
+	if have, want := fmt.Sprint(history.BaseGasPrice[0]), fmt.Sprint(price); have != want {
+		t.Fatalf("base price mismatch: have %s, want %s", have, want)
+	}
+}
@@ -29,12 +29,18 @@ import (
 	"github.com/athereum/go-athereum/rpc"
 )
 
-var maxPrice = big.NewInt(500 * params.Shannon)
+// DefaultMaxPrice is the ceiling of the suggested gas prices if none is configured.
+var DefaultMaxPrice = big.NewInt(500 * params.Shannon)
 
 type Config struct {
 	Blocks     int
 	Percentile int
 	Default    *big.Int `toml:",omitempty"`
+	MaxPrice   *big.Int `toml:",omitempty"` // Ceiling of suggested prices, DefaultMaxPrice if nil
+
+	// Senders whose transactions are not sampled, e.g. the node's own accounts
+	// including cheap internal transactions in locally mined blocks.
+	IgnoreSenders []common.Address `toml:",omitempty"`
 }
 
 // Oracle recommends gas prices based on the content of recent
@@ -48,6 +54,8 @@ type Oracle struct {
 
 	checkBlocks, maxEmpty, maxBlocks int
 	percentile                       int
+	maxPrice                         *big.Int
+	ignore                           map[common.Address]struct{} // Senders excluded from sampling
 }
 
 // NewOracle returns a new oracle.
@@ -63,6 +71,14 @@ func NewOracle(backend athapi.Backend, params Config) *Oracle {
 	if percent > 100 {
 		percent = 100
 	}
+	maxPrice := params.MaxPrice
+	if maxPrice == nil {
+		maxPrice = DefaultMaxPrice
+	}
+	ignore := make(map[common.Address]struct{}, len(params.IgnoreSenders))
+	for _, addr := range params.IgnoreSenders {
+		ignore[addr] = struct{}{}
+	}
 	return &Oracle{
 		backend:     backend,
 		lastPrice:   params.Default,
@@ -70,6 +86,8 @@ func NewOracle(backend athapi.Backend, params Config) *Oracle {
 		maxEmpty:    blocks / 2,
 		maxBlocks:   blocks * 5,
 		percentile:  percent,
+		maxPrice:    maxPrice,
+		ignore:      ignore,
 	}
 }
 
@@ -136,8 +154,8 @@ func (gpo *Oracle) SuggestPrice(ctx context.Context) (*big.Int, error) {
 		sort.Sort(bigIntArray(blockPrices))
 		price = blockPrices[(len(blockPrices)-1)*gpo.percentile/100]
 	}
-	if price.Cmp(maxPrice) > 0 {
-		price = new(big.Int).Set(maxPrice)
+	if price.Cmp(gpo.maxPrice) > 0 {
+		price = new(big.Int).Set(gpo.maxPrice)
 	}
 
 	gpo.cacheLock.Lock()
@@ -174,10 +192,14 @@ func (gpo *Oracle) getBlockPrices(ctx context.Context, signer types.Signer, bloc
 
 	for _, tx := range txs {
 		sender, err := types.Sender(signer, tx)
-		if err == nil && sender != block.Coinbase() {
-			ch <- getBlockPricesResult{tx.GasPrice(), nil}
-			return
+		if err != nil || sender == block.Coinbase() {
+			continue
 		}
+		if _, ignored := gpo.ignore[sender]; ignored {
+			continue
+		}
+		ch <- getBlockPricesResult{tx.GasPrice(), nil}
+		return
 	}
 	ch <- getBlockPricesResult{nil, nil}
 }
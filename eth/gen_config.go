@@ -4,6 +4,7 @@ package ath
 
 import (
 	"math/big"
+	"time"
 
 	"github.com/athereum/go-athereum/common"
 	"github.com/athereum/go-athereum/common/hexutil"
@@ -11,6 +12,7 @@ import (
 	"github.com/athereum/go-athereum/core"
 	"github.com/athereum/go-athereum/ath/downloader"
 	"github.com/athereum/go-athereum/ath/gasprice"
+	"github.com/athereum/go-athereum/p2p/discover"
 )
 
 var _ = (*configMarshaling)(nil)
@@ -20,38 +22,102 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		Genesis                 *core.Genesis `toml:",omitempty"`
 		NetworkId               uint64
 		SyncMode                downloader.SyncMode
+		FastSyncRetries         int  `toml:",omitempty"`
 		LightServ               int  `toml:",omitempty"`
 		LightPeers              int  `toml:",omitempty"`
 		SkipBcVersionCheck      bool `toml:"-"`
+		DatabaseReadOnly        bool `toml:",omitempty"`
 		DatabaseHandles         int  `toml:"-"`
 		DatabaseCache           int
+		TriesInMemory           uint64         `toml:",omitempty"`
+		TrieGCInterval          uint64         `toml:",omitempty"`
+		HeaderCache             int            `toml:",omitempty"`
+		BodyCache               int            `toml:",omitempty"`
+		ReceiptCache            int            `toml:",omitempty"`
+		TdCache                 int            `toml:",omitempty"`
+		MaxReorgDepth           uint64         `toml:",omitempty"`
+		StatePrefetch           bool           `toml:",omitempty"`
 		Atlantisbase               common.Address `toml:",omitempty"`
 		MinerThreads            int            `toml:",omitempty"`
 		ExtraData               hexutil.Bytes  `toml:",omitempty"`
 		GasPrice                *big.Int
+		ExtraDataClientName     string `toml:",omitempty"`
+		MinerGasFloor           uint64 `toml:",omitempty"`
+		MinerGasCeil            uint64 `toml:",omitempty"`
+		MinerEmptyBlockPeriod   uint64 `toml:",omitempty"`
+		Engine                  string `toml:",omitempty"`
 		Ethash                  athash.Config
 		TxPool                  core.TxPoolConfig
+		MinAcceptedGasPrice     *big.Int          `toml:",omitempty"`
+		TxBroadcastPeers        int               `toml:",omitempty"`
+		RequestRateLimit        float64           `toml:",omitempty"`
+		RequestBurstLimit       int               `toml:",omitempty"`
+		RequestDropLimit        int               `toml:",omitempty"`
+		PeerIdleTimeout         time.Duration     `toml:",omitempty"`
+		MinPeers                int               `toml:",omitempty"`
+		TrustedPeerOnly         bool              `toml:",omitempty"`
+		AllowedPeers            []discover.NodeID `toml:",omitempty"`
+		DeniedPeers             []discover.NodeID `toml:",omitempty"`
 		GPO                     gasprice.Config
+		BloomServiceThreads     int           `toml:",omitempty"`
+		MaxLogsConcurrency      int           `toml:",omitempty"`
+		MaxLogsReturned         int           `toml:",omitempty"`
+		RPCEVMTimeout           time.Duration `toml:",omitempty"`
+		DisablePersonalAPI      bool          `toml:",omitempty"`
+		FinalityConfirmations   uint64        `toml:",omitempty"`
 		EnablePreimageRecording bool
-		DocRoot                 string `toml:"-"`
+		ShutdownTimeout         time.Duration `toml:",omitempty"`
+		DocRoot                 string        `toml:"-"`
 	}
 	var enc Config
 	enc.Genesis = c.Genesis
 	enc.NetworkId = c.NetworkId
 	enc.SyncMode = c.SyncMode
+	enc.FastSyncRetries = c.FastSyncRetries
 	enc.LightServ = c.LightServ
 	enc.LightPeers = c.LightPeers
 	enc.SkipBcVersionCheck = c.SkipBcVersionCheck
+	enc.DatabaseReadOnly = c.DatabaseReadOnly
 	enc.DatabaseHandles = c.DatabaseHandles
 	enc.DatabaseCache = c.DatabaseCache
+	enc.TriesInMemory = c.TriesInMemory
+	enc.TrieGCInterval = c.TrieGCInterval
+	enc.HeaderCache = c.HeaderCache
+	enc.BodyCache = c.BodyCache
+	enc.ReceiptCache = c.ReceiptCache
+	enc.TdCache = c.TdCache
+	enc.MaxReorgDepth = c.MaxReorgDepth
+	enc.StatePrefetch = c.StatePrefetch
 	enc.Atlantisbase = c.Atlantisbase
 	enc.MinerThreads = c.MinerThreads
 	enc.ExtraData = c.ExtraData
 	enc.GasPrice = c.GasPrice
+	enc.ExtraDataClientName = c.ExtraDataClientName
+	enc.MinerGasFloor = c.MinerGasFloor
+	enc.MinerGasCeil = c.MinerGasCeil
+	enc.MinerEmptyBlockPeriod = c.MinerEmptyBlockPeriod
+	enc.Engine = c.Engine
 	enc.Ethash = c.Ethash
 	enc.TxPool = c.TxPool
+	enc.MinAcceptedGasPrice = c.MinAcceptedGasPrice
+	enc.TxBroadcastPeers = c.TxBroadcastPeers
+	enc.RequestRateLimit = c.RequestRateLimit
+	enc.RequestBurstLimit = c.RequestBurstLimit
+	enc.RequestDropLimit = c.RequestDropLimit
+	enc.PeerIdleTimeout = c.PeerIdleTimeout
+	enc.MinPeers = c.MinPeers
+	enc.TrustedPeerOnly = c.TrustedPeerOnly
+	enc.AllowedPeers = c.AllowedPeers
+	enc.DeniedPeers = c.DeniedPeers
 	enc.GPO = c.GPO
+	enc.BloomServiceThreads = c.BloomServiceThreads
+	enc.MaxLogsConcurrency = c.MaxLogsConcurrency
+	enc.MaxLogsReturned = c.MaxLogsReturned
+	enc.RPCEVMTimeout = c.RPCEVMTimeout
+	enc.DisablePersonalAPI = c.DisablePersonalAPI
+	enc.FinalityConfirmations = c.FinalityConfirmations
 	enc.EnablePreimageRecording = c.EnablePreimageRecording
+	enc.ShutdownTimeout = c.ShutdownTimeout
 	enc.DocRoot = c.DocRoot
 	return &enc, nil
 }
@@ -61,20 +127,52 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		Genesis                 *core.Genesis `toml:",omitempty"`
 		NetworkId               *uint64
 		SyncMode                *downloader.SyncMode
+		FastSyncRetries         *int  `toml:",omitempty"`
 		LightServ               *int  `toml:",omitempty"`
 		LightPeers              *int  `toml:",omitempty"`
 		SkipBcVersionCheck      *bool `toml:"-"`
+		DatabaseReadOnly        *bool `toml:",omitempty"`
 		DatabaseHandles         *int  `toml:"-"`
 		DatabaseCache           *int
+		TriesInMemory           *uint64         `toml:",omitempty"`
+		TrieGCInterval          *uint64         `toml:",omitempty"`
+		HeaderCache             *int            `toml:",omitempty"`
+		BodyCache               *int            `toml:",omitempty"`
+		ReceiptCache            *int            `toml:",omitempty"`
+		TdCache                 *int            `toml:",omitempty"`
+		MaxReorgDepth           *uint64         `toml:",omitempty"`
+		StatePrefetch           *bool           `toml:",omitempty"`
 		Atlantisbase               *common.Address `toml:",omitempty"`
 		MinerThreads            *int            `toml:",omitempty"`
 		ExtraData               *hexutil.Bytes  `toml:",omitempty"`
 		GasPrice                *big.Int
+		ExtraDataClientName     *string `toml:",omitempty"`
+		MinerGasFloor           *uint64 `toml:",omitempty"`
+		MinerGasCeil            *uint64 `toml:",omitempty"`
+		MinerEmptyBlockPeriod   *uint64 `toml:",omitempty"`
+		Engine                  *string `toml:",omitempty"`
 		Ethash                  *athash.Config
 		TxPool                  *core.TxPoolConfig
+		MinAcceptedGasPrice     *big.Int          `toml:",omitempty"`
+		TxBroadcastPeers        *int              `toml:",omitempty"`
+		RequestRateLimit        *float64          `toml:",omitempty"`
+		RequestBurstLimit       *int              `toml:",omitempty"`
+		RequestDropLimit        *int              `toml:",omitempty"`
+		PeerIdleTimeout         *time.Duration    `toml:",omitempty"`
+		MinPeers                *int              `toml:",omitempty"`
+		TrustedPeerOnly         *bool             `toml:",omitempty"`
+		AllowedPeers            []discover.NodeID `toml:",omitempty"`
+		DeniedPeers             []discover.NodeID `toml:",omitempty"`
 		GPO                     *gasprice.Config
+		BloomServiceThreads     *int           `toml:",omitempty"`
+		MaxLogsConcurrency      *int           `toml:",omitempty"`
+		MaxLogsReturned         *int           `toml:",omitempty"`
+		RPCEVMTimeout           *time.Duration `toml:",omitempty"`
+		DisablePersonalAPI      *bool          `toml:",omitempty"`
+		FinalityConfirmations   *uint64        `toml:",omitempty"`
 		EnablePreimageRecording *bool
-		DocRoot                 *string `toml:"-"`
+		ShutdownTimeout         *time.Duration `toml:",omitempty"`
+		DocRoot                 *string        `toml:"-"`
 	}
 	var dec Config
 	if err := unmarshal(&dec); err != nil {
@@ -89,6 +187,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.SyncMode != nil {
 		c.SyncMode = *dec.SyncMode
 	}
+	if dec.FastSyncRetries != nil {
+		c.FastSyncRetries = *dec.FastSyncRetries
+	}
 	if dec.LightServ != nil {
 		c.LightServ = *dec.LightServ
 	}
@@ -98,12 +199,39 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.SkipBcVersionCheck != nil {
 		c.SkipBcVersionCheck = *dec.SkipBcVersionCheck
 	}
+	if dec.DatabaseReadOnly != nil {
+		c.DatabaseReadOnly = *dec.DatabaseReadOnly
+	}
 	if dec.DatabaseHandles != nil {
 		c.DatabaseHandles = *dec.DatabaseHandles
 	}
 	if dec.DatabaseCache != nil {
 		c.DatabaseCache = *dec.DatabaseCache
 	}
+	if dec.TriesInMemory != nil {
+		c.TriesInMemory = *dec.TriesInMemory
+	}
+	if dec.TrieGCInterval != nil {
+		c.TrieGCInterval = *dec.TrieGCInterval
+	}
+	if dec.HeaderCache != nil {
+		c.HeaderCache = *dec.HeaderCache
+	}
+	if dec.BodyCache != nil {
+		c.BodyCache = *dec.BodyCache
+	}
+	if dec.ReceiptCache != nil {
+		c.ReceiptCache = *dec.ReceiptCache
+	}
+	if dec.TdCache != nil {
+		c.TdCache = *dec.TdCache
+	}
+	if dec.MaxReorgDepth != nil {
+		c.MaxReorgDepth = *dec.MaxReorgDepth
+	}
+	if dec.StatePrefetch != nil {
+		c.StatePrefetch = *dec.StatePrefetch
+	}
 	if dec.Atlantisbase != nil {
 		c.Atlantisbase = *dec.Atlantisbase
 	}
@@ -116,18 +244,84 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.GasPrice != nil {
 		c.GasPrice = dec.GasPrice
 	}
+	if dec.ExtraDataClientName != nil {
+		c.ExtraDataClientName = *dec.ExtraDataClientName
+	}
+	if dec.MinerGasFloor != nil {
+		c.MinerGasFloor = *dec.MinerGasFloor
+	}
+	if dec.MinerGasCeil != nil {
+		c.MinerGasCeil = *dec.MinerGasCeil
+	}
+	if dec.MinerEmptyBlockPeriod != nil {
+		c.MinerEmptyBlockPeriod = *dec.MinerEmptyBlockPeriod
+	}
+	if dec.Engine != nil {
+		c.Engine = *dec.Engine
+	}
 	if dec.Ethash != nil {
 		c.Ethash = *dec.Ethash
 	}
 	if dec.TxPool != nil {
 		c.TxPool = *dec.TxPool
 	}
+	if dec.MinAcceptedGasPrice != nil {
+		c.MinAcceptedGasPrice = dec.MinAcceptedGasPrice
+	}
+	if dec.TxBroadcastPeers != nil {
+		c.TxBroadcastPeers = *dec.TxBroadcastPeers
+	}
+	if dec.RequestRateLimit != nil {
+		c.RequestRateLimit = *dec.RequestRateLimit
+	}
+	if dec.RequestBurstLimit != nil {
+		c.RequestBurstLimit = *dec.RequestBurstLimit
+	}
+	if dec.RequestDropLimit != nil {
+		c.RequestDropLimit = *dec.RequestDropLimit
+	}
+	if dec.PeerIdleTimeout != nil {
+		c.PeerIdleTimeout = *dec.PeerIdleTimeout
+	}
+	if dec.MinPeers != nil {
+		c.MinPeers = *dec.MinPeers
+	}
+	if dec.TrustedPeerOnly != nil {
+		c.TrustedPeerOnly = *dec.TrustedPeerOnly
+	}
+	if dec.AllowedPeers != nil {
+		c.AllowedPeers = dec.AllowedPeers
+	}
+	if dec.DeniedPeers != nil {
+		c.DeniedPeers = dec.DeniedPeers
+	}
 	if dec.GPO != nil {
 		c.GPO = *dec.GPO
 	}
+	if dec.BloomServiceThreads != nil {
+		c.BloomServiceThreads = *dec.BloomServiceThreads
+	}
+	if dec.MaxLogsConcurrency != nil {
+		c.MaxLogsConcurrency = *dec.MaxLogsConcurrency
+	}
+	if dec.MaxLogsReturned != nil {
+		c.MaxLogsReturned = *dec.MaxLogsReturned
+	}
+	if dec.RPCEVMTimeout != nil {
+		c.RPCEVMTimeout = *dec.RPCEVMTimeout
+	}
+	if dec.DisablePersonalAPI != nil {
+		c.DisablePersonalAPI = *dec.DisablePersonalAPI
+	}
+	if dec.FinalityConfirmations != nil {
+		c.FinalityConfirmations = *dec.FinalityConfirmations
+	}
 	if dec.EnablePreimageRecording != nil {
 		c.EnablePreimageRecording = *dec.EnablePreimageRecording
 	}
+	if dec.ShutdownTimeout != nil {
+		c.ShutdownTimeout = *dec.ShutdownTimeout
+	}
 	if dec.DocRoot != nil {
 		c.DocRoot = *dec.DocRoot
 	}
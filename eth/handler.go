@@ -69,11 +69,29 @@ type ProtocolManager struct {
 	fastSync  uint32 // Flag whather fast sync is enabled (gets disabled if we already have blocks)
 	acceptTxs uint32 // Flag whather we're considered synchronised (enables transaction processing)
 
+	fastSyncRetries  int    // Failed fast sync attempts after which to fall back to full sync, zero for never
+	fastSyncFailures uint32 // Number of consecutive failed fast sync attempts (atomic access)
+	fastSyncFallback uint32 // Flag whather fast sync was abandoned in favour of full sync (atomic access)
+
 	txpool      txPool
 	blockchain  *core.BlockChain
 	chainconfig *params.ChainConfig
 	maxPeers    int
 
+	txBroadcastPeers int // Number of peers to push each transaction to, zero for all
+
+	requestRate      float64 // Requests per second allowed per peer and request type, zero for unlimited
+	requestBurst     int     // Requests allowed in a burst per peer and request type
+	requestDropLimit int     // Dropped requests after which a peer is disconnected, zero for never
+
+	peerIdleTimeout time.Duration // Time without inbound messages after which a peer is dropped, zero for never
+
+	trustedPeerOnly bool                          // Whether only allowed or p2p trusted peers may connect
+	allowedPeers    map[discover.NodeID]struct{}  // Peers accepted even if trustedPeerOnly is set
+	deniedPeers     map[discover.NodeID]struct{}  // Peers always rejected during the handshake
+	bannedPeers     map[discover.NodeID]time.Time // Peers temporarily rejected, mapped to their ban expiry
+	banLock         sync.Mutex                    // Protects the temporary ban list
+
 	downloader *downloader.Downloader
 	fetcher    *fetcher.Fetcher
 	peers      *peerSet
@@ -111,6 +129,7 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		noMorePeers: make(chan struct{}),
 		txsyncCh:    make(chan *txsync),
 		quitSync:    make(chan struct{}),
+		bannedPeers: make(map[discover.NodeID]time.Time),
 	}
 	// Figure out whather to allow fast sync or not
 	if mode == downloader.FastSync && blockchain.CurrentBlock().NumberU64() > 0 {
@@ -181,6 +200,73 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 	return manager, nil
 }
 
+// setPeerFilter configures the application level peer filter enforced before the
+// ath handshake.
+func (pm *ProtocolManager) setPeerFilter(trustedOnly bool, allowed, denied []discover.NodeID) {
+	pm.trustedPeerOnly = trustedOnly
+	pm.allowedPeers = make(map[discover.NodeID]struct{}, len(allowed))
+	for _, id := range allowed {
+		pm.allowedPeers[id] = struct{}{}
+	}
+	pm.deniedPeers = make(map[discover.NodeID]struct{}, len(denied))
+	for _, id := range denied {
+		pm.deniedPeers[id] = struct{}{}
+	}
+}
+
+// peerAllowed checks whether the remote peer passes the application level filter.
+func (pm *ProtocolManager) peerAllowed(p *peer) bool {
+	id := p.Peer.ID()
+	if _, ok := pm.deniedPeers[id]; ok {
+		return false
+	}
+	if pm.peerBanned(id) {
+		return false
+	}
+	if !pm.trustedPeerOnly {
+		return true
+	}
+	if _, ok := pm.allowedPeers[id]; ok {
+		return true
+	}
+	return p.Peer.Info().Network.Trusted
+}
+
+// banPeer drops the peer with the given node id and rejects any reconnection
+// attempt until the ban duration elapses. Expired bans of other peers are pruned
+// to keep the ban list from growing indefinitely.
+func (pm *ProtocolManager) banPeer(id discover.NodeID, duration time.Duration) {
+	pm.banLock.Lock()
+	now := time.Now()
+	for banned, expiry := range pm.bannedPeers {
+		if now.After(expiry) {
+			delete(pm.bannedPeers, banned)
+		}
+	}
+	pm.bannedPeers[id] = now.Add(duration)
+	pm.banLock.Unlock()
+
+	log.Debug("Banning Atlantis peer", "peer", id, "duration", duration)
+	pm.removePeer(fmt.Sprintf("%x", id[:8]))
+}
+
+// peerBanned checks whether the node id is temporarily banned, forgetting the
+// ban if it already expired.
+func (pm *ProtocolManager) peerBanned(id discover.NodeID) bool {
+	pm.banLock.Lock()
+	defer pm.banLock.Unlock()
+
+	expiry, ok := pm.bannedPeers[id]
+	if !ok {
+		return false
+	}
+	if time.Now().After(expiry) {
+		delete(pm.bannedPeers, id)
+		return false
+	}
+	return true
+}
+
 func (pm *ProtocolManager) removePeer(id string) {
 	// Short circuit if the peer was already removed
 	peer := pm.peers.Peer(id)
@@ -243,12 +329,21 @@ func (pm *ProtocolManager) Stop() {
 }
 
 func (pm *ProtocolManager) newPeer(pv int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
-	return newPeer(pv, p, newMeteredMsgWriter(rw))
+	athPeer := newPeer(pv, p, newMeteredMsgWriter(rw))
+	if pm.requestRate > 0 {
+		athPeer.limiter = newRequestLimiter(pm.requestRate, pm.requestBurst)
+	}
+	return athPeer
 }
 
 // handle is the callback invoked to manage the life cycle of an ath peer. When
 // this function terminates, the peer is disconnected.
 func (pm *ProtocolManager) handle(p *peer) error {
+	// Reject peers filtered out at the application level
+	if !pm.peerAllowed(p) {
+		p.Log().Debug("Atlantis peer rejected by filter")
+		return p2p.DiscUselessPeer
+	}
 	// Ignore maxPeers if this is a trusted peer
 	if pm.peers.Len() >= pm.maxPeers && !p.Peer.Info().Network.Trusted {
 		return p2p.DiscTooManyPeers
@@ -304,12 +399,24 @@ func (pm *ProtocolManager) handle(p *peer) error {
 			}
 		}()
 	}
+	// Start a timer to disconnect the peer if it stays silent for too long
+	if pm.peerIdleTimeout > 0 {
+		p.idleDrop = time.AfterFunc(pm.peerIdleTimeout, func() {
+			p.Log().Debug("Atlantis peer idle, dropping", "timeout", pm.peerIdleTimeout)
+			idleDropCounter.Inc(1)
+			pm.removePeer(p.id)
+		})
+		defer p.idleDrop.Stop()
+	}
 	// main loop. handle incoming messages.
 	for {
 		if err := pm.handleMsg(p); err != nil {
 			p.Log().Debug("Atlantis message handling failed", "err", err)
 			return err
 		}
+		if p.idleDrop != nil {
+			p.idleDrop.Reset(pm.peerIdleTimeout)
+		}
 	}
 }
 
@@ -326,6 +433,15 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 	}
 	defer msg.Discard()
 
+	// Drop requests exceeding the peer's rate limit, disconnecting abusive peers
+	if p.limiter != nil && !p.limiter.allow(msg.Code, time.Now()) {
+		rateLimitDropCounter.Inc(1)
+		if pm.requestDropLimit > 0 && p.limiter.drops >= pm.requestDropLimit {
+			return errResp(ErrRequestRateExceeded, "%d requests dropped", p.limiter.drops)
+		}
+		p.Log().Trace("Dropped rate limited request", "code", msg.Code)
+		return nil
+	}
 	// Handle the message depending on its contents
 	switch {
 	case msg.Code == StatusMsg:
@@ -680,7 +796,13 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			}
 			p.MarkTransaction(tx.Hash())
 		}
-		pm.txpool.AddRemotes(txs)
+		duplicates := 0
+		for _, err := range pm.txpool.AddRemotes(txs) {
+			if err == core.ErrKnownTransaction {
+				duplicates++
+			}
+		}
+		p.markTxDeliveries(len(txs), duplicates)
 
 	default:
 		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
@@ -729,9 +851,13 @@ func (pm *ProtocolManager) BroadcastTxs(txs types.Transactions) {
 	// Broadcast transactions to a batch of peers not knowing about it
 	for _, tx := range txs {
 		peers := pm.peers.PeersWithoutTx(tx.Hash())
+		if pm.txBroadcastPeers > 0 && len(peers) > pm.txBroadcastPeers {
+			peers = peers[:pm.txBroadcastPeers]
+		}
 		for _, peer := range peers {
 			txset[peer] = append(txset[peer], tx)
 		}
+		propTxnPeersHistogram.Update(int64(len(peers)))
 		log.Trace("Broadcast transaction", "hash", tx.Hash(), "recipients", len(peers))
 	}
 	// FIXME include this again: peers = peers[:int(math.Sqrt(float64(len(peers))))]
@@ -740,6 +866,24 @@ func (pm *ProtocolManager) BroadcastTxs(txs types.Transactions) {
 	}
 }
 
+// RebroadcastTx sends a transaction to all peers again, including those that are
+// believed to already know it, returning the number of peers that accepted it
+// into their broadcast queues.
+func (pm *ProtocolManager) RebroadcastTx(tx *types.Transaction) int {
+	peers := pm.peers.AllPeers()
+	if pm.txBroadcastPeers > 0 && len(peers) > pm.txBroadcastPeers {
+		peers = peers[:pm.txBroadcastPeers]
+	}
+	sent := 0
+	for _, peer := range peers {
+		if peer.AsyncSendTransactions(types.Transactions{tx}) {
+			sent++
+		}
+	}
+	log.Debug("Rebroadcast transaction", "hash", tx.Hash(), "recipients", sent)
+	return sent
+}
+
 // Mined broadcast loop
 func (pm *ProtocolManager) minedBroadcastLoop() {
 	// automatically stops if unsubscribe
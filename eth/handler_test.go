@@ -34,6 +34,7 @@ import (
 	"github.com/athereum/go-athereum/athdb"
 	"github.com/athereum/go-athereum/event"
 	"github.com/athereum/go-athereum/p2p"
+	"github.com/athereum/go-athereum/p2p/discover"
 	"github.com/athereum/go-athereum/params"
 )
 
@@ -520,3 +521,30 @@ func testDAOChallenge(t *testing.T, localForked, remoteForked bool, timeout bool
 		}
 	}
 }
+
+// Tests that rebroadcasting a transaction only counts the peers that accepted it
+// into their broadcast queues, not the ones dropping it due to a full queue.
+func TestRebroadcastTxFullQueue(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	// Inject the peers directly to avoid their broadcast loops draining the queues
+	idle := pm.newPeer(ath63, p2p.NewPeer(discover.NodeID{0x01}, "idle", nil), nil)
+	busy := pm.newPeer(ath63, p2p.NewPeer(discover.NodeID{0x02}, "busy", nil), nil)
+	for i := 0; i < maxQueuedTxs; i++ {
+		busy.queuedTxs <- nil
+	}
+	pm.peers.peers[idle.id] = idle
+	pm.peers.peers[busy.id] = busy
+
+	tx := types.NewTransaction(0, common.Address{}, new(big.Int), params.TxGas, new(big.Int), nil)
+	if sent := pm.RebroadcastTx(tx); sent != 1 {
+		t.Fatalf("recipient count mismatch: have %d, want %d", sent, 1)
+	}
+	if !idle.knownTxs.Has(tx.Hash()) {
+		t.Errorf("transaction not queued to the idle peer")
+	}
+	if busy.knownTxs.Has(tx.Hash()) {
+		t.Errorf("transaction marked known by the busy peer")
+	}
+}
@@ -17,6 +17,8 @@
 package ath
 
 import (
+	"sync"
+
 	"github.com/athereum/go-athereum/metrics"
 	"github.com/athereum/go-athereum/p2p"
 )
@@ -54,13 +56,67 @@ var (
 	miscInTrafficMeter        = metrics.NewRegisteredMeter("ath/misc/in/traffic", nil)
 	miscOutPacketsMeter       = metrics.NewRegisteredMeter("ath/misc/out/packets", nil)
 	miscOutTrafficMeter       = metrics.NewRegisteredMeter("ath/misc/out/traffic", nil)
+
+	propTxnPeersHistogram = metrics.NewRegisteredHistogram("ath/prop/txns/peers", nil, metrics.NewExpDecaySample(1028, 0.015))
+
+	rateLimitDropCounter = metrics.NewRegisteredCounter("ath/protocol/ratelimit/drops", nil)
+	idleDropCounter      = metrics.NewRegisteredCounter("ath/protocol/idle/drops", nil)
 )
 
+// TrafficStats is the amount of data exchanged with a single peer within one
+// message category.
+type TrafficStats struct {
+	Ingress uint64 `json:"ingress"` // Bytes received from the peer
+	Egress  uint64 `json:"egress"`  // Bytes sent to the peer
+}
+
+// peerTraffic accumulates the data traffic exchanged with a single peer, broken
+// out by message category.
+type peerTraffic struct {
+	stats map[string]*TrafficStats
+	lock  sync.Mutex
+}
+
+// newPeerTraffic creates an empty per-peer traffic accumulator.
+func newPeerTraffic() *peerTraffic {
+	return &peerTraffic{stats: make(map[string]*TrafficStats)}
+}
+
+// mark accounts a single message of the given category and size.
+func (t *peerTraffic) mark(category string, ingress bool, size uint32) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	stats, ok := t.stats[category]
+	if !ok {
+		stats = new(TrafficStats)
+		t.stats[category] = stats
+	}
+	if ingress {
+		stats.Ingress += uint64(size)
+	} else {
+		stats.Egress += uint64(size)
+	}
+}
+
+// snapshot returns a copy of the accumulated traffic statistics.
+func (t *peerTraffic) snapshot() map[string]TrafficStats {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	stats := make(map[string]TrafficStats, len(t.stats))
+	for category, traffic := range t.stats {
+		stats[category] = *traffic
+	}
+	return stats
+}
+
 // meteredMsgReadWriter is a wrapper around a p2p.MsgReadWriter, capable of
 // accumulating the above defined metrics based on the data stream contents.
 type meteredMsgReadWriter struct {
-	p2p.MsgReadWriter     // Wrapped message stream to meter
-	version           int // Protocol version to select correct meters
+	p2p.MsgReadWriter              // Wrapped message stream to meter
+	version           int          // Protocol version to select correct meters
+	traffic           *peerTraffic // Traffic accumulated with this particular peer
 }
 
 // newMeteredMsgWriter wraps a p2p MsgReadWriter with metering support. If the
@@ -69,7 +125,7 @@ func newMeteredMsgWriter(rw p2p.MsgReadWriter) p2p.MsgReadWriter {
 	if !metrics.Enabled {
 		return rw
 	}
-	return &meteredMsgReadWriter{MsgReadWriter: rw}
+	return &meteredMsgReadWriter{MsgReadWriter: rw, traffic: newPeerTraffic()}
 }
 
 // Init sets the protocol version used by the stream to know which meters to
@@ -85,54 +141,56 @@ func (rw *meteredMsgReadWriter) ReadMsg() (p2p.Msg, error) {
 		return msg, err
 	}
 	// Account for the data traffic
-	packets, traffic := miscInPacketsMeter, miscInTrafficMeter
+	packets, traffic, category := miscInPacketsMeter, miscInTrafficMeter, "misc"
 	switch {
 	case msg.Code == BlockHeadersMsg:
-		packets, traffic = reqHeaderInPacketsMeter, reqHeaderInTrafficMeter
+		packets, traffic, category = reqHeaderInPacketsMeter, reqHeaderInTrafficMeter, "headers"
 	case msg.Code == BlockBodiesMsg:
-		packets, traffic = reqBodyInPacketsMeter, reqBodyInTrafficMeter
+		packets, traffic, category = reqBodyInPacketsMeter, reqBodyInTrafficMeter, "bodies"
 
 	case rw.version >= ath63 && msg.Code == NodeDataMsg:
-		packets, traffic = reqStateInPacketsMeter, reqStateInTrafficMeter
+		packets, traffic, category = reqStateInPacketsMeter, reqStateInTrafficMeter, "states"
 	case rw.version >= ath63 && msg.Code == ReceiptsMsg:
-		packets, traffic = reqReceiptInPacketsMeter, reqReceiptInTrafficMeter
+		packets, traffic, category = reqReceiptInPacketsMeter, reqReceiptInTrafficMeter, "receipts"
 
 	case msg.Code == NewBlockHashesMsg:
-		packets, traffic = propHashInPacketsMeter, propHashInTrafficMeter
+		packets, traffic, category = propHashInPacketsMeter, propHashInTrafficMeter, "hashes"
 	case msg.Code == NewBlockMsg:
-		packets, traffic = propBlockInPacketsMeter, propBlockInTrafficMeter
+		packets, traffic, category = propBlockInPacketsMeter, propBlockInTrafficMeter, "blocks"
 	case msg.Code == TxMsg:
-		packets, traffic = propTxnInPacketsMeter, propTxnInTrafficMeter
+		packets, traffic, category = propTxnInPacketsMeter, propTxnInTrafficMeter, "txns"
 	}
 	packets.Mark(1)
 	traffic.Mark(int64(msg.Size))
+	rw.traffic.mark(category, true, msg.Size)
 
 	return msg, err
 }
 
 func (rw *meteredMsgReadWriter) WriteMsg(msg p2p.Msg) error {
 	// Account for the data traffic
-	packets, traffic := miscOutPacketsMeter, miscOutTrafficMeter
+	packets, traffic, category := miscOutPacketsMeter, miscOutTrafficMeter, "misc"
 	switch {
 	case msg.Code == BlockHeadersMsg:
-		packets, traffic = reqHeaderOutPacketsMeter, reqHeaderOutTrafficMeter
+		packets, traffic, category = reqHeaderOutPacketsMeter, reqHeaderOutTrafficMeter, "headers"
 	case msg.Code == BlockBodiesMsg:
-		packets, traffic = reqBodyOutPacketsMeter, reqBodyOutTrafficMeter
+		packets, traffic, category = reqBodyOutPacketsMeter, reqBodyOutTrafficMeter, "bodies"
 
 	case rw.version >= ath63 && msg.Code == NodeDataMsg:
-		packets, traffic = reqStateOutPacketsMeter, reqStateOutTrafficMeter
+		packets, traffic, category = reqStateOutPacketsMeter, reqStateOutTrafficMeter, "states"
 	case rw.version >= ath63 && msg.Code == ReceiptsMsg:
-		packets, traffic = reqReceiptOutPacketsMeter, reqReceiptOutTrafficMeter
+		packets, traffic, category = reqReceiptOutPacketsMeter, reqReceiptOutTrafficMeter, "receipts"
 
 	case msg.Code == NewBlockHashesMsg:
-		packets, traffic = propHashOutPacketsMeter, propHashOutTrafficMeter
+		packets, traffic, category = propHashOutPacketsMeter, propHashOutTrafficMeter, "hashes"
 	case msg.Code == NewBlockMsg:
-		packets, traffic = propBlockOutPacketsMeter, propBlockOutTrafficMeter
+		packets, traffic, category = propBlockOutPacketsMeter, propBlockOutTrafficMeter, "blocks"
 	case msg.Code == TxMsg:
-		packets, traffic = propTxnOutPacketsMeter, propTxnOutTrafficMeter
+		packets, traffic, category = propTxnOutPacketsMeter, propTxnOutTrafficMeter, "txns"
 	}
 	packets.Mark(1)
 	traffic.Mark(int64(msg.Size))
+	rw.traffic.mark(category, false, msg.Size)
 
 	// Send the packet to the p2p layer
 	return rw.MsgReadWriter.WriteMsg(msg)
@@ -0,0 +1,47 @@
+// Copyright 2018 The go-athereum Authors
+// This file is part of the go-athereum library.
+//
+// The go-athereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-athereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-athereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ath
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Tests that per-peer traffic is accumulated separately for each message
+// category and direction.
+func TestPeerTrafficAccounting(t *testing.T) {
+	traffic := newPeerTraffic()
+
+	traffic.mark("txns", true, 100)
+	traffic.mark("txns", true, 50)
+	traffic.mark("txns", false, 10)
+	traffic.mark("headers", false, 512)
+
+	want := map[string]TrafficStats{
+		"txns":    {Ingress: 150, Egress: 10},
+		"headers": {Egress: 512},
+	}
+	stats := traffic.snapshot()
+	if !reflect.DeepEqual(stats, want) {
+		t.Fatalf("traffic mismatch: have %+v, want %+v", stats, want)
+	}
+	// Ensure the snapshot is detached from the live counters
+	traffic.mark("txns", true, 1)
+	if stats["txns"].Ingress != 150 {
+		t.Fatalf("snapshot modified by later traffic: have %d, want 150", stats["txns"].Ingress)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"math/big"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/athereum/go-athereum/common"
@@ -64,6 +65,8 @@ type PeerInfo struct {
 	Version    int      `json:"version"`    // Atlantis protocol version negotiated
 	Difficulty *big.Int `json:"difficulty"` // Total difficulty of the peer's blockchain
 	Head       string   `json:"head"`       // SHA3 hash of the peer's best owned block
+
+	DuplicateTxRatio float64 `json:"duplicateTxRatio"` // Fraction of the peer's transactions already known to the pool
 }
 
 // propEvent is a block propagation, waiting for its turn in the broadcast queue.
@@ -78,8 +81,15 @@ type peer struct {
 	*p2p.Peer
 	rw p2p.MsgReadWriter
 
+	traffic *peerTraffic    // Per-category traffic accounting (nil if metrics are disabled)
+	limiter *requestLimiter // Per message type request rate limiter (nil if unlimited)
+
+	txsReceived  uint64 // Number of transactions received from the peer (atomic access)
+	txsDuplicate uint64 // Number of received transactions already known to the pool (atomic access)
+
 	version  int         // Protocol version negotiated
 	forkDrop *time.Timer // Timed connection dropper if forks aren't validated in time
+	idleDrop *time.Timer // Timed connection dropper if no messages are received in time
 
 	head common.Hash
 	td   *big.Int
@@ -94,8 +104,13 @@ type peer struct {
 }
 
 func newPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
+	var traffic *peerTraffic
+	if metered, ok := rw.(*meteredMsgReadWriter); ok {
+		traffic = metered.traffic
+	}
 	return &peer{
 		Peer:        p,
+		traffic:     traffic,
 		rw:          rw,
 		version:     version,
 		id:          fmt.Sprintf("%x", p.ID().Bytes()[:8]),
@@ -148,12 +163,30 @@ func (p *peer) Info() *PeerInfo {
 	hash, td := p.Head()
 
 	return &PeerInfo{
-		Version:    p.version,
-		Difficulty: td,
-		Head:       hash.Hex(),
+		Version:          p.version,
+		Difficulty:       td,
+		Head:             hash.Hex(),
+		DuplicateTxRatio: p.duplicateTxRatio(),
 	}
 }
 
+// markTxDeliveries accounts for a batch of transactions received from the peer,
+// duplicates of which were already known to the pool.
+func (p *peer) markTxDeliveries(received, duplicates int) {
+	atomic.AddUint64(&p.txsReceived, uint64(received))
+	atomic.AddUint64(&p.txsDuplicate, uint64(duplicates))
+}
+
+// duplicateTxRatio returns the fraction of transactions received from the peer
+// that were already known to the pool.
+func (p *peer) duplicateTxRatio() float64 {
+	received := atomic.LoadUint64(&p.txsReceived)
+	if received == 0 {
+		return 0
+	}
+	return float64(atomic.LoadUint64(&p.txsDuplicate)) / float64(received)
+}
+
 // Head retrieves a copy of the current head hash and total difficulty of the
 // peer.
 func (p *peer) Head() (hash common.Hash, td *big.Int) {
@@ -203,15 +236,18 @@ func (p *peer) SendTransactions(txs types.Transactions) error {
 }
 
 // AsyncSendTransactions queues list of transactions propagation to a remote
-// peer. If the peer's broadcast queue is full, the event is silently dropped.
-func (p *peer) AsyncSendTransactions(txs []*types.Transaction) {
+// peer. If the peer's broadcast queue is full, the event is silently dropped
+// and false is returned.
+func (p *peer) AsyncSendTransactions(txs []*types.Transaction) bool {
 	select {
 	case p.queuedTxs <- txs:
 		for _, tx := range txs {
 			p.knownTxs.Add(tx.Hash())
 		}
+		return true
 	default:
 		p.Log().Debug("Dropping transaction propagation", "count", len(txs))
+		return false
 	}
 }
 
@@ -462,6 +498,34 @@ func (ps *peerSet) Len() int {
 	return len(ps.peers)
 }
 
+// Traffic retrieves the data traffic exchanged with each of the active peers,
+// keyed by node ID and broken out by message category. Peers without traffic
+// accounting are omitted.
+func (ps *peerSet) Traffic() map[string]map[string]TrafficStats {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	traffic := make(map[string]map[string]TrafficStats, len(ps.peers))
+	for _, p := range ps.peers {
+		if p.traffic != nil {
+			traffic[p.ID().String()] = p.traffic.snapshot()
+		}
+	}
+	return traffic
+}
+
+// AllPeers retrieves a flat list of all the peers within the set.
+func (ps *peerSet) AllPeers() []*peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	list := make([]*peer, 0, len(ps.peers))
+	for _, p := range ps.peers {
+		list = append(list, p)
+	}
+	return list
+}
+
 // PeersWithoutBlock retrieves a list of peers that do not have a given block in
 // their set of known hashes.
 func (ps *peerSet) PeersWithoutBlock(hash common.Hash) []*peer {
@@ -27,6 +27,7 @@ import (
 	"github.com/athereum/go-athereum/crypto"
 	"github.com/athereum/go-athereum/ath/downloader"
 	"github.com/athereum/go-athereum/p2p"
+	"github.com/athereum/go-athereum/p2p/discover"
 	"github.com/athereum/go-athereum/rlp"
 )
 
@@ -178,6 +179,51 @@ func testSendTransactions(t *testing.T, protocol int) {
 	wg.Wait()
 }
 
+// Tests that new transactions are only pushed to the configured number of peers.
+func TestBroadcastTxsFanOut(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	pm.txBroadcastPeers = 1
+	defer pm.Stop()
+
+	var peers []*testPeer
+	for i := 0; i < 3; i++ {
+		p, _ := newTestPeer(fmt.Sprintf("peer #%d", i), ath63, pm, true)
+		defer p.close()
+		peers = append(peers, p)
+	}
+	for start := time.Now(); pm.peers.Len() < len(peers); {
+		if time.Since(start) > time.Second {
+			t.Fatalf("peers not registered: have %d, want %d", pm.peers.Len(), len(peers))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	pm.BroadcastTxs(types.Transactions{newTestTransaction(testAccount, 0, 0)})
+
+	received := make(chan bool, len(peers))
+	for _, p := range peers {
+		go func(p *testPeer) {
+			msg, err := p.app.ReadMsg()
+			received <- err == nil && msg.Code == TxMsg
+		}(p)
+	}
+	pushed := 0
+	timeout := time.After(500 * time.Millisecond)
+loop:
+	for i := 0; i < len(peers); i++ {
+		select {
+		case ok := <-received:
+			if ok {
+				pushed++
+			}
+		case <-timeout:
+			break loop
+		}
+	}
+	if pushed != 1 {
+		t.Fatalf("transaction fan-out mismatch: have %d peers, want %d", pushed, 1)
+	}
+}
+
 // Tests that the custom union field encoder and decoder works correctly.
 func TestGetBlockHeadersDataEncodeDecode(t *testing.T) {
 	// Create a "random" hash for testing
@@ -221,3 +267,110 @@ func TestGetBlockHeadersDataEncodeDecode(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the application level peer filter rejects denied peers and, if only
+// trusted peers are accepted, any peer not explicitly allowed.
+func TestPeerFilter(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	var (
+		allowed = discover.NodeID{0x01}
+		denied  = discover.NodeID{0x02}
+		unknown = discover.NodeID{0x03}
+	)
+	newPeer := func(id discover.NodeID) *peer {
+		return pm.newPeer(ath63, p2p.NewPeer(id, "filtered", nil), nil)
+	}
+	pm.setPeerFilter(false, nil, []discover.NodeID{denied})
+	if !pm.peerAllowed(newPeer(unknown)) {
+		t.Errorf("unknown peer rejected without trusted-only mode")
+	}
+	if pm.peerAllowed(newPeer(denied)) {
+		t.Errorf("denied peer accepted")
+	}
+	pm.setPeerFilter(true, []discover.NodeID{allowed, denied}, []discover.NodeID{denied})
+	if !pm.peerAllowed(newPeer(allowed)) {
+		t.Errorf("allowed peer rejected")
+	}
+	if pm.peerAllowed(newPeer(unknown)) {
+		t.Errorf("unknown peer accepted in trusted-only mode")
+	}
+	if pm.peerAllowed(newPeer(denied)) {
+		t.Errorf("denied peer accepted despite being allowed")
+	}
+	// Filtered peers must be dropped before the handshake
+	p, errc := newTestPeer("unknown", ath63, pm, false)
+	defer p.close()
+
+	select {
+	case err := <-errc:
+		if err != p2p.DiscUselessPeer {
+			t.Fatalf("disconnect reason mismatch: have %v, want %v", err, p2p.DiscUselessPeer)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("filtered peer not disconnected")
+	}
+}
+
+// Tests that banned peers are rejected until their ban expires.
+func TestPeerBan(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	var (
+		banned  = discover.NodeID{0x01}
+		expired = discover.NodeID{0x02}
+	)
+	newPeer := func(id discover.NodeID) *peer {
+		return pm.newPeer(ath63, p2p.NewPeer(id, "banned", nil), nil)
+	}
+	pm.banPeer(banned, time.Minute)
+	pm.banPeer(expired, -time.Second)
+
+	if pm.peerAllowed(newPeer(banned)) {
+		t.Errorf("banned peer accepted")
+	}
+	if !pm.peerAllowed(newPeer(expired)) {
+		t.Errorf("peer with expired ban rejected")
+	}
+	if _, ok := pm.bannedPeers[expired]; ok {
+		t.Errorf("expired ban not forgotten")
+	}
+	// Ensure expired bans are pruned even if the peer never reconnects
+	stale := discover.NodeID{0x03}
+	pm.banPeer(stale, -time.Second)
+	pm.banPeer(discover.NodeID{0x04}, time.Minute)
+
+	if _, ok := pm.bannedPeers[stale]; ok {
+		t.Errorf("expired ban not pruned")
+	}
+	if len(pm.bannedPeers) != 2 {
+		t.Errorf("ban count mismatch: have %d, want %d", len(pm.bannedPeers), 2)
+	}
+}
+
+// Tests that peers not sending any messages within the idle timeout are dropped.
+func TestPeerIdleTimeout(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	pm.peerIdleTimeout = 100 * time.Millisecond
+
+	p, _ := newTestPeer("idle", ath63, pm, true)
+	defer p.close()
+
+	// Wait for the peer to be registered, then for it to be dropped
+	for i := 0; i < 100 && pm.peers.Peer(p.id) == nil; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if pm.peers.Peer(p.id) == nil {
+		t.Fatalf("peer not registered")
+	}
+	for i := 0; i < 100 && pm.peers.Peer(p.id) != nil; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if pm.peers.Peer(p.id) != nil {
+		t.Fatalf("idle peer not dropped")
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2018 The go-athereum Authors
+// This file is part of the go-athereum library.
+//
+// The go-athereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-athereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-athereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ath
+
+import (
+	"time"
+)
+
+// rateLimitedMsgs is the set of request messages subject to per peer rate limits,
+// as serving them is considerably more expensive than receiving them.
+var rateLimitedMsgs = map[uint64]bool{
+	GetBlockHeadersMsg: true,
+	GetBlockBodiesMsg:  true,
+	GetNodeDataMsg:     true,
+	GetReceiptsMsg:     true,
+}
+
+// tokenBucket is a classic token bucket, refilled continuously at a fixed rate
+// up to its burst capacity.
+type tokenBucket struct {
+	tokens float64   // Number of tokens currently available
+	last   time.Time // Time of the last refill
+}
+
+// requestLimiter rate limits the requests of a single peer, maintaining a token
+// bucket for each rate limited message code. It is not safe for concurrent use,
+// it's meant to be used from the peer's message handling loop only.
+type requestLimiter struct {
+	rate    float64 // Number of requests allowed per second and message code
+	burst   float64 // Number of requests allowed in a burst per message code
+	buckets map[uint64]*tokenBucket
+	drops   int // Number of requests dropped so far
+}
+
+// newRequestLimiter creates a per peer request limiter. A non-positive burst is
+// raised to the smallest sensible value.
+func newRequestLimiter(rate float64, burst int) *requestLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &requestLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[uint64]*tokenBucket),
+	}
+}
+
+// allow reports whether a message with the given code may be processed at the
+// given time, consuming a token if so. Messages not subject to rate limiting are
+// always allowed.
+func (l *requestLimiter) allow(code uint64, now time.Time) bool {
+	if !rateLimitedMsgs[code] {
+		return true
+	}
+	bucket := l.buckets[code]
+	if bucket == nil {
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[code] = bucket
+	}
+	if elapsed := now.Sub(bucket.last); elapsed > 0 {
+		bucket.tokens += elapsed.Seconds() * l.rate
+		if bucket.tokens > l.burst {
+			bucket.tokens = l.burst
+		}
+		bucket.last = now
+	}
+	if bucket.tokens < 1 {
+		l.drops++
+		return false
+	}
+	bucket.tokens--
+	return true
+}
@@ -0,0 +1,67 @@
+// Copyright 2018 The go-athereum Authors
+// This file is part of the go-athereum library.
+//
+// The go-athereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-athereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-athereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ath
+
+import (
+	"testing"
+	"time"
+)
+
+// Tests that the request limiter allows bursts up to its capacity, refills its
+// buckets over time and tracks each message type separately.
+func TestRequestLimiter(t *testing.T) {
+	var (
+		limiter = newRequestLimiter(2, 3)
+		start   = time.Now()
+	)
+	// The initial burst is allowed, anything beyond dropped
+	for i := 0; i < 3; i++ {
+		if !limiter.allow(GetNodeDataMsg, start) {
+			t.Fatalf("request %d within burst dropped", i)
+		}
+	}
+	if limiter.allow(GetNodeDataMsg, start) {
+		t.Fatalf("request beyond burst allowed")
+	}
+	// Other request types and non-request messages are unaffected
+	if !limiter.allow(GetBlockHeadersMsg, start) {
+		t.Fatalf("request of a different type dropped")
+	}
+	for i := 0; i < 10; i++ {
+		if !limiter.allow(TxMsg, start) {
+			t.Fatalf("non-request message %d dropped", i)
+		}
+	}
+	// Half a second refills a single token at two requests per second
+	later := start.Add(500 * time.Millisecond)
+	if !limiter.allow(GetNodeDataMsg, later) {
+		t.Fatalf("request after refill dropped")
+	}
+	if limiter.allow(GetNodeDataMsg, later) {
+		t.Fatalf("request beyond refill allowed")
+	}
+	if limiter.drops != 2 {
+		t.Fatalf("drop count mismatch: have %d, want %d", limiter.drops, 2)
+	}
+	// Refills are capped at the burst size
+	if !limiter.allow(GetNodeDataMsg, later.Add(time.Hour)) {
+		t.Fatalf("request after long idle dropped")
+	}
+	if limiter.buckets[GetNodeDataMsg].tokens != 2 {
+		t.Fatalf("bucket overfilled: have %v tokens, want %v", limiter.buckets[GetNodeDataMsg].tokens, 2)
+	}
+}
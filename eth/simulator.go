@@ -0,0 +1,133 @@
+// Copyright 2018 The go-athereum Authors
+// This file is part of the go-athereum library.
+//
+// The go-athereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-athereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-athereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ath
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/athereum/go-athereum/accounts/abi/bind/backends"
+	"github.com/athereum/go-athereum/common"
+	"github.com/athereum/go-athereum/core"
+	"github.com/athereum/go-athereum/core/state"
+	"github.com/athereum/go-athereum/core/types"
+	"github.com/athereum/go-athereum/crypto"
+	"github.com/athereum/go-athereum/rlp"
+	"github.com/athereum/go-athereum/rpc"
+	"github.com/athereum/go-athereum/trie"
+)
+
+// errMissingPreimage is returned if the state contains an account or storage slot
+// whose hashed key cannot be resolved to the original one.
+var errMissingPreimage = errors.New("missing hash preimage (record them with --vmdebug)")
+
+// NewStateSimulator creates an in-memory simulated backend seeded with a snapshot
+// of the state at the given block, which can be used to dry-run transactions
+// (e.g. replay a failing one) without touching the live chain. The snapshot is
+// loaded into the genesis block of a fresh simulated chain, so block numbers,
+// hashes and the chain configuration differ from the live chain.
+//
+// Note, accounts and storage slots are recovered via their hash preimages, which
+// are only available if preimage recording was enabled when the state was built.
+// An error is returned if any of them is missing or the state was already pruned.
+func (s *Atlantis) NewStateSimulator(blockNr rpc.BlockNumber) (*backends.SimulatedBackend, error) {
+	var header *types.Header
+	if blockNr == rpc.LatestBlockNumber || blockNr == rpc.PendingBlockNumber {
+		header = s.blockchain.CurrentBlock().Header()
+	} else {
+		header = s.blockchain.GetHeaderByNumber(uint64(blockNr))
+	}
+	if header == nil {
+		return nil, fmt.Errorf("block #%d not found", blockNr)
+	}
+	alloc, err := stateAlloc(s.blockchain.StateCache(), header.Root)
+	if err != nil {
+		return nil, fmt.Errorf("state of block #%d not available: %v", header.Number, err)
+	}
+	return backends.NewSimulatedBackend(alloc), nil
+}
+
+// stateAlloc iterates the state trie with the given root, collecting all accounts
+// along with their code and storage into a genesis allocation.
+func stateAlloc(db state.Database, root common.Hash) (core.GenesisAlloc, error) {
+	tr, err := db.OpenTrie(root)
+	if err != nil {
+		return nil, err
+	}
+	alloc := make(core.GenesisAlloc)
+
+	it := trie.NewIterator(tr.NodeIterator(nil))
+	for it.Next() {
+		addr := tr.GetKey(it.Key)
+		if addr == nil {
+			return nil, fmt.Errorf("account %x: %v", it.Key, errMissingPreimage)
+		}
+		var data state.Account
+		if err := rlp.DecodeBytes(it.Value, &data); err != nil {
+			return nil, fmt.Errorf("invalid account %x: %v", addr, err)
+		}
+		addrHash := common.BytesToHash(it.Key)
+
+		var code []byte
+		if !bytes.Equal(data.CodeHash, crypto.Keccak256(nil)) {
+			if code, err = db.ContractCode(addrHash, common.BytesToHash(data.CodeHash)); err != nil {
+				return nil, fmt.Errorf("missing code of account %x: %v", addr, err)
+			}
+		}
+		storage, err := storageAlloc(db, addrHash, data.Root)
+		if err != nil {
+			return nil, fmt.Errorf("account %x: %v", addr, err)
+		}
+		alloc[common.BytesToAddress(addr)] = core.GenesisAccount{
+			Code:    code,
+			Storage: storage,
+			Balance: data.Balance,
+			Nonce:   data.Nonce,
+		}
+	}
+	if it.Err != nil {
+		return nil, it.Err
+	}
+	return alloc, nil
+}
+
+// storageAlloc iterates the storage trie of an account, collecting all the slots.
+func storageAlloc(db state.Database, addrHash, root common.Hash) (map[common.Hash]common.Hash, error) {
+	tr, err := db.OpenStorageTrie(addrHash, root)
+	if err != nil {
+		return nil, err
+	}
+	storage := make(map[common.Hash]common.Hash)
+
+	it := trie.NewIterator(tr.NodeIterator(nil))
+	for it.Next() {
+		key := tr.GetKey(it.Key)
+		if key == nil {
+			return nil, fmt.Errorf("storage slot %x: %v", it.Key, errMissingPreimage)
+		}
+		var value []byte
+		if err := rlp.DecodeBytes(it.Value, &value); err != nil {
+			return nil, fmt.Errorf("invalid storage slot %x: %v", key, err)
+		}
+		storage[common.BytesToHash(key)] = common.BytesToHash(value)
+	}
+	if it.Err != nil {
+		return nil, it.Err
+	}
+	return storage, nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2018 The go-athereum Authors
+// This file is part of the go-athereum library.
+//
+// The go-athereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-athereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-athereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ath
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/athereum/go-athereum/accounts/abi/bind/backends"
+	"github.com/athereum/go-athereum/athdb"
+	"github.com/athereum/go-athereum/common"
+	"github.com/athereum/go-athereum/core/state"
+)
+
+// newSimulatorState creates a state containing a plain account and a contract
+// with storage, flushed into the given database along with the preimages.
+func newSimulatorState(t *testing.T, db athdb.Database) common.Hash {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+
+	statedb.SetBalance(common.Address{0x01}, big.NewInt(1000))
+	statedb.SetNonce(common.Address{0x01}, 3)
+
+	statedb.SetCode(common.Address{0x02}, []byte{0x60, 0x00})
+	statedb.SetState(common.Address{0x02}, common.Hash{0xaa}, common.Hash{0xbb})
+
+	root, err := statedb.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := statedb.Database().TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to flush state: %v", err)
+	}
+	return root
+}
+
+// Tests that the state is collected from the tries into a genesis allocation the
+// simulated backend can run on.
+func TestStateSimulatorAlloc(t *testing.T) {
+	db := athdb.NewMemDatabase()
+	root := newSimulatorState(t, db)
+
+	alloc, err := stateAlloc(state.NewDatabase(db), root)
+	if err != nil {
+		t.Fatalf("failed to collect state: %v", err)
+	}
+	if len(alloc) != 2 {
+		t.Fatalf("account count mismatch: have %d, want %d", len(alloc), 2)
+	}
+	if account := alloc[common.Address{0x01}]; account.Balance.Int64() != 1000 || account.Nonce != 3 {
+		t.Errorf("account balance/nonce mismatch: have %v/%d, want %v/%d", account.Balance, account.Nonce, 1000, 3)
+	}
+	contract := alloc[common.Address{0x02}]
+	if !bytes.Equal(contract.Code, []byte{0x60, 0x00}) {
+		t.Errorf("contract code mismatch: have %x, want %x", contract.Code, []byte{0x60, 0x00})
+	}
+	if slot := contract.Storage[common.Hash{0xaa}]; slot != (common.Hash{0xbb}) {
+		t.Errorf("contract storage mismatch: have %x, want %x", slot, common.Hash{0xbb})
+	}
+	// Ensure the simulated chain starts out with the collected state
+	sim := backends.NewSimulatedBackend(alloc)
+	if balance, _ := sim.BalanceAt(context.Background(), common.Address{0x01}, nil); balance.Int64() != 1000 {
+		t.Errorf("simulated balance mismatch: have %v, want %v", balance, 1000)
+	}
+	if slot, _ := sim.StorageAt(context.Background(), common.Address{0x02}, common.Hash{0xaa}, nil); !bytes.Equal(slot, common.Hash{0xbb}.Bytes()) {
+		t.Errorf("simulated storage mismatch: have %x, want %x", slot, common.Hash{0xbb})
+	}
+}
+
+// Tests that a state without recorded preimages is rejected instead of silently
+// producing a broken snapshot.
+func TestStateSimulatorMissingPreimages(t *testing.T) {
+	full := athdb.NewMemDatabase()
+	root := newSimulatorState(t, full)
+
+	db := athdb.NewMemDatabase()
+	for _, key := range full.Keys() {
+		if !bytes.HasPrefix(key, []byte("secure-key-")) {
+			value, _ := full.Get(key)
+			db.Put(key, value)
+		}
+	}
+	if _, err := stateAlloc(state.NewDatabase(db), root); err == nil || !strings.Contains(err.Error(), errMissingPreimage.Error()) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errMissingPreimage)
+	}
+}
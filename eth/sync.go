@@ -179,7 +179,7 @@ func (pm *ProtocolManager) synchronise(peer *peer) {
 	if atomic.LoadUint32(&pm.fastSync) == 1 {
 		// Fast sync was explicitly requested, and explicitly granted
 		mode = downloader.FastSync
-	} else if currentBlock.NumberU64() == 0 && pm.blockchain.CurrentFastBlock().NumberU64() > 0 {
+	} else if currentBlock.NumberU64() == 0 && pm.blockchain.CurrentFastBlock().NumberU64() > 0 && atomic.LoadUint32(&pm.fastSyncFallback) == 0 {
 		// The database seems empty as the current block is the genesis. Yet the fast
 		// block is ahead, so fast sync was enabled for this node at a certain point.
 		// The only scenario where this can happen is if the user manually (or via a
@@ -197,9 +197,19 @@ func (pm *ProtocolManager) synchronise(peer *peer) {
 	}
 
 	// Run the sync cycle, and disable fast sync if we've went past the pivot block
+	origin := pm.blockchain.CurrentFastBlock().NumberU64()
 	if err := pm.downloader.Synchronise(peer.id, pHead, pTd, mode); err != nil {
+		if mode == downloader.FastSync {
+			if pm.blockchain.CurrentFastBlock().NumberU64() > origin {
+				// The cycle failed, but the chain did advance, it's not stuck
+				atomic.StoreUint32(&pm.fastSyncFailures, 0)
+			} else if downloader.IsStalled(err) {
+				pm.fastSyncFailed(err)
+			}
+		}
 		return
 	}
+	atomic.StoreUint32(&pm.fastSyncFailures, 0)
 	if atomic.LoadUint32(&pm.fastSync) == 1 {
 		log.Info("Fast sync complete, auto disabling")
 		atomic.StoreUint32(&pm.fastSync, 0)
@@ -215,3 +225,21 @@ func (pm *ProtocolManager) synchronise(peer *peer) {
 		go pm.BroadcastBlock(head, false)
 	}
 }
+
+// fastSyncFailed accounts for a fast sync attempt that stalled without making any
+// progress, falling back to full sync if the configured number of consecutive
+// stalls was reached.
+func (pm *ProtocolManager) fastSyncFailed(err error) {
+	if pm.fastSyncRetries <= 0 {
+		return
+	}
+	failures := atomic.AddUint32(&pm.fastSyncFailures, 1)
+	if failures < uint32(pm.fastSyncRetries) {
+		log.Debug("Fast sync attempt failed", "attempt", failures, "limit", pm.fastSyncRetries, "err", err)
+		return
+	}
+	if atomic.CompareAndSwapUint32(&pm.fastSync, 1, 0) {
+		log.Warn("Fast sync failed repeatedly, falling back to full sync", "attempts", failures, "err", err)
+		atomic.StoreUint32(&pm.fastSyncFallback, 1)
+	}
+}
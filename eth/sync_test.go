@@ -17,6 +17,7 @@
 package ath
 
 import (
+	"errors"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -53,3 +54,25 @@ func TestFastSyncDisabling(t *testing.T) {
 		t.Fatalf("fast sync not disabled after successful synchronisation")
 	}
 }
+
+// Tests that fast sync is abandoned in favour of full sync after the configured
+// number of consecutive failures.
+func TestFastSyncFallback(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FastSync, 0, nil, nil)
+	defer pm.Stop()
+
+	pm.fastSyncRetries = 2
+	failure := errors.New("no pivot")
+
+	pm.fastSyncFailed(failure)
+	if atomic.LoadUint32(&pm.fastSync) == 0 {
+		t.Fatalf("fast sync disabled before reaching the retry limit")
+	}
+	pm.fastSyncFailed(failure)
+	if atomic.LoadUint32(&pm.fastSync) == 1 {
+		t.Fatalf("fast sync not disabled after reaching the retry limit")
+	}
+	if atomic.LoadUint32(&pm.fastSyncFallback) == 0 {
+		t.Fatalf("fast sync fallback not recorded")
+	}
+}
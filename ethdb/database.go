@@ -42,8 +42,9 @@ const (
 var OpenFileLimit = 64
 
 type LDBDatabase struct {
-	fn string      // filename for reporting
-	db *leveldb.DB // LevelDB instance
+	fn       string      // filename for reporting
+	db       *leveldb.DB // LevelDB instance
+	readonly bool        // Whether the database was opened read-only
 
 	compTimeMeter    metrics.Meter // Meter for measuring the total time spent in database compaction
 	compReadMeter    metrics.Meter // Meter for measuring the data read during compaction
@@ -61,6 +62,17 @@ type LDBDatabase struct {
 
 // NewLDBDatabase returns a LevelDB wrapped object.
 func NewLDBDatabase(file string, cache int, handles int) (*LDBDatabase, error) {
+	return newLDBDatabase(file, cache, handles, false)
+}
+
+// NewLDBDatabaseReadOnly returns a LevelDB wrapped object opened in read-only
+// mode. Any write or compaction attempt on the returned database will fail, and
+// no recovery is attempted if the database is found to be corrupted.
+func NewLDBDatabaseReadOnly(file string, cache int, handles int) (*LDBDatabase, error) {
+	return newLDBDatabase(file, cache, handles, true)
+}
+
+func newLDBDatabase(file string, cache int, handles int, readonly bool) (*LDBDatabase, error) {
 	logger := log.New("database", file)
 
 	// Ensure we have some minimal caching and file guarantees
@@ -70,7 +82,7 @@ func NewLDBDatabase(file string, cache int, handles int) (*LDBDatabase, error) {
 	if handles < 16 {
 		handles = 16
 	}
-	logger.Info("Allocated cache and file handles", "cache", cache, "handles", handles)
+	logger.Info("Allocated cache and file handles", "cache", cache, "handles", handles, "readonly", readonly)
 
 	// Open the db and recover any potential corruptions
 	db, err := leveldb.OpenFile(file, &opt.Options{
@@ -78,8 +90,9 @@ func NewLDBDatabase(file string, cache int, handles int) (*LDBDatabase, error) {
 		BlockCacheCapacity:     cache / 2 * opt.MiB,
 		WriteBuffer:            cache / 4 * opt.MiB, // Two of these are used internally
 		Filter:                 filter.NewBloomFilter(10),
+		ReadOnly:               readonly,
 	})
-	if _, corrupted := err.(*errors.ErrCorrupted); corrupted {
+	if _, corrupted := err.(*errors.ErrCorrupted); corrupted && !readonly {
 		db, err = leveldb.RecoverFile(file, nil)
 	}
 	// (Re)check for errors and abort if opening of the db failed
@@ -87,9 +100,10 @@ func NewLDBDatabase(file string, cache int, handles int) (*LDBDatabase, error) {
 		return nil, err
 	}
 	return &LDBDatabase{
-		fn:  file,
-		db:  db,
-		log: logger,
+		fn:       file,
+		db:       db,
+		readonly: readonly,
+		log:      logger,
 	}, nil
 }
 
@@ -98,6 +112,11 @@ func (db *LDBDatabase) Path() string {
 	return db.fn
 }
 
+// ReadOnly reports whether the database was opened read-only.
+func (db *LDBDatabase) ReadOnly() bool {
+	return db.readonly
+}
+
 // Put puts the given key / value to the queue
 func (db *LDBDatabase) Put(key []byte, value []byte) error {
 	return db.db.Put(key, value, nil)
@@ -52,6 +52,43 @@ func TestLDB_PutGet(t *testing.T) {
 	testPutGet(db, t)
 }
 
+func TestLDB_ReadOnly(t *testing.T) {
+	dirname, err := ioutil.TempDir(os.TempDir(), "athdb_test_")
+	if err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	defer os.RemoveAll(dirname)
+
+	db, err := athdb.NewLDBDatabase(dirname, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	if err := db.Put([]byte("foo"), []byte("bar")); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	if athdb.IsReadOnly(db) {
+		t.Fatalf("writable database reported read-only")
+	}
+	db.Close()
+
+	ro, err := athdb.NewLDBDatabaseReadOnly(dirname, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to open read-only database: %v", err)
+	}
+	defer ro.Close()
+
+	if !athdb.IsReadOnly(ro) {
+		t.Fatalf("read-only database reported writable")
+	}
+
+	if data, err := ro.Get([]byte("foo")); err != nil || !bytes.Equal(data, []byte("bar")) {
+		t.Fatalf("get mismatch: have %q (%v), want %q", data, err, "bar")
+	}
+	if err := ro.Put([]byte("foo"), []byte("baz")); err == nil {
+		t.Fatalf("put succeeded on read-only database")
+	}
+}
+
 func TestMemoryDB_PutGet(t *testing.T) {
 	testPutGet(athdb.NewMemDatabase(), t)
 }
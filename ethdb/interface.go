@@ -35,6 +35,15 @@ type Database interface {
 	NewBatch() Batch
 }
 
+// IsReadOnly reports whether the given database was opened read-only, rejecting
+// any writes.
+func IsReadOnly(db Database) bool {
+	ro, ok := db.(interface {
+		ReadOnly() bool
+	})
+	return ok && ro.ReadOnly()
+}
+
 // Batch is a write-only database that commits changes to its host database
 // when Write is called. Batch cannot be used concurrently.
 type Batch interface {
@@ -82,6 +82,45 @@ func (*HandlerT) GcStats() *debug.GCStats {
 	return s
 }
 
+// RuntimeStats is a compact summary of the Go runtime state. Goroutine and heap
+// figures are current, whereas the GC figures are as of the last completed cycle.
+type RuntimeStats struct {
+	Goroutines  int    `json:"goroutines"`
+	HeapAlloc   uint64 `json:"heapAlloc"`   // Bytes of allocated heap objects
+	HeapSys     uint64 `json:"heapSys"`     // Bytes of heap memory obtained from the OS
+	HeapObjects uint64 `json:"heapObjects"` // Number of allocated heap objects
+
+	GCCompleted bool          `json:"gcCompleted"` // Whether any GC cycle completed yet, otherwise GC figures are zero
+	NumGC       uint32        `json:"numGC"`       // Number of completed GC cycles
+	LastGC      time.Time     `json:"lastGC"`      // Time the last GC cycle finished
+	LastPause   time.Duration `json:"lastPause"`   // Stop-the-world pause of the last GC cycle
+	PauseTotal  time.Duration `json:"pauseTotal"`  // Cumulative stop-the-world pause since start
+	NextGC      uint64        `json:"nextGC"`      // Heap size target of the next GC cycle
+}
+
+// RuntimeStats returns the goroutine count and a summary of the heap and GC
+// statistics. It doesn't force a garbage collection.
+func (*HandlerT) RuntimeStats() *RuntimeStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	stats := &RuntimeStats{
+		Goroutines:  runtime.NumGoroutine(),
+		HeapAlloc:   m.HeapAlloc,
+		HeapSys:     m.HeapSys,
+		HeapObjects: m.HeapObjects,
+		GCCompleted: m.NumGC > 0,
+		NumGC:       m.NumGC,
+		PauseTotal:  time.Duration(m.PauseTotalNs),
+		NextGC:      m.NextGC,
+	}
+	if stats.GCCompleted {
+		stats.LastGC = time.Unix(0, int64(m.LastGC))
+		stats.LastPause = time.Duration(m.PauseNs[(m.NumGC+255)%256])
+	}
+	return stats
+}
+
 // CpuProfile turns on CPU profiling for nsec seconds and writes
 // profile data to file.
 func (h *HandlerT) CpuProfile(file string, nsec uint) error {
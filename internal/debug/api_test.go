@@ -0,0 +1,52 @@
+// Copyright 2018 The go-athereum Authors
+// This file is part of the go-athereum library.
+//
+// The go-athereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-athereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-athereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package debug
+
+import (
+	"runtime"
+	"testing"
+)
+
+// Tests that the runtime stats report the live goroutine and heap figures without
+// forcing a garbage collection, and the last cycle's figures once one completed.
+func TestRuntimeStats(t *testing.T) {
+	before := new(runtime.MemStats)
+	runtime.ReadMemStats(before)
+
+	stats := Handler.RuntimeStats()
+	if stats.Goroutines <= 0 {
+		t.Errorf("goroutine count mismatch: have %d, want > 0", stats.Goroutines)
+	}
+	if stats.HeapAlloc == 0 || stats.HeapSys < stats.HeapAlloc {
+		t.Errorf("heap figures mismatch: have alloc %d, sys %d", stats.HeapAlloc, stats.HeapSys)
+	}
+	after := new(runtime.MemStats)
+	runtime.ReadMemStats(after)
+	if after.NumForcedGC != before.NumForcedGC {
+		t.Errorf("garbage collection forced by the stats retrieval")
+	}
+	// Complete a GC cycle and ensure its figures are reported
+	runtime.GC()
+
+	stats = Handler.RuntimeStats()
+	if !stats.GCCompleted || stats.NumGC == 0 {
+		t.Fatalf("completed GC cycle not reported: completed %v, cycles %d", stats.GCCompleted, stats.NumGC)
+	}
+	if stats.LastGC.IsZero() || stats.NextGC == 0 {
+		t.Errorf("last GC figures missing: last %v, next target %d", stats.LastGC, stats.NextGC)
+	}
+}
@@ -28,12 +28,14 @@ import (
 	"github.com/davecgh/go-spew/spew"
 	"github.com/athereum/go-athereum/accounts"
 	"github.com/athereum/go-athereum/accounts/keystore"
+	"github.com/athereum/go-athereum/athdb"
 	"github.com/athereum/go-athereum/common"
 	"github.com/athereum/go-athereum/common/hexutil"
 	"github.com/athereum/go-athereum/common/math"
 	"github.com/athereum/go-athereum/consensus/athash"
 	"github.com/athereum/go-athereum/core"
 	"github.com/athereum/go-athereum/core/rawdb"
+	"github.com/athereum/go-athereum/core/state"
 	"github.com/athereum/go-athereum/core/types"
 	"github.com/athereum/go-athereum/core/vm"
 	"github.com/athereum/go-athereum/crypto"
@@ -67,6 +69,49 @@ func (s *PublicAtlantisAPI) GasPrice(ctx context.Context) (*hexutil.Big, error)
 	return (*hexutil.Big)(price), err
 }
 
+// feeHistoryResult is the RPC representation of the gas price distribution of a
+// range of blocks.
+type feeHistoryResult struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseGasPrice []*hexutil.Big   `json:"baseGasPrice"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// FeeHistory returns the gas price distribution of the blockCount blocks ending
+// with lastBlock, reporting the requested percentiles of the included gas prices.
+func (s *PublicAtlantisAPI) FeeHistory(ctx context.Context, blockCount hexutil.Uint, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*feeHistoryResult, error) {
+	oldest, reward, base, gasUsed, err := s.b.FeeHistory(ctx, int(blockCount), lastBlock, rewardPercentiles)
+	if err != nil {
+		return nil, err
+	}
+	results := &feeHistoryResult{
+		OldestBlock:  (*hexutil.Big)(oldest),
+		BaseGasPrice: make([]*hexutil.Big, len(base)),
+		GasUsedRatio: gasUsed,
+	}
+	for i, price := range base {
+		results.BaseGasPrice[i] = (*hexutil.Big)(price)
+	}
+	if len(rewardPercentiles) > 0 {
+		results.Reward = make([][]*hexutil.Big, len(reward))
+		for i, rewards := range reward {
+			results.Reward[i] = make([]*hexutil.Big, len(rewards))
+			for j, price := range rewards {
+				results.Reward[i][j] = (*hexutil.Big)(price)
+			}
+		}
+	}
+	return results, nil
+}
+
+// ChainConfig returns the chain configuration the node is running with, including
+// the fork schedule and the consensus engine parameters. Forks not scheduled are
+// omitted.
+func (s *PublicAtlantisAPI) ChainConfig() *params.ChainConfig {
+	return s.b.ChainConfig()
+}
+
 // ProtocolVersion returns the current Atlantis protocol version this node supports
 func (s *PublicAtlantisAPI) ProtocolVersion() hexutil.Uint {
 	return hexutil.Uint(s.b.ProtocolVersion())
@@ -118,7 +163,7 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransac
 	for account, txs := range pending {
 		dump := make(map[string]*RPCTransaction)
 		for _, tx := range txs {
-			dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx)
+			dump[fmt.Sprintf("%d", tx.Nonce())] = NewRPCPendingTransaction(tx)
 		}
 		content["pending"][account.Hex()] = dump
 	}
@@ -126,7 +171,7 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransac
 	for account, txs := range queue {
 		dump := make(map[string]*RPCTransaction)
 		for _, tx := range txs {
-			dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx)
+			dump[fmt.Sprintf("%d", tx.Nonce())] = NewRPCPendingTransaction(tx)
 		}
 		content["queued"][account.Hex()] = dump
 	}
@@ -142,6 +187,102 @@ func (s *PublicTxPoolAPI) Status() map[string]hexutil.Uint {
 	}
 }
 
+// PrivateTxPoolAPI offers operator-only access to the transaction pool, managing
+// the local accounts which are exempt from its price and capacity limits and
+// pushing pooled transactions to the network.
+type PrivateTxPoolAPI struct {
+	b Backend
+}
+
+// NewPrivateTxPoolAPI creates a new tx pool service for the node operator.
+func NewPrivateTxPoolAPI(b Backend) *PrivateTxPoolAPI {
+	return &PrivateTxPoolAPI{b}
+}
+
+// LocalTransactions returns the transactions of the pool's local accounts, which
+// are exempt from eviction and journaled across restarts, grouped by account and
+// keyed by nonce.
+func (s *PrivateTxPoolAPI) LocalTransactions() map[string]map[string]*RPCTransaction {
+	locals := make(map[string]map[string]*RPCTransaction)
+	for account, txs := range s.b.TxPoolLocals() {
+		dump := make(map[string]*RPCTransaction)
+		for _, tx := range txs {
+			dump[fmt.Sprintf("%d", tx.Nonce())] = NewRPCPendingTransaction(tx)
+		}
+		locals[account.Hex()] = dump
+	}
+	return locals
+}
+
+// MarkLocal promotes the sender of a pooled transaction to a local account,
+// protecting all its transactions from eviction and price limits and journaling
+// them so they survive restarts. As local accounts bypass the pool's spam
+// protection, this is reserved for the node operator.
+func (s *PrivateTxPoolAPI) MarkLocal(txHash common.Hash) (bool, error) {
+	if err := s.b.TxPoolMarkLocal(txHash); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Rebroadcast sends a pooled transaction to the node's peers again, including
+// those believed to already know it, returning the number of peers it was sent to.
+// As every call fans out to all peers, this is reserved for the node operator.
+func (s *PrivateTxPoolAPI) Rebroadcast(txHash common.Hash) (int, error) {
+	return s.b.TxPoolRebroadcast(txHash)
+}
+
+// NonceGap is an inclusive range of nonces missing from an account's transactions,
+// preventing its later, queued transactions from becoming executable.
+type NonceGap struct {
+	From hexutil.Uint64 `json:"from"`
+	To   hexutil.Uint64 `json:"to"`
+}
+
+// AccountStatus is the transaction pool status of a single account.
+type AccountStatus struct {
+	Nonce     hexutil.Uint64 `json:"nonce"`     // Nonce of the account in the latest state
+	Pending   hexutil.Uint   `json:"pending"`   // Number of executable transactions
+	Queued    hexutil.Uint   `json:"queued"`    // Number of non-executable transactions
+	NonceGaps []NonceGap     `json:"nonceGaps"` // Nonces missing before the queued transactions
+}
+
+// AccountStatus returns the number of pending and queued transactions of a single
+// account, along with the nonce gaps holding back its queued transactions. Light
+// clients only know about the transactions sent through them.
+func (s *PublicTxPoolAPI) AccountStatus(ctx context.Context, addr common.Address) (*AccountStatus, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	nonce := state.GetNonce(addr)
+	if err := state.Error(); err != nil {
+		return nil, err
+	}
+	pending, queued := s.b.TxPoolInspectByAccount(addr)
+
+	status := &AccountStatus{
+		Nonce:     hexutil.Uint64(nonce),
+		Pending:   hexutil.Uint(len(pending)),
+		Queued:    hexutil.Uint(len(queued)),
+		NonceGaps: []NonceGap{},
+	}
+	// Walk the queued transactions, collecting the nonces missing in between
+	next := nonce
+	if len(pending) > 0 {
+		next = pending[len(pending)-1].Nonce + 1
+	}
+	for _, tx := range queued {
+		if tx.Nonce > next {
+			status.NonceGaps = append(status.NonceGaps, NonceGap{From: hexutil.Uint64(next), To: hexutil.Uint64(tx.Nonce - 1)})
+		}
+		if tx.Nonce >= next {
+			next = tx.Nonce + 1
+		}
+	}
+	return status, nil
+}
+
 // Inspect retrieves the content of the transaction pool and flattens it into an
 // easily inspectable list.
 func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
@@ -318,17 +459,81 @@ func (s *PrivateAccountAPI) ImportRawKey(privkey string, password string) (commo
 // the given password for duration seconds. If duration is nil it will use a
 // default of 300 seconds. It returns an indication if the account was unlocked.
 func (s *PrivateAccountAPI) UnlockAccount(addr common.Address, password string, duration *uint64) (bool, error) {
+	d, err := unlockDuration(duration)
+	if err != nil {
+		return false, err
+	}
+	err = fetchKeystore(s.am).TimedUnlock(accounts.Account{Address: addr}, password, d)
+	return err == nil, err
+}
+
+// UnlockResult is the outcome of unlocking a single account in a batch.
+type UnlockResult struct {
+	Unlocked bool   `json:"unlocked"`
+	Error    string `json:"error,omitempty"`
+}
+
+// UnlockAccounts will unlock all the accounts associated with the given addresses
+// with the same password for duration seconds, reporting the outcome for each of
+// them. If atomic is set and any of the accounts fails to unlock, all the others
+// unlocked by this call are locked again. Accounts which were already unlocked
+// beforehand are left untouched.
+func (s *PrivateAccountAPI) UnlockAccounts(addrs []common.Address, password string, duration *uint64, atomic *bool) (map[common.Address]*UnlockResult, error) {
+	d, err := unlockDuration(duration)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		ks      = fetchKeystore(s.am)
+		results = make(map[common.Address]*UnlockResult, len(addrs))
+		fresh   []common.Address
+		failed  bool
+	)
+	for _, addr := range addrs {
+		wasUnlocked := isUnlocked(ks, addr)
+		if err := ks.TimedUnlock(accounts.Account{Address: addr}, password, d); err != nil {
+			results[addr] = &UnlockResult{Error: err.Error()}
+			failed = true
+			continue
+		}
+		results[addr] = &UnlockResult{Unlocked: true}
+		if !wasUnlocked {
+			fresh = append(fresh, addr)
+		}
+	}
+	// Roll back the new unlocks if the batch was requested to be atomic
+	if failed && atomic != nil && *atomic {
+		for _, addr := range fresh {
+			ks.Lock(addr)
+			results[addr].Unlocked = false
+		}
+	}
+	return results, nil
+}
+
+// isUnlocked reports whether the key of the given account is currently held
+// decrypted by the keystore.
+func isUnlocked(ks *keystore.KeyStore, addr common.Address) bool {
+	for _, wallet := range ks.Wallets() {
+		if wallet.Contains(accounts.Account{Address: addr}) {
+			status, _ := wallet.Status()
+			return status == "Unlocked"
+		}
+	}
+	return false
+}
+
+// unlockDuration converts an optional account unlock duration in seconds into a
+// time.Duration, defaulting to 300 seconds.
+func unlockDuration(duration *uint64) (time.Duration, error) {
 	const max = uint64(time.Duration(math.MaxInt64) / time.Second)
-	var d time.Duration
 	if duration == nil {
-		d = 300 * time.Second
-	} else if *duration > max {
-		return false, errors.New("unlock duration too large")
-	} else {
-		d = time.Duration(*duration) * time.Second
+		return 300 * time.Second, nil
 	}
-	err := fetchKeystore(s.am).TimedUnlock(accounts.Account{Address: addr}, password, d)
-	return err == nil, err
+	if *duration > max {
+		return 0, errors.New("unlock duration too large")
+	}
+	return time.Duration(*duration) * time.Second, nil
 }
 
 // LockAccount will lock the account associated with the given address when it's unlocked.
@@ -442,6 +647,56 @@ func (s *PrivateAccountAPI) Sign(ctx context.Context, data hexutil.Bytes, addr c
 	return signature, nil
 }
 
+// Message signing modes supported by personal_signWithMode.
+const (
+	// SignModePrefixed hashes the message with the personal message prefix before
+	// signing, exactly like personal_sign. Signatures are recoverable with
+	// personal_ecRecover from the original message.
+	SignModePrefixed = "prefixed"
+
+	// SignModeRaw signs the message as is, which must be a 32 byte hash computed
+	// by the caller (e.g. an EIP-191 or EIP-712 digest). Signatures are NOT
+	// recoverable with personal_ecRecover, only by running ecrecover on the hash.
+	SignModeRaw = "raw"
+)
+
+// SignWithMode calculates an Atlantis ECDSA signature of the message using the
+// given signing mode (SignModePrefixed if unspecified), allowing dapps to target
+// the hashing scheme expected by their wallets and verifiers.
+//
+// The signature is returned in the 65 byte [R || S || V] form, where the V value
+// will be 27 or 28 for legacy reasons.
+//
+// The key used to calculate the signature is decrypted with the given password.
+func (s *PrivateAccountAPI) SignWithMode(ctx context.Context, data hexutil.Bytes, addr common.Address, passwd string, mode *string) (hexutil.Bytes, error) {
+	var hash []byte
+
+	switch {
+	case mode == nil || *mode == SignModePrefixed:
+		hash = signHash(data)
+	case *mode == SignModeRaw:
+		if len(data) != common.HashLength {
+			return nil, fmt.Errorf("raw signing requires a %d byte hash, got %d bytes", common.HashLength, len(data))
+		}
+		hash = data
+	default:
+		return nil, fmt.Errorf("unknown signing mode %q, must be %q or %q", *mode, SignModePrefixed, SignModeRaw)
+	}
+	// Look up the wallet containing the requested signer
+	account := accounts.Account{Address: addr}
+
+	wallet, err := s.b.AccountManager().Find(account)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := wallet.SignHashWithPassphrase(account, passwd, hash)
+	if err != nil {
+		return nil, err
+	}
+	signature[64] += 27 // Transform V from 0/1 to 27/28 according to the yellow paper
+	return signature, nil
+}
+
 // EcRecover returns the address for the account that was used to create the signature.
 // Note, this function is compatible with ath_sign and personal_sign. As such it recovers
 // the address of:
@@ -491,17 +746,57 @@ func (s *PublicBlockChainAPI) BlockNumber() hexutil.Uint64 {
 	return hexutil.Uint64(header.Number.Uint64())
 }
 
+// GetBlockHashByNumber returns the hash of the canonical block at the given height,
+// or nil if the chain isn't that long yet. Meta block numbers are resolved to the
+// corresponding headers.
+func (s *PublicBlockChainAPI) GetBlockHashByNumber(ctx context.Context, number rpc.BlockNumber) (*common.Hash, error) {
+	if number < 0 {
+		header, err := s.b.HeaderByNumber(ctx, number)
+		if header == nil || err != nil {
+			return nil, err
+		}
+		hash := header.Hash()
+		return &hash, nil
+	}
+	hash, err := s.b.GetCanonicalHash(ctx, uint64(number))
+	if hash == (common.Hash{}) || err != nil {
+		return nil, err
+	}
+	return &hash, nil
+}
+
 // GetBalance returns the amount of wei for the given address in the state of the
-// given block number. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta
-// block numbers are also allowed.
-func (s *PublicBlockChainAPI) GetBalance(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (*hexutil.Big, error) {
-	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+// given block number or hash. The rpc.LatestBlockNumber and rpc.PendingBlockNumber
+// meta block numbers are also allowed.
+func (s *PublicBlockChainAPI) GetBalance(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error) {
+	state, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 	if state == nil || err != nil {
 		return nil, err
 	}
 	return (*hexutil.Big)(state.GetBalance(address)), state.Error()
 }
 
+// MaxBalanceAddresses is the maximum number of accounts whose balances are served
+// by a single GetBalances call.
+const MaxBalanceAddresses = 1024
+
+// GetBalances returns the amount of wei for each of the given addresses, in the
+// same order, all read from the state of the given block number or hash.
+func (s *PublicBlockChainAPI) GetBalances(ctx context.Context, addresses []common.Address, blockNrOrHash rpc.BlockNumberOrHash) ([]*hexutil.Big, error) {
+	if len(addresses) > MaxBalanceAddresses {
+		return nil, fmt.Errorf("too many addresses: %d requested, maximum is %d", len(addresses), MaxBalanceAddresses)
+	}
+	state, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	balances := make([]*hexutil.Big, len(addresses))
+	for i, address := range addresses {
+		balances[i] = (*hexutil.Big)(state.GetBalance(address))
+	}
+	return balances, state.Error()
+}
+
 // GetBlockByNumber returns the requested block. When blockNr is -1 the chain head is returned. When fullTx is true all
 // transactions in the block are returned in full detail, otherwise only the transaction hash is returned.
 func (s *PublicBlockChainAPI) GetBlockByNumber(ctx context.Context, blockNr rpc.BlockNumber, fullTx bool) (map[string]interface{}, error) {
@@ -522,6 +817,10 @@ func (s *PublicBlockChainAPI) GetBlockByNumber(ctx context.Context, blockNr rpc.
 // GetBlockByHash returns the requested block. When fullTx is true all transactions in the block are returned in full
 // detail, otherwise only the transaction hash is returned.
 func (s *PublicBlockChainAPI) GetBlockByHash(ctx context.Context, blockHash common.Hash, fullTx bool) (map[string]interface{}, error) {
+	// Check the header first to avoid retrieving bodies for unknown blocks
+	if header, err := s.b.HeaderByHash(ctx, blockHash); header == nil || err != nil {
+		return nil, err
+	}
 	block, err := s.b.GetBlock(ctx, blockHash)
 	if block != nil {
 		return s.rpcOutputBlock(block, true, fullTx)
@@ -529,6 +828,14 @@ func (s *PublicBlockChainAPI) GetBlockByHash(ctx context.Context, blockHash comm
 	return nil, err
 }
 
+// GetReceiptsRange returns the receipts of all the canonical blocks within the
+// given inclusive range, ordered by block number. The result is cut short at the
+// first block not available locally, and ranges longer than MaxReceiptsRange are
+// rejected, so callers need to paginate.
+func (s *PublicBlockChainAPI) GetReceiptsRange(ctx context.Context, from, to rpc.BlockNumber) ([]types.Receipts, error) {
+	return s.b.GetReceiptsRange(ctx, from, to)
+}
+
 // GetUncleByBlockNumberAndIndex returns the uncle block for the given block hash and index. When fullTx is true
 // all transactions in the block are returned in full detail, otherwise only the transaction hash is returned.
 func (s *PublicBlockChainAPI) GetUncleByBlockNumberAndIndex(ctx context.Context, blockNr rpc.BlockNumber, index hexutil.Uint) (map[string]interface{}, error) {
@@ -579,9 +886,9 @@ func (s *PublicBlockChainAPI) GetUncleCountByBlockHash(ctx context.Context, bloc
 	return nil
 }
 
-// GetCode returns the code stored at the given address in the state for the given block number.
-func (s *PublicBlockChainAPI) GetCode(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (hexutil.Bytes, error) {
-	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+// GetCode returns the code stored at the given address in the state for the given block number or hash.
+func (s *PublicBlockChainAPI) GetCode(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	state, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 	if state == nil || err != nil {
 		return nil, err
 	}
@@ -589,11 +896,18 @@ func (s *PublicBlockChainAPI) GetCode(ctx context.Context, address common.Addres
 	return code, state.Error()
 }
 
+// GetCodeSize returns the size of the code stored at the given address in the
+// state for the given block number or hash, without transferring the code itself.
+func (s *PublicBlockChainAPI) GetCodeSize(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
+	size, err := s.b.GetCodeSize(ctx, address, blockNrOrHash)
+	return hexutil.Uint64(size), err
+}
+
 // GetStorageAt returns the storage from the state at the given address, key and
-// block number. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta block
-// numbers are also allowed.
-func (s *PublicBlockChainAPI) GetStorageAt(ctx context.Context, address common.Address, key string, blockNr rpc.BlockNumber) (hexutil.Bytes, error) {
-	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+// block number or hash. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta
+// block numbers are also allowed.
+func (s *PublicBlockChainAPI) GetStorageAt(ctx context.Context, address common.Address, key string, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	state, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 	if state == nil || err != nil {
 		return nil, err
 	}
@@ -601,6 +915,81 @@ func (s *PublicBlockChainAPI) GetStorageAt(ctx context.Context, address common.A
 	return res[:], state.Error()
 }
 
+// AccountResult is the Merkle proof of an account and a set of its storage slots,
+// as returned by GetProof.
+type AccountResult struct {
+	Address      common.Address  `json:"address"`
+	AccountProof []string        `json:"accountProof"`
+	Balance      *hexutil.Big    `json:"balance"`
+	CodeHash     common.Hash     `json:"codeHash"`
+	Nonce        hexutil.Uint64  `json:"nonce"`
+	StorageHash  common.Hash     `json:"storageHash"`
+	StorageProof []StorageResult `json:"storageProof"`
+}
+
+// StorageResult is the Merkle proof of a single storage slot.
+type StorageResult struct {
+	Key   string       `json:"key"`
+	Value *hexutil.Big `json:"value"`
+	Proof []string     `json:"proof"`
+}
+
+// GetProof returns the Merkle proof of the account and the given storage keys at
+// the given block number or hash. Non-existent accounts yield a proof of their
+// absence, along with empty storage proofs.
+func (s *PublicBlockChainAPI) GetProof(ctx context.Context, address common.Address, storageKeys []string, blockNrOrHash rpc.BlockNumberOrHash) (*AccountResult, error) {
+	state, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	var (
+		storageTrie  = state.StorageTrie(address)
+		storageHash  = types.EmptyRootHash
+		codeHash     = state.GetCodeHash(address)
+		storageProof = make([]StorageResult, len(storageKeys))
+	)
+	if storageTrie != nil {
+		storageHash = storageTrie.Hash()
+	} else {
+		// Non-existent accounts have neither storage nor code
+		codeHash = crypto.Keccak256Hash(nil)
+	}
+	for i, key := range storageKeys {
+		if storageTrie == nil {
+			storageProof[i] = StorageResult{Key: key, Value: new(hexutil.Big), Proof: []string{}}
+			continue
+		}
+		proof, err := state.GetStorageProof(address, common.HexToHash(key))
+		if err != nil {
+			return nil, err
+		}
+		value := state.GetState(address, common.HexToHash(key)).Big()
+		storageProof[i] = StorageResult{Key: key, Value: (*hexutil.Big)(value), Proof: encodeProof(proof)}
+	}
+	accountProof, err := state.GetProof(address)
+	if err != nil {
+		return nil, err
+	}
+	return &AccountResult{
+		Address:      address,
+		AccountProof: encodeProof(accountProof),
+		Balance:      (*hexutil.Big)(state.GetBalance(address)),
+		CodeHash:     codeHash,
+		Nonce:        hexutil.Uint64(state.GetNonce(address)),
+		StorageHash:  storageHash,
+		StorageProof: storageProof,
+	}, state.Error()
+}
+
+// encodeProof hex encodes the nodes of a Merkle proof.
+func encodeProof(proof [][]byte) []string {
+	encoded := make([]string, len(proof))
+	for i, node := range proof {
+		encoded[i] = hexutil.Encode(node)
+	}
+	return encoded
+}
+
 // CallArgs represents the arguments for a call.
 type CallArgs struct {
 	From     common.Address  `json:"from"`
@@ -609,14 +998,58 @@ type CallArgs struct {
 	GasPrice hexutil.Big     `json:"gasPrice"`
 	Value    hexutil.Big     `json:"value"`
 	Data     hexutil.Bytes   `json:"data"`
+
+	// RealBalance executes the call with the sender's actual balance instead of
+	// crediting it with an unlimited allowance, reproducing insufficient funds
+	// failures. Explicit balance overrides of the sender still take precedence.
+	RealBalance bool `json:"realBalance"`
+}
+
+// OverrideAccount indicates the overriding fields of an account during the
+// execution of a message call. Omitted fields are left untouched, storage slots
+// in StateDiff are patched on top of the account's existing storage.
+type OverrideAccount struct {
+	Nonce     *hexutil.Uint64             `json:"nonce"`
+	Code      *hexutil.Bytes              `json:"code"`
+	Balance   **hexutil.Big               `json:"balance"`
+	StateDiff map[common.Hash]common.Hash `json:"stateDiff"`
 }
 
-func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, vmCfg vm.Config, timeout time.Duration) ([]byte, uint64, bool, error) {
+// StateOverride is the collection of overridden accounts.
+type StateOverride map[common.Address]OverrideAccount
+
+// Apply overrides the fields of the specified accounts in the given state. The
+// state is expected to be a throwaway copy, the changes are never committed.
+func (diff *StateOverride) Apply(state *state.StateDB) {
+	if diff == nil {
+		return
+	}
+	for addr, account := range *diff {
+		if account.Nonce != nil {
+			state.SetNonce(addr, uint64(*account.Nonce))
+		}
+		if account.Code != nil {
+			state.SetCode(addr, *account.Code)
+		}
+		// An explicit null balance decodes into a nil inner pointer, leave it alone
+		if account.Balance != nil && *account.Balance != nil {
+			state.SetBalance(addr, (*big.Int)(*account.Balance))
+		}
+		for key, value := range account.StateDiff {
+			state.SetState(addr, key, value)
+		}
+	}
+}
+
+// doCall executes the given call against the requested state, returning the EVM
+// output, the gas used and the EVM error the execution failed with, if any. The
+// optional overrides are applied to the retrieved state before execution.
+func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride, vmCfg vm.Config, timeout time.Duration) ([]byte, uint64, error, error) {
 	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
 
-	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	state, header, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 	if state == nil || err != nil {
-		return nil, 0, false, err
+		return nil, 0, nil, err
 	}
 	// Set sender address or use a default if none specified
 	addr := args.From
@@ -651,11 +1084,16 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 	// this makes sure resources are cleaned up.
 	defer cancel()
 
-	// Get a new instance of the EVM.
-	evm, vmError, err := s.b.GetEVM(ctx, msg, state, header, vmCfg)
+	// Get a new instance of the EVM, crediting the sender with an unlimited
+	// allowance unless its real balance was requested.
+	evm, vmError, err := s.b.GetEVM(ctx, msg, state, header, vmCfg, !args.RealBalance)
 	if err != nil {
-		return nil, 0, false, err
+		return nil, 0, nil, err
 	}
+	// Patch the state after the EVM setup so explicit balance overrides of the
+	// sender take precedence over the default unlimited call allowance.
+	overrides.Apply(state)
+
 	// Wait for the context to be done and cancel the evm. Even if the
 	// EVM has finished, cancelling may be done (repeatedly)
 	go func() {
@@ -666,23 +1104,107 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 	// Setup the gas pool (also for unmetered requests)
 	// and apply the message.
 	gp := new(core.GasPool).AddGas(math.MaxUint64)
-	res, gas, failed, err := core.ApplyMessage(evm, msg, gp)
+	res, gas, failure, err := core.ApplyMessageWithVMError(evm, msg, gp)
 	if err := vmError(); err != nil {
-		return nil, 0, false, err
+		return nil, 0, nil, err
+	}
+	// If the context caused an abort, report it instead of the partial result
+	if evm.Cancelled() {
+		if ctx.Err() == context.DeadlineExceeded && timeout > 0 {
+			return nil, 0, nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
+		}
+		return nil, 0, nil, fmt.Errorf("execution aborted: %v", ctx.Err())
+	}
+	return res, gas, failure, err
+}
+
+// revertReasonSelector is the selector of the standard Error(string) revert
+// reason encoding emitted by Solidity's revert and require statements.
+var revertReasonSelector = []byte{0x08, 0xc3, 0x79, 0xa0}
+
+// revertError is an API error that encompasses an EVM revert together with the
+// data returned by the reverted execution.
+type revertError struct {
+	message string
+	reason  string // Decoded revert reason, or the raw hex encoded revert data
+}
+
+// newRevertError creates a revertError from the data returned by a reverted
+// execution, decoding the reason if it's encoded in the standard Error(string)
+// format. Any other revert data (e.g. custom errors) is kept as raw hex.
+func newRevertError(ret []byte) *revertError {
+	if reason, ok := unpackRevertReason(ret); ok {
+		return &revertError{
+			message: "execution reverted: " + reason,
+			reason:  reason,
+		}
+	}
+	return &revertError{
+		message: "execution reverted",
+		reason:  hexutil.Encode(ret),
+	}
+}
+
+// Error implements error, returning the revert message.
+func (e *revertError) Error() string {
+	return e.message
+}
+
+// ErrorData implements rpc.DataError, returning the revert reason.
+func (e *revertError) ErrorData() interface{} {
+	return e.reason
+}
+
+// unpackRevertReason decodes the ABI encoded Error(string) revert reason from the
+// data returned by a reverted execution.
+func unpackRevertReason(ret []byte) (string, bool) {
+	if len(ret) < 4+64 || !bytes.Equal(ret[:4], revertReasonSelector) {
+		return "", false
+	}
+	data := ret[4:]
+
+	offset := new(big.Int).SetBytes(data[:32])
+	if !offset.IsUint64() || offset.Uint64()+32 > uint64(len(data)) {
+		return "", false
+	}
+	start := offset.Uint64() + 32
+	size := new(big.Int).SetBytes(data[offset.Uint64():start])
+	if !size.IsUint64() || size.Uint64() > uint64(len(data))-start {
+		return "", false
 	}
-	return res, gas, failed, err
+	return string(data[start : start+size.Uint64()]), true
 }
 
-// Call executes the given transaction on the state for the given block number.
+// callError converts the EVM error a call failed with into an API error. Reverts
+// are reported along with their reason, other failures (e.g. running out of gas)
+// with the plain EVM error.
+func callError(ret []byte, failure error) error {
+	if failure == vm.ErrExecutionReverted {
+		return newRevertError(ret)
+	}
+	return failure
+}
+
+// Call executes the given transaction on the state for the given block number or hash.
 // It doesn't make and changes in the state/blockchain and is useful to execute and retrieve values.
-func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber) (hexutil.Bytes, error) {
-	result, _, _, err := s.doCall(ctx, args, blockNr, vm.Config{}, 5*time.Second)
-	return (hexutil.Bytes)(result), err
+// If the execution fails, the error is returned, with the decoded reason for reverts.
+//
+// Additionally, the caller can specify a batch of contract for fields overriding.
+func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride) (hexutil.Bytes, error) {
+	result, _, failure, err := s.doCall(ctx, args, blockNrOrHash, overrides, vm.Config{}, s.b.RPCEVMTimeout())
+	if err != nil {
+		return nil, err
+	}
+	if failure != nil {
+		return nil, callError(result, failure)
+	}
+	return (hexutil.Bytes)(result), nil
 }
 
 // EstimateGas returns an estimate of the amount of gas needed to execute the
-// given transaction against the current pending block.
-func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (hexutil.Uint64, error) {
+// given transaction against the current pending block, optionally patched with
+// the given state overrides.
+func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs, overrides *StateOverride) (hexutil.Uint64, error) {
 	// Binary search the gas requirement, as it may be higher than the amount used
 	var (
 		lo  uint64 = params.TxGas - 1
@@ -702,19 +1224,19 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (h
 	cap = hi
 
 	// Create a helper to check if a gas allowance results in an executable transaction
-	executable := func(gas uint64) bool {
+	executable := func(gas uint64) (bool, []byte, error) {
 		args.Gas = hexutil.Uint64(gas)
 
-		_, _, failed, err := s.doCall(ctx, args, rpc.PendingBlockNumber, vm.Config{}, 0)
-		if err != nil || failed {
-			return false
+		res, _, failure, err := s.doCall(ctx, args, rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber), overrides, vm.Config{}, 0)
+		if err != nil || failure != nil {
+			return false, res, failure
 		}
-		return true
+		return true, nil, nil
 	}
 	// Execute the binary search and hone in on an executable gas limit
 	for lo+1 < hi {
 		mid := (hi + lo) / 2
-		if !executable(mid) {
+		if ok, _, _ := executable(mid); !ok {
 			lo = mid
 		} else {
 			hi = mid
@@ -722,7 +1244,11 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (h
 	}
 	// Reject the transaction as invalid if it still fails at the highest allowance
 	if hi == cap {
-		if !executable(hi) {
+		if ok, res, failure := executable(hi); !ok {
+			// Surface explicit reverts, they won't succeed with any allowance
+			if failure == vm.ErrExecutionReverted {
+				return 0, newRevertError(res)
+			}
 			return 0, fmt.Errorf("gas required exceeds allowance or always failing transaction")
 		}
 	}
@@ -905,8 +1431,8 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 	return result
 }
 
-// newRPCPendingTransaction returns a pending transaction that will serialize to the RPC representation
-func newRPCPendingTransaction(tx *types.Transaction) *RPCTransaction {
+// NewRPCPendingTransaction returns a pending transaction that will serialize to the RPC representation
+func NewRPCPendingTransaction(tx *types.Transaction) *RPCTransaction {
 	return newRPCTransaction(tx, common.Hash{}, 0, 0)
 }
 
@@ -984,6 +1510,25 @@ func (s *PublicTransactionPoolAPI) GetTransactionByBlockHashAndIndex(ctx context
 	return nil
 }
 
+// GetTransactionsByBlockHash returns all the transactions of the given block along
+// with their indices, retrieving them in a single backend call. Empty blocks yield
+// an empty list, unknown blocks nil.
+func (s *PublicTransactionPoolAPI) GetTransactionsByBlockHash(ctx context.Context, blockHash common.Hash) ([]*RPCTransaction, error) {
+	header, err := s.b.HeaderByHash(ctx, blockHash)
+	if header == nil || err != nil {
+		return nil, err
+	}
+	txs, err := s.b.GetBlockTransactions(ctx, blockHash)
+	if txs == nil || err != nil {
+		return nil, err
+	}
+	result := make([]*RPCTransaction, len(txs))
+	for i, tx := range txs {
+		result[i] = newRPCTransaction(tx, blockHash, header.Number.Uint64(), uint64(i))
+	}
+	return result, nil
+}
+
 // GetRawTransactionByBlockNumberAndIndex returns the bytes of the transaction for the given block number and index.
 func (s *PublicTransactionPoolAPI) GetRawTransactionByBlockNumberAndIndex(ctx context.Context, blockNr rpc.BlockNumber, index hexutil.Uint) hexutil.Bytes {
 	if block, _ := s.b.BlockByNumber(ctx, blockNr); block != nil {
@@ -1018,7 +1563,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionByHash(ctx context.Context, has
 	}
 	// No finalized transaction, try to retrieve it from the pool
 	if tx := s.b.GetPoolTransaction(hash); tx != nil {
-		return newRPCPendingTransaction(tx)
+		return NewRPCPendingTransaction(tx)
 	}
 	// Transaction unknown, return as such
 	return nil
@@ -1069,6 +1614,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 		"to":                tx.To(),
 		"gasUsed":           hexutil.Uint64(receipt.GasUsed),
 		"cumulativeGasUsed": hexutil.Uint64(receipt.CumulativeGasUsed),
+		"effectiveGasPrice": (*hexutil.Big)(EffectiveGasPrice(tx)),
 		"contractAddress":   nil,
 		"logs":              receipt.Logs,
 		"logsBloom":         receipt.Bloom,
@@ -1320,12 +1866,33 @@ func (s *PublicTransactionPoolAPI) PendingTransactions() ([]*RPCTransaction, err
 		}
 		from, _ := types.Sender(signer, tx)
 		if _, exists := accounts[from]; exists {
-			transactions = append(transactions, newRPCPendingTransaction(tx))
+			transactions = append(transactions, NewRPCPendingTransaction(tx))
 		}
 	}
 	return transactions, nil
 }
 
+// PendingBlockTransactions returns the transactions included in the block the
+// miner is currently assembling, i.e. the ones about to be mined, as opposed to
+// the whole transaction pool.
+func (s *PublicTransactionPoolAPI) PendingBlockTransactions() ([]*RPCTransaction, error) {
+	block, err := s.b.PendingBlock()
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		// The miner hasn't assembled a pending block yet
+		return nil, errPendingBlockUnavailable
+	}
+	txs := block.Transactions()
+
+	transactions := make([]*RPCTransaction, len(txs))
+	for i, tx := range txs {
+		transactions[i] = NewRPCPendingTransaction(tx)
+	}
+	return transactions, nil
+}
+
 // Resend accepts an existing transaction and a new gas price and limit. It will remove
 // the given transaction from the pool and reinsert it with the new gas price and limit.
 func (s *PublicTransactionPoolAPI) Resend(ctx context.Context, sendArgs SendTxArgs, gasPrice *hexutil.Big, gasLimit *hexutil.Uint64) (common.Hash, error) {
@@ -1370,6 +1937,42 @@ func (s *PublicTransactionPoolAPI) Resend(ctx context.Context, sendArgs SendTxAr
 	return common.Hash{}, fmt.Errorf("Transaction %#x not found", matchTx.Hash())
 }
 
+// ReplaceTransaction replaces the pending transaction with the given hash by the
+// given signed transaction, which must be sent from the same account with the same
+// nonce and a higher gas price. The swap itself is done by the transaction pool,
+// so the replacement must also satisfy the pool's minimum price bump.
+func (s *PublicTransactionPoolAPI) ReplaceTransaction(ctx context.Context, oldHash common.Hash, encodedTx hexutil.Bytes) (common.Hash, error) {
+	old := s.b.GetPoolTransaction(oldHash)
+	if old == nil {
+		if tx, _, _, _ := rawdb.ReadTransaction(s.b.ChainDb(), oldHash); tx != nil {
+			return common.Hash{}, fmt.Errorf("transaction %#x already confirmed", oldHash)
+		}
+		return common.Hash{}, fmt.Errorf("transaction %#x not found", oldHash)
+	}
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
+		return common.Hash{}, err
+	}
+	// Ensure the new transaction really is a replacement of the old one
+	signer := types.MakeSigner(s.b.ChainConfig(), s.b.CurrentBlock().Number())
+
+	oldFrom, err := types.Sender(signer, old)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if from != oldFrom || tx.Nonce() != old.Nonce() {
+		return common.Hash{}, fmt.Errorf("replacement must have the same sender and nonce as %#x", oldHash)
+	}
+	if tx.GasPrice().Cmp(old.GasPrice()) <= 0 {
+		return common.Hash{}, fmt.Errorf("replacement gas price %v not higher than %v", tx.GasPrice(), old.GasPrice())
+	}
+	return submitTransaction(ctx, s.b, tx)
+}
+
 // PublicDebugAPI is the collection of Atlantis APIs exposed over the public
 // debugging endpoint.
 type PublicDebugAPI struct {
@@ -1395,6 +1998,52 @@ func (api *PublicDebugAPI) GetBlockRlp(ctx context.Context, number uint64) (stri
 	return fmt.Sprintf("%x", encoded), nil
 }
 
+// GetRawBlock retrieves the RLP encoding of a single block, identified either by
+// number or by hash.
+func (api *PublicDebugAPI) GetRawBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	var (
+		block *types.Block
+		err   error
+	)
+	if number, ok := blockNrOrHash.Number(); ok {
+		block, err = api.b.BlockByNumber(ctx, number)
+	} else if hash, ok := blockNrOrHash.Hash(); ok {
+		block, err = api.b.GetBlock(ctx, hash)
+	} else {
+		return nil, errors.New("invalid arguments; neither block nor hash specified")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.New("block not found")
+	}
+	return rlp.EncodeToBytes(block)
+}
+
+// GetRawHeader retrieves the RLP encoding of a single header, identified either
+// by number or by hash.
+func (api *PublicDebugAPI) GetRawHeader(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	var (
+		header *types.Header
+		err    error
+	)
+	if number, ok := blockNrOrHash.Number(); ok {
+		header, err = api.b.HeaderByNumber(ctx, number)
+	} else if hash, ok := blockNrOrHash.Hash(); ok {
+		header, err = api.b.HeaderByHash(ctx, hash)
+	} else {
+		return nil, errors.New("invalid arguments; neither block nor hash specified")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errors.New("header not found")
+	}
+	return rlp.EncodeToBytes(header)
+}
+
 // PrintBlock retrieves a block and returns its pretty printed form.
 func (api *PublicDebugAPI) PrintBlock(ctx context.Context, number uint64) (string, error) {
 	block, _ := api.b.BlockByNumber(ctx, rpc.BlockNumber(number))
@@ -1448,6 +2097,9 @@ func (api *PrivateDebugAPI) ChaindbCompact() error {
 	if !ok {
 		return fmt.Errorf("chaindbCompact does not work for memory databases")
 	}
+	if athdb.IsReadOnly(api.b.ChainDb()) {
+		return fmt.Errorf("chaindbCompact does not work for read-only databases")
+	}
 	for b := byte(0); b < 255; b++ {
 		log.Info("Compacting chain database", "range", fmt.Sprintf("0x%0.2X-0x%0.2X", b, b+1))
 		err := ldb.LDB().CompactRange(util.Range{Start: []byte{b}, Limit: []byte{b + 1}})
@@ -1459,9 +2111,15 @@ func (api *PrivateDebugAPI) ChaindbCompact() error {
 	return nil
 }
 
-// SetHead rewinds the head of the blockchain to a previous block.
-func (api *PrivateDebugAPI) SetHead(number hexutil.Uint64) {
-	api.b.SetHead(uint64(number))
+// SetHead rewinds the head of the blockchain to a previous block. If resetTxPool
+// is set, the transaction pool is revalidated against the new head, retaining the
+// transactions still valid there, instead of being left on the old state.
+func (api *PrivateDebugAPI) SetHead(number hexutil.Uint64, resetTxPool *bool) error {
+	if athdb.IsReadOnly(api.b.ChainDb()) {
+		return fmt.Errorf("setHead does not work for read-only databases")
+	}
+	api.b.SetHead(uint64(number), resetTxPool != nil && *resetTxPool)
+	return nil
 }
 
 // PublicNetAPI offers network related RPC methods
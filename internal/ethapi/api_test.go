@@ -0,0 +1,777 @@
+// Copyright 2018 The go-athereum Authors
+// This file is part of the go-athereum library.
+//
+// The go-athereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-athereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-athereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package athapi
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/athereum/go-athereum/accounts"
+	"github.com/athereum/go-athereum/accounts/keystore"
+	"github.com/athereum/go-athereum/athdb"
+	"github.com/athereum/go-athereum/common"
+	"github.com/athereum/go-athereum/common/hexutil"
+	"github.com/athereum/go-athereum/consensus/athash"
+	"github.com/athereum/go-athereum/core"
+	"github.com/athereum/go-athereum/core/state"
+	"github.com/athereum/go-athereum/core/types"
+	"github.com/athereum/go-athereum/core/vm"
+	"github.com/athereum/go-athereum/crypto"
+	"github.com/athereum/go-athereum/params"
+	"github.com/athereum/go-athereum/rlp"
+	"github.com/athereum/go-athereum/rpc"
+)
+
+// testBackend is a minimal API backend, implementing only the methods exercised
+// by the tests. Calling any other method panics.
+type testBackend struct {
+	Backend
+
+	am      *accounts.Manager // Account manager backed by a test keystore
+	pending *types.Block      // Block currently assembled by the miner, if any
+	state   *state.StateDB    // State to execute calls on, copied for every call
+	header  *types.Header     // Header of the block the state belongs to
+	db      athdb.Database    // Database of the chain transactions are pooled on
+	chain   *core.BlockChain  // Chain transactions are pooled on
+	pool    *core.TxPool      // Pool to submit transactions into
+	bodies  int               // Number of full blocks retrieved from the chain
+
+	noPersonal bool // Whether the personal namespace is left unregistered
+
+	pendingTxs []TxSummary // Executable pool transactions of the inspected account
+	queuedTxs  []TxSummary // Non-executable pool transactions of the inspected account
+}
+
+func (b *testBackend) AccountManager() *accounts.Manager {
+	return b.am
+}
+
+func (b *testBackend) PersonalAPIDisabled() bool {
+	return b.noPersonal
+}
+
+func (b *testBackend) PendingBlock() (*types.Block, error) {
+	return b.pending, nil
+}
+
+func (b *testBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	return b.state.Copy(), b.header, nil
+}
+
+func (b *testBackend) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	return b.state.Copy(), b.header, nil
+}
+
+func (b *testBackend) TxPoolInspectByAccount(addr common.Address) ([]TxSummary, []TxSummary) {
+	return b.pendingTxs, b.queuedTxs
+}
+
+func (b *testBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg vm.Config, overrideBalance bool) (*vm.EVM, func() error, error) {
+	if overrideBalance {
+		state.SetBalance(msg.From(), new(big.Int).Lsh(big.NewInt(1), 255))
+	}
+	context := core.NewEVMContext(msg, header, nil, &header.Coinbase)
+	return vm.NewEVM(context, state, params.TestChainConfig, vmCfg), func() error { return nil }, nil
+}
+
+func (b *testBackend) RPCEVMTimeout() time.Duration {
+	return 0
+}
+
+func (b *testBackend) ChainDb() athdb.Database {
+	return b.db
+}
+
+func (b *testBackend) ChainConfig() *params.ChainConfig {
+	return params.TestChainConfig
+}
+
+func (b *testBackend) CurrentBlock() *types.Block {
+	return b.chain.CurrentBlock()
+}
+
+func (b *testBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return b.chain.GetHeaderByHash(hash), nil
+}
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	if number < 0 {
+		return b.chain.CurrentHeader(), nil
+	}
+	return b.chain.GetHeaderByNumber(uint64(number)), nil
+}
+
+func (b *testBackend) GetCanonicalHash(ctx context.Context, number uint64) (common.Hash, error) {
+	return b.chain.GetCanonicalHash(number), nil
+}
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	if number < 0 {
+		return b.chain.CurrentBlock(), nil
+	}
+	return b.chain.GetBlockByNumber(uint64(number)), nil
+}
+
+func (b *testBackend) GetBlock(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	b.bodies++
+	return b.chain.GetBlockByHash(hash), nil
+}
+
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return b.chain.GetReceiptsByHash(hash), nil
+}
+
+func (b *testBackend) GetTd(hash common.Hash) *big.Int {
+	return b.chain.GetTdByHash(hash)
+}
+
+func (b *testBackend) SendTx(ctx context.Context, tx *types.Transaction) error {
+	return b.pool.AddLocal(tx)
+}
+
+func (b *testBackend) GetPoolTransaction(hash common.Hash) *types.Transaction {
+	return b.pool.Get(hash)
+}
+
+// newPoolBackend creates a test backend with a transaction pool on top of a chain
+// funding the given account.
+func newPoolBackend(t *testing.T, funded common.Address) *testBackend {
+	db := athdb.NewMemDatabase()
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  core.GenesisAlloc{funded: {Balance: big.NewInt(params.Atlantis)}},
+	}
+	genesis.MustCommit(db)
+
+	chain, err := core.NewBlockChain(db, nil, params.TestChainConfig, athash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	config := core.DefaultTxPoolConfig
+	config.Journal = ""
+
+	return &testBackend{db: db, chain: chain, pool: core.NewTxPool(config, params.TestChainConfig, chain)}
+}
+
+// newCallBackend creates a test backend to execute calls on, with the given
+// accounts deployed.
+func newCallBackend(t *testing.T, alloc map[common.Address][]byte) *testBackend {
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(athdb.NewMemDatabase()))
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	for addr, code := range alloc {
+		statedb.SetCode(addr, code)
+	}
+	header := &types.Header{Number: big.NewInt(1), Time: big.NewInt(0), Difficulty: big.NewInt(1), GasLimit: 8000000}
+	return &testBackend{state: statedb, header: header}
+}
+
+// Tests that the pending block transactions are reported as unavailable instead
+// of crashing while the miner hasn't assembled a pending block yet.
+func TestPendingBlockTransactionsUnavailable(t *testing.T) {
+	api := NewPublicTransactionPoolAPI(new(testBackend), new(AddrLocker))
+
+	if _, err := api.PendingBlockTransactions(); err != errPendingBlockUnavailable {
+		t.Fatalf("error mismatch: have %v, want %v", err, errPendingBlockUnavailable)
+	}
+}
+
+// Tests that the transactions of the pending block are returned in order.
+func TestPendingBlockTransactions(t *testing.T) {
+	txs := types.Transactions{
+		types.NewTransaction(0, common.Address{0x01}, nil, 21000, nil, nil),
+		types.NewTransaction(1, common.Address{0x02}, nil, 21000, nil, nil),
+	}
+	block := types.NewBlock(&types.Header{}, txs, nil, nil)
+	api := NewPublicTransactionPoolAPI(&testBackend{pending: block}, new(AddrLocker))
+
+	have, err := api.PendingBlockTransactions()
+	if err != nil {
+		t.Fatalf("failed to retrieve pending block transactions: %v", err)
+	}
+	if len(have) != len(txs) {
+		t.Fatalf("transaction count mismatch: have %d, want %d", len(have), len(txs))
+	}
+	for i, tx := range txs {
+		if have[i].Hash != tx.Hash() {
+			t.Errorf("transaction %d: hash mismatch: have %x, want %x", i, have[i].Hash, tx.Hash())
+		}
+	}
+}
+
+// Tests that an atomic batch unlock rolls back the accounts it unlocked if any of
+// them fails, but leaves the ones unlocked beforehand alone.
+func TestUnlockAccountsAtomic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethapi-keystore-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary keystore: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	signer, _ := ks.NewAccount("foo")
+	fresh, _ := ks.NewAccount("foo")
+	broken, _ := ks.NewAccount("bar")
+
+	if err := ks.Unlock(signer, "foo"); err != nil {
+		t.Fatalf("failed to unlock signer: %v", err)
+	}
+	api := NewPrivateAccountAPI(&testBackend{am: accounts.NewManager(ks)}, new(AddrLocker))
+	atomic := true
+
+	// Ensure a failed atomic unlock only reverts its own unlocks
+	results, err := api.UnlockAccounts([]common.Address{signer.Address, fresh.Address, broken.Address}, "foo", nil, &atomic)
+	if err != nil {
+		t.Fatalf("failed to unlock accounts: %v", err)
+	}
+	if results[broken.Address].Unlocked || results[broken.Address].Error == "" {
+		t.Errorf("account with mismatching password unlocked: %+v", results[broken.Address])
+	}
+	if results[fresh.Address].Unlocked || isUnlocked(ks, fresh.Address) {
+		t.Errorf("newly unlocked account not rolled back")
+	}
+	if !results[signer.Address].Unlocked || !isUnlocked(ks, signer.Address) {
+		t.Errorf("previously unlocked account locked by rollback")
+	}
+	// Ensure a successful atomic unlock keeps all the accounts unlocked
+	results, err = api.UnlockAccounts([]common.Address{signer.Address, fresh.Address}, "foo", nil, &atomic)
+	if err != nil {
+		t.Fatalf("failed to unlock accounts: %v", err)
+	}
+	for _, addr := range []common.Address{signer.Address, fresh.Address} {
+		if !results[addr].Unlocked || !isUnlocked(ks, addr) {
+			t.Errorf("account %x not unlocked: %+v", addr, results[addr])
+		}
+	}
+}
+
+// Tests that state overrides patch the nonce, code, balance and storage of the
+// accounts a call executes against, with null fields being ignored.
+func TestCallStateOverrides(t *testing.T) {
+	var (
+		sender   = common.Address{0xaa}
+		contract = common.Address{0xcc}
+		// Returns the address of an empty contract created by the callee
+		creator = []byte{0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0xf0, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3}
+		// Returns the balance of the callee
+		balance = []byte{0x30, 0x31, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3}
+		// Returns storage slot zero of the callee
+		storage = []byte{0x60, 0x00, 0x54, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3}
+	)
+	api := NewPublicBlockChainAPI(newCallBackend(t, map[common.Address][]byte{contract: storage}))
+
+	decode := func(overrides string) *StateOverride {
+		override := new(StateOverride)
+		if err := json.Unmarshal([]byte(overrides), override); err != nil {
+			t.Fatalf("failed to decode overrides: %v", err)
+		}
+		return override
+	}
+	tests := []struct {
+		overrides string
+		want      common.Hash
+	}{
+		// No overrides, the deployed code reads empty storage
+		{`{}`, common.Hash{}},
+		// Patched storage on top of the deployed code
+		{`{"0x` + common.Bytes2Hex(contract[:]) + `": {"stateDiff": {"0x0000000000000000000000000000000000000000000000000000000000000000": "0x0000000000000000000000000000000000000000000000000000000000000007"}}}`, common.BigToHash(big.NewInt(7))},
+		// Replaced code reading the balance, with and without a balance override
+		{`{"0x` + common.Bytes2Hex(contract[:]) + `": {"code": "` + hexutil.Encode(balance) + `", "balance": "0x2a"}}`, common.BigToHash(big.NewInt(42))},
+		{`{"0x` + common.Bytes2Hex(contract[:]) + `": {"code": "` + hexutil.Encode(balance) + `", "balance": null}}`, common.Hash{}},
+		// Replaced code creating a contract, the address depending on the nonce
+		{`{"0x` + common.Bytes2Hex(contract[:]) + `": {"code": "` + hexutil.Encode(creator) + `", "nonce": "0x5"}}`, common.BytesToHash(crypto.CreateAddress(contract, 5).Bytes())},
+		// A null sender balance must not crash the call
+		{`{"0x` + common.Bytes2Hex(sender[:]) + `": {"balance": null}}`, common.Hash{}},
+	}
+	for i, tt := range tests {
+		args := CallArgs{From: sender, To: &contract, Gas: 1000000}
+		res, err := api.Call(context.Background(), args, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), decode(tt.overrides))
+		if err != nil {
+			t.Errorf("test %d: call failed: %v", i, err)
+			continue
+		}
+		if common.BytesToHash(res) != tt.want {
+			t.Errorf("test %d: result mismatch: have %x, want %x", i, res, tt.want)
+		}
+	}
+}
+
+// Tests that gas estimation runs against the overridden state.
+func TestEstimateGasStateOverrides(t *testing.T) {
+	var (
+		sender   = common.Address{0xaa}
+		contract = common.Address{0xcc}
+		// Stores a value into storage slot zero
+		storer = []byte{0x60, 0x01, 0x60, 0x00, 0x55, 0x00}
+	)
+	api := NewPublicBlockChainAPI(newCallBackend(t, nil))
+
+	estimate := func(overrides *StateOverride) (uint64, error) {
+		args := CallArgs{From: sender, To: &contract, Gas: 1000000}
+		gas, err := api.EstimateGas(context.Background(), args, overrides)
+		return uint64(gas), err
+	}
+	// A plain transfer to the empty account costs the intrinsic gas
+	if gas, err := estimate(nil); err != nil || gas != params.TxGas {
+		t.Fatalf("plain estimate mismatch: have %d/%v, want %d", gas, err, params.TxGas)
+	}
+	// Overridden code writing to storage costs more
+	code := hexutil.Bytes(storer)
+	gas, err := estimate(&StateOverride{contract: {Code: &code}})
+	if err != nil {
+		t.Fatalf("failed to estimate with code override: %v", err)
+	}
+	if gas < params.TxGas+params.SstoreSetGas {
+		t.Fatalf("code override ignored: estimated %d gas", gas)
+	}
+	// Patched storage turns the write into a cheaper reset
+	reset, err := estimate(&StateOverride{contract: {Code: &code, StateDiff: map[common.Hash]common.Hash{{}: {0x01}}}})
+	if err != nil {
+		t.Fatalf("failed to estimate with storage override: %v", err)
+	}
+	if reset >= gas {
+		t.Fatalf("storage override ignored: estimated %d gas, want below %d", reset, gas)
+	}
+	// An empty sender balance can't pay for the gas, a null one is ignored
+	empty := (*hexutil.Big)(new(big.Int))
+	if _, err := estimate(&StateOverride{sender: {Balance: &empty}}); err == nil {
+		t.Fatalf("estimate succeeded without funds")
+	}
+	var null *hexutil.Big
+	nonce := hexutil.Uint64(1)
+	if gas, err := estimate(&StateOverride{sender: {Balance: &null, Nonce: &nonce}}); err != nil || gas != params.TxGas {
+		t.Fatalf("null balance estimate mismatch: have %d/%v, want %d", gas, err, params.TxGas)
+	}
+}
+
+// Tests that calls opting out of the unlimited sender allowance execute with the
+// sender's real balance, unless it's explicitly overridden.
+func TestEstimateGasRealBalance(t *testing.T) {
+	var (
+		sender   = common.Address{0xaa}
+		contract = common.Address{0xcc}
+	)
+	backend := newCallBackend(t, nil)
+	backend.state.SetBalance(sender, big.NewInt(1))
+	api := NewPublicBlockChainAPI(backend)
+
+	estimate := func(real bool, overrides *StateOverride) (uint64, error) {
+		args := CallArgs{From: sender, To: &contract, Gas: 1000000, RealBalance: real}
+		gas, err := api.EstimateGas(context.Background(), args, overrides)
+		return uint64(gas), err
+	}
+	// The sender can't pay for the gas with its real balance
+	if gas, err := estimate(false, nil); err != nil || gas != params.TxGas {
+		t.Fatalf("allowance estimate mismatch: have %d/%v, want %d", gas, err, params.TxGas)
+	}
+	if _, err := estimate(true, nil); err == nil {
+		t.Fatalf("estimate succeeded with insufficient real funds")
+	}
+	// An explicit balance override funds the sender, a null one is ignored
+	funds := (*hexutil.Big)(big.NewInt(params.Atlantis))
+	if gas, err := estimate(true, &StateOverride{sender: {Balance: &funds}}); err != nil || gas != params.TxGas {
+		t.Fatalf("funded estimate mismatch: have %d/%v, want %d", gas, err, params.TxGas)
+	}
+	var null *hexutil.Big
+	if _, err := estimate(true, &StateOverride{sender: {Balance: &null}}); err == nil {
+		t.Fatalf("estimate succeeded with insufficient real funds and a null override")
+	}
+}
+
+// Tests that pending transactions can only be replaced by transactions of the same
+// sender and nonce, paying a gas price sufficiently higher to be accepted.
+func TestReplaceTransaction(t *testing.T) {
+	var (
+		key, _    = crypto.GenerateKey()
+		other, _  = crypto.GenerateKey()
+		sender    = crypto.PubkeyToAddress(key.PublicKey)
+		signer    = types.NewEIP155Signer(params.TestChainConfig.ChainID)
+		recipient = common.Address{0x01}
+	)
+	backend := newPoolBackend(t, sender)
+	defer backend.chain.Stop()
+	defer backend.pool.Stop()
+
+	api := NewPublicTransactionPoolAPI(backend, new(AddrLocker))
+
+	transaction := func(key *ecdsa.PrivateKey, nonce uint64, price int64) (*types.Transaction, hexutil.Bytes) {
+		tx, err := types.SignTx(types.NewTransaction(nonce, recipient, big.NewInt(1), params.TxGas, big.NewInt(price), nil), signer, key)
+		if err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		blob, err := rlp.EncodeToBytes(tx)
+		if err != nil {
+			t.Fatalf("failed to encode transaction: %v", err)
+		}
+		return tx, blob
+	}
+	old, _ := transaction(key, 0, 100)
+	if err := backend.pool.AddLocal(old); err != nil {
+		t.Fatalf("failed to pool transaction: %v", err)
+	}
+	// Replacing unknown transactions should fail
+	_, blob := transaction(key, 0, 200)
+	if _, err := api.ReplaceTransaction(context.Background(), common.Hash{0xff}, blob); err == nil {
+		t.Fatalf("replaced unknown transaction")
+	}
+	// Replacements from other accounts, with other nonces or not paying more should fail
+	_, blob = transaction(other, 0, 200)
+	if _, err := api.ReplaceTransaction(context.Background(), old.Hash(), blob); err == nil {
+		t.Fatalf("replaced transaction from other sender")
+	}
+	_, blob = transaction(key, 1, 200)
+	if _, err := api.ReplaceTransaction(context.Background(), old.Hash(), blob); err == nil {
+		t.Fatalf("replaced transaction with other nonce")
+	}
+	_, blob = transaction(key, 0, 100)
+	if _, err := api.ReplaceTransaction(context.Background(), old.Hash(), blob); err == nil {
+		t.Fatalf("replaced transaction without gas price increase")
+	}
+	// Replacements not satisfying the pool's price bump should be rejected by it
+	_, blob = transaction(key, 0, 105)
+	if _, err := api.ReplaceTransaction(context.Background(), old.Hash(), blob); err != core.ErrReplaceUnderpriced {
+		t.Fatalf("price bump error mismatch: have %v, want %v", err, core.ErrReplaceUnderpriced)
+	}
+	// Sufficiently priced replacements should swap out the old transaction
+	tx, blob := transaction(key, 0, 200)
+	hash, err := api.ReplaceTransaction(context.Background(), old.Hash(), blob)
+	if err != nil {
+		t.Fatalf("failed to replace transaction: %v", err)
+	}
+	if hash != tx.Hash() {
+		t.Fatalf("replacement hash mismatch: have %x, want %x", hash, tx.Hash())
+	}
+	if backend.pool.Get(old.Hash()) != nil {
+		t.Fatalf("replaced transaction still pooled")
+	}
+	if backend.pool.Get(tx.Hash()) == nil {
+		t.Fatalf("replacement transaction not pooled")
+	}
+}
+
+// Tests that blocks are retrieved by hash, without retrieving the bodies of
+// unknown ones.
+func TestGetBlockByHash(t *testing.T) {
+	backend := newPoolBackend(t, common.Address{})
+	defer backend.chain.Stop()
+	defer backend.pool.Stop()
+
+	api := NewPublicBlockChainAPI(backend)
+
+	if block, err := api.GetBlockByHash(context.Background(), common.Hash{0x01}, false); block != nil || err != nil {
+		t.Fatalf("unknown block mismatch: have %v/%v, want nil", block, err)
+	}
+	if backend.bodies != 0 {
+		t.Fatalf("unknown block body retrieved")
+	}
+	genesis := backend.chain.Genesis()
+	block, err := api.GetBlockByHash(context.Background(), genesis.Hash(), false)
+	if err != nil {
+		t.Fatalf("failed to retrieve block: %v", err)
+	}
+	if block["hash"] != genesis.Hash() {
+		t.Fatalf("block hash mismatch: have %v, want %x", block["hash"], genesis.Hash())
+	}
+}
+
+// Tests that the account status reports the pooled transaction counts along with
+// the nonce gaps between the account nonce and its queued transactions.
+func TestTxPoolAccountStatus(t *testing.T) {
+	addr := common.Address{0x01}
+
+	backend := newCallBackend(t, nil)
+	backend.state.SetNonce(addr, 2)
+
+	summaries := func(nonces ...uint64) []TxSummary {
+		var txs types.Transactions
+		for _, nonce := range nonces {
+			txs = append(txs, types.NewTransaction(nonce, common.Address{}, new(big.Int), params.TxGas, new(big.Int), nil))
+		}
+		return SummarizeTxs(txs)
+	}
+	tests := []struct {
+		pending []TxSummary
+		queued  []TxSummary
+		gaps    []NonceGap
+	}{
+		{nil, nil, []NonceGap{}},
+		{summaries(2, 3), nil, []NonceGap{}},
+		{nil, summaries(4), []NonceGap{{From: 2, To: 3}}},
+		{summaries(2), summaries(5, 6, 9), []NonceGap{{From: 3, To: 4}, {From: 7, To: 8}}},
+	}
+	for i, tt := range tests {
+		backend.pendingTxs, backend.queuedTxs = tt.pending, tt.queued
+
+		status, err := NewPublicTxPoolAPI(backend).AccountStatus(context.Background(), addr)
+		if err != nil {
+			t.Fatalf("test %d: failed to retrieve account status: %v", i, err)
+		}
+		if status.Nonce != 2 || int(status.Pending) != len(tt.pending) || int(status.Queued) != len(tt.queued) {
+			t.Errorf("test %d: status mismatch: have nonce %d, %d/%d pending/queued, want nonce 2, %d/%d", i, status.Nonce, status.Pending, status.Queued, len(tt.pending), len(tt.queued))
+		}
+		if !reflect.DeepEqual(status.NonceGaps, tt.gaps) {
+			t.Errorf("test %d: nonce gaps mismatch: have %v, want %v", i, status.NonceGaps, tt.gaps)
+		}
+	}
+}
+
+// Tests that the active chain config is served over RPC with the fork schedule,
+// omitting the forks and consensus engines not configured.
+func TestChainConfig(t *testing.T) {
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("ath", NewPublicAtlantisAPI(new(testBackend))); err != nil {
+		t.Fatalf("failed to register API: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	var config map[string]interface{}
+	if err := client.Call(&config, "ath_chainConfig"); err != nil {
+		t.Fatalf("failed to retrieve chain config: %v", err)
+	}
+	for field, want := range map[string]interface{}{"chainId": 1.0, "homesteadBlock": 0.0, "byzantiumBlock": 0.0, "constantinopleBlock": nil, "clique": nil} {
+		if have := config[field]; have != want {
+			t.Errorf("%s mismatch: have %v, want %v", field, have, want)
+		}
+	}
+	if _, ok := config["athash"]; !ok {
+		t.Errorf("athash config missing")
+	}
+}
+
+// Tests that the raw RLP encoding of blocks and headers can be retrieved both by
+// number and by hash, reproducing the original block hash.
+func TestGetRawBlockAndHeader(t *testing.T) {
+	backend := newPoolBackend(t, common.Address{})
+	defer backend.chain.Stop()
+	defer backend.pool.Stop()
+
+	blocks, _ := core.GenerateChain(params.TestChainConfig, backend.chain.Genesis(), athash.NewFaker(), backend.db, 2, nil)
+	if _, err := backend.chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	api := NewPublicDebugAPI(backend)
+
+	want := blocks[0]
+	for i, id := range []rpc.BlockNumberOrHash{
+		rpc.BlockNumberOrHashWithNumber(1),
+		rpc.BlockNumberOrHashWithHash(want.Hash(), false),
+	} {
+		raw, err := api.GetRawBlock(context.Background(), id)
+		if err != nil {
+			t.Fatalf("test %d: failed to retrieve raw block: %v", i, err)
+		}
+		block := new(types.Block)
+		if err := rlp.DecodeBytes(raw, block); err != nil {
+			t.Fatalf("test %d: failed to decode raw block: %v", i, err)
+		}
+		if block.Hash() != want.Hash() {
+			t.Errorf("test %d: block hash mismatch: have %x, want %x", i, block.Hash(), want.Hash())
+		}
+		raw, err = api.GetRawHeader(context.Background(), id)
+		if err != nil {
+			t.Fatalf("test %d: failed to retrieve raw header: %v", i, err)
+		}
+		if hash := crypto.Keccak256Hash(raw); hash != want.Hash() {
+			t.Errorf("test %d: header hash mismatch: have %x, want %x", i, hash, want.Hash())
+		}
+	}
+	if _, err := api.GetRawBlock(context.Background(), rpc.BlockNumberOrHashWithNumber(10)); err == nil {
+		t.Errorf("unknown block retrieved")
+	}
+	if _, err := api.GetRawHeader(context.Background(), rpc.BlockNumberOrHashWithHash(common.Hash{0x01}, false)); err == nil {
+		t.Errorf("unknown header retrieved")
+	}
+}
+
+// Tests that balances are returned in the order of the requested addresses, and
+// that requests exceeding the address cap are rejected.
+func TestGetBalances(t *testing.T) {
+	backend := newCallBackend(t, nil)
+	backend.state.SetBalance(common.Address{0x01}, big.NewInt(1))
+	backend.state.SetBalance(common.Address{0x02}, big.NewInt(2))
+	api := NewPublicBlockChainAPI(backend)
+
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	balances, err := api.GetBalances(context.Background(), []common.Address{{0x02}, {0x03}, {0x01}}, latest)
+	if err != nil {
+		t.Fatalf("failed to retrieve balances: %v", err)
+	}
+	want := []int64{2, 0, 1}
+	if len(balances) != len(want) {
+		t.Fatalf("balance count mismatch: have %d, want %d", len(balances), len(want))
+	}
+	for i, balance := range balances {
+		if balance.ToInt().Int64() != want[i] {
+			t.Errorf("balance %d mismatch: have %v, want %d", i, balance, want[i])
+		}
+	}
+	if _, err := api.GetBalances(context.Background(), make([]common.Address, MaxBalanceAddresses+1), latest); err == nil {
+		t.Errorf("oversized balance request accepted")
+	}
+}
+
+// Tests that the canonical hashes are returned for heights up to the head, and
+// nil beyond it.
+func TestGetBlockHashByNumber(t *testing.T) {
+	backend := newPoolBackend(t, common.Address{})
+	defer backend.chain.Stop()
+	defer backend.pool.Stop()
+
+	blocks, _ := core.GenerateChain(params.TestChainConfig, backend.chain.Genesis(), athash.NewFaker(), backend.db, 2, nil)
+	if _, err := backend.chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	api := NewPublicBlockChainAPI(backend)
+
+	tests := []struct {
+		number rpc.BlockNumber
+		hash   common.Hash // Zero if no hash is expected
+	}{
+		{0, backend.chain.Genesis().Hash()},
+		{1, blocks[0].Hash()},
+		{rpc.LatestBlockNumber, blocks[1].Hash()},
+		{3, common.Hash{}},
+	}
+	for i, tt := range tests {
+		hash, err := api.GetBlockHashByNumber(context.Background(), tt.number)
+		if err != nil {
+			t.Errorf("test %d: failed to retrieve hash: %v", i, err)
+			continue
+		}
+		switch {
+		case tt.hash == (common.Hash{}) && hash != nil:
+			t.Errorf("test %d: hash mismatch: have %x, want nil", i, *hash)
+		case tt.hash != (common.Hash{}) && (hash == nil || *hash != tt.hash):
+			t.Errorf("test %d: hash mismatch: have %v, want %x", i, hash, tt.hash)
+		}
+	}
+}
+
+// Tests that messages are signed according to the requested mode, the prefixed
+// signatures being recoverable from the message and the raw ones from the hash.
+func TestSignWithMode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethapi-keystore-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary keystore: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	signer, _ := ks.NewAccount("foo")
+
+	api := NewPrivateAccountAPI(&testBackend{am: accounts.NewManager(ks)}, new(AddrLocker))
+	var (
+		ctx      = context.Background()
+		message  = hexutil.Bytes("hello world")
+		hash     = hexutil.Bytes(crypto.Keccak256(message))
+		prefixed = SignModePrefixed
+		raw      = SignModeRaw
+		unknown  = "unknown"
+	)
+	// Ensure prefixed signatures are the default and recoverable from the message
+	for _, mode := range []*string{nil, &prefixed} {
+		sig, err := api.SignWithMode(ctx, message, signer.Address, "foo", mode)
+		if err != nil {
+			t.Fatalf("failed to sign prefixed message: %v", err)
+		}
+		if len(sig) != 65 || (sig[64] != 27 && sig[64] != 28) {
+			t.Fatalf("signature format mismatch: have %x", sig)
+		}
+		if addr, err := api.EcRecover(ctx, message, sig); err != nil || addr != signer.Address {
+			t.Errorf("prefixed signer mismatch: have %x/%v, want %x", addr, err, signer.Address)
+		}
+	}
+	// Ensure raw signatures are recoverable from the hash itself
+	sig, err := api.SignWithMode(ctx, hash, signer.Address, "foo", &raw)
+	if err != nil {
+		t.Fatalf("failed to sign raw hash: %v", err)
+	}
+	sig[64] -= 27
+	pubkey, err := crypto.SigToPub(hash, sig)
+	if err != nil || crypto.PubkeyToAddress(*pubkey) != signer.Address {
+		t.Errorf("raw signer mismatch: have %v, want %x", err, signer.Address)
+	}
+	// Ensure invalid raw hashes, unknown modes and wrong passwords are rejected
+	if _, err := api.SignWithMode(ctx, message, signer.Address, "foo", &raw); err == nil {
+		t.Errorf("raw signing of a non-hash accepted")
+	}
+	if _, err := api.SignWithMode(ctx, message, signer.Address, "foo", &unknown); err == nil {
+		t.Errorf("unknown signing mode accepted")
+	}
+	if _, err := api.SignWithMode(ctx, message, signer.Address, "bar", &prefixed); err == nil {
+		t.Errorf("signing with a wrong password accepted")
+	}
+}
+
+// Tests that transaction receipts include the effective gas price, such that the
+// fee paid is the gas used times this price.
+func TestGetTransactionReceiptEffectiveGasPrice(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+
+	backend := newPoolBackend(t, sender)
+	defer backend.chain.Stop()
+	defer backend.pool.Stop()
+
+	tx, _ := types.SignTx(types.NewTransaction(0, common.Address{0x01}, big.NewInt(1), params.TxGas, big.NewInt(5), nil), types.HomesteadSigner{}, key)
+	blocks, _ := core.GenerateChain(params.TestChainConfig, backend.chain.Genesis(), athash.NewFaker(), backend.db, 1, func(i int, b *core.BlockGen) {
+		b.AddTx(tx)
+	})
+	if _, err := backend.chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	receipt, err := NewPublicTransactionPoolAPI(backend, new(AddrLocker)).GetTransactionReceipt(context.Background(), tx.Hash())
+	if err != nil || receipt == nil {
+		t.Fatalf("failed to retrieve receipt: %v", err)
+	}
+	price := receipt["effectiveGasPrice"].(*hexutil.Big).ToInt()
+	if price.Cmp(tx.GasPrice()) != 0 {
+		t.Errorf("effective gas price mismatch: have %v, want %v", price, tx.GasPrice())
+	}
+	// Ensure the fee derived from the receipt matches the balance charged
+	state, _ := backend.chain.State()
+	fee := new(big.Int).Mul(price, new(big.Int).SetUint64(uint64(receipt["gasUsed"].(hexutil.Uint64))))
+	spent := new(big.Int).Sub(big.NewInt(params.Atlantis), state.GetBalance(sender))
+	if want := new(big.Int).Add(fee, tx.Value()); spent.Cmp(want) != 0 {
+		t.Errorf("charged amount mismatch: have %v, want %v", spent, want)
+	}
+}
+
+// Tests that the personal namespace is only registered if not disabled.
+func TestGetAPIsPersonalDisabled(t *testing.T) {
+	for _, disabled := range []bool{false, true} {
+		var registered bool
+		for _, api := range GetAPIs(&testBackend{noPersonal: disabled}) {
+			if api.Namespace == "personal" {
+				registered = true
+			}
+		}
+		if registered == disabled {
+			t.Errorf("personal API registration mismatch with disabled %v: have %v, want %v", disabled, registered, !disabled)
+		}
+	}
+}
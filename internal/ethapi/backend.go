@@ -19,7 +19,10 @@ package athapi
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/athereum/go-athereum/accounts"
 	"github.com/athereum/go-athereum/common"
@@ -34,6 +37,22 @@ import (
 	"github.com/athereum/go-athereum/rpc"
 )
 
+// MaxReceiptsRange is the maximum number of blocks whose receipts are served by
+// a single GetReceiptsRange call. Longer ranges need to be paginated.
+const MaxReceiptsRange = 1024
+
+// ErrPendingBlockUnsupported is returned by backends which don't assemble a
+// pending block, such as light clients.
+var ErrPendingBlockUnsupported = errors.New("pending block unsupported on light client")
+
+// errPendingBlockUnavailable is returned when the pending block is requested
+// before the miner assembled one.
+var errPendingBlockUnavailable = errors.New("pending block not available")
+
+// ErrRebroadcastUnsupported is returned by backends which can't push pooled
+// transactions to their peers on demand, such as light clients.
+var ErrRebroadcastUnsupported = errors.New("transaction rebroadcast unsupported on light client")
+
 // Backend interface provides the common API services (that are provided by
 // both full and light clients) with access to necessary functions.
 type Backend interface {
@@ -41,19 +60,30 @@ type Backend interface {
 	Downloader() *downloader.Downloader
 	ProtocolVersion() int
 	SuggestPrice(ctx context.Context) (*big.Int, error)
+	FeeHistory(ctx context.Context, blockCount int, lastBlock rpc.BlockNumber, percentiles []float64) (*big.Int, [][]*big.Int, []*big.Int, []float64, error)
 	ChainDb() athdb.Database
 	EventMux() *event.TypeMux
 	AccountManager() *accounts.Manager
+	RPCEVMTimeout() time.Duration // Execution time limit of ath_call, zero if unlimited
+	PersonalAPIDisabled() bool    // Whether the personal namespace is left unregistered
 
 	// BlockChain API
-	SetHead(number uint64)
+	SetHead(number uint64, resetTxPool bool)
 	HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error)
+	HeaderByHash(ctx context.Context, blockHash common.Hash) (*types.Header, error)
+	GetCanonicalHash(ctx context.Context, number uint64) (common.Hash, error)
 	BlockByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Block, error)
 	StateAndHeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDB, *types.Header, error)
+	StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error)
+	GetCodeSize(ctx context.Context, addr common.Address, blockNrOrHash rpc.BlockNumberOrHash) (int, error)
 	GetBlock(ctx context.Context, blockHash common.Hash) (*types.Block, error)
+	PendingBlock() (*types.Block, error)
+	GetBlockTransactions(ctx context.Context, blockHash common.Hash) (types.Transactions, error)
 	GetReceipts(ctx context.Context, blockHash common.Hash) (types.Receipts, error)
+	GetReceiptsRange(ctx context.Context, from, to rpc.BlockNumber) ([]types.Receipts, error)
+	GetBlockLogs(ctx context.Context, blockHash common.Hash) ([]*types.Log, error)
 	GetTd(blockHash common.Hash) *big.Int
-	GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg vm.Config) (*vm.EVM, func() error, error)
+	GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg vm.Config, overrideBalance bool) (*vm.EVM, func() error, error)
 	SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription
 	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
 	SubscribeChainSideEvent(ch chan<- core.ChainSideEvent) event.Subscription
@@ -65,15 +95,98 @@ type Backend interface {
 	GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error)
 	Stats() (pending int, queued int)
 	TxPoolContent() (map[common.Address]types.Transactions, map[common.Address]types.Transactions)
+	TxPoolInspectByAccount(addr common.Address) (pending []TxSummary, queued []TxSummary)
+	TxPoolLocals() map[common.Address]types.Transactions
+	TxPoolMarkLocal(txHash common.Hash) error
+	TxPoolRebroadcast(txHash common.Hash) (int, error)
 	SubscribeNewTxsEvent(chan<- core.NewTxsEvent) event.Subscription
 
 	ChainConfig() *params.ChainConfig
 	CurrentBlock() *types.Block
 }
 
+// ReceiptsRangeBounds resolves a block range requested via GetReceiptsRange into
+// absolute block numbers, substituting the given head for the latest and pending
+// meta blocks. An error is returned if the range is inverted or exceeds the
+// MaxReceiptsRange limit.
+func ReceiptsRangeBounds(from, to rpc.BlockNumber, head uint64) (uint64, uint64, error) {
+	resolve := func(number rpc.BlockNumber) uint64 {
+		if number < 0 {
+			return head
+		}
+		return uint64(number)
+	}
+	first, last := resolve(from), resolve(to)
+	if first > last {
+		return 0, 0, fmt.Errorf("invalid receipts range: from block %d is after to block %d", first, last)
+	}
+	if last-first >= MaxReceiptsRange {
+		return 0, 0, fmt.Errorf("receipts range too large: %d blocks requested, maximum is %d", last-first+1, MaxReceiptsRange)
+	}
+	return first, last, nil
+}
+
+// FlattenLogs collects the logs of a block's receipts into a single slice in
+// their order of emission, filling in the positional fields of each log. The
+// logs are copied, leaving the receipts themselves untouched.
+func FlattenLogs(hash common.Hash, number uint64, receipts types.Receipts) []*types.Log {
+	var (
+		logs  []*types.Log
+		index uint
+	)
+	for i, receipt := range receipts {
+		for _, log := range receipt.Logs {
+			cpy := *log
+			cpy.BlockHash = hash
+			cpy.BlockNumber = number
+			cpy.TxHash = receipt.TxHash
+			cpy.TxIndex = uint(i)
+			cpy.Index = index
+			logs = append(logs, &cpy)
+			index++
+		}
+	}
+	return logs
+}
+
+// EffectiveGasPrice returns the price per unit of gas actually paid by a mined
+// transaction, such that the fee charged is the receipt's gas used times this
+// price. Without a base fee, this is the gas price set in the transaction.
+func EffectiveGasPrice(tx *types.Transaction) *big.Int {
+	return tx.GasPrice()
+}
+
+// TxSummary is the brief description of a pooled transaction reported by the
+// per-account transaction pool inspection.
+type TxSummary struct {
+	Hash     common.Hash
+	Nonce    uint64
+	To       *common.Address
+	Value    *big.Int
+	Gas      uint64
+	GasPrice *big.Int
+}
+
+// SummarizeTxs converts a list of pooled transactions into their summaries,
+// retaining their order.
+func SummarizeTxs(txs types.Transactions) []TxSummary {
+	summaries := make([]TxSummary, len(txs))
+	for i, tx := range txs {
+		summaries[i] = TxSummary{
+			Hash:     tx.Hash(),
+			Nonce:    tx.Nonce(),
+			To:       tx.To(),
+			Value:    tx.Value(),
+			Gas:      tx.Gas(),
+			GasPrice: tx.GasPrice(),
+		}
+	}
+	return summaries
+}
+
 func GetAPIs(apiBackend Backend) []rpc.API {
 	nonceLock := new(AddrLocker)
-	return []rpc.API{
+	apis := []rpc.API{
 		{
 			Namespace: "ath",
 			Version:   "1.0",
@@ -94,6 +207,10 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 			Version:   "1.0",
 			Service:   NewPublicTxPoolAPI(apiBackend),
 			Public:    true,
+		}, {
+			Namespace: "txpool",
+			Version:   "1.0",
+			Service:   NewPrivateTxPoolAPI(apiBackend),
 		}, {
 			Namespace: "debug",
 			Version:   "1.0",
@@ -108,11 +225,15 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 			Version:   "1.0",
 			Service:   NewPublicAccountAPI(apiBackend.AccountManager()),
 			Public:    true,
-		}, {
+		},
+	}
+	if !apiBackend.PersonalAPIDisabled() {
+		apis = append(apis, rpc.API{
 			Namespace: "personal",
 			Version:   "1.0",
 			Service:   NewPrivateAccountAPI(apiBackend, nonceLock),
 			Public:    false,
-		},
+		})
 	}
+	return apis
 }
@@ -123,17 +123,53 @@ web3._extend({
 			call: 'admin_removePeer',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'dropPeer',
+			call: 'admin_dropPeer',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
 		new web3._extend.Method({
 			name: 'exportChain',
 			call: 'admin_exportChain',
-			params: 1,
-			inputFormatter: [null]
+			params: 3,
+			inputFormatter: [null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'resyncFrom',
+			call: 'admin_resyncFrom',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'reindexBloom',
+			call: 'admin_reindexBloom',
+			params: 2
 		}),
 		new web3._extend.Method({
 			name: 'importChain',
 			call: 'admin_importChain',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'compactChainDb',
+			call: 'admin_compactChainDb',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'exportTxPool',
+			call: 'admin_exportTxPool',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'importTxPool',
+			call: 'admin_importTxPool',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'peerBandwidth',
+			call: 'admin_peerBandwidth'
+		}),
 		new web3._extend.Method({
 			name: 'sleepBlocks',
 			call: 'admin_sleepBlocks',
@@ -191,10 +227,26 @@ web3._extend({
 			call: 'debug_getBlockRlp',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'dbGet',
+			call: 'debug_dbGet',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getRawBlock',
+			call: 'debug_getRawBlock',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getRawHeader',
+			call: 'debug_getRawHeader',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'setHead',
 			call: 'debug_setHead',
-			params: 1
+			params: 2,
+			inputFormatter: [null, null]
 		}),
 		new web3._extend.Method({
 			name: 'seedHash',
@@ -262,6 +314,11 @@ web3._extend({
 			call: 'debug_gcStats',
 			params: 0,
 		}),
+		new web3._extend.Method({
+			name: 'runtimeStats',
+			call: 'debug_runtimeStats',
+			params: 0,
+		}),
 		new web3._extend.Method({
 			name: 'cpuProfile',
 			call: 'debug_cpuProfile',
@@ -363,6 +420,16 @@ web3._extend({
 			params: 1,
 			inputFormatter: [null]
 		}),
+		new web3._extend.Method({
+			name: 'exportPreimages',
+			call: 'debug_exportPreimages',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'importPreimages',
+			call: 'debug_importPreimages',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'getBadBlocks',
 			call: 'debug_getBadBlocks',
@@ -431,6 +498,63 @@ web3._extend({
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, web3._extend.utils.toHex]
 		}),
+		new web3._extend.Method({
+			name: 'getBlockHashByNumber',
+			call: 'ath_getBlockHashByNumber',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getBalances',
+			call: 'ath_getBalances',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getCodeSize',
+			call: 'ath_getCodeSize',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputBlockNumberFormatter],
+			outputFormatter: web3._extend.utils.toDecimal
+		}),
+		new web3._extend.Method({
+			name: 'getProof',
+			call: 'ath_getProof',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getReceiptsRange',
+			call: 'ath_getReceiptsRange',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'replaceTransaction',
+			call: 'ath_replaceTransaction',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'chainConfig',
+			call: 'ath_chainConfig',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'consensusInfo',
+			call: 'ath_consensusInfo',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'feeHistory',
+			call: 'ath_feeHistory',
+			params: 3,
+			inputFormatter: [web3._extend.utils.toHex, web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'getTransactionsByBlockHash',
+			call: 'ath_getTransactionsByBlockHash',
+			params: 1
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -445,6 +569,43 @@ web3._extend({
 				return formatted;
 			}
 		}),
+		new web3._extend.Property({
+			name: 'pendingBlockTransactions',
+			getter: 'ath_pendingBlockTransactions',
+			outputFormatter: function(txs) {
+				var formatted = [];
+				for (var i = 0; i < txs.length; i++) {
+					formatted.push(web3._extend.formatters.outputTransactionFormatter(txs[i]));
+					formatted[i].blockHash = null;
+				}
+				return formatted;
+			}
+		}),
+		new web3._extend.Property({
+			name: 'syncETA',
+			getter: 'ath_syncETA',
+			outputFormatter: web3._extend.utils.toDecimal
+		}),
+		new web3._extend.Property({
+			name: 'syncMode',
+			getter: 'ath_syncMode'
+		}),
+		new web3._extend.Property({
+			name: 'importing',
+			getter: 'ath_importing'
+		}),
+		new web3._extend.Property({
+			name: 'peerTarget',
+			getter: 'ath_peerTarget'
+		}),
+		new web3._extend.Property({
+			name: 'bloomIndexStatus',
+			getter: 'ath_bloomIndexStatus'
+		}),
+		new web3._extend.Property({
+			name: 'health',
+			getter: 'ath_health'
+		}),
 	]
 });
 `
@@ -463,12 +624,30 @@ web3._extend({
 			name: 'stop',
 			call: 'miner_stop'
 		}),
+		new web3._extend.Method({
+			name: 'pause',
+			call: 'miner_pause'
+		}),
+		new web3._extend.Method({
+			name: 'resume',
+			call: 'miner_resume'
+		}),
 		new web3._extend.Method({
 			name: 'setAtlantisbase',
 			call: 'miner_setAtlantisbase',
 			params: 1,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'setAtlantisbases',
+			call: 'miner_setAtlantisbases',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'rewardLog',
+			call: 'miner_rewardLog',
+			params: 0
+		}),
 		new web3._extend.Method({
 			name: 'setExtra',
 			call: 'miner_setExtra',
@@ -517,6 +696,12 @@ web3._extend({
 			params: 3,
 			inputFormatter: [null, web3._extend.formatters.inputAddressFormatter, null]
 		}),
+		new web3._extend.Method({
+			name: 'signWithMode',
+			call: 'personal_signWithMode',
+			params: 4,
+			inputFormatter: [null, web3._extend.formatters.inputAddressFormatter, null, null]
+		}),
 		new web3._extend.Method({
 			name: 'ecRecover',
 			call: 'personal_ecRecover',
@@ -538,6 +723,11 @@ web3._extend({
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputTransactionFormatter, null]
 		}),
+		new web3._extend.Method({
+			name: 'unlockAccounts',
+			call: 'personal_unlockAccounts',
+			params: 4
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -608,7 +798,23 @@ web3._extend({
 const TxPool_JS = `
 web3._extend({
 	property: 'txpool',
-	methods: [],
+	methods: [
+		new web3._extend.Method({
+			name: 'accountStatus',
+			call: 'txpool_accountStatus',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'markLocal',
+			call: 'txpool_markLocal',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'rebroadcast',
+			call: 'txpool_rebroadcast',
+			params: 1
+		}),
+	],
 	properties:
 	[
 		new web3._extend.Property({
@@ -619,6 +825,10 @@ web3._extend({
 			name: 'inspect',
 			getter: 'txpool_inspect'
 		}),
+		new web3._extend.Property({
+			name: 'localTransactions',
+			getter: 'txpool_localTransactions'
+		}),
 		new web3._extend.Property({
 			name: 'status',
 			getter: 'txpool_status',
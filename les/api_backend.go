@@ -18,7 +18,9 @@ package les
 
 import (
 	"context"
+	"errors"
 	"math/big"
+	"time"
 
 	"github.com/athereum/go-athereum/accounts"
 	"github.com/athereum/go-athereum/common"
@@ -30,9 +32,11 @@ import (
 	"github.com/athereum/go-athereum/core/types"
 	"github.com/athereum/go-athereum/core/vm"
 	"github.com/athereum/go-athereum/ath/downloader"
+	"github.com/athereum/go-athereum/ath/filters"
 	"github.com/athereum/go-athereum/ath/gasprice"
 	"github.com/athereum/go-athereum/athdb"
 	"github.com/athereum/go-athereum/event"
+	"github.com/athereum/go-athereum/internal/athapi"
 	"github.com/athereum/go-athereum/light"
 	"github.com/athereum/go-athereum/params"
 	"github.com/athereum/go-athereum/rpc"
@@ -51,7 +55,9 @@ func (b *LesApiBackend) CurrentBlock() *types.Block {
 	return types.NewBlockWithHeader(b.ath.BlockChain().CurrentHeader())
 }
 
-func (b *LesApiBackend) SetHead(number uint64) {
+// SetHead rewinds the local header chain. The light transaction pool is not
+// tied to local state, so there is nothing to reset there.
+func (b *LesApiBackend) SetHead(number uint64, resetTxPool bool) {
 	b.ath.protocolManager.downloader.Cancel()
 	b.ath.blockchain.SetHead(number)
 }
@@ -60,10 +66,44 @@ func (b *LesApiBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNum
 	if blockNr == rpc.LatestBlockNumber || blockNr == rpc.PendingBlockNumber {
 		return b.ath.blockchain.CurrentHeader(), nil
 	}
-
+	if blockNr == rpc.SafeBlockNumber || blockNr == rpc.FinalizedBlockNumber {
+		blockNr = b.finalizedNumber()
+	}
 	return b.ath.blockchain.GetHeaderByNumberOdr(ctx, uint64(blockNr))
 }
 
+// finalizedNumber resolves the safe and finalized block tags to the header the
+// configured number of confirmations below the current head.
+func (b *LesApiBackend) finalizedNumber() rpc.BlockNumber {
+	head, confirms := b.ath.blockchain.CurrentHeader().Number.Uint64(), b.ath.config.FinalityConfirmations
+	if head < confirms {
+		return rpc.EarliestBlockNumber
+	}
+	return rpc.BlockNumber(head - confirms)
+}
+
+func (b *LesApiBackend) GetCanonicalHash(ctx context.Context, number uint64) (common.Hash, error) {
+	if hash := b.ath.blockchain.GetCanonicalHash(number); hash != (common.Hash{}) {
+		return hash, nil
+	}
+	// Headers below a checkpoint might not be stored locally, retrieve them on demand
+	if number > b.ath.blockchain.CurrentHeader().Number.Uint64() {
+		return common.Hash{}, nil
+	}
+	header, err := b.ath.blockchain.GetHeaderByNumberOdr(ctx, number)
+	if header == nil || err != nil {
+		return common.Hash{}, err
+	}
+	return header.Hash(), nil
+}
+
+// HeaderByHash retrieves the header with the given hash from the local header
+// chain. The CHTs index headers by number only, so headers below the checkpoint
+// the client synced from can't be retrieved by hash on demand.
+func (b *LesApiBackend) HeaderByHash(ctx context.Context, blockHash common.Hash) (*types.Header, error) {
+	return b.ath.blockchain.GetHeaderByHash(blockHash), nil
+}
+
 func (b *LesApiBackend) BlockByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Block, error) {
 	header, err := b.HeaderByNumber(ctx, blockNr)
 	if header == nil || err != nil {
@@ -80,10 +120,59 @@ func (b *LesApiBackend) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.
 	return light.NewState(ctx, header, b.ath.odr), header, nil
 }
 
+func (b *LesApiBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	if blockNr, ok := blockNrOrHash.Number(); ok {
+		return b.StateAndHeaderByNumber(ctx, blockNr)
+	}
+	hash, ok := blockNrOrHash.Hash()
+	if !ok {
+		return nil, nil, errors.New("invalid arguments; neither block nor hash specified")
+	}
+	header, err := b.HeaderByHash(ctx, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if header == nil {
+		return nil, nil, errors.New("header for hash not found")
+	}
+	if blockNrOrHash.RequireCanonical && rawdb.ReadCanonicalHash(b.ath.chainDb, header.Number.Uint64()) != hash {
+		return nil, nil, errors.New("hash is not currently canonical")
+	}
+	return light.NewState(ctx, header, b.ath.odr), header, nil
+}
+
+func (b *LesApiBackend) GetCodeSize(ctx context.Context, addr common.Address, blockNrOrHash rpc.BlockNumberOrHash) (int, error) {
+	state, _, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if state == nil || err != nil {
+		return 0, err
+	}
+	size := state.GetCodeSize(addr)
+	return size, state.Error()
+}
+
 func (b *LesApiBackend) GetBlock(ctx context.Context, blockHash common.Hash) (*types.Block, error) {
 	return b.ath.blockchain.GetBlockByHash(ctx, blockHash)
 }
 
+func (b *LesApiBackend) PendingBlock() (*types.Block, error) {
+	return nil, athapi.ErrPendingBlockUnsupported
+}
+
+func (b *LesApiBackend) GetBlockTransactions(ctx context.Context, hash common.Hash) (types.Transactions, error) {
+	number := rawdb.ReadHeaderNumber(b.ath.chainDb, hash)
+	if number == nil {
+		return nil, nil
+	}
+	body, err := light.GetBody(ctx, b.ath.odr, hash, *number)
+	if err != nil {
+		return nil, err
+	}
+	if body.Transactions == nil {
+		return types.Transactions{}, nil
+	}
+	return body.Transactions, nil
+}
+
 func (b *LesApiBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
 	if number := rawdb.ReadHeaderNumber(b.ath.chainDb, hash); number != nil {
 		return light.GetBlockReceipts(ctx, b.ath.odr, hash, *number)
@@ -91,6 +180,29 @@ func (b *LesApiBackend) GetReceipts(ctx context.Context, hash common.Hash) (type
 	return nil, nil
 }
 
+func (b *LesApiBackend) GetReceiptsRange(ctx context.Context, from, to rpc.BlockNumber) ([]types.Receipts, error) {
+	first, last, err := athapi.ReceiptsRangeBounds(from, to, b.ath.blockchain.CurrentHeader().Number.Uint64())
+	if err != nil {
+		return nil, err
+	}
+	var receipts []types.Receipts
+	for number := first; number <= last; number++ {
+		header, err := b.ath.blockchain.GetHeaderByNumberOdr(ctx, number)
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			break
+		}
+		blockReceipts, err := light.GetBlockReceipts(ctx, b.ath.odr, header.Hash(), number)
+		if err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, blockReceipts)
+	}
+	return receipts, nil
+}
+
 func (b *LesApiBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*types.Log, error) {
 	if number := rawdb.ReadHeaderNumber(b.ath.chainDb, hash); number != nil {
 		return light.GetBlockLogs(ctx, b.ath.odr, hash, *number)
@@ -98,12 +210,26 @@ func (b *LesApiBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*typ
 	return nil, nil
 }
 
+func (b *LesApiBackend) GetBlockLogs(ctx context.Context, hash common.Hash) ([]*types.Log, error) {
+	number := rawdb.ReadHeaderNumber(b.ath.chainDb, hash)
+	if number == nil {
+		return nil, nil
+	}
+	receipts, err := light.GetBlockReceipts(ctx, b.ath.odr, hash, *number)
+	if err != nil {
+		return nil, err
+	}
+	return athapi.FlattenLogs(hash, *number, receipts), nil
+}
+
 func (b *LesApiBackend) GetTd(hash common.Hash) *big.Int {
 	return b.ath.blockchain.GetTdByHash(hash)
 }
 
-func (b *LesApiBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg vm.Config) (*vm.EVM, func() error, error) {
-	state.SetBalance(msg.From(), math.MaxBig256)
+func (b *LesApiBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg vm.Config, overrideBalance bool) (*vm.EVM, func() error, error) {
+	if overrideBalance {
+		state.SetBalance(msg.From(), math.MaxBig256)
+	}
 	context := core.NewEVMContext(msg, header, b.ath.blockchain, nil)
 	return vm.NewEVM(context, state, b.ath.chainConfig, vmCfg), state.Error, nil
 }
@@ -136,6 +262,36 @@ func (b *LesApiBackend) TxPoolContent() (map[common.Address]types.Transactions,
 	return b.ath.txPool.Content()
 }
 
+// TxPoolInspectByAccount returns the pending transactions of the account known to
+// the local light pool, i.e. the ones sent through this node. There are no queued
+// transactions in a light pool.
+func (b *LesApiBackend) TxPoolInspectByAccount(addr common.Address) ([]athapi.TxSummary, []athapi.TxSummary) {
+	pending, queued := b.ath.txPool.ContentFrom(addr)
+	return athapi.SummarizeTxs(pending), athapi.SummarizeTxs(queued)
+}
+
+// TxPoolLocals returns the transactions of the light pool, all of which are local
+// as they were sent through this node.
+func (b *LesApiBackend) TxPoolLocals() map[common.Address]types.Transactions {
+	pending, _ := b.ath.txPool.Content()
+	return pending
+}
+
+// TxPoolMarkLocal is a noop for pooled transactions, as light pools only contain
+// local transactions.
+func (b *LesApiBackend) TxPoolMarkLocal(txHash common.Hash) error {
+	if b.ath.txPool.GetTransaction(txHash) == nil {
+		return core.ErrUnknownTransaction
+	}
+	return nil
+}
+
+// TxPoolRebroadcast is not supported by light clients, which relay their pooled
+// transactions to servers on their own until they are mined.
+func (b *LesApiBackend) TxPoolRebroadcast(txHash common.Hash) (int, error) {
+	return 0, athapi.ErrRebroadcastUnsupported
+}
+
 func (b *LesApiBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
 	return b.ath.txPool.SubscribeNewTxsEvent(ch)
 }
@@ -156,6 +312,12 @@ func (b *LesApiBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscri
 	return b.ath.blockchain.SubscribeLogsEvent(ch)
 }
 
+// SubscribePendingLogsEvent reports pending logs as unsupported, light clients
+// don't assemble a pending block.
+func (b *LesApiBackend) SubscribePendingLogsEvent() (*event.TypeMuxSubscription, error) {
+	return nil, filters.ErrPendingLogsUnsupported
+}
+
 func (b *LesApiBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
 	return b.ath.blockchain.SubscribeRemovedLogsEvent(ch)
 }
@@ -172,6 +334,14 @@ func (b *LesApiBackend) SuggestPrice(ctx context.Context) (*big.Int, error) {
 	return b.gpo.SuggestPrice(ctx)
 }
 
+func (b *LesApiBackend) FeeHistory(ctx context.Context, blockCount int, lastBlock rpc.BlockNumber, percentiles []float64) (*big.Int, [][]*big.Int, []*big.Int, []float64, error) {
+	history, err := b.gpo.FeeHistory(ctx, blockCount, lastBlock, percentiles)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return history.OldestBlock, history.Reward, history.BaseGasPrice, history.GasUsedRatio, nil
+}
+
 func (b *LesApiBackend) ChainDb() athdb.Database {
 	return b.ath.chainDb
 }
@@ -184,6 +354,14 @@ func (b *LesApiBackend) AccountManager() *accounts.Manager {
 	return b.ath.accountManager
 }
 
+func (b *LesApiBackend) RPCEVMTimeout() time.Duration {
+	return b.ath.config.EVMTimeout()
+}
+
+func (b *LesApiBackend) PersonalAPIDisabled() bool {
+	return b.ath.config.DisablePersonalAPI
+}
+
 func (b *LesApiBackend) BloomStatus() (uint64, uint64) {
 	if b.ath.bloomIndexer == nil {
 		return 0, 0
@@ -192,8 +370,9 @@ func (b *LesApiBackend) BloomStatus() (uint64, uint64) {
 	return light.BloomTrieFrequency, sections
 }
 
-func (b *LesApiBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {
-	for i := 0; i < bloomFilterThreads; i++ {
+func (b *LesApiBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) error {
+	for i := 0; i < b.ath.config.BloomServiceThreads; i++ {
 		go session.Multiplex(bloomRetrievalBatch, bloomRetrievalWait, b.ath.bloomRequests)
 	}
+	return nil
 }
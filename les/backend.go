@@ -22,6 +22,7 @@ import (
 	"sync"
 	"time"
 
+	athereum "github.com/athereum/go-athereum"
 	"github.com/athereum/go-athereum/accounts"
 	"github.com/athereum/go-athereum/common"
 	"github.com/athereum/go-athereum/common/hexutil"
@@ -81,6 +82,9 @@ type LightAtlantis struct {
 }
 
 func New(ctx *node.ServiceContext, config *ath.Config) (*LightAtlantis, error) {
+	if config.BloomServiceThreads <= 0 {
+		config.BloomServiceThreads = bloomFilterThreads
+	}
 	chainDb, err := ath.CreateDB(ctx, config, "lightchaindata")
 	if err != nil {
 		return nil, err
@@ -91,6 +95,10 @@ func New(ctx *node.ServiceContext, config *ath.Config) (*LightAtlantis, error) {
 	}
 	log.Info("Initialised chain configuration", "config", chainConfig)
 
+	engine, err := ath.CreateConsensusEngine(ctx, config.Engine, &config.Ethash, chainConfig, config.MinerEmptyBlockPeriod, chainDb)
+	if err != nil {
+		return nil, err
+	}
 	peers := newPeerSet()
 	quitSync := make(chan struct{})
 
@@ -102,7 +110,7 @@ func New(ctx *node.ServiceContext, config *ath.Config) (*LightAtlantis, error) {
 		peers:            peers,
 		reqDist:          newRequestDistributor(peers, quitSync),
 		accountManager:   ctx.AccountManager,
-		engine:           ath.CreateConsensusEngine(ctx, &config.Ethash, chainConfig, chainDb),
+		engine:           engine,
 		shutdownChan:     make(chan bool),
 		networkId:        config.NetworkId,
 		bloomRequests:    make(chan chan *bloombits.Retrieval),
@@ -118,6 +126,7 @@ func New(ctx *node.ServiceContext, config *ath.Config) (*LightAtlantis, error) {
 	if lath.blockchain, err = light.NewLightChain(lath.odr, lath.chainConfig, lath.engine); err != nil {
 		return nil, err
 	}
+	lath.blockchain.SetMaxReorgDepth(config.MaxReorgDepth)
 	lath.bloomIndexer.Start(lath.blockchain)
 	// Rewind the chain in case of an incompatible config upgrade.
 	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
@@ -191,7 +200,7 @@ func (s *LightAtlantis) APIs() []rpc.API {
 		}, {
 			Namespace: "ath",
 			Version:   "1.0",
-			Service:   filters.NewPublicFilterAPI(s.ApiBackend, true),
+			Service:   filters.NewPublicFilterAPIWithLogLimit(s.ApiBackend, true, s.config.MaxLogsReturned),
 			Public:    true,
 		}, {
 			Namespace: "net",
@@ -213,6 +222,11 @@ func (s *LightAtlantis) LesVersion() int                    { return int(s.proto
 func (s *LightAtlantis) Downloader() *downloader.Downloader { return s.protocolManager.downloader }
 func (s *LightAtlantis) EventMux() *event.TypeMux           { return s.eventMux }
 
+// SyncProgress retrieves the current progress of the chain synchronisation.
+func (s *LightAtlantis) SyncProgress() athereum.SyncProgress {
+	return s.protocolManager.downloader.Progress()
+}
+
 // Protocols implements node.Service, returning all the currently configured
 // network protocols to start.
 func (s *LightAtlantis) Protocols() []p2p.Protocol {
@@ -22,46 +22,42 @@ import (
 )
 
 var (
-	/*	propTxnInPacketsMeter     = metrics.NewMeter("ath/prop/txns/in/packets")
-		propTxnInTrafficMeter     = metrics.NewMeter("ath/prop/txns/in/traffic")
-		propTxnOutPacketsMeter    = metrics.NewMeter("ath/prop/txns/out/packets")
-		propTxnOutTrafficMeter    = metrics.NewMeter("ath/prop/txns/out/traffic")
-		propHashInPacketsMeter    = metrics.NewMeter("ath/prop/hashes/in/packets")
-		propHashInTrafficMeter    = metrics.NewMeter("ath/prop/hashes/in/traffic")
-		propHashOutPacketsMeter   = metrics.NewMeter("ath/prop/hashes/out/packets")
-		propHashOutTrafficMeter   = metrics.NewMeter("ath/prop/hashes/out/traffic")
-		propBlockInPacketsMeter   = metrics.NewMeter("ath/prop/blocks/in/packets")
-		propBlockInTrafficMeter   = metrics.NewMeter("ath/prop/blocks/in/traffic")
-		propBlockOutPacketsMeter  = metrics.NewMeter("ath/prop/blocks/out/packets")
-		propBlockOutTrafficMeter  = metrics.NewMeter("ath/prop/blocks/out/traffic")
-		reqHashInPacketsMeter     = metrics.NewMeter("ath/req/hashes/in/packets")
-		reqHashInTrafficMeter     = metrics.NewMeter("ath/req/hashes/in/traffic")
-		reqHashOutPacketsMeter    = metrics.NewMeter("ath/req/hashes/out/packets")
-		reqHashOutTrafficMeter    = metrics.NewMeter("ath/req/hashes/out/traffic")
-		reqBlockInPacketsMeter    = metrics.NewMeter("ath/req/blocks/in/packets")
-		reqBlockInTrafficMeter    = metrics.NewMeter("ath/req/blocks/in/traffic")
-		reqBlockOutPacketsMeter   = metrics.NewMeter("ath/req/blocks/out/packets")
-		reqBlockOutTrafficMeter   = metrics.NewMeter("ath/req/blocks/out/traffic")
-		reqHeaderInPacketsMeter   = metrics.NewMeter("ath/req/headers/in/packets")
-		reqHeaderInTrafficMeter   = metrics.NewMeter("ath/req/headers/in/traffic")
-		reqHeaderOutPacketsMeter  = metrics.NewMeter("ath/req/headers/out/packets")
-		reqHeaderOutTrafficMeter  = metrics.NewMeter("ath/req/headers/out/traffic")
-		reqBodyInPacketsMeter     = metrics.NewMeter("ath/req/bodies/in/packets")
-		reqBodyInTrafficMeter     = metrics.NewMeter("ath/req/bodies/in/traffic")
-		reqBodyOutPacketsMeter    = metrics.NewMeter("ath/req/bodies/out/packets")
-		reqBodyOutTrafficMeter    = metrics.NewMeter("ath/req/bodies/out/traffic")
-		reqStateInPacketsMeter    = metrics.NewMeter("ath/req/states/in/packets")
-		reqStateInTrafficMeter    = metrics.NewMeter("ath/req/states/in/traffic")
-		reqStateOutPacketsMeter   = metrics.NewMeter("ath/req/states/out/packets")
-		reqStateOutTrafficMeter   = metrics.NewMeter("ath/req/states/out/traffic")
-		reqReceiptInPacketsMeter  = metrics.NewMeter("ath/req/receipts/in/packets")
-		reqReceiptInTrafficMeter  = metrics.NewMeter("ath/req/receipts/in/traffic")
-		reqReceiptOutPacketsMeter = metrics.NewMeter("ath/req/receipts/out/packets")
-		reqReceiptOutTrafficMeter = metrics.NewMeter("ath/req/receipts/out/traffic")*/
-	miscInPacketsMeter  = metrics.NewRegisteredMeter("les/misc/in/packets", nil)
-	miscInTrafficMeter  = metrics.NewRegisteredMeter("les/misc/in/traffic", nil)
-	miscOutPacketsMeter = metrics.NewRegisteredMeter("les/misc/out/packets", nil)
-	miscOutTrafficMeter = metrics.NewRegisteredMeter("les/misc/out/traffic", nil)
+	reqHeaderInPacketsMeter      = metrics.NewRegisteredMeter("les/req/headers/in/packets", nil)
+	reqHeaderInTrafficMeter      = metrics.NewRegisteredMeter("les/req/headers/in/traffic", nil)
+	reqHeaderOutPacketsMeter     = metrics.NewRegisteredMeter("les/req/headers/out/packets", nil)
+	reqHeaderOutTrafficMeter     = metrics.NewRegisteredMeter("les/req/headers/out/traffic", nil)
+	reqBodyInPacketsMeter        = metrics.NewRegisteredMeter("les/req/bodies/in/packets", nil)
+	reqBodyInTrafficMeter        = metrics.NewRegisteredMeter("les/req/bodies/in/traffic", nil)
+	reqBodyOutPacketsMeter       = metrics.NewRegisteredMeter("les/req/bodies/out/packets", nil)
+	reqBodyOutTrafficMeter       = metrics.NewRegisteredMeter("les/req/bodies/out/traffic", nil)
+	reqReceiptInPacketsMeter     = metrics.NewRegisteredMeter("les/req/receipts/in/packets", nil)
+	reqReceiptInTrafficMeter     = metrics.NewRegisteredMeter("les/req/receipts/in/traffic", nil)
+	reqReceiptOutPacketsMeter    = metrics.NewRegisteredMeter("les/req/receipts/out/packets", nil)
+	reqReceiptOutTrafficMeter    = metrics.NewRegisteredMeter("les/req/receipts/out/traffic", nil)
+	reqProofInPacketsMeter       = metrics.NewRegisteredMeter("les/req/proofs/in/packets", nil)
+	reqProofInTrafficMeter       = metrics.NewRegisteredMeter("les/req/proofs/in/traffic", nil)
+	reqProofOutPacketsMeter      = metrics.NewRegisteredMeter("les/req/proofs/out/packets", nil)
+	reqProofOutTrafficMeter      = metrics.NewRegisteredMeter("les/req/proofs/out/traffic", nil)
+	reqCodeInPacketsMeter        = metrics.NewRegisteredMeter("les/req/code/in/packets", nil)
+	reqCodeInTrafficMeter        = metrics.NewRegisteredMeter("les/req/code/in/traffic", nil)
+	reqCodeOutPacketsMeter       = metrics.NewRegisteredMeter("les/req/code/out/packets", nil)
+	reqCodeOutTrafficMeter       = metrics.NewRegisteredMeter("les/req/code/out/traffic", nil)
+	reqHelperTrieInPacketsMeter  = metrics.NewRegisteredMeter("les/req/helpertrie/in/packets", nil)
+	reqHelperTrieInTrafficMeter  = metrics.NewRegisteredMeter("les/req/helpertrie/in/traffic", nil)
+	reqHelperTrieOutPacketsMeter = metrics.NewRegisteredMeter("les/req/helpertrie/out/packets", nil)
+	reqHelperTrieOutTrafficMeter = metrics.NewRegisteredMeter("les/req/helpertrie/out/traffic", nil)
+	reqTxnInPacketsMeter         = metrics.NewRegisteredMeter("les/req/txns/in/packets", nil)
+	reqTxnInTrafficMeter         = metrics.NewRegisteredMeter("les/req/txns/in/traffic", nil)
+	reqTxnOutPacketsMeter        = metrics.NewRegisteredMeter("les/req/txns/out/packets", nil)
+	reqTxnOutTrafficMeter        = metrics.NewRegisteredMeter("les/req/txns/out/traffic", nil)
+	reqTxStatusInPacketsMeter    = metrics.NewRegisteredMeter("les/req/txstatus/in/packets", nil)
+	reqTxStatusInTrafficMeter    = metrics.NewRegisteredMeter("les/req/txstatus/in/traffic", nil)
+	reqTxStatusOutPacketsMeter   = metrics.NewRegisteredMeter("les/req/txstatus/out/packets", nil)
+	reqTxStatusOutTrafficMeter   = metrics.NewRegisteredMeter("les/req/txstatus/out/traffic", nil)
+	miscInPacketsMeter           = metrics.NewRegisteredMeter("les/misc/in/packets", nil)
+	miscInTrafficMeter           = metrics.NewRegisteredMeter("les/misc/in/traffic", nil)
+	miscOutPacketsMeter          = metrics.NewRegisteredMeter("les/misc/out/packets", nil)
+	miscOutTrafficMeter          = metrics.NewRegisteredMeter("les/misc/out/traffic", nil)
 )
 
 // meteredMsgReadWriter is a wrapper around a p2p.MsgReadWriter, capable of
@@ -94,6 +90,24 @@ func (rw *meteredMsgReadWriter) ReadMsg() (p2p.Msg, error) {
 	}
 	// Account for the data traffic
 	packets, traffic := miscInPacketsMeter, miscInTrafficMeter
+	switch msg.Code {
+	case GetBlockHeadersMsg, BlockHeadersMsg:
+		packets, traffic = reqHeaderInPacketsMeter, reqHeaderInTrafficMeter
+	case GetBlockBodiesMsg, BlockBodiesMsg:
+		packets, traffic = reqBodyInPacketsMeter, reqBodyInTrafficMeter
+	case GetReceiptsMsg, ReceiptsMsg:
+		packets, traffic = reqReceiptInPacketsMeter, reqReceiptInTrafficMeter
+	case GetProofsV1Msg, ProofsV1Msg, GetProofsV2Msg, ProofsV2Msg:
+		packets, traffic = reqProofInPacketsMeter, reqProofInTrafficMeter
+	case GetCodeMsg, CodeMsg:
+		packets, traffic = reqCodeInPacketsMeter, reqCodeInTrafficMeter
+	case GetHeaderProofsMsg, HeaderProofsMsg, GetHelperTrieProofsMsg, HelperTrieProofsMsg:
+		packets, traffic = reqHelperTrieInPacketsMeter, reqHelperTrieInTrafficMeter
+	case SendTxMsg, SendTxV2Msg:
+		packets, traffic = reqTxnInPacketsMeter, reqTxnInTrafficMeter
+	case GetTxStatusMsg, TxStatusMsg:
+		packets, traffic = reqTxStatusInPacketsMeter, reqTxStatusInTrafficMeter
+	}
 	packets.Mark(1)
 	traffic.Mark(int64(msg.Size))
 
@@ -103,6 +117,24 @@ func (rw *meteredMsgReadWriter) ReadMsg() (p2p.Msg, error) {
 func (rw *meteredMsgReadWriter) WriteMsg(msg p2p.Msg) error {
 	// Account for the data traffic
 	packets, traffic := miscOutPacketsMeter, miscOutTrafficMeter
+	switch msg.Code {
+	case GetBlockHeadersMsg, BlockHeadersMsg:
+		packets, traffic = reqHeaderOutPacketsMeter, reqHeaderOutTrafficMeter
+	case GetBlockBodiesMsg, BlockBodiesMsg:
+		packets, traffic = reqBodyOutPacketsMeter, reqBodyOutTrafficMeter
+	case GetReceiptsMsg, ReceiptsMsg:
+		packets, traffic = reqReceiptOutPacketsMeter, reqReceiptOutTrafficMeter
+	case GetProofsV1Msg, ProofsV1Msg, GetProofsV2Msg, ProofsV2Msg:
+		packets, traffic = reqProofOutPacketsMeter, reqProofOutTrafficMeter
+	case GetCodeMsg, CodeMsg:
+		packets, traffic = reqCodeOutPacketsMeter, reqCodeOutTrafficMeter
+	case GetHeaderProofsMsg, HeaderProofsMsg, GetHelperTrieProofsMsg, HelperTrieProofsMsg:
+		packets, traffic = reqHelperTrieOutPacketsMeter, reqHelperTrieOutTrafficMeter
+	case SendTxMsg, SendTxV2Msg:
+		packets, traffic = reqTxnOutPacketsMeter, reqTxnOutTrafficMeter
+	case GetTxStatusMsg, TxStatusMsg:
+		packets, traffic = reqTxStatusOutPacketsMeter, reqTxStatusOutTrafficMeter
+	}
 	packets.Mark(1)
 	traffic.Mark(int64(msg.Size))
 
@@ -170,6 +170,13 @@ func (bc *LightChain) SetHead(head uint64) {
 	bc.loadLastState()
 }
 
+// SetMaxReorgDepth limits the number of canonical headers a reorg may drop. Deeper
+// reorgs are refused, keeping the current chain and firing a ReorgRejectedEvent
+// once per refused fork. Zero disables the limit.
+func (self *LightChain) SetMaxReorgDepth(depth uint64) {
+	self.hc.SetMaxReorgDepth(depth)
+}
+
 // GasLimit returns the gas limit of the current HEAD block.
 func (self *LightChain) GasLimit() uint64 {
 	return self.hc.CurrentHeader().GasLimit
@@ -445,6 +452,12 @@ func (bc *LightChain) GetAncestor(hash common.Hash, number, ancestor uint64, max
 	return bc.hc.GetAncestor(hash, number, ancestor, maxNonCanonical)
 }
 
+// GetCanonicalHash retrieves the hash of the canonical block at the given
+// height from the local database, or the zero hash if it's not known.
+func (self *LightChain) GetCanonicalHash(number uint64) common.Hash {
+	return self.hc.GetCanonicalHash(number)
+}
+
 // GetHeaderByNumber retrieves a block header from the database by number,
 // caching it (associated with its hash) if found.
 func (self *LightChain) GetHeaderByNumber(number uint64) *types.Header {
@@ -510,6 +523,11 @@ func (self *LightChain) SubscribeChainSideEvent(ch chan<- core.ChainSideEvent) e
 	return self.scope.Track(self.chainSideFeed.Subscribe(ch))
 }
 
+// SubscribeReorgRejectedEvent registers a subscription of ReorgRejectedEvent.
+func (self *LightChain) SubscribeReorgRejectedEvent(ch chan<- core.ReorgRejectedEvent) event.Subscription {
+	return self.scope.Track(self.hc.SubscribeReorgRejectedEvent(ch))
+}
+
 // SubscribeLogsEvent implements the interface of filters.Backend
 // LightChain does not send logs events, so return an empty subscription.
 func (self *LightChain) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription {
@@ -20,6 +20,7 @@ import (
 	"context"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/athereum/go-athereum/common"
 	"github.com/athereum/go-athereum/consensus/athash"
@@ -308,6 +309,48 @@ func testReorg(t *testing.T, first, second []int, td int64) {
 	}
 }
 
+// Tests that header reorgs deeper than the configured limit are refused, reporting
+// the rejected fork only once even if it keeps growing.
+func TestMaxReorgDepthHeaders(t *testing.T) {
+	bc := newTestLightChain()
+	bc.SetMaxReorgDepth(2)
+
+	canonical := makeHeaderChainWithDiff(bc.genesisBlock, []int{1, 2, 3}, 11)
+	if _, err := bc.InsertHeaderChain(canonical, 1); err != nil {
+		t.Fatalf("failed to insert canonical chain: %v", err)
+	}
+	rejectCh := make(chan core.ReorgRejectedEvent, 16)
+	sub := bc.SubscribeReorgRejectedEvent(rejectCh)
+	defer sub.Unsubscribe()
+
+	fork := makeHeaderChainWithDiff(bc.genesisBlock, []int{1, 10, 1, 1}, 22)
+	if _, err := bc.InsertHeaderChain(fork[:2], 1); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	if _, err := bc.InsertHeaderChain(fork[2:], 1); err != nil {
+		t.Fatalf("failed to extend fork: %v", err)
+	}
+	if head := bc.CurrentHeader().Hash(); head != canonical[2].Hash() {
+		t.Fatalf("head moved despite deep reorg: have %x, want %x", head, canonical[2].Hash())
+	}
+	select {
+	case ev := <-rejectCh:
+		if ev.Depth != 3 {
+			t.Errorf("rejected depth mismatch: have %d, want %d", ev.Depth, 3)
+		}
+		if ev.CommonAncestor.Hash() != bc.genesisBlock.Hash() {
+			t.Errorf("common ancestor mismatch: have %x, want %x", ev.CommonAncestor.Hash(), bc.genesisBlock.Hash())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("reorg rejection not reported")
+	}
+	select {
+	case ev := <-rejectCh:
+		t.Fatalf("rejected fork reported again: new head %x", ev.NewHead.Hash())
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
 // Tests that the insertion functions detect banned hashes.
 func TestBadHeaderHashes(t *testing.T) {
 	bc := newTestLightChain()
@@ -141,8 +141,12 @@ func (t *odrTrie) GetKey(sha []byte) []byte {
 	return nil
 }
 
+// errProofUnsupported is returned when a Merkle proof is requested from a trie
+// backed by on-demand retrieval, which doesn't hold the nodes to assemble it.
+var errProofUnsupported = errors.New("merkle proofs unsupported on light client")
+
 func (t *odrTrie) Prove(key []byte, fromLevel uint, proofDb athdb.Putter) error {
-	return errors.New("not implemented, needs client/server interface split")
+	return errProofUnsupported
 }
 
 // do tries and retries to execute a function until it returns with no error or
@@ -42,6 +42,12 @@ type Backend interface {
 	ChainDb() athdb.Database
 }
 
+// sealAuthorizer is implemented by consensus engines able to tell upfront whather
+// the local node is authorized to seal blocks on top of a given parent.
+type sealAuthorizer interface {
+	Authorized(chain consensus.ChainReader, parent *types.Header) error
+}
+
 // Miner creates blocks and searches for proof-of-work values.
 type Miner struct {
 	mux *event.TypeMux
@@ -57,12 +63,12 @@ type Miner struct {
 	shouldStart int32 // should start indicates whather we should start after sync
 }
 
-func New(ath Backend, config *params.ChainConfig, mux *event.TypeMux, engine consensus.Engine) *Miner {
+func New(ath Backend, config *params.ChainConfig, mux *event.TypeMux, engine consensus.Engine, gasFloor, gasCeil uint64) *Miner {
 	miner := &Miner{
 		ath:      ath,
 		mux:      mux,
 		engine:   engine,
-		worker:   newWorker(config, engine, common.Address{}, ath, mux),
+		worker:   newWorker(config, engine, common.Address{}, ath, mux, gasFloor, gasCeil),
 		canStart: 1,
 	}
 	miner.Register(NewCpuAgent(ath.BlockChain(), engine))
@@ -93,7 +99,9 @@ out:
 			atomic.StoreInt32(&self.canStart, 1)
 			atomic.StoreInt32(&self.shouldStart, 0)
 			if shouldStart {
-				self.Start(self.coinbase)
+				if err := self.Start(self.coinbase); err != nil {
+					log.Error("Failed to start mining after sync", "err", err)
+				}
 			}
 			// unsubscribe. we're only interested in this event once
 			events.Unsubscribe()
@@ -103,19 +111,34 @@ out:
 	}
 }
 
-func (self *Miner) Start(coinbase common.Address) {
+// Start begins mining with the given coinbase, returning an error if sealing can
+// not be started (e.g. the engine refuses to seal or the first work package can
+// not be assembled). If the node is syncing, mining is deferred until the sync
+// completes and no error is reported.
+func (self *Miner) Start(coinbase common.Address) error {
 	atomic.StoreInt32(&self.shouldStart, 1)
 	self.SetAtlantisbase(coinbase)
 
 	if atomic.LoadInt32(&self.canStart) == 0 {
 		log.Info("Network syncing, will start miner afterwards")
-		return
+		return nil
+	}
+	if auth, ok := self.engine.(sealAuthorizer); ok {
+		chain := self.ath.BlockChain()
+		if err := auth.Authorized(chain, chain.CurrentHeader()); err != nil {
+			atomic.StoreInt32(&self.shouldStart, 0)
+			return fmt.Errorf("sealing not authorized: %v", err)
+		}
 	}
 	atomic.StoreInt32(&self.mining, 1)
 
 	log.Info("Starting mining operation")
 	self.worker.start()
-	self.worker.commitNewWork()
+	if err := self.worker.commitNewWork(); err != nil {
+		self.Stop()
+		return fmt.Errorf("failed to assemble mining work: %v", err)
+	}
+	return nil
 }
 
 func (self *Miner) Stop() {
@@ -124,8 +147,28 @@ func (self *Miner) Stop() {
 	atomic.StoreInt32(&self.shouldStart, 0)
 }
 
+// Pause suspends sealing without tearing down the miner. The pending block is
+// kept alive and updated with new transactions, so that mining can be resumed
+// without rebuilding the work from scratch.
+func (self *Miner) Pause() {
+	self.worker.pause()
+}
+
+// Resume restarts sealing on a paused miner, immediately pushing the current
+// pending work to the agents.
+func (self *Miner) Resume() {
+	if self.worker.resume() {
+		self.worker.commitNewWork()
+	}
+}
+
+// Paused returns whather the miner is running with sealing suspended.
+func (self *Miner) Paused() bool {
+	return atomic.LoadInt32(&self.worker.paused) > 0
+}
+
 func (self *Miner) Register(agent Agent) {
-	if self.Mining() {
+	if self.Mining() && !self.Paused() {
 		agent.Start()
 	}
 	self.worker.register(agent)
@@ -180,3 +223,14 @@ func (self *Miner) SetAtlantisbase(addr common.Address) {
 	self.coinbase = addr
 	self.worker.setAtlantisbase(addr)
 }
+
+// SetAtlantisbases sets a list of reward addresses the miner rotates through, one
+// per sealed block. An empty list pays all rewards to the single atherbase again.
+func (self *Miner) SetAtlantisbases(addrs []common.Address) {
+	self.worker.setAtlantisbases(addrs)
+}
+
+// RewardLog returns the reward addresses of the most recently sealed blocks.
+func (self *Miner) RewardLog() []RewardRecord {
+	return self.worker.rewardLog()
+}
@@ -26,6 +26,7 @@ import (
 
 	"github.com/athereum/go-athereum/common"
 	"github.com/athereum/go-athereum/consensus"
+	"github.com/athereum/go-athereum/consensus/clique"
 	"github.com/athereum/go-athereum/consensus/misc"
 	"github.com/athereum/go-athereum/core"
 	"github.com/athereum/go-athereum/core/state"
@@ -49,8 +50,17 @@ const (
 	chainHeadChanSize = 10
 	// chainSideChanSize is the size of channel listening to ChainSideEvent.
 	chainSideChanSize = 10
+	// rewardLogSize is the number of sealed blocks whose reward address is kept.
+	rewardLogSize = 1024
 )
 
+// RewardRecord notes the address a locally sealed block paid its reward to.
+type RewardRecord struct {
+	Number   uint64         `json:"number"`
+	Hash     common.Hash    `json:"hash"`
+	Coinbase common.Address `json:"coinbase"`
+}
+
 // Agent can register themself with the worker
 type Agent interface {
 	Work() chan<- *Work
@@ -79,6 +89,8 @@ type Work struct {
 	txs      []*types.Transaction
 	receipts []*types.Receipt
 
+	logsAnnounced bool // Whather the pending logs of the block were announced already
+
 	createdAt time.Time
 }
 
@@ -112,8 +124,13 @@ type worker struct {
 	proc    core.Validator
 	chainDb athdb.Database
 
-	coinbase common.Address
-	extra    []byte
+	coinbase  common.Address
+	coinbases []common.Address // Reward addresses rotated across sealed blocks, overriding coinbase if set
+	nextBase  int              // Index of the rotated reward address the next block pays to
+	rewards   []RewardRecord   // Reward addresses of the most recently sealed local blocks
+	extra     []byte
+	gasFloor  uint64 // Target lower bound of the mined blocks' gas limit
+	gasCeil   uint64 // Target upper bound of the mined blocks' gas limit
 
 	currentMu sync.Mutex
 	current   *Work
@@ -129,10 +146,11 @@ type worker struct {
 
 	// atomic status counters
 	mining int32
+	paused int32 // sealing suspended, but pending work still maintained
 	atWork int32
 }
 
-func newWorker(config *params.ChainConfig, engine consensus.Engine, coinbase common.Address, ath Backend, mux *event.TypeMux) *worker {
+func newWorker(config *params.ChainConfig, engine consensus.Engine, coinbase common.Address, ath Backend, mux *event.TypeMux, gasFloor, gasCeil uint64) *worker {
 	worker := &worker{
 		config:         config,
 		engine:         engine,
@@ -147,6 +165,8 @@ func newWorker(config *params.ChainConfig, engine consensus.Engine, coinbase com
 		proc:           ath.BlockChain().Validator(),
 		possibleUncles: make(map[common.Hash]*types.Block),
 		coinbase:       coinbase,
+		gasFloor:       gasFloor,
+		gasCeil:        gasCeil,
 		agents:         make(map[Agent]struct{}),
 		unconfirmed:    newUnconfirmedBlocks(ath.BlockChain(), miningLogAtDepth),
 	}
@@ -169,6 +189,46 @@ func (self *worker) setAtlantisbase(addr common.Address) {
 	self.coinbase = addr
 }
 
+// setAtlantisbases sets the reward addresses to rotate across sealed blocks. An
+// empty list reverts to paying all rewards to the single coinbase.
+func (self *worker) setAtlantisbases(addrs []common.Address) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.coinbases = append([]common.Address(nil), addrs...)
+	self.nextBase = 0
+}
+
+// currentCoinbase returns the address the next block pays its reward to. The
+// worker's mutex must be held.
+func (self *worker) currentCoinbase() common.Address {
+	if len(self.coinbases) == 0 {
+		return self.coinbase
+	}
+	return self.coinbases[self.nextBase%len(self.coinbases)]
+}
+
+// recordReward logs the reward address of a locally sealed block and advances
+// the reward rotation to the next address.
+func (self *worker) recordReward(block *types.Block) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	self.rewards = append(self.rewards, RewardRecord{Number: block.NumberU64(), Hash: block.Hash(), Coinbase: block.Coinbase()})
+	if len(self.rewards) > rewardLogSize {
+		self.rewards = self.rewards[len(self.rewards)-rewardLogSize:]
+	}
+	if len(self.coinbases) > 0 {
+		self.nextBase = (self.nextBase + 1) % len(self.coinbases)
+	}
+}
+
+// rewardLog returns the reward addresses of the most recently sealed blocks.
+func (self *worker) rewardLog() []RewardRecord {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return append([]RewardRecord(nil), self.rewards...)
+}
+
 func (self *worker) setExtra(extra []byte) {
 	self.mu.Lock()
 	defer self.mu.Unlock()
@@ -176,8 +236,9 @@ func (self *worker) setExtra(extra []byte) {
 }
 
 func (self *worker) pending() (*types.Block, *state.StateDB) {
-	if atomic.LoadInt32(&self.mining) == 0 {
-		// return a snapshot to avoid contention on currentMu mutex
+	if atomic.LoadInt32(&self.mining) == 0 || atomic.LoadInt32(&self.paused) == 1 {
+		// return a snapshot to avoid contention on currentMu mutex. While paused,
+		// only the snapshot is updated with incoming transactions.
 		self.snapshotMu.RLock()
 		defer self.snapshotMu.RUnlock()
 		return self.snapshotBlock, self.snapshotState.Copy()
@@ -189,8 +250,9 @@ func (self *worker) pending() (*types.Block, *state.StateDB) {
 }
 
 func (self *worker) pendingBlock() *types.Block {
-	if atomic.LoadInt32(&self.mining) == 0 {
-		// return a snapshot to avoid contention on currentMu mutex
+	if atomic.LoadInt32(&self.mining) == 0 || atomic.LoadInt32(&self.paused) == 1 {
+		// return a snapshot to avoid contention on currentMu mutex. While paused,
+		// only the snapshot is updated with incoming transactions.
 		self.snapshotMu.RLock()
 		defer self.snapshotMu.RUnlock()
 		return self.snapshotBlock
@@ -224,9 +286,40 @@ func (self *worker) stop() {
 		}
 	}
 	atomic.StoreInt32(&self.mining, 0)
+	atomic.StoreInt32(&self.paused, 0)
 	atomic.StoreInt32(&self.atWork, 0)
 }
 
+// pause stops all the sealing agents, but keeps the worker in mining mode so
+// that the pending block is still assembled and updated with new transactions.
+func (self *worker) pause() {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if atomic.LoadInt32(&self.mining) == 0 || !atomic.CompareAndSwapInt32(&self.paused, 0, 1) {
+		return
+	}
+	for agent := range self.agents {
+		agent.Stop()
+	}
+	atomic.StoreInt32(&self.atWork, 0)
+}
+
+// resume restarts the sealing agents of a paused worker. It returns whather
+// the worker was paused before.
+func (self *worker) resume() bool {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if !atomic.CompareAndSwapInt32(&self.paused, 1, 0) {
+		return false
+	}
+	for agent := range self.agents {
+		agent.Start()
+	}
+	return true
+}
+
 func (self *worker) register(agent Agent) {
 	self.mu.Lock()
 	defer self.mu.Unlock()
@@ -261,12 +354,12 @@ func (self *worker) update() {
 
 		// Handle NewTxsEvent
 		case ev := <-self.txsCh:
-			// Apply transactions to the pending state if we're not mining.
+			// Apply transactions to the pending state if we're not sealing.
 			//
 			// Note all transactions received may not be continuous with transactions
 			// already included in the current mining block. These transactions will
 			// be automatically eliminated.
-			if atomic.LoadInt32(&self.mining) == 0 {
+			if atomic.LoadInt32(&self.mining) == 0 || atomic.LoadInt32(&self.paused) == 1 {
 				self.currentMu.Lock()
 				txs := make(map[common.Address]types.Transactions)
 				for _, tx := range ev.Txs {
@@ -282,6 +375,16 @@ func (self *worker) update() {
 				if self.config.Clique != nil && self.config.Clique.Period == 0 {
 					self.commitNewWork()
 				}
+				// If an empty block is being held back, replace it with one including them
+				if engine, ok := self.engine.(*clique.Clique); ok && self.config.Clique != nil && self.config.Clique.Period > 0 && engine.EmptyBlockPeriod() > 0 {
+					self.currentMu.Lock()
+					empty := self.current != nil && self.current.tcount == 0
+					self.currentMu.Unlock()
+
+					if empty {
+						self.commitNewWork()
+					}
+				}
 			}
 
 		// System stopped
@@ -321,6 +424,8 @@ func (self *worker) wait() {
 				log.Error("Failed writing block to chain", "err", err)
 				continue
 			}
+			self.recordReward(block)
+
 			// Broadcast the block and announce chain insertion event
 			self.mux.Post(core.NewMinedBlockEvent{Block: block})
 			var (
@@ -341,7 +446,7 @@ func (self *worker) wait() {
 
 // push sends a new work task to currently live miner agents.
 func (self *worker) push(work *Work) {
-	if atomic.LoadInt32(&self.mining) != 1 {
+	if atomic.LoadInt32(&self.mining) != 1 || atomic.LoadInt32(&self.paused) == 1 {
 		return
 	}
 	for agent := range self.agents {
@@ -384,7 +489,7 @@ func (self *worker) makeCurrent(parent *types.Block, header *types.Header) error
 	return nil
 }
 
-func (self *worker) commitNewWork() {
+func (self *worker) commitNewWork() error {
 	self.mu.Lock()
 	defer self.mu.Unlock()
 	self.uncleMu.Lock()
@@ -406,21 +511,26 @@ func (self *worker) commitNewWork() {
 		time.Sleep(wait)
 	}
 
+	gasLimit := core.CalcGasLimit(parent)
+	if self.gasFloor != 0 || self.gasCeil != 0 {
+		gasLimit = core.CalcGasLimitWithin(parent, self.gasFloor, self.gasCeil)
+	}
 	num := parent.Number()
 	header := &types.Header{
 		ParentHash: parent.Hash(),
 		Number:     num.Add(num, common.Big1),
-		GasLimit:   core.CalcGasLimit(parent),
+		GasLimit:   gasLimit,
 		Extra:      self.extra,
 		Time:       big.NewInt(tstamp),
 	}
 	// Only set the coinbase if we are mining (avoid spurious block rewards)
+	coinbase := self.currentCoinbase()
 	if atomic.LoadInt32(&self.mining) == 1 {
-		header.Coinbase = self.coinbase
+		header.Coinbase = coinbase
 	}
 	if err := self.engine.Prepare(self.chain, header); err != nil {
 		log.Error("Failed to prepare header for mining", "err", err)
-		return
+		return err
 	}
 	// If we are care about TheDAO hard-fork check whather to override the extra-data or not
 	if daoBlock := self.config.DAOForkBlock; daoBlock != nil {
@@ -439,7 +549,7 @@ func (self *worker) commitNewWork() {
 	err := self.makeCurrent(parent, header)
 	if err != nil {
 		log.Error("Failed to create mining context", "err", err)
-		return
+		return err
 	}
 	// Create the current work task and check any fork transitions needed
 	work := self.current
@@ -449,10 +559,10 @@ func (self *worker) commitNewWork() {
 	pending, err := self.ath.TxPool().Pending()
 	if err != nil {
 		log.Error("Failed to fetch pending transactions", "err", err)
-		return
+		return err
 	}
 	txs := types.NewTransactionsByPriceAndNonce(self.current.signer, pending)
-	work.commitTransactions(self.mux, txs, self.chain, self.coinbase)
+	work.commitTransactions(self.mux, txs, self.chain, coinbase)
 
 	// compute uncles for the new block.
 	var (
@@ -479,7 +589,7 @@ func (self *worker) commitNewWork() {
 	// Create the new block to seal with the consensus engine
 	if work.Block, err = self.engine.Finalize(self.chain, header, work.state, work.txs, uncles, work.receipts); err != nil {
 		log.Error("Failed to finalize block for sealing", "err", err)
-		return
+		return err
 	}
 	// We only care about logging if we're actually mining.
 	if atomic.LoadInt32(&self.mining) == 1 {
@@ -488,6 +598,7 @@ func (self *worker) commitNewWork() {
 	}
 	self.push(work)
 	self.updateSnapshot()
+	return nil
 }
 
 func (self *worker) commitUncle(work *Work, uncle *types.Header) error {
@@ -583,7 +694,12 @@ func (env *Work) commitTransactions(mux *event.TypeMux, txs *types.TransactionsB
 		}
 	}
 
-	if len(coalescedLogs) > 0 || env.tcount > 0 {
+	// The first batch of logs of a freshly built pending block resets any previously
+	// announced ones, even if there are none (the previous pending logs disappeared)
+	reset := !env.logsAnnounced
+	env.logsAnnounced = true
+
+	if len(coalescedLogs) > 0 || env.tcount > 0 || reset {
 		// make a copy, the state caches the logs and these logs get "upgraded" from pending to mined
 		// logs by filling in the block hash when the block was mined by the local miner. This can
 		// cause a race condition if a log was "upgraded" before the PendingLogsEvent is processed.
@@ -593,8 +709,8 @@ func (env *Work) commitTransactions(mux *event.TypeMux, txs *types.TransactionsB
 			*cpy[i] = *l
 		}
 		go func(logs []*types.Log, tcount int) {
-			if len(logs) > 0 {
-				mux.Post(core.PendingLogsEvent{Logs: logs})
+			if len(logs) > 0 || reset {
+				mux.Post(core.PendingLogsEvent{Logs: logs, Reset: reset})
 			}
 			if tcount > 0 {
 				mux.Post(core.PendingStateEvent{})
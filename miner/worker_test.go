@@ -0,0 +1,247 @@
+// Copyright 2018 The go-athereum Authors
+// This file is part of the go-athereum library.
+//
+// The go-athereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-athereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-athereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/athereum/go-athereum/accounts"
+	"github.com/athereum/go-athereum/athdb"
+	"github.com/athereum/go-athereum/common"
+	"github.com/athereum/go-athereum/consensus/athash"
+	"github.com/athereum/go-athereum/consensus/clique"
+	"github.com/athereum/go-athereum/core"
+	"github.com/athereum/go-athereum/core/types"
+	"github.com/athereum/go-athereum/core/vm"
+	"github.com/athereum/go-athereum/crypto"
+	"github.com/athereum/go-athereum/event"
+	"github.com/athereum/go-athereum/params"
+)
+
+// testBackend is a miner backend serving a chain and a transaction pool on top.
+type testBackend struct {
+	db    athdb.Database
+	chain *core.BlockChain
+	pool  *core.TxPool
+}
+
+func (b *testBackend) AccountManager() *accounts.Manager { return nil }
+func (b *testBackend) BlockChain() *core.BlockChain      { return b.chain }
+func (b *testBackend) TxPool() *core.TxPool              { return b.pool }
+func (b *testBackend) ChainDb() athdb.Database           { return b.db }
+
+// newCliqueBackend creates a single signer clique chain holding back empty blocks
+// for a minute, along with a transaction pool on top. The returned engine is
+// authorized to seal with the returned key.
+func newCliqueBackend(t *testing.T) (*testBackend, *params.ChainConfig, *clique.Clique, *ecdsa.PrivateKey) {
+	key, _ := crypto.GenerateKey()
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+
+	config := *params.AllCliqueProtocolChanges
+	config.Clique = &params.CliqueConfig{Period: 1, Epoch: 30000}
+
+	genesis := &core.Genesis{
+		Config:    &config,
+		Timestamp: uint64(time.Now().Unix()),
+		ExtraData: make([]byte, 32+common.AddressLength+65),
+		Alloc:     core.GenesisAlloc{signer: {Balance: big.NewInt(params.Atlantis)}},
+	}
+	copy(genesis.ExtraData[32:], signer[:])
+
+	db := athdb.NewMemDatabase()
+	genesis.MustCommit(db)
+
+	engine := clique.New(config.Clique, db)
+	engine.SetEmptyBlockPeriod(60)
+	engine.Authorize(signer, func(account accounts.Account, hash []byte) ([]byte, error) {
+		return crypto.Sign(hash, key)
+	})
+	chain, err := core.NewBlockChain(db, nil, &config, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	txconfig := core.DefaultTxPoolConfig
+	txconfig.Journal = ""
+
+	return &testBackend{db: db, chain: chain, pool: core.NewTxPool(txconfig, &config, chain)}, &config, engine, key
+}
+
+// stop terminates the transaction pool and the chain of the backend.
+func (b *testBackend) stop() {
+	b.pool.Stop()
+	b.chain.Stop()
+}
+
+// Tests that a clique signer holds back empty blocks for the empty block period,
+// but replaces the held back block as soon as transactions arrive.
+func TestEmptyBlockReplacedByTransactions(t *testing.T) {
+	backend, config, engine, key := newCliqueBackend(t)
+	defer backend.stop()
+
+	heads := make(chan core.ChainHeadEvent, 1)
+	sub := backend.chain.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+
+	// Start mining and ensure the empty block isn't sealed
+	miner := New(backend, config, new(event.TypeMux), engine, params.GenesisGasLimit, params.GenesisGasLimit)
+	if err := miner.Start(crypto.PubkeyToAddress(key.PublicKey)); err != nil {
+		t.Fatalf("failed to start mining: %v", err)
+	}
+	defer miner.Stop()
+
+	select {
+	case ev := <-heads:
+		t.Fatalf("empty block #%v sealed before the empty block period", ev.Block.Number())
+	case <-time.After(2 * time.Second):
+	}
+	// Submit a transaction and ensure the held back block gets replaced by one
+	// including it
+	tx, err := types.SignTx(types.NewTransaction(0, common.Address{0x01}, big.NewInt(1), params.TxGas, big.NewInt(params.Shannon), nil), types.MakeSigner(config, common.Big1), key)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	if err := backend.pool.AddLocal(tx); err != nil {
+		t.Fatalf("failed to pool transaction: %v", err)
+	}
+	select {
+	case ev := <-heads:
+		if ev.Block.NumberU64() != 1 {
+			t.Fatalf("sealed block number mismatch: have %v, want %v", ev.Block.Number(), 1)
+		}
+		if txs := ev.Block.Transactions(); len(txs) != 1 || txs[0].Hash() != tx.Hash() {
+			t.Fatalf("sealed transactions mismatch: have %v, want [%x]", txs, tx.Hash())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("block with transactions not sealed")
+	}
+}
+
+// Tests that a paused miner keeps adding transactions to its pending block without
+// sealing it, and seals the accumulated block once resumed.
+func TestPauseResumeMining(t *testing.T) {
+	backend, config, engine, key := newCliqueBackend(t)
+	defer backend.stop()
+
+	heads := make(chan core.ChainHeadEvent, 1)
+	sub := backend.chain.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+
+	miner := New(backend, config, new(event.TypeMux), engine, params.GenesisGasLimit, params.GenesisGasLimit)
+	if err := miner.Start(crypto.PubkeyToAddress(key.PublicKey)); err != nil {
+		t.Fatalf("failed to start mining: %v", err)
+	}
+	defer miner.Stop()
+
+	miner.Pause()
+	if !miner.Mining() || !miner.Paused() {
+		t.Fatalf("miner state mismatch: have mining %v, paused %v, want both", miner.Mining(), miner.Paused())
+	}
+	// Submit a transaction and ensure it's added to the pending block, but not sealed
+	tx, err := types.SignTx(types.NewTransaction(0, common.Address{0x01}, big.NewInt(1), params.TxGas, big.NewInt(params.Shannon), nil), types.MakeSigner(config, common.Big1), key)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	if err := backend.pool.AddLocal(tx); err != nil {
+		t.Fatalf("failed to pool transaction: %v", err)
+	}
+	select {
+	case ev := <-heads:
+		t.Fatalf("block #%v sealed while paused", ev.Block.Number())
+	case <-time.After(2 * time.Second):
+	}
+	if txs := miner.PendingBlock().Transactions(); len(txs) != 1 || txs[0].Hash() != tx.Hash() {
+		t.Fatalf("pending transactions mismatch: have %v, want [%x]", txs, tx.Hash())
+	}
+	// Resume mining and ensure the pending transaction gets sealed
+	miner.Resume()
+	if miner.Paused() {
+		t.Fatalf("miner still paused after resuming")
+	}
+	select {
+	case ev := <-heads:
+		if txs := ev.Block.Transactions(); len(txs) != 1 || txs[0].Hash() != tx.Hash() {
+			t.Fatalf("sealed transactions mismatch: have %v, want [%x]", txs, tx.Hash())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("block not sealed after resuming")
+	}
+}
+
+// Tests that the block rewards of sealed blocks rotate across the configured
+// atherbases, each sealed block being noted in the reward log.
+func TestAtlantisbaseRotation(t *testing.T) {
+	db := athdb.NewMemDatabase()
+	genesis := &core.Genesis{Config: params.TestChainConfig}
+	genesis.MustCommit(db)
+
+	engine := athash.NewFaker()
+	chain, err := core.NewBlockChain(db, nil, params.TestChainConfig, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	txconfig := core.DefaultTxPoolConfig
+	txconfig.Journal = ""
+
+	backend := &testBackend{db: db, chain: chain, pool: core.NewTxPool(txconfig, params.TestChainConfig, chain)}
+	defer backend.stop()
+
+	heads := make(chan core.ChainHeadEvent, 16)
+	sub := backend.chain.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+
+	bases := []common.Address{{0x01}, {0x02}, {0x03}}
+
+	miner := New(backend, params.TestChainConfig, new(event.TypeMux), engine, params.GenesisGasLimit, params.GenesisGasLimit)
+	miner.SetAtlantisbases(bases)
+	if err := miner.Start(bases[0]); err != nil {
+		t.Fatalf("failed to start mining: %v", err)
+	}
+	for i := 0; i < 2*len(bases); i++ {
+		select {
+		case <-heads:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("block %d not sealed", i+1)
+		}
+	}
+	miner.Stop()
+
+	rewards := miner.RewardLog()
+	if len(rewards) < 2*len(bases) {
+		t.Fatalf("reward log length mismatch: have %d, want >= %d", len(rewards), 2*len(bases))
+	}
+	for i, reward := range rewards {
+		if want := bases[i%len(bases)]; reward.Coinbase != want {
+			t.Errorf("reward %d: coinbase mismatch: have %x, want %x", i, reward.Coinbase, want)
+		}
+		if block := chain.GetBlockByHash(reward.Hash); block == nil || block.Coinbase() != reward.Coinbase || block.NumberU64() != reward.Number {
+			t.Errorf("reward %d: not matching sealed block #%d [%x]", i, reward.Number, reward.Hash)
+		}
+	}
+	// Ensure dropping the rotation pays the rewards to the single atherbase again
+	miner.SetAtlantisbases(nil)
+
+	miner.worker.mu.Lock()
+	coinbase := miner.worker.currentCoinbase()
+	miner.worker.mu.Unlock()
+
+	if coinbase != bases[0] {
+		t.Errorf("coinbase mismatch after dropping the rotation: have %x, want %x", coinbase, bases[0])
+	}
+}
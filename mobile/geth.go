@@ -22,8 +22,13 @@ package gath
 import (
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"path/filepath"
+	"time"
 
+	"github.com/athereum/go-athereum/accounts"
+	"github.com/athereum/go-athereum/accounts/keystore"
+	"github.com/athereum/go-athereum/consensus/athash"
 	"github.com/athereum/go-athereum/core"
 	"github.com/athereum/go-athereum/ath"
 	"github.com/athereum/go-athereum/ath/downloader"
@@ -31,8 +36,11 @@ import (
 	"github.com/athereum/go-athereum/athstats"
 	"github.com/athereum/go-athereum/internal/debug"
 	"github.com/athereum/go-athereum/les"
+	"github.com/athereum/go-athereum/log"
 	"github.com/athereum/go-athereum/node"
 	"github.com/athereum/go-athereum/p2p"
+	"github.com/athereum/go-athereum/p2p/discover"
+	"github.com/athereum/go-athereum/p2p/discv5"
 	"github.com/athereum/go-athereum/p2p/nat"
 	"github.com/athereum/go-athereum/params"
 	whisper "github.com/athereum/go-athereum/whisper/whisperv6"
@@ -46,10 +54,20 @@ type NodeConfig struct {
 	// Bootstrap nodes used to establish connectivity with the rest of the network.
 	BootstrapNodes *Enodes
 
+	// ExtraBootstrapNodes are additional nodes used to establish connectivity,
+	// dialed alongside (not instead of) the BootstrapNodes. They are meant to add
+	// e.g. private testnet bootnodes while keeping the public ones for redundancy.
+	ExtraBootstrapNodes *Enodes
+
 	// MaxPeers is the maximum number of peers that can be connected. If this is
 	// set to zero, then only the configured static and trusted peers can connect.
 	MaxPeers int
 
+	// MaxInboundPeers is the maximum number of remotely initiated connections
+	// accepted, useful to keep a node on a metered connection mostly dialing out.
+	// If this is set to zero, inbound peers are only limited by MaxPeers.
+	MaxInboundPeers int
+
 	// AtlantisEnabled specifies whather the node should run the Atlantis protocol.
 	AtlantisEnabled bool
 
@@ -65,15 +83,42 @@ type NodeConfig struct {
 	// A minimum of 16MB is always reserved.
 	AtlantisDatabaseCache int
 
+	// AtlantisPowMode is the proof-of-work engine variant to run. It may be one
+	// of "normal", "shared", "test" or "fake". An empty mode is equivalent to
+	// running a normal PoW engine.
+	AtlantisPowMode string
+
+	// AtlantisDatasetDir is the directory to store the Ethash mining datasets in.
+	// If empty, the default location is used.
+	AtlantisDatasetDir string
+
 	// AtlantisNetStats is a netstats connection string to use to report various
 	// chain, transaction and node stats to a monitoring server.
 	//
 	// It has the form "nodename:secret@host:port"
 	AtlantisNetStats string
 
+	// DevMode runs an ephemeral, single node proof-of-authority chain with a pre-funded
+	// developer account, sealing blocks whenever transactions are pending. The first
+	// keystore account is used as the developer, a passwordless one is created if
+	// the keystore is empty. Networking is disabled and a full node is run instead
+	// of a light client. If AtlantisGenesis is also set, it is ignored.
+	DevMode bool
+
 	// WhisperEnabled specifies whather the node should run the Whisper protocol.
 	WhisperEnabled bool
 
+	// NatSpec is the NAT port mapping mechanism to use, in the format accepted
+	// by nat.Parse (e.g. "any", "none", "upnp", "pmp" or "extip:<IP>"). Use
+	// "none" to skip NAT probing when the ports are mapped explicitly, as the
+	// default "any" might stall the startup in containerized deployments.
+	NatSpec string
+
+	// DialProxy is the address of a SOCKS5 proxy to route outbound peer connections
+	// through, in the form "[socks5://][user:password@]host:port". Peer discovery is
+	// disabled when set, the bootstrap nodes being dialed through the proxy instead.
+	DialProxy string
+
 	// Listening address of pprof server.
 	PprofAddress string
 }
@@ -86,6 +131,7 @@ var defaultNodeConfig = &NodeConfig{
 	AtlantisEnabled:       true,
 	AtlantisNetworkID:     1,
 	AtlantisDatabaseCache: 16,
+	NatSpec:               node.DefaultNATSpec,
 }
 
 // NewNodeConfig creates a new node option set, initialized to the default values.
@@ -96,7 +142,8 @@ func NewNodeConfig() *NodeConfig {
 
 // Node represents a Gath Atlantis node instance.
 type Node struct {
-	node *node.Node
+	node    *node.Node
+	devMode bool // Whether to start sealing on the developer chain when started
 }
 
 // NewNode creates and configures a new Gath node.
@@ -112,10 +159,22 @@ func NewNode(datadir string, config *NodeConfig) (stack *Node, _ error) {
 		config.BootstrapNodes = defaultNodeConfig.BootstrapNodes
 	}
 
+	if config.NatSpec == "" {
+		config.NatSpec = defaultNodeConfig.NatSpec
+	}
+	natif, err := nat.Parse(config.NatSpec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid NAT spec: %v", err)
+	}
+
 	if config.PprofAddress != "" {
 		debug.StartPProf(config.PprofAddress)
 	}
 
+	bootnodes := config.BootstrapNodes.nodes
+	if config.ExtraBootstrapNodes != nil && config.ExtraBootstrapNodes.Size() > 0 {
+		bootnodes = append(append([]*discv5.Node{}, bootnodes...), config.ExtraBootstrapNodes.nodes...)
+	}
 	// Create the empty networking stack
 	nodeConf := &node.Config{
 		Name:        clientIdentifier,
@@ -125,11 +184,24 @@ func NewNode(datadir string, config *NodeConfig) (stack *Node, _ error) {
 		P2P: p2p.Config{
 			NoDiscovery:      true,
 			DiscoveryV5:      true,
-			BootstrapNodesV5: config.BootstrapNodes.nodes,
+			BootstrapNodesV5: bootnodes,
 			ListenAddr:       ":0",
-			NAT:              nat.Any(),
+			NAT:              natif,
 			MaxPeers:         config.MaxPeers,
+			MaxInboundPeers:  config.MaxInboundPeers,
 		},
+		DialProxy: config.DialProxy,
+	}
+	if config.DialProxy != "" {
+		// Without discovery, the bootnodes can only be reached by dialing them as static peers
+		for _, n := range bootnodes {
+			nodeConf.P2P.BootstrapNodes = append(nodeConf.P2P.BootstrapNodes, discover.NewNode(discover.NodeID(n.ID), n.IP, n.UDP, n.TCP))
+		}
+	}
+	if config.DevMode {
+		// Developer chains are local only, don't touch the network
+		nodeConf.P2P.MaxPeers = 0
+		nodeConf.P2P.DiscoveryV5 = false
 	}
 	rawStack, err := node.New(nodeConf)
 	if err != nil {
@@ -138,8 +210,19 @@ func NewNode(datadir string, config *NodeConfig) (stack *Node, _ error) {
 
 	debug.Memsize.Add("node", rawStack)
 
-	var genesis *core.Genesis
-	if config.AtlantisGenesis != "" {
+	var (
+		genesis   *core.Genesis
+		developer accounts.Account
+	)
+	if config.DevMode {
+		if config.AtlantisGenesis != "" {
+			log.Warn("Ignoring custom genesis in developer mode")
+		}
+		if developer, err = unlockDeveloper(rawStack); err != nil {
+			return nil, err
+		}
+		genesis = core.DeveloperGenesisBlock(0, developer.Address)
+	} else if config.AtlantisGenesis != "" {
 		// Parse the user supplied genesis spec if not mainnet
 		genesis = new(core.Genesis)
 		if err := json.Unmarshal([]byte(config.AtlantisGenesis), genesis); err != nil {
@@ -155,18 +238,37 @@ func NewNode(datadir string, config *NodeConfig) (stack *Node, _ error) {
 	}
 	// Register the Atlantis protocol if requested
 	if config.AtlantisEnabled {
+		powMode, err := parsePowMode(config.AtlantisPowMode)
+		if err != nil {
+			return nil, err
+		}
 		athConf := ath.DefaultConfig
 		athConf.Genesis = genesis
 		athConf.SyncMode = downloader.LightSync
 		athConf.NetworkId = uint64(config.AtlantisNetworkID)
 		athConf.DatabaseCache = config.AtlantisDatabaseCache
-		if err := rawStack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		athConf.Ethash.PowMode = powMode
+		if config.AtlantisDatasetDir != "" {
+			athConf.Ethash.DatasetDir = config.AtlantisDatasetDir
+		}
+		if config.DevMode {
+			// Light clients can't seal, run a full node mining to the developer
+			athConf.SyncMode = downloader.FullSync
+			athConf.Atlantisbase = developer.Address
+			athConf.GasPrice = big.NewInt(1)
+
+			if err := rawStack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+				return ath.New(ctx, &athConf)
+			}); err != nil {
+				return nil, fmt.Errorf("athereum init: %v", err)
+			}
+		} else if err := rawStack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
 			return les.New(ctx, &athConf)
 		}); err != nil {
 			return nil, fmt.Errorf("athereum init: %v", err)
 		}
 		// If netstats reporting is requested, do it
-		if config.AtlantisNetStats != "" {
+		if config.AtlantisNetStats != "" && !config.DevMode {
 			if err := rawStack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
 				var lesServ *les.LightAtlantis
 				ctx.Service(&lesServ)
@@ -185,12 +287,63 @@ func NewNode(datadir string, config *NodeConfig) (stack *Node, _ error) {
 			return nil, fmt.Errorf("whisper init: %v", err)
 		}
 	}
-	return &Node{rawStack}, nil
+	return &Node{node: rawStack, devMode: config.DevMode && config.AtlantisEnabled}, nil
 }
 
-// Start creates a live P2P node and starts running it.
+// unlockDeveloper retrieves the first account from the node's keystore, creating
+// a passwordless one if none exists, and unlocks it for sealing developer blocks.
+func unlockDeveloper(stack *node.Node) (accounts.Account, error) {
+	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+
+	var (
+		developer accounts.Account
+		err       error
+	)
+	if accs := ks.Accounts(); len(accs) > 0 {
+		developer = accs[0]
+	} else if developer, err = ks.NewAccount(""); err != nil {
+		return accounts.Account{}, fmt.Errorf("failed to create developer account: %v", err)
+	}
+	if err := ks.Unlock(developer, ""); err != nil {
+		return accounts.Account{}, fmt.Errorf("failed to unlock developer account: %v", err)
+	}
+	log.Info("Using developer account", "address", developer.Address)
+	return developer, nil
+}
+
+// parsePowMode converts a user supplied proof-of-work mode name into its athash
+// counterpart.
+func parsePowMode(mode string) (athash.Mode, error) {
+	switch mode {
+	case "", "normal":
+		return athash.ModeNormal, nil
+	case "shared":
+		return athash.ModeShared, nil
+	case "test":
+		return athash.ModeTest, nil
+	case "fake":
+		return athash.ModeFake, nil
+	default:
+		return athash.ModeNormal, fmt.Errorf("invalid pow mode %q", mode)
+	}
+}
+
+// Start creates a live P2P node and starts running it. In developer mode, block
+// sealing is started too.
 func (n *Node) Start() error {
-	return n.node.Start()
+	if err := n.node.Start(); err != nil {
+		return err
+	}
+	if n.devMode {
+		var athServ *ath.Atlantis
+		if err := n.node.Service(&athServ); err != nil {
+			return err
+		}
+		if err := athServ.StartMining(true); err != nil {
+			return fmt.Errorf("failed to start developer mining: %v", err)
+		}
+	}
+	return nil
 }
 
 // Stop terminates a running node along with all it's services. In the node was
@@ -213,6 +366,31 @@ func (n *Node) GetNodeInfo() *NodeInfo {
 	return &NodeInfo{n.node.Server().NodeInfo()}
 }
 
+// GetSyncProgress retrieves the current progress of the sync algorithm. If there's
+// no sync currently running, it returns nil.
+func (n *Node) GetSyncProgress() *SyncProgress {
+	var lesServ *les.LightAtlantis
+	if err := n.node.Service(&lesServ); err != nil {
+		return nil
+	}
+	progress := lesServ.SyncProgress()
+	if progress.CurrentBlock >= progress.HighestBlock {
+		return nil
+	}
+	return &SyncProgress{progress}
+}
+
+// GetSyncETA estimates the number of seconds remaining until the current sync
+// completes. It returns zero if there's no sync currently running or if the
+// import rate is not known yet.
+func (n *Node) GetSyncETA() int64 {
+	var lesServ *les.LightAtlantis
+	if err := n.node.Service(&lesServ); err != nil {
+		return 0
+	}
+	return int64(lesServ.Downloader().ETA() / time.Second)
+}
+
 // GetPeersInfo returns an array of metadata objects describing connected peers.
 func (n *Node) GetPeersInfo() *PeerInfos {
 	return &PeerInfos{n.node.Server().PeersInfo()}
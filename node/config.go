@@ -24,6 +24,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/athereum/go-athereum/accounts"
 	"github.com/athereum/go-athereum/accounts/keystore"
@@ -119,6 +120,20 @@ type Config struct {
 	// exposed.
 	HTTPModules []string `toml:",omitempty"`
 
+	// HTTPBodyLimit is the maximum size in bytes of a request body accepted by the
+	// HTTP RPC server. Larger requests are rejected with 413 Request Entity Too Large.
+	// A zero value falls back to DefaultHTTPBodyLimit, it does not disable the check.
+	HTTPBodyLimit int64 `toml:",omitempty"`
+
+	// HTTPUnixSocket is the path of a Unix domain socket on which to additionally
+	// serve the HTTP RPC API, without exposing any TCP port. Relative paths are
+	// placed into the data directory. If empty, no socket will be opened.
+	HTTPUnixSocket string `toml:",omitempty"`
+
+	// HTTPUnixSocketMode is the file permission set on the HTTP RPC socket. A zero
+	// value falls back to DefaultHTTPUnixSocketMode.
+	HTTPUnixSocketMode os.FileMode `toml:",omitempty"`
+
 	// WSHost is the host interface on which to start the websocket RPC server. If
 	// this field is empty, no websocket API endpoint will be started.
 	WSHost string `toml:",omitempty"`
@@ -145,6 +160,23 @@ type Config struct {
 	// private APIs to untrusted users is a major security risk.
 	WSExposeAll bool `toml:",omitempty"`
 
+	// MaxSubscriptionsPerConn is the maximum number of subscriptions a single
+	// websocket connection may hold at once. Further subscribe requests fail until
+	// an existing subscription is cancelled. A zero value falls back to
+	// DefaultMaxSubscriptionsPerConn, it does not disable the check.
+	MaxSubscriptionsPerConn int `toml:",omitempty"`
+
+	// WSPingInterval is the interval at which the websocket RPC server pings its
+	// connections, keeping idle subscriptions alive behind load balancers and
+	// dropping connections whose peer went away. Zero disables pinging.
+	WSPingInterval time.Duration `toml:",omitempty"`
+
+	// DialProxy is the address of a SOCKS5 proxy to route outbound peer connections
+	// through, in the form [socks5://][user:password@]host:port. As UDP doesn't pass
+	// the proxy, peer discovery is disabled when it is set and peers are found via
+	// the static, trusted and bootstrap nodes only, the latter dialed as static peers.
+	DialProxy string `toml:",omitempty"`
+
 	// Logger is a custom logger to use with the p2p.Server.
 	Logger log.Logger `toml:",omitempty"`
 }
@@ -203,6 +235,15 @@ func (c *Config) HTTPEndpoint() string {
 	return fmt.Sprintf("%s:%d", c.HTTPHost, c.HTTPPort)
 }
 
+// HTTPUnixEndpoint resolves the path of the Unix domain socket serving the HTTP
+// RPC API, placing relative paths into the data directory.
+func (c *Config) HTTPUnixEndpoint() string {
+	if c.HTTPUnixSocket == "" || c.DataDir == "" || filepath.IsAbs(c.HTTPUnixSocket) {
+		return c.HTTPUnixSocket
+	}
+	return filepath.Join(c.DataDir, c.HTTPUnixSocket)
+}
+
 // DefaultHTTPEndpoint returns the HTTP endpoint used by default.
 func DefaultHTTPEndpoint() string {
 	config := &Config{HTTPHost: DefaultHTTPHost, HTTPPort: DefaultHTTPPort}
@@ -26,6 +26,7 @@ import (
 
 	"github.com/athereum/go-athereum/crypto"
 	"github.com/athereum/go-athereum/p2p"
+	"github.com/athereum/go-athereum/p2p/nat"
 )
 
 // Tests that datadirs can be successfully created, be them manually configured
@@ -148,3 +149,41 @@ func TestNodeKeyPersistency(t *testing.T) {
 		t.Fatalf("ephemeral node key persisted to disk")
 	}
 }
+
+// Tests that default ports can be overridden from the environment, falling back
+// to the compiled in defaults for missing or malformed values.
+func TestEnvPortOverride(t *testing.T) {
+	const env = "GETH_TEST_PORT"
+	defer os.Unsetenv(env)
+
+	tests := []struct {
+		value string
+		port  int
+	}{
+		{"", DefaultHTTPPort},
+		{"8080", 8080},
+		{"65535", 65535},
+		{"0", DefaultHTTPPort},
+		{"65536", DefaultHTTPPort},
+		{"-1", DefaultHTTPPort},
+		{"http", DefaultHTTPPort},
+	}
+	for i, tt := range tests {
+		os.Setenv(env, tt.value)
+		if port := envPort(env, DefaultHTTPPort); port != tt.port {
+			t.Errorf("test %d (%q): port mismatch: have %d, want %d", i, tt.value, port, tt.port)
+		}
+	}
+}
+
+// Tests that the default NAT spec is valid and selects the same port mapping
+// mechanism as the default config.
+func TestDefaultNATSpec(t *testing.T) {
+	natif, err := nat.Parse(DefaultNATSpec)
+	if err != nil {
+		t.Fatalf("failed to parse default NAT spec %q: %v", DefaultNATSpec, err)
+	}
+	if have, want := natif.String(), DefaultConfig.P2P.NAT.String(); have != want {
+		t.Fatalf("NAT mechanism mismatch: have %q, want %q", have, want)
+	}
+}
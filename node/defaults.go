@@ -21,26 +21,42 @@ import (
 	"os/user"
 	"path/filepath"
 	"runtime"
+	"strconv"
 
+	"github.com/athereum/go-athereum/log"
 	"github.com/athereum/go-athereum/p2p"
 	"github.com/athereum/go-athereum/p2p/nat"
 )
 
 const (
-	DefaultHTTPHost = "localhost" // Default host interface for the HTTP RPC server
-	DefaultHTTPPort = 55555       // Default TCP port for the HTTP RPC server
-	DefaultWSHost   = "localhost" // Default host interface for the websocket RPC server
-	DefaultWSPort   = 8546        // Default TCP port for the websocket RPC server
+	DefaultHTTPHost                = "localhost" // Default host interface for the HTTP RPC server
+	DefaultHTTPPort                = 55555       // Default TCP port for the HTTP RPC server
+	DefaultHTTPBodyLimit           = 128 * 1024  // Default maximum request body size for the HTTP RPC server
+	DefaultHTTPUnixSocketMode      = 0600        // Default file permission of the HTTP RPC Unix domain socket
+	DefaultWSHost                  = "localhost" // Default host interface for the websocket RPC server
+	DefaultWSPort                  = 8546        // Default TCP port for the websocket RPC server
+	DefaultMaxSubscriptionsPerConn = 1000        // Default maximum number of subscriptions per websocket connection
+	DefaultNATSpec                 = "any"       // Default NAT port mapping mechanism (see nat.Parse)
+)
+
+// Environment variables overriding the default RPC ports, allowing the same binary
+// and config to be deployed across differently mapped containers. They only change
+// the defaults, ports set in a config file or via flags still take precedence.
+const (
+	httpPortEnv = "GETH_HTTP_PORT"
+	wsPortEnv   = "GETH_WS_PORT"
 )
 
 // DefaultConfig contains reasonable default settings.
 var DefaultConfig = Config{
-	DataDir:          DefaultDataDir(),
-	HTTPPort:         DefaultHTTPPort,
-	HTTPModules:      []string{"net", "web3"},
-	HTTPVirtualHosts: []string{"localhost"},
-	WSPort:           DefaultWSPort,
-	WSModules:        []string{"net", "web3"},
+	DataDir:                 DefaultDataDir(),
+	HTTPPort:                envPort(httpPortEnv, DefaultHTTPPort),
+	HTTPModules:             []string{"net", "web3"},
+	HTTPVirtualHosts:        []string{"localhost"},
+	HTTPBodyLimit:           DefaultHTTPBodyLimit,
+	WSPort:                  envPort(wsPortEnv, DefaultWSPort),
+	WSModules:               []string{"net", "web3"},
+	MaxSubscriptionsPerConn: DefaultMaxSubscriptionsPerConn,
 	P2P: p2p.Config{
 		ListenAddr: ":44444",
 		MaxPeers:   25,
@@ -48,6 +64,21 @@ var DefaultConfig = Config{
 	},
 }
 
+// envPort returns the TCP port set in the given environment variable, or the
+// fallback if it's unset. Malformed values are reported and ignored.
+func envPort(env string, fallback int) int {
+	value := os.Getenv(env)
+	if value == "" {
+		return fallback
+	}
+	port, err := strconv.ParseUint(value, 10, 16)
+	if err != nil || port == 0 {
+		log.Warn("Ignoring invalid port override", "env", env, "value", value)
+		return fallback
+	}
+	return int(port)
+}
+
 // DefaultDataDir is the default data directory to use for the databases and other
 // persistence requirements.
 func DefaultDataDir() string {
@@ -25,6 +25,7 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/athereum/go-athereum/accounts"
 	"github.com/athereum/go-athereum/athdb"
@@ -32,6 +33,7 @@ import (
 	"github.com/athereum/go-athereum/internal/debug"
 	"github.com/athereum/go-athereum/log"
 	"github.com/athereum/go-athereum/p2p"
+	"github.com/athereum/go-athereum/p2p/discover"
 	"github.com/athereum/go-athereum/rpc"
 	"github.com/promatheus/promatheus/util/flock"
 )
@@ -63,6 +65,10 @@ type Node struct {
 	httpListener  net.Listener // HTTP RPC listener socket to server API requests
 	httpHandler   *rpc.Server  // HTTP RPC request handler to process the API requests
 
+	httpUnixEndpoint string       // HTTP Unix domain socket path to listen at (empty = disabled)
+	httpUnixListener net.Listener // HTTP RPC Unix socket listener to serve API requests
+	httpUnixHandler  *rpc.Server  // HTTP RPC request handler to process the Unix socket API requests
+
 	wsEndpoint string       // Websocket endpoint (interface + port) to listen at (empty = websocket disabled)
 	wsListener net.Listener // Websocket RPC listener socket to server API requests
 	wsHandler  *rpc.Server  // Websocket RPC request handler to process the API requests
@@ -115,6 +121,7 @@ func New(conf *Config) (*Node, error) {
 		serviceFuncs:      []ServiceConstructor{},
 		ipcEndpoint:       conf.IPCEndpoint(),
 		httpEndpoint:      conf.HTTPEndpoint(),
+		httpUnixEndpoint:  conf.HTTPUnixEndpoint(),
 		wsEndpoint:        conf.WSEndpoint(),
 		eventmux:          new(event.TypeMux),
 		log:               conf.Logger,
@@ -143,6 +150,14 @@ func (n *Node) Start() error {
 	if n.server != nil {
 		return ErrNodeRunning
 	}
+	var dialer p2p.NodeDialer
+	if n.config.DialProxy != "" {
+		proxy, err := p2p.NewSOCKS5Dialer(n.config.DialProxy)
+		if err != nil {
+			return err
+		}
+		dialer = proxy
+	}
 	if err := n.openDataDir(); err != nil {
 		return err
 	}
@@ -162,6 +177,30 @@ func (n *Node) Start() error {
 	if n.serverConfig.NodeDatabase == "" {
 		n.serverConfig.NodeDatabase = n.config.NodeDB()
 	}
+	if dialer != nil {
+		// Discovery runs over UDP, which the proxy can't carry
+		if !n.serverConfig.NoDiscovery || n.serverConfig.DiscoveryV5 {
+			n.log.Warn("Disabling peer discovery behind dial proxy")
+		}
+		n.serverConfig.Dialer = dialer
+		n.serverConfig.NoDiscovery = true
+		n.serverConfig.DiscoveryV5 = false
+
+		// Without discovery there are no dynamic dials, keep the bootnodes as static peers
+		n.serverConfig.StaticNodes = append([]*discover.Node{}, n.serverConfig.StaticNodes...)
+		for _, boot := range n.serverConfig.BootstrapNodes {
+			known := false
+			for _, static := range n.serverConfig.StaticNodes {
+				if static.ID == boot.ID {
+					known = true
+					break
+				}
+			}
+			if !known {
+				n.serverConfig.StaticNodes = append(n.serverConfig.StaticNodes, boot)
+			}
+		}
+	}
 	running := &p2p.Server{Config: n.serverConfig}
 	n.log.Info("Starting peer-to-peer node", "instance", n.serverConfig.Name)
 
@@ -263,12 +302,19 @@ func (n *Node) startRPC(services map[reflect.Type]Service) error {
 		n.stopInProc()
 		return err
 	}
-	if err := n.startHTTP(n.httpEndpoint, apis, n.config.HTTPModules, n.config.HTTPCors, n.config.HTTPVirtualHosts); err != nil {
+	if err := n.startHTTP(n.httpEndpoint, apis, n.config.HTTPModules, n.config.HTTPCors, n.config.HTTPVirtualHosts, n.config.HTTPBodyLimit); err != nil {
+		n.stopIPC()
+		n.stopInProc()
+		return err
+	}
+	if err := n.startHTTPUnix(apis); err != nil {
+		n.stopHTTP()
 		n.stopIPC()
 		n.stopInProc()
 		return err
 	}
-	if err := n.startWS(n.wsEndpoint, apis, n.config.WSModules, n.config.WSOrigins, n.config.WSExposeAll); err != nil {
+	if err := n.startWS(n.wsEndpoint, apis, n.config.WSModules, n.config.WSOrigins, n.config.WSExposeAll, n.config.MaxSubscriptionsPerConn, n.config.WSPingInterval); err != nil {
+		n.stopHTTPUnix()
 		n.stopHTTP()
 		n.stopIPC()
 		n.stopInProc()
@@ -331,12 +377,15 @@ func (n *Node) stopIPC() {
 }
 
 // startHTTP initializes and starts the HTTP RPC endpoint.
-func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors []string, vhosts []string) error {
+func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors []string, vhosts []string, bodyLimit int64) error {
 	// Short circuit if the HTTP endpoint isn't being exposed
 	if endpoint == "" {
 		return nil
 	}
-	listener, handler, err := rpc.StartHTTPEndpoint(endpoint, apis, modules, cors, vhosts)
+	if bodyLimit <= 0 {
+		bodyLimit = DefaultHTTPBodyLimit
+	}
+	listener, handler, err := rpc.StartHTTPEndpoint(endpoint, apis, modules, cors, vhosts, bodyLimit)
 	if err != nil {
 		return err
 	}
@@ -363,13 +412,56 @@ func (n *Node) stopHTTP() {
 	}
 }
 
+// startHTTPUnix initializes and starts the HTTP RPC endpoint on a Unix domain socket.
+func (n *Node) startHTTPUnix(apis []rpc.API) error {
+	if n.httpUnixEndpoint == "" {
+		return nil // HTTP over Unix socket disabled.
+	}
+	bodyLimit := n.config.HTTPBodyLimit
+	if bodyLimit <= 0 {
+		bodyLimit = DefaultHTTPBodyLimit
+	}
+	mode := n.config.HTTPUnixSocketMode
+	if mode == 0 {
+		mode = DefaultHTTPUnixSocketMode
+	}
+	listener, handler, err := rpc.StartHTTPUnixEndpoint(n.httpUnixEndpoint, mode, apis, n.config.HTTPModules, n.config.HTTPCors, n.config.HTTPVirtualHosts, bodyLimit)
+	if err != nil {
+		return err
+	}
+	n.log.Info("HTTP endpoint opened", "url", "unix://"+n.httpUnixEndpoint, "mode", mode)
+	n.httpUnixListener = listener
+	n.httpUnixHandler = handler
+
+	return nil
+}
+
+// stopHTTPUnix terminates the HTTP RPC endpoint on the Unix domain socket and
+// removes the socket file.
+func (n *Node) stopHTTPUnix() {
+	if n.httpUnixListener != nil {
+		n.httpUnixListener.Close()
+		n.httpUnixListener = nil
+		os.Remove(n.httpUnixEndpoint)
+
+		n.log.Info("HTTP endpoint closed", "url", "unix://"+n.httpUnixEndpoint)
+	}
+	if n.httpUnixHandler != nil {
+		n.httpUnixHandler.Stop()
+		n.httpUnixHandler = nil
+	}
+}
+
 // startWS initializes and starts the websocket RPC endpoint.
-func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrigins []string, exposeAll bool) error {
+func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrigins []string, exposeAll bool, maxSubs int, pingInterval time.Duration) error {
 	// Short circuit if the WS endpoint isn't being exposed
 	if endpoint == "" {
 		return nil
 	}
-	listener, handler, err := rpc.StartWSEndpoint(endpoint, apis, modules, wsOrigins, exposeAll)
+	if maxSubs <= 0 {
+		maxSubs = DefaultMaxSubscriptionsPerConn
+	}
+	listener, handler, err := rpc.StartWSEndpoint(endpoint, apis, modules, wsOrigins, exposeAll, maxSubs, pingInterval)
 	if err != nil {
 		return err
 	}
@@ -409,6 +501,7 @@ func (n *Node) Stop() error {
 
 	// Terminate the API, services and the p2p server.
 	n.stopWS()
+	n.stopHTTPUnix()
 	n.stopHTTP()
 	n.stopIPC()
 	n.rpcAPIs = nil
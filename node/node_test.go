@@ -18,7 +18,10 @@ package node
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
 	"os"
 	"reflect"
 	"testing"
@@ -26,6 +29,7 @@ import (
 
 	"github.com/athereum/go-athereum/crypto"
 	"github.com/athereum/go-athereum/p2p"
+	"github.com/athereum/go-athereum/p2p/discover"
 	"github.com/athereum/go-athereum/rpc"
 )
 
@@ -573,3 +577,67 @@ func TestAPIGather(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the bootstrap nodes are still dialed, through the proxy, when peer
+// discovery is disabled by a dial proxy.
+func TestBootnodeDialedThroughProxy(t *testing.T) {
+	proxy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to open proxy listener: %v", err)
+	}
+	defer proxy.Close()
+
+	bootkey, _ := crypto.GenerateKey()
+	bootnode := discover.NewNode(discover.PubkeyID(&bootkey.PublicKey), net.IP{10, 0, 0, 1}, 30303, 30303)
+
+	config := testNodeConfig()
+	config.P2P.MaxPeers = 10
+	config.P2P.BootstrapNodes = []*discover.Node{bootnode}
+	config.DialProxy = proxy.Addr().String()
+
+	stack, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	defer stack.Stop()
+
+	// Accept the dial on the proxy and check the requested destination
+	errc := make(chan error, 1)
+	go func() {
+		conn, err := proxy.Accept()
+		if err != nil {
+			errc <- err
+			return
+		}
+		defer conn.Close()
+
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		if _, err := io.ReadFull(conn, make([]byte, 3)); err != nil {
+			errc <- err
+			return
+		}
+		conn.Write([]byte{0x05, 0x00})
+
+		req := make([]byte, 10)
+		if _, err := io.ReadFull(conn, req); err != nil {
+			errc <- err
+			return
+		}
+		if ip, port := net.IP(req[4:8]), int(req[8])<<8|int(req[9]); !ip.Equal(bootnode.IP) || port != int(bootnode.TCP) {
+			errc <- fmt.Errorf("destination mismatch: have %v:%d, want %v:%d", ip, port, bootnode.IP, bootnode.TCP)
+			return
+		}
+		errc <- nil
+	}()
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Fatalf("proxied bootnode dial failed: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("bootnode not dialed through the proxy")
+	}
+}
@@ -50,6 +50,20 @@ func (ctx *ServiceContext) OpenDatabase(name string, cache int, handles int) (at
 	return db, nil
 }
 
+// OpenDatabaseReadOnly opens an existing database with the given name from
+// within the node's data directory in read-only mode. If the node is an
+// ephemeral one, a memory database is returned.
+func (ctx *ServiceContext) OpenDatabaseReadOnly(name string, cache int, handles int) (athdb.Database, error) {
+	if ctx.config.DataDir == "" {
+		return athdb.NewMemDatabase(), nil
+	}
+	db, err := athdb.NewLDBDatabaseReadOnly(ctx.config.resolvePath(name), cache, handles)
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
 // ResolvePath resolves a user path into the data directory if that was relative
 // and if the user actually uses persistent storage. It will return an empty string
 // for emphemeral storage and the user's own input for absolute paths.
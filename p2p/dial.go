@@ -34,6 +34,10 @@ const (
 	// redialing a certain node.
 	dialHistoryExpiration = 30 * time.Second
 
+	// Nodes dialed more recently than this are not retried on
+	// a refill, giving pending or just failed dials some rest.
+	refillRetryDelay = 5 * time.Second
+
 	// Discovery lookups are throttled and can only run
 	// once every few seconds.
 	lookupInterval = 4 * time.Second
@@ -82,6 +86,7 @@ type dialstate struct {
 
 	start     time.Time        // time when the dialer was first used
 	bootnodes []*discover.Node // default dials when there are no peers
+	refilling bool             // draw all dynamic dials from the table next round
 }
 
 type discoverTable interface {
@@ -159,6 +164,15 @@ func (s *dialstate) removeStatic(n *discover.Node) {
 	s.hist.remove(n.ID)
 }
 
+// refill makes the next round of dynamic dials draw all its candidates from the
+// discovery table instead of only half of them, and forgets the dial history of
+// nodes not tried within refillRetryDelay so that they may be dialed again right
+// away.
+func (s *dialstate) refill(now time.Time) {
+	s.refilling = true
+	s.hist.expire(now.Add(dialHistoryExpiration - refillRetryDelay))
+}
+
 func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now time.Time) []task {
 	if s.start.IsZero() {
 		s.start = now
@@ -217,11 +231,16 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 	}
 	// Use random nodes from the table for half of the necessary
 	// dynamic dials.
-	randomCandidates := needDynDials / 2
+	randomCandidates, randomNodes := needDynDials/2, s.randomNodes
+	if s.refilling {
+		// A refill was requested, use the table for all of them.
+		randomCandidates, randomNodes = needDynDials, make([]*discover.Node, needDynDials)
+		s.refilling = false
+	}
 	if randomCandidates > 0 {
-		n := s.ntab.ReadRandomNodes(s.randomNodes)
+		n := s.ntab.ReadRandomNodes(randomNodes)
 		for i := 0; i < randomCandidates && i < n; i++ {
-			if addDial(dynDialedConn, s.randomNodes[i]) {
+			if addDial(dynDialedConn, randomNodes[i]) {
 				needDynDials--
 			}
 		}
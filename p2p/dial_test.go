@@ -402,6 +402,75 @@ func TestDialStateDynDialFromTable(t *testing.T) {
 	})
 }
 
+// This test checks that a refill dials all needed candidates from the table,
+// including previously dialed ones unless they were only just tried.
+func TestDialStateRefill(t *testing.T) {
+	table := fakeTable{
+		{ID: uintID(1)},
+		{ID: uintID(2)},
+		{ID: uintID(3)},
+		{ID: uintID(4)},
+		{ID: uintID(5)},
+		{ID: uintID(6)},
+		{ID: uintID(7)},
+		{ID: uintID(8)},
+	}
+	var (
+		now   time.Time
+		state = newDialState(nil, nil, table, 6, nil)
+		peers = make(map[discover.NodeID]*Peer)
+	)
+	// Half of the dynamic dials are drawn from the table, all of which fail.
+	first := state.newTasks(0, peers, now)
+	want := []task{
+		&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(1)}},
+		&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(2)}},
+		&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(3)}},
+		&discoverTask{},
+	}
+	if !sametasks(first, want) {
+		t.Fatalf("initial tasks mismatch:\ngot %v\nwant %v", spew.Sdump(first), spew.Sdump(want))
+	}
+	for _, task := range first[:3] {
+		state.taskDone(task, now)
+	}
+	// Without a refill, the failed nodes are not retried until their history expires.
+	if tasks := state.newTasks(1, peers, now); len(tasks) != 0 {
+		t.Fatalf("unexpected tasks before refill: %v", spew.Sdump(tasks))
+	}
+	// A refill right away dials the remaining nodes from the table, but doesn't
+	// retry the ones just tried.
+	state.refill(now)
+
+	want = []task{
+		&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(4)}},
+		&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(5)}},
+		&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(6)}},
+	}
+	second := state.newTasks(1, peers, now)
+	if !sametasks(second, want) {
+		t.Fatalf("immediate refill tasks mismatch:\ngot %v\nwant %v", spew.Sdump(second), spew.Sdump(want))
+	}
+	for _, task := range second {
+		state.taskDone(task, now)
+	}
+	// A later refill retries all the failed nodes, before their history expires.
+	now = now.Add(refillRetryDelay + time.Second)
+	state.refill(now)
+
+	want = []task{
+		&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(1)}},
+		&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(2)}},
+		&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(3)}},
+		&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(4)}},
+		&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(5)}},
+		&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(6)}},
+	}
+	if tasks := state.newTasks(1, peers, now); !sametasks(tasks, want) {
+		t.Fatalf("refill tasks mismatch:\ngot %v\nwant %v", spew.Sdump(tasks), spew.Sdump(want))
+	}
+}
+
 // This test checks that candidates that do not match the netrestrict list are not dialed.
 func TestDialStateNetRestrict(t *testing.T) {
 	// This table always returns the same random nodes
@@ -72,6 +72,10 @@ type Config struct {
 	// Setting DialRatio to zero defaults it to 3.
 	DialRatio int `toml:",omitempty"`
 
+	// MaxInboundPeers caps the number of inbound connections below the limit
+	// implied by DialRatio. Setting MaxInboundPeers to zero disables the cap.
+	MaxInboundPeers int `toml:",omitempty"`
+
 	// NoDiscovery can be used to disable the peer discovery mechanism.
 	// Disabling is useful for protocol debugging (manual topology).
 	NoDiscovery bool
@@ -169,6 +173,7 @@ type Server struct {
 	quit          chan struct{}
 	addstatic     chan *discover.Node
 	removestatic  chan *discover.Node
+	refill        chan struct{}
 	posthandshake chan *conn
 	addpeer       chan *conn
 	delpeer       chan peerDrop
@@ -300,6 +305,16 @@ func (srv *Server) RemovePeer(node *discover.Node) {
 	}
 }
 
+// RefillPeers asks the dialer to dial more aggressively from the discovery table,
+// retrying recently dialed nodes too. It is meant to be called when the peer
+// count dropped below what the application needs.
+func (srv *Server) RefillPeers() {
+	select {
+	case srv.refill <- struct{}{}:
+	case <-srv.quit:
+	}
+}
+
 // SubscribePeers subscribes the given channel to peer events
 func (srv *Server) SubscribeEvents(ch chan *PeerEvent) event.Subscription {
 	return srv.peerFeed.Subscribe(ch)
@@ -410,6 +425,7 @@ func (srv *Server) Start() (err error) {
 	srv.posthandshake = make(chan *conn)
 	srv.addstatic = make(chan *discover.Node)
 	srv.removestatic = make(chan *discover.Node)
+	srv.refill = make(chan struct{})
 	srv.peerOp = make(chan peerOpFunc)
 	srv.peerOpDone = make(chan struct{})
 
@@ -533,6 +549,7 @@ type dialer interface {
 	taskDone(task, time.Time)
 	addStatic(*discover.Node)
 	removeStatic(*discover.Node)
+	refill(time.Time)
 }
 
 func (srv *Server) run(dialstate dialer) {
@@ -605,6 +622,11 @@ running:
 			if p, ok := peers[n.ID]; ok {
 				p.Disconnect(DiscRequested)
 			}
+		case <-srv.refill:
+			// This channel is used by RefillPeers to request more
+			// dynamic dials from the discovery table.
+			srv.log.Debug("Refilling dynamic peers")
+			dialstate.refill(time.Now())
 		case op := <-srv.peerOp:
 			// This channel is used by Peers and PeerCount.
 			op(peers)
@@ -717,7 +739,11 @@ func (srv *Server) encHandshakeChecks(peers map[discover.NodeID]*Peer, inboundCo
 }
 
 func (srv *Server) maxInboundConns() int {
-	return srv.MaxPeers - srv.maxDialedConns()
+	max := srv.MaxPeers - srv.maxDialedConns()
+	if srv.MaxInboundPeers > 0 && srv.MaxInboundPeers < max {
+		max = srv.MaxInboundPeers
+	}
+	return max
 }
 
 func (srv *Server) maxDialedConns() int {
@@ -309,6 +309,8 @@ func (tg taskgen) addStatic(*discover.Node) {
 }
 func (tg taskgen) removeStatic(*discover.Node) {
 }
+func (tg taskgen) refill(time.Time) {
+}
 
 type testTask struct {
 	index  int
@@ -366,6 +368,48 @@ func TestServerAtCap(t *testing.T) {
 
 }
 
+// Tests that inbound connections are capped by MaxInboundPeers, while trusted
+// peers can still connect.
+func TestServerInboundCap(t *testing.T) {
+	trustedID := randomID()
+	srv := &Server{
+		Config: Config{
+			PrivateKey:      newkey(),
+			MaxPeers:        10,
+			MaxInboundPeers: 2,
+			NoDial:          true,
+			TrustedNodes:    []*discover.Node{{ID: trustedID}},
+		},
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start: %v", err)
+	}
+	defer srv.Stop()
+
+	newconn := func(id discover.NodeID) *conn {
+		fd, _ := net.Pipe()
+		tx := newTestTransport(id, fd)
+		return &conn{fd: fd, transport: tx, flags: inboundConn, id: id, cont: make(chan error)}
+	}
+	// Inject inbound connections up to the cap.
+	for i := 0; i < 2; i++ {
+		c := newconn(randomID())
+		if err := srv.checkpoint(c, srv.addpeer); err != nil {
+			t.Fatalf("could not add conn %d: %v", i, err)
+		}
+	}
+	// Try inserting a non-trusted inbound connection.
+	c := newconn(randomID())
+	if err := srv.checkpoint(c, srv.posthandshake); err != DiscTooManyPeers {
+		t.Error("wrong error for insert:", err)
+	}
+	// Try inserting a trusted inbound connection.
+	c = newconn(trustedID)
+	if err := srv.checkpoint(c, srv.posthandshake); err != nil {
+		t.Error("unexpected error for trusted conn @posthandshake:", err)
+	}
+}
+
 func TestServerSetupConn(t *testing.T) {
 	id := randomID()
 	srvkey := newkey()
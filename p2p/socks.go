@@ -0,0 +1,214 @@
+// Copyright 2018 The go-athereum Authors
+// This file is part of the go-athereum library.
+//
+// The go-athereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-athereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-athereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/athereum/go-athereum/p2p/discover"
+)
+
+// SOCKS5 protocol constants, see RFC 1928 and RFC 1929.
+const (
+	socksVersion = 0x05
+
+	socksAuthNone     = 0x00
+	socksAuthPassword = 0x02
+	socksAuthRejected = 0xff
+
+	socksCmdConnect = 0x01
+
+	socksAddrIPv4   = 0x01
+	socksAddrDomain = 0x03
+	socksAddrIPv6   = 0x04
+)
+
+var (
+	errSOCKSVersion      = errors.New("socks5: unexpected protocol version")
+	errSOCKSAuthRejected = errors.New("socks5: no acceptable authentication method")
+	errSOCKSAuthFailed   = errors.New("socks5: username/password authentication failed")
+)
+
+// socksReplies are the descriptions of the SOCKS5 connect failure codes.
+var socksReplies = map[byte]string{
+	0x01: "general failure",
+	0x02: "connection not allowed by ruleset",
+	0x03: "network unreachable",
+	0x04: "host unreachable",
+	0x05: "connection refused",
+	0x06: "TTL expired",
+	0x07: "command not supported",
+	0x08: "address type not supported",
+}
+
+// SOCKS5Dialer implements the NodeDialer interface by tunneling the TCP
+// connections to nodes through a SOCKS5 proxy.
+type SOCKS5Dialer struct {
+	Proxy    string // Address of the proxy server (host:port)
+	Username string // Username to authenticate with, no authentication if empty
+	Password string // Password to authenticate with
+
+	Dialer *net.Dialer // Dialer used to reach the proxy, its timeout also bounds the handshake
+}
+
+// NewSOCKS5Dialer creates a node dialer connecting through the proxy at the given
+// address, which has the form [socks5://][user:password@]host:port.
+func NewSOCKS5Dialer(proxy string) (*SOCKS5Dialer, error) {
+	if !strings.Contains(proxy, "://") {
+		proxy = "socks5://" + proxy
+	}
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dial proxy: %v", err)
+	}
+	if u.Scheme != "socks5" {
+		return nil, fmt.Errorf("unsupported dial proxy scheme %q", u.Scheme)
+	}
+	if u.Port() == "" {
+		return nil, fmt.Errorf("dial proxy %q has no port", u.Host)
+	}
+	d := &SOCKS5Dialer{
+		Proxy:  u.Host,
+		Dialer: &net.Dialer{Timeout: defaultDialTimeout},
+	}
+	if u.User != nil {
+		d.Username = u.User.Username()
+		d.Password, _ = u.User.Password()
+	}
+	return d, nil
+}
+
+// Dial connects to the proxy and asks it to open a connection to the node.
+func (d *SOCKS5Dialer) Dial(dest *discover.Node) (net.Conn, error) {
+	conn, err := d.Dialer.Dial("tcp", d.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	if d.Dialer.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(d.Dialer.Timeout))
+	}
+	if err := d.handshake(conn, &net.TCPAddr{IP: dest.IP, Port: int(dest.TCP)}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// handshake negotiates the authentication method with the proxy and requests
+// a connection to the given address.
+func (d *SOCKS5Dialer) handshake(conn net.Conn, addr *net.TCPAddr) error {
+	// Offer the supported authentication methods and run the selected one
+	methods := []byte{socksVersion, 1, socksAuthNone}
+	if d.Username != "" {
+		methods = []byte{socksVersion, 2, socksAuthNone, socksAuthPassword}
+	}
+	if _, err := conn.Write(methods); err != nil {
+		return err
+	}
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+	if reply[0] != socksVersion {
+		return errSOCKSVersion
+	}
+	switch reply[1] {
+	case socksAuthNone:
+	case socksAuthPassword:
+		if d.Username == "" {
+			return errSOCKSAuthRejected
+		}
+		if err := d.authenticate(conn); err != nil {
+			return err
+		}
+	default:
+		return errSOCKSAuthRejected
+	}
+	// Request a connection to the destination and wait for the verdict
+	req := []byte{socksVersion, socksCmdConnect, 0}
+	if ip4 := addr.IP.To4(); ip4 != nil {
+		req = append(append(req, socksAddrIPv4), ip4...)
+	} else {
+		req = append(append(req, socksAddrIPv6), addr.IP.To16()...)
+	}
+	req = append(req, byte(addr.Port>>8), byte(addr.Port))
+	if _, err := conn.Write(req); err != nil {
+		return err
+	}
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return err
+	}
+	if header[0] != socksVersion {
+		return errSOCKSVersion
+	}
+	if header[1] != 0 {
+		reason, ok := socksReplies[header[1]]
+		if !ok {
+			reason = "reply code " + strconv.Itoa(int(header[1]))
+		}
+		return fmt.Errorf("socks5: connect to %v failed: %s", addr, reason)
+	}
+	// Discard the address the proxy bound for the connection
+	var size int
+	switch header[3] {
+	case socksAddrIPv4:
+		size = net.IPv4len
+	case socksAddrIPv6:
+		size = net.IPv6len
+	case socksAddrDomain:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return err
+		}
+		size = int(length[0])
+	default:
+		return fmt.Errorf("socks5: unknown bound address type %d", header[3])
+	}
+	_, err := io.ReadFull(conn, make([]byte, size+2))
+	return err
+}
+
+// authenticate runs the username/password subnegotiation of RFC 1929.
+func (d *SOCKS5Dialer) authenticate(conn net.Conn) error {
+	if len(d.Username) > 255 || len(d.Password) > 255 {
+		return errors.New("socks5: username or password too long")
+	}
+	req := []byte{0x01, byte(len(d.Username))}
+	req = append(req, d.Username...)
+	req = append(req, byte(len(d.Password)))
+	req = append(req, d.Password...)
+	if _, err := conn.Write(req); err != nil {
+		return err
+	}
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+	if reply[1] != 0 {
+		return errSOCKSAuthFailed
+	}
+	return nil
+}
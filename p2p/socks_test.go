@@ -0,0 +1,138 @@
+// Copyright 2018 The go-athereum Authors
+// This file is part of the go-athereum library.
+//
+// The go-athereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-athereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-athereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/athereum/go-athereum/p2p/discover"
+)
+
+// testSOCKSProxy serves a single SOCKS5 connection on the listener, expecting the
+// given credentials and a connect request to want. The tunnel is then mocked by
+// echoing back anything received. Failures are reported on errc.
+func testSOCKSProxy(listener net.Listener, user, pass string, want *net.TCPAddr, errc chan<- string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		errc <- err.Error()
+		return
+	}
+	defer conn.Close()
+
+	read := func(n int) []byte {
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			errc <- err.Error()
+			return nil
+		}
+		return buf
+	}
+	// Authentication method negotiation and subnegotiation
+	header := read(2)
+	if header == nil {
+		return
+	}
+	methods := read(int(header[1]))
+	if user == "" {
+		conn.Write([]byte{socksVersion, socksAuthNone})
+	} else {
+		if !bytes.Contains(methods, []byte{socksAuthPassword}) {
+			errc <- "password authentication not offered"
+			return
+		}
+		conn.Write([]byte{socksVersion, socksAuthPassword})
+		have := string(read(int(read(2)[1])))
+		have += ":" + string(read(int(read(1)[0])))
+		if have != user+":"+pass {
+			conn.Write([]byte{0x01, 0x01})
+			errc <- "credentials mismatch: " + have
+			return
+		}
+		conn.Write([]byte{0x01, 0x00})
+	}
+	// Connect request, expecting an IPv4 destination
+	req := read(4 + net.IPv4len + 2)
+	if req[1] != socksCmdConnect || req[3] != socksAddrIPv4 {
+		errc <- "unexpected connect request"
+		return
+	}
+	if ip, port := net.IP(req[4:8]), int(req[8])<<8|int(req[9]); !ip.Equal(want.IP) || port != want.Port {
+		errc <- "destination mismatch"
+		return
+	}
+	conn.Write([]byte{socksVersion, 0, 0, socksAddrIPv4, 127, 0, 0, 1, 0x12, 0x34})
+	errc <- ""
+
+	io.Copy(conn, conn)
+}
+
+// Tests that nodes are dialed through the proxy, with and without authentication.
+func TestSOCKS5Dialer(t *testing.T) {
+	tests := []struct {
+		proxy      string
+		user, pass string
+	}{
+		{proxy: "%s"},
+		{proxy: "socks5://alice:secret@%s", user: "alice", pass: "secret"},
+	}
+	for i, tt := range tests {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("test %d: failed to listen: %v", i, err)
+		}
+		dest := discover.NewNode(discover.NodeID{1}, net.IP{10, 0, 0, 1}, 30303, 30303)
+		errc := make(chan string, 1)
+		go testSOCKSProxy(listener, tt.user, tt.pass, &net.TCPAddr{IP: dest.IP, Port: int(dest.TCP)}, errc)
+
+		dialer, err := NewSOCKS5Dialer(fmt.Sprintf(tt.proxy, listener.Addr().String()))
+		if err != nil {
+			t.Fatalf("test %d: failed to create dialer: %v", i, err)
+		}
+		if dialer.Username != tt.user || dialer.Password != tt.pass {
+			t.Errorf("test %d: credentials mismatch: have %s:%s, want %s:%s", i, dialer.Username, dialer.Password, tt.user, tt.pass)
+		}
+		conn, err := dialer.Dial(dest)
+		if err != nil {
+			t.Fatalf("test %d: failed to dial: %v", i, err)
+		}
+		if msg := <-errc; msg != "" {
+			t.Fatalf("test %d: proxy failure: %s", i, msg)
+		}
+		if _, err := conn.Write([]byte("ping")); err != nil {
+			t.Fatalf("test %d: failed to write: %v", i, err)
+		}
+		pong := make([]byte, 4)
+		if _, err := io.ReadFull(conn, pong); err != nil || string(pong) != "ping" {
+			t.Errorf("test %d: tunnel mismatch: have %q, %v", i, pong, err)
+		}
+		conn.Close()
+		listener.Close()
+	}
+}
+
+// Tests that malformed or unsupported proxy addresses are rejected.
+func TestSOCKS5DialerInvalid(t *testing.T) {
+	for _, proxy := range []string{"http://127.0.0.1:8080", "127.0.0.1", "socks5://%zz"} {
+		if _, err := NewSOCKS5Dialer(proxy); err == nil {
+			t.Errorf("proxy %q accepted", proxy)
+		}
+	}
+}
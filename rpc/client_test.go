@@ -49,6 +49,37 @@ func TestClientRequest(t *testing.T) {
 	}
 }
 
+type dataError struct{}
+
+func (dataError) Error() string          { return "data error" }
+func (dataError) ErrorData() interface{} { return "extra data" }
+
+type DataErrorService struct{}
+
+func (s *DataErrorService) Fail() error { return dataError{} }
+
+func TestClientErrorData(t *testing.T) {
+	server := newTestServer("service", new(DataErrorService))
+	defer server.Stop()
+	client := DialInProc(server)
+	defer client.Close()
+
+	err := client.Call(nil, "service_fail")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if err.Error() != "data error" {
+		t.Errorf("error message mismatch: have %q, want %q", err.Error(), "data error")
+	}
+	de, ok := err.(DataError)
+	if !ok {
+		t.Fatalf("error is not a DataError: %T", err)
+	}
+	if data := de.ErrorData(); data != "extra data" {
+		t.Errorf("error data mismatch: have %v, want %q", data, "extra data")
+	}
+}
+
 func TestClientBatchRequest(t *testing.T) {
 	server := newTestServer("service", new(Service))
 	defer server.Stop()
@@ -18,12 +18,17 @@ package rpc
 
 import (
 	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/athereum/go-athereum/log"
 )
 
 // StartHTTPEndpoint starts the HTTP RPC endpoint, configured with cors/vhosts/modules
-func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []string, vhosts []string) (net.Listener, *Server, error) {
+// and a maximum request body size
+func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []string, vhosts []string, bodyLimit int64) (net.Listener, *Server, error) {
 	// Generate the whitelist based on the allowed modules
 	whitelist := make(map[string]bool)
 	for _, module := range modules {
@@ -47,12 +52,48 @@ func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []str
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return nil, nil, err
 	}
-	go NewHTTPServer(cors, vhosts, handler).Serve(listener)
+	go NewHTTPServer(cors, vhosts, bodyLimit, handler).Serve(listener)
 	return listener, handler, err
 }
 
-// StartWSEndpoint starts a websocket endpoint
-func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []string, exposeAll bool) (net.Listener, *Server, error) {
+// StartHTTPUnixEndpoint starts the HTTP RPC endpoint on a Unix domain socket at
+// the given path, restricting access to the socket file with the given mode
+func StartHTTPUnixEndpoint(path string, mode os.FileMode, apis []API, modules []string, cors []string, vhosts []string, bodyLimit int64) (net.Listener, *Server, error) {
+	// Generate the whitelist based on the allowed modules
+	whitelist := make(map[string]bool)
+	for _, module := range modules {
+		whitelist[module] = true
+	}
+	// Register all the APIs exposed by the services
+	handler := NewServer()
+	for _, api := range apis {
+		if whitelist[api.Namespace] || (len(whitelist) == 0 && api.Public) {
+			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
+				return nil, nil, err
+			}
+			log.Debug("HTTP registered", "namespace", api.Namespace)
+		}
+	}
+	// All APIs registered, ensure the socket path exists and remove any leftover
+	if err := os.MkdirAll(filepath.Dir(path), 0751); err != nil {
+		return nil, nil, err
+	}
+	os.Remove(path)
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+		return nil, nil, err
+	}
+	go NewHTTPServer(cors, vhosts, bodyLimit, handler).Serve(listener)
+	return listener, handler, nil
+}
+
+// StartWSEndpoint starts a websocket endpoint, limiting each connection to at
+// most maxSubs active subscriptions and pinging it every pingInterval (0 = never)
+func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []string, exposeAll bool, maxSubs int, pingInterval time.Duration) (net.Listener, *Server, error) {
 
 	// Generate the whitelist based on the allowed modules
 	whitelist := make(map[string]bool)
@@ -61,6 +102,7 @@ func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []
 	}
 	// Register all the APIs exposed by the services
 	handler := NewServer()
+	handler.SetMaxSubscriptions(maxSubs)
 	for _, api := range apis {
 		if exposeAll || whitelist[api.Namespace] || (len(whitelist) == 0 && api.Public) {
 			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
@@ -77,7 +119,7 @@ func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return nil, nil, err
 	}
-	go NewWSServer(wsOrigins, handler).Serve(listener)
+	go (&http.Server{Handler: handler.WebsocketHandlerWithPing(wsOrigins, pingInterval)}).Serve(listener)
 	return listener, handler, err
 
 }
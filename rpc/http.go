@@ -158,12 +158,15 @@ func (t *httpReadWriteNopCloser) Close() error {
 	return nil
 }
 
-// NewHTTPServer creates a new HTTP RPC server around an API provider.
+// NewHTTPServer creates a new HTTP RPC server around an API provider. Request
+// bodies larger than bodyLimit are rejected, a non-positive limit selecting the
+// default maximum request size.
 //
 // Deprecated: Server implements http.Handler
-func NewHTTPServer(cors []string, vhosts []string, srv *Server) *http.Server {
-	// Wrap the CORS-handler within a host-handler
-	handler := newCorsHandler(srv, cors)
+func NewHTTPServer(cors []string, vhosts []string, bodyLimit int64, srv *Server) *http.Server {
+	// Wrap the body limiter within a CORS-handler within a host-handler
+	handler := newBodyLimitHandler(srv, bodyLimit)
+	handler = newCorsHandler(handler, cors)
 	handler = newVHostHandler(vhosts, handler)
 	return &http.Server{
 		Handler:      handler,
@@ -175,24 +178,40 @@ func NewHTTPServer(cors []string, vhosts []string, srv *Server) *http.Server {
 
 // ServeHTTP serves JSON-RPC requests over HTTP.
 func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	srv.serveHTTP(w, r, maxRequestContentLength)
+}
+
+// serveHTTP serves JSON-RPC requests over HTTP, refusing to read more than limit
+// bytes from the request body.
+func (srv *Server) serveHTTP(w http.ResponseWriter, r *http.Request, limit int64) {
 	// Permit dumb empty requests for remote health-checks (AWS)
 	if r.Method == http.MethodGet && r.ContentLength == 0 && r.URL.RawQuery == "" {
 		return
 	}
-	if code, err := validateRequest(r); err != nil {
+	if code, err := validateRequest(r, limit); err != nil {
 		http.Error(w, err.Error(), code)
 		return
 	}
-	// All checks passed, create a codec that reads direct from the request body
-	// untilEOF and writes the response to w and order the server to process a
-	// single request.
+	// Read the body up front, as the announced content length is missing for e.g.
+	// chunked requests and can't be relied on to enforce the limit
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if int64(len(body)) > limit {
+		err := fmt.Errorf("request body too large (>%d)", limit)
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	// All checks passed, create a codec that reads from the request body until EOF
+	// and writes the response to w and order the server to process a single request.
 	ctx := r.Context()
 	ctx = context.WithValue(ctx, "remote", r.RemoteAddr)
 	ctx = context.WithValue(ctx, "scheme", r.Proto)
 	ctx = context.WithValue(ctx, "local", r.Host)
 
-	body := io.LimitReader(r.Body, maxRequestContentLength)
-	codec := NewJSONCodec(&httpReadWriteNopCloser{body, w})
+	codec := NewJSONCodec(&httpReadWriteNopCloser{bytes.NewReader(body), w})
 	defer codec.Close()
 
 	w.Header().Set("content-type", contentType)
@@ -201,12 +220,12 @@ func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 // validateRequest returns a non-zero response code and error message if the
 // request is invalid.
-func validateRequest(r *http.Request) (int, error) {
+func validateRequest(r *http.Request, limit int64) (int, error) {
 	if r.Method == http.MethodPut || r.Method == http.MethodDelete {
 		return http.StatusMethodNotAllowed, errors.New("method not allowed")
 	}
-	if r.ContentLength > maxRequestContentLength {
-		err := fmt.Errorf("content length too large (%d>%d)", r.ContentLength, maxRequestContentLength)
+	if r.ContentLength > limit {
+		err := fmt.Errorf("content length too large (%d>%d)", r.ContentLength, limit)
 		return http.StatusRequestEntityTooLarge, err
 	}
 	mt, _, err := mime.ParseMediaType(r.Header.Get("content-type"))
@@ -217,7 +236,26 @@ func validateRequest(r *http.Request) (int, error) {
 	return 0, nil
 }
 
-func newCorsHandler(srv *Server, allowedOrigins []string) http.Handler {
+// bodyLimitHandler is a handler which serves JSON-RPC requests over HTTP, but
+// rejects any request with a body larger than the configured limit.
+type bodyLimitHandler struct {
+	srv   *Server
+	limit int64
+}
+
+// ServeHTTP serves JSON-RPC requests over HTTP, implements http.Handler
+func (h *bodyLimitHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.srv.serveHTTP(w, r, h.limit)
+}
+
+func newBodyLimitHandler(srv *Server, limit int64) http.Handler {
+	if limit <= 0 {
+		limit = maxRequestContentLength
+	}
+	return &bodyLimitHandler{srv, limit}
+}
+
+func newCorsHandler(srv http.Handler, allowedOrigins []string) http.Handler {
 	// disable CORS support if user has not specified a custom CORS configuration
 	if len(allowedOrigins) == 0 {
 		return srv
@@ -17,8 +17,14 @@
 package rpc
 
 import (
+	"context"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -37,6 +43,42 @@ func TestHTTPErrorResponseWithMaxContentLength(t *testing.T) {
 		http.MethodPost, contentType, string(body), http.StatusRequestEntityTooLarge)
 }
 
+func TestHTTPErrorResponseWithCustomContentLength(t *testing.T) {
+	body := make([]rune, 1025)
+	request := httptest.NewRequest(http.MethodPost, "http://url.com", strings.NewReader(string(body)))
+	request.Header.Set("content-type", contentType)
+	if code, _ := validateRequest(request, 1024); code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("response code should be %d not %d", http.StatusRequestEntityTooLarge, code)
+	}
+}
+
+// Tests that request bodies exceeding the limit are rejected even if their size
+// isn't announced upfront, as with chunked transfer encoding.
+func TestHTTPErrorResponseWithChunkedBody(t *testing.T) {
+	srv := NewServer()
+	defer srv.Stop()
+
+	tests := []struct {
+		body     string
+		expected int
+	}{
+		{`{"jsonrpc":"2.0","id":1,"method":"rpc_modules"}`, http.StatusOK},
+		{string(make([]rune, 1025)), http.StatusRequestEntityTooLarge},
+	}
+	for i, tt := range tests {
+		request := httptest.NewRequest(http.MethodPost, "http://url.com", strings.NewReader(tt.body))
+		request.Header.Set("content-type", contentType)
+		request.ContentLength = -1
+		request.TransferEncoding = []string{"chunked"}
+
+		recorder := httptest.NewRecorder()
+		newBodyLimitHandler(srv, 1024).ServeHTTP(recorder, request)
+		if recorder.Code != tt.expected {
+			t.Errorf("test %d: response code should be %d not %d", i, tt.expected, recorder.Code)
+		}
+	}
+}
+
 func TestHTTPErrorResponseWithEmptyContentType(t *testing.T) {
 	testHTTPErrorResponse(t, http.MethodPost, "", "", http.StatusUnsupportedMediaType)
 }
@@ -48,7 +90,59 @@ func TestHTTPErrorResponseWithValidRequest(t *testing.T) {
 func testHTTPErrorResponse(t *testing.T, method, contentType, body string, expected int) {
 	request := httptest.NewRequest(method, "http://url.com", strings.NewReader(body))
 	request.Header.Set("content-type", contentType)
-	if code, _ := validateRequest(request); code != expected {
+	if code, _ := validateRequest(request, maxRequestContentLength); code != expected {
 		t.Fatalf("response code should be %d not %d", expected, code)
 	}
 }
+
+// Tests that the HTTP RPC endpoint can be served over a Unix domain socket, and
+// that the socket file is removed when the endpoint is closed.
+func TestHTTPUnixEndpoint(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix domain sockets are not supported")
+	}
+	dir, err := ioutil.TempDir("", "rpc-http-unix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "http.sock")
+	apis := []API{{Namespace: "test", Version: "1.0", Service: new(Service), Public: true}}
+	listener, handler, err := StartHTTPUnixEndpoint(path, 0660, apis, nil, nil, []string{"localhost"}, maxRequestContentLength)
+	if err != nil {
+		t.Fatalf("failed to start endpoint: %v", err)
+	}
+	defer handler.Stop()
+
+	if info, err := os.Stat(path); err != nil {
+		t.Fatalf("socket not created: %v", err)
+	} else if mode := info.Mode().Perm(); mode != 0660 {
+		t.Errorf("socket permission mismatch: have %o, want %o", mode, 0660)
+	}
+	// Issue a request through the socket
+	client, err := DialHTTPWithClient("http://localhost", &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return new(net.Dialer).DialContext(ctx, "unix", path)
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to dial endpoint: %v", err)
+	}
+	defer client.Close()
+
+	var result Result
+	if err := client.Call(&result, "test_echo", "hello", 10, &Args{"world"}); err != nil {
+		t.Fatalf("failed to call endpoint: %v", err)
+	}
+	if result.String != "hello" || result.Int != 10 || result.Args == nil || result.Args.S != "world" {
+		t.Errorf("result mismatch: have %+v", result)
+	}
+	// Close the endpoint and ensure the socket is cleaned up
+	listener.Close()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("socket not removed: %v", err)
+	}
+}
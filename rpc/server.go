@@ -58,6 +58,13 @@ func NewServer() *Server {
 	return server
 }
 
+// SetMaxSubscriptions limits the number of subscriptions a single connection may
+// hold at the same time. A non-positive limit allows an unlimited number. The
+// limit only applies to connections served after the call.
+func (s *Server) SetMaxSubscriptions(limit int) {
+	s.maxSubs = limit
+}
+
 // RPCService gives meta information about the server.
 // e.g. gives information about the loaded modules.
 type RPCService struct {
@@ -148,7 +155,7 @@ func (s *Server) serveRequest(ctx context.Context, codec ServerCodec, singleShot
 	// to send notification to clients. It is tied to the codec/connection. If the
 	// connection is closed the notifier will stop and cancels all active subscriptions.
 	if options&OptionSubscriptions == OptionSubscriptions {
-		ctx = context.WithValue(ctx, notifierKey{}, newNotifier(codec))
+		ctx = context.WithValue(ctx, notifierKey{}, newNotifier(codec, s.maxSubs))
 	}
 	s.codecsMu.Lock()
 	if atomic.LoadInt32(&s.run) != 1 { // server stopped
@@ -278,8 +285,17 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	}
 
 	if req.callb.isSubscribe {
+		notifier, supported := NotifierFromContext(ctx)
+		if supported {
+			if err := notifier.reserve(); err != nil {
+				return codec.CreateErrorResponse(&req.id, &callbackError{err.Error()}), nil
+			}
+		}
 		subid, err := s.createSubscription(ctx, codec, req)
 		if err != nil {
+			if supported {
+				notifier.release()
+			}
 			return codec.CreateErrorResponse(&req.id, &callbackError{err.Error()}), nil
 		}
 
@@ -316,6 +332,9 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	if req.callb.errPos >= 0 { // test if method returned an error
 		if !reply[req.callb.errPos].IsNil() {
 			e := reply[req.callb.errPos].Interface().(error)
+			if de, ok := e.(DataError); ok {
+				return codec.CreateErrorResponseWithInfo(&req.id, &callbackError{e.Error()}, de.ErrorData()), nil
+			}
 			res := codec.CreateErrorResponse(&req.id, &callbackError{e.Error()})
 			return res, nil
 		}
@@ -27,6 +27,9 @@ var (
 	ErrNotificationsUnsupported = errors.New("notifications not supported")
 	// ErrNotificationNotFound is returned when the notification for the given id is not found
 	ErrSubscriptionNotFound = errors.New("subscription not found")
+	// ErrTooManySubscriptions is returned when a connection already holds the maximum
+	// number of subscriptions allowed by the server
+	ErrTooManySubscriptions = errors.New("too many subscriptions")
 )
 
 // ID defines a pseudo random number that is used to identify RPC subscriptions.
@@ -35,12 +38,14 @@ type ID string
 // a Subscription is created by a notifier and tight to that notifier. The client can use
 // this subscription to wait for an unsubscribe request for the client, see Err().
 type Subscription struct {
-	ID        ID
-	namespace string
-	err       chan error // closed on unsubscribe
+	ID         ID
+	namespace  string
+	err        chan error // closed on unsubscribe or termination
+	terminated bool       // terminated before activation, guarded by the notifier's subMu
 }
 
-// Err returns a channel that is closed when the client send an unsubscribe request.
+// Err returns a channel that is closed when the client send an unsubscribe request
+// or the subscription was terminated by the server.
 func (s *Subscription) Err() <-chan error {
 	return s.err
 }
@@ -55,15 +60,20 @@ type Notifier struct {
 	subMu    sync.RWMutex // guards active and inactive maps
 	active   map[ID]*Subscription
 	inactive map[ID]*Subscription
+
+	limit int // maximum number of subscriptions on the connection (0 = unlimited)
+	count int // number of reserved subscriptions, guarded by subMu
 }
 
 // newNotifier creates a new notifier that can be used to send subscription
-// notifications to the client.
-func newNotifier(codec ServerCodec) *Notifier {
+// notifications to the client. At most limit subscriptions can be held at any
+// time, a non-positive limit disabling the check.
+func newNotifier(codec ServerCodec, limit int) *Notifier {
 	return &Notifier{
 		codec:    codec,
 		active:   make(map[ID]*Subscription),
 		inactive: make(map[ID]*Subscription),
+		limit:    limit,
 	}
 }
 
@@ -107,6 +117,49 @@ func (n *Notifier) Closed() <-chan interface{} {
 	return n.codec.Closed()
 }
 
+// reserve claims a subscription slot on the connection before the subscription
+// callback is invoked. If the connection is already at its limit the slot is not
+// granted and ErrTooManySubscriptions is returned.
+func (n *Notifier) reserve() error {
+	n.subMu.Lock()
+	defer n.subMu.Unlock()
+	if n.limit > 0 && n.count >= n.limit {
+		return ErrTooManySubscriptions
+	}
+	n.count++
+	return nil
+}
+
+// release returns a subscription slot claimed by reserve.
+func (n *Notifier) release() {
+	n.subMu.Lock()
+	n.count--
+	n.subMu.Unlock()
+}
+
+// Terminate ends a subscription from the server side, releasing its slot on the
+// connection. No further notifications are sent for it, the client is expected
+// to tell the end from the notifications of the subscription itself.
+// If the subscription could not be found ErrSubscriptionNotFound is returned.
+func (n *Notifier) Terminate(id ID) error {
+	n.subMu.Lock()
+	defer n.subMu.Unlock()
+
+	if s, found := n.active[id]; found {
+		close(s.err)
+		delete(n.active, id)
+		n.count--
+		return nil
+	}
+	// The subscription isn't activated yet, drop it upon activation
+	if s, found := n.inactive[id]; found && !s.terminated {
+		close(s.err)
+		s.terminated = true
+		return nil
+	}
+	return ErrSubscriptionNotFound
+}
+
 // unsubscribe a subscription.
 // If the subscription could not be found ErrSubscriptionNotFound is returned.
 func (n *Notifier) unsubscribe(id ID) error {
@@ -115,6 +168,7 @@ func (n *Notifier) unsubscribe(id ID) error {
 	if s, found := n.active[id]; found {
 		close(s.err)
 		delete(n.active, id)
+		n.count--
 		return nil
 	}
 	return ErrSubscriptionNotFound
@@ -129,7 +183,12 @@ func (n *Notifier) activate(id ID, namespace string) {
 	defer n.subMu.Unlock()
 	if sub, found := n.inactive[id]; found {
 		sub.namespace = namespace
-		n.active[id] = sub
 		delete(n.inactive, id)
+
+		if sub.terminated {
+			n.count--
+			return
+		}
+		n.active[id] = sub
 	}
 }
@@ -87,6 +87,41 @@ func (s *NotificationTestService) SomeSubscription(ctx context.Context, n, val i
 	return subscription, nil
 }
 
+// FiniteSubscription sends n notifications and then terminates the subscription
+// from the server side.
+func (s *NotificationTestService) FiniteSubscription(ctx context.Context, n, val int) (*Subscription, error) {
+	notifier, supported := NotifierFromContext(ctx)
+	if !supported {
+		return nil, ErrNotificationsUnsupported
+	}
+	subscription := notifier.CreateSubscription()
+
+	go func() {
+		// Give the server time to send the subscription id and activate it
+		time.Sleep(100 * time.Millisecond)
+		for i := 0; i < n; i++ {
+			if err := notifier.Notify(subscription.ID, val+i); err != nil {
+				return
+			}
+		}
+		notifier.Terminate(subscription.ID)
+	}()
+	return subscription, nil
+}
+
+// TerminatedSubscription terminates the subscription before it was activated.
+func (s *NotificationTestService) TerminatedSubscription(ctx context.Context) (*Subscription, error) {
+	notifier, supported := NotifierFromContext(ctx)
+	if !supported {
+		return nil, ErrNotificationsUnsupported
+	}
+	subscription := notifier.CreateSubscription()
+	if err := notifier.Terminate(subscription.ID); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
 // HangSubscription blocks on s.unblockHangSubscription before
 // sending anything.
 func (s *NotificationTestService) HangSubscription(ctx context.Context, val int) (*Subscription, error) {
@@ -328,3 +363,117 @@ func TestSubscriptionMultipleNamespaces(t *testing.T) {
 		}
 	}
 }
+
+// Tests that a connection can't hold more subscriptions than the server allows,
+// and that cancelling a subscription frees its slot again.
+func TestSubscriptionLimit(t *testing.T) {
+	server := NewServer()
+	server.SetMaxSubscriptions(2)
+	defer server.Stop()
+
+	if err := server.RegisterName("ath", new(NotificationTestService)); err != nil {
+		t.Fatalf("unable to register test service %v", err)
+	}
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+
+	go server.ServeCodec(NewJSONCodec(serverConn), OptionMethodInvocation|OptionSubscriptions)
+
+	out := json.NewEncoder(clientConn)
+	in := json.NewDecoder(clientConn)
+
+	call := func(id int, method string, params ...interface{}) map[string]interface{} {
+		request := map[string]interface{}{
+			"id":      id,
+			"method":  method,
+			"version": "2.0",
+			"params":  params,
+		}
+		if err := out.Encode(request); err != nil {
+			t.Fatal(err)
+		}
+		var response map[string]interface{}
+		if err := in.Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		return response
+	}
+	var subids []interface{}
+	for i := 0; i < 2; i++ {
+		response := call(i, "ath_subscribe", "someSubscription", 0, i)
+		if _, ok := response["result"].(string); !ok {
+			t.Fatalf("subscription %d: expected subscription id, got %v", i, response)
+		}
+		subids = append(subids, response["result"])
+	}
+	response := call(2, "ath_subscribe", "someSubscription", 0, 2)
+	if rpcErr, ok := response["error"].(map[string]interface{}); !ok || rpcErr["message"] != ErrTooManySubscriptions.Error() {
+		t.Fatalf("subscription over limit: expected %q error, got %v", ErrTooManySubscriptions, response)
+	}
+	if response := call(3, "ath_unsubscribe", subids[0]); response["result"] != true {
+		t.Fatalf("unsubscribe failed: %v", response)
+	}
+	if response := call(4, "ath_subscribe", "someSubscription", 0, 4); response["result"] == nil {
+		t.Fatalf("subscription after unsubscribe: expected subscription id, got %v", response)
+	}
+}
+
+// Tests that subscriptions terminated by the server free their slot on the
+// connection, both before and after activation.
+func TestSubscriptionTermination(t *testing.T) {
+	server := NewServer()
+	server.SetMaxSubscriptions(1)
+	defer server.Stop()
+
+	if err := server.RegisterName("ath", new(NotificationTestService)); err != nil {
+		t.Fatalf("unable to register test service %v", err)
+	}
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+
+	go server.ServeCodec(NewJSONCodec(serverConn), OptionMethodInvocation|OptionSubscriptions)
+
+	out := json.NewEncoder(clientConn)
+	in := json.NewDecoder(clientConn)
+
+	read := func() map[string]interface{} {
+		var msg map[string]interface{}
+		if err := in.Decode(&msg); err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+	// subscribe retries the subscription until the slot of the previous one was
+	// released by its termination
+	subscribe := func(id int, params ...interface{}) {
+		for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+			request := map[string]interface{}{
+				"id":      id,
+				"method":  "ath_subscribe",
+				"version": "2.0",
+				"params":  params,
+			}
+			if err := out.Encode(request); err != nil {
+				t.Fatal(err)
+			}
+			response := read()
+			if _, ok := response["result"].(string); ok {
+				return
+			}
+			if time.Since(start) > time.Second {
+				t.Fatalf("subscription %d: expected subscription id, got %v", id, response)
+			}
+		}
+	}
+	// Terminate an active subscription after its notifications
+	subscribe(0, "finiteSubscription", 2, 10)
+	for i := 0; i < 2; i++ {
+		msg := read()
+		if params, _ := msg["params"].(map[string]interface{}); params == nil || params["result"] != float64(10+i) {
+			t.Fatalf("notification %d: unexpected message %v", i, msg)
+		}
+	}
+	// Terminate a subscription before activation, the slots must be free again
+	subscribe(1, "terminatedSubscription")
+	subscribe(2, "finiteSubscription", 0, 0)
+}
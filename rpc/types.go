@@ -17,12 +17,14 @@
 package rpc
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"reflect"
 	"strings"
 	"sync"
 
+	"github.com/athereum/go-athereum/common"
 	"github.com/athereum/go-athereum/common/hexutil"
 	"gopkg.in/fatih/set.v0"
 )
@@ -74,6 +76,7 @@ type Server struct {
 	run      int32
 	codecsMu sync.Mutex
 	codecs   *set.Set
+	maxSubs  int // maximum number of subscriptions per connection (0 = unlimited)
 }
 
 // rpcRequest represents a raw incoming RPC request
@@ -92,6 +95,14 @@ type Error interface {
 	ErrorCode() int // returns the code
 }
 
+// DataError is an error carrying additional data besides the message. If a
+// callback returns such an error, the data is sent to the client in the data
+// field of the JSON-RPC error object.
+type DataError interface {
+	Error() string          // returns the message
+	ErrorData() interface{} // returns the error data
+}
+
 // ServerCodec implements reading, parsing and writing RPC messages for the server side of
 // a RPC session. Implementations must be go-routine safe since the codec can be called in
 // multiple go-routines concurrently.
@@ -119,13 +130,15 @@ type ServerCodec interface {
 type BlockNumber int64
 
 const (
-	PendingBlockNumber  = BlockNumber(-2)
-	LatestBlockNumber   = BlockNumber(-1)
-	EarliestBlockNumber = BlockNumber(0)
+	SafeBlockNumber      = BlockNumber(-4)
+	FinalizedBlockNumber = BlockNumber(-3)
+	PendingBlockNumber   = BlockNumber(-2)
+	LatestBlockNumber    = BlockNumber(-1)
+	EarliestBlockNumber  = BlockNumber(0)
 )
 
 // UnmarshalJSON parses the given JSON fragment into a BlockNumber. It supports:
-// - "latest", "earliest" or "pending" as string arguments
+// - "latest", "earliest", "pending", "safe" or "finalized" as string arguments
 // - the block number
 // Returned errors:
 // - an invalid block number error when the given argument isn't a known strings
@@ -146,6 +159,12 @@ func (bn *BlockNumber) UnmarshalJSON(data []byte) error {
 	case "pending":
 		*bn = PendingBlockNumber
 		return nil
+	case "safe":
+		*bn = SafeBlockNumber
+		return nil
+	case "finalized":
+		*bn = FinalizedBlockNumber
+		return nil
 	}
 
 	blckNum, err := hexutil.DecodeUint64(input)
@@ -163,3 +182,83 @@ func (bn *BlockNumber) UnmarshalJSON(data []byte) error {
 func (bn BlockNumber) Int64() int64 {
 	return (int64)(bn)
 }
+
+// BlockNumberOrHash is a block selector accepting either a block number (with
+// the usual named tags) or a block hash, as specified by EIP-1898.
+type BlockNumberOrHash struct {
+	BlockNumber      *BlockNumber `json:"blockNumber,omitempty"`
+	BlockHash        *common.Hash `json:"blockHash,omitempty"`
+	RequireCanonical bool         `json:"requireCanonical,omitempty"`
+}
+
+// UnmarshalJSON parses the given JSON fragment into a BlockNumberOrHash. Next
+// to the plain block number or hash, and the "latest", "earliest" or "pending"
+// string arguments, it also supports the EIP-1898 object form, containing either
+// a "blockNumber" or a "blockHash" field, the latter optionally accompanied by a
+// "requireCanonical" flag.
+func (bnh *BlockNumberOrHash) UnmarshalJSON(data []byte) error {
+	type erased BlockNumberOrHash
+	e := erased{}
+	if err := json.Unmarshal(data, &e); err == nil {
+		if e.BlockNumber != nil && e.BlockHash != nil {
+			return fmt.Errorf("cannot specify both BlockHash and BlockNumber, choose one or the other")
+		}
+		if e.BlockNumber == nil && e.BlockHash == nil {
+			return fmt.Errorf("either BlockHash or BlockNumber must be specified")
+		}
+		bnh.BlockNumber = e.BlockNumber
+		bnh.BlockHash = e.BlockHash
+		bnh.RequireCanonical = e.RequireCanonical
+		return nil
+	}
+	var input string
+	if err := json.Unmarshal(data, &input); err != nil {
+		return err
+	}
+	// A 32 byte hex string is a block hash, anything else must be a number or tag
+	if len(input) == 66 {
+		hash := new(common.Hash)
+		if err := hash.UnmarshalText([]byte(input)); err != nil {
+			return err
+		}
+		bnh.BlockHash = hash
+		return nil
+	}
+	number := new(BlockNumber)
+	if err := number.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	bnh.BlockNumber = number
+	return nil
+}
+
+// Number returns the selected block number, if the selector is number based.
+func (bnh *BlockNumberOrHash) Number() (BlockNumber, bool) {
+	if bnh.BlockNumber != nil {
+		return *bnh.BlockNumber, true
+	}
+	return BlockNumber(0), false
+}
+
+// Hash returns the selected block hash, if the selector is hash based.
+func (bnh *BlockNumberOrHash) Hash() (common.Hash, bool) {
+	if bnh.BlockHash != nil {
+		return *bnh.BlockHash, true
+	}
+	return common.Hash{}, false
+}
+
+// BlockNumberOrHashWithNumber creates a block selector for the given number.
+func BlockNumberOrHashWithNumber(blockNr BlockNumber) BlockNumberOrHash {
+	return BlockNumberOrHash{
+		BlockNumber: &blockNr,
+	}
+}
+
+// BlockNumberOrHashWithHash creates a block selector for the given hash.
+func BlockNumberOrHashWithHash(hash common.Hash, canonical bool) BlockNumberOrHash {
+	return BlockNumberOrHash{
+		BlockHash:        &hash,
+		RequireCanonical: canonical,
+	}
+}
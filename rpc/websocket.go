@@ -17,11 +17,14 @@
 package rpc
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/url"
@@ -51,12 +54,33 @@ var websocketJSONCodec = websocket.Codec{
 	},
 }
 
+// websocketPingCodec sends empty ping frames, to which the remote side replies
+// with pongs handled internally by the websocket library.
+var websocketPingCodec = websocket.Codec{
+	Marshal: func(v interface{}) ([]byte, byte, error) {
+		return nil, websocket.PingFrame, nil
+	},
+}
+
 // WebsocketHandler returns a handler that serves JSON-RPC to WebSocket connections.
 //
 // allowedOrigins should be a comma-separated list of allowed origin URLs.
 // To allow connections with any origin, pass "*".
 func (srv *Server) WebsocketHandler(allowedOrigins []string) http.Handler {
-	return websocket.Server{
+	return srv.WebsocketHandlerWithPing(allowedOrigins, 0)
+}
+
+// wsPingWriteTimeout is the maximum time allowed for sending a ping to a peer
+// before the connection is deemed dead.
+const wsPingWriteTimeout = 5 * time.Second
+
+// WebsocketHandlerWithPing returns a handler that serves JSON-RPC to WebSocket
+// connections, pinging each connection at the given interval to keep it from
+// being dropped as idle by intermediaries. Connections which stay silent, not even
+// answering the pings, for two intervals are considered dead and dropped. A zero
+// interval disables pinging.
+func (srv *Server) WebsocketHandlerWithPing(allowedOrigins []string, pingInterval time.Duration) http.Handler {
+	server := websocket.Server{
 		Handshake: wsHandshakeValidator(allowedOrigins),
 		Handler: func(conn *websocket.Conn) {
 			// Create a custom encode/decode pair to enforce payload size and number encoding
@@ -68,9 +92,89 @@ func (srv *Server) WebsocketHandler(allowedOrigins []string) http.Handler {
 			decoder := func(v interface{}) error {
 				return websocketJSONCodec.Receive(conn, v)
 			}
-			srv.ServeCodec(NewCodec(conn, encoder, decoder), OptionMethodInvocation|OptionSubscriptions)
+			codec := NewCodec(conn, encoder, decoder)
+			if pingInterval > 0 {
+				go wsPingLoop(conn, codec, pingInterval)
+			}
+			srv.ServeCodec(codec, OptionMethodInvocation|OptionSubscriptions)
 		},
 	}
+	if pingInterval <= 0 {
+		return server
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.ServeHTTP(&wsDeadlineHijacker{ResponseWriter: w, timeout: 2 * pingInterval}, r)
+	})
+}
+
+// wsDeadlineHijacker is an http.ResponseWriter which, when hijacked, extends the
+// read deadline of the connection by the given timeout whenever any data, be it a
+// pong or a regular frame, is received from the peer.
+type wsDeadlineHijacker struct {
+	http.ResponseWriter
+	timeout time.Duration
+}
+
+// Hijack implements http.Hijacker, wrapping the buffered reader of the hijacked
+// connection to track the peer's activity.
+func (h *wsDeadlineHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := h.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("websocket connection not hijackable")
+	}
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := conn.SetReadDeadline(time.Now().Add(h.timeout)); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	reader := &wsDeadlineReader{conn: conn, reader: buf.Reader, timeout: h.timeout}
+	return conn, bufio.NewReadWriter(bufio.NewReader(reader), buf.Writer), nil
+}
+
+// wsDeadlineReader extends the read deadline of a connection after every read
+// returning data.
+type wsDeadlineReader struct {
+	conn    net.Conn
+	reader  io.Reader
+	timeout time.Duration
+}
+
+// Read implements io.Reader.
+func (r *wsDeadlineReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.conn.SetReadDeadline(time.Now().Add(r.timeout))
+	}
+	return n, err
+}
+
+// wsPingLoop periodically sends a ping over the websocket connection until the
+// codec is closed. A ping which can't be sent in time is taken as a sign of a dead
+// peer and closes the codec, cancelling its subscriptions. Peers not answering the
+// pings are dropped by the read deadline of the connection.
+func wsPingLoop(conn *websocket.Conn, codec ServerCodec, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(wsPingWriteTimeout))
+			err := websocketPingCodec.Send(conn, nil)
+			conn.SetWriteDeadline(time.Time{})
+
+			if err != nil {
+				log.Debug("WebSocket ping failed", "err", err)
+				codec.Close()
+				return
+			}
+		case <-codec.Closed():
+			return
+		}
+	}
 }
 
 // NewWSServer creates a new websocket RPC server around an API provider.
@@ -0,0 +1,87 @@
+// Copyright 2018 The go-athereum Authors
+// This file is part of the go-athereum library.
+//
+// The go-athereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-athereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-athereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// Tests that pinged websocket connections of peers answering the pings are kept
+// alive, even if they stay idle otherwise.
+func TestWebsocketPingKeepAlive(t *testing.T) {
+	srv := newTestServer("service", new(Service))
+	defer srv.Stop()
+
+	hs := httptest.NewServer(srv.WebsocketHandlerWithPing([]string{"*"}, 50*time.Millisecond))
+	defer hs.Close()
+
+	client, err := DialWebsocket(context.Background(), "ws://"+strings.TrimPrefix(hs.URL, "http://"), "")
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer client.Close()
+
+	time.Sleep(500 * time.Millisecond)
+
+	var resp Result
+	if err := client.Call(&resp, "service_echo", "", 1, nil); err != nil {
+		t.Fatalf("idle connection dropped: %v", err)
+	}
+}
+
+// Tests that pinged websocket connections of peers not answering the pings are
+// dropped.
+func TestWebsocketPingDeadPeer(t *testing.T) {
+	srv := newTestServer("service", new(Service))
+	defer srv.Stop()
+
+	hs := httptest.NewServer(srv.WebsocketHandlerWithPing([]string{"*"}, 50*time.Millisecond))
+	defer hs.Close()
+
+	config, err := websocket.NewConfig("ws://"+strings.TrimPrefix(hs.URL, "http://"), "http://localhost")
+	if err != nil {
+		t.Fatalf("failed to create config: %v", err)
+	}
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	// Stay silent, not answering the pings, until the server gives up on the peer
+	time.Sleep(500 * time.Millisecond)
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		var msg []byte
+		err := websocket.Message.Receive(conn, &msg)
+		if err == nil {
+			continue
+		}
+		if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
+			t.Fatalf("dead peer not dropped")
+		}
+		break
+	}
+}
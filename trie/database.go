@@ -190,6 +190,14 @@ func (db *Database) secureKey(key []byte) []byte {
 	return buf
 }
 
+// NodeCount returns the number of nodes cached within the memory database.
+func (db *Database) NodeCount() int {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return len(db.nodes) - 1 // Exclude the special "root" metadata node
+}
+
 // Nodes retrieves the hashes of all the nodes cached within the memory database.
 // This method is extremely expensive and should only be used to validate internal
 // states in test code.